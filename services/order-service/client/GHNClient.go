@@ -58,6 +58,63 @@ type CreateOrderResponse struct {
 	Data    CreateOrderData `json:"data"`
 }
 
+// OrderDetailData is the state of a GHN shipping order
+type OrderDetailData struct {
+	OrderCode   string           `json:"order_code"`
+	Status      string           `json:"status"` // ready_to_pick, picking, delivering, delivered, return, returned, cancel, ...
+	Leadtime    time.Time        `json:"leadtime"` // expected delivery
+	UpdatedDate time.Time        `json:"updated_date"`
+	Log         []OrderStatusLog `json:"log"`
+}
+
+type OrderStatusLog struct {
+	Status      string    `json:"status"`
+	UpdatedDate time.Time `json:"updated_date"`
+}
+
+// DeliveredAt returns when the parcel was delivered, or nil while it is not
+func (d *OrderDetailData) DeliveredAt() *time.Time {
+	if d.Status != "delivered" {
+		return nil
+	}
+	for i := len(d.Log) - 1; i >= 0; i-- {
+		if d.Log[i].Status == "delivered" {
+			return &d.Log[i].UpdatedDate
+		}
+	}
+	return &d.UpdatedDate
+}
+
+// ShipmentStatus maps the GHN status to a shipment status. It returns "" for
+// statuses that don't change it, such as a failed delivery attempt.
+func (d *OrderDetailData) ShipmentStatus() string {
+	return GHNShipmentStatus(d.Status)
+}
+
+// GHNShipmentStatus maps a GHN status to a shipment status, or "" for one that
+// doesn't change it
+func GHNShipmentStatus(status string) string {
+	switch status {
+	case "ready_to_pick", "picking", "money_collect_picking":
+		return model.ShipmentToPickup
+	case "picked", "storing", "transporting", "sorting", "delivering", "money_collect_delivering":
+		return model.ShipmentShipping
+	case "delivered":
+		return model.ShipmentDelivered
+	case "waiting_to_return", "return", "return_transporting", "return_sorting", "returning", "returned":
+		return model.ShipmentReturned
+	case "cancel":
+		return model.ShipmentCancelled
+	}
+	return ""
+}
+
+type CancelOrderData struct {
+	OrderCode string `json:"order_code"`
+	Result    bool   `json:"result"`
+	Message   string `json:"message"`
+}
+
 type GHNResponse[T any] struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -238,6 +295,29 @@ func (c *GHNClient) CreateOrder(request dto.GHNCreateOrderRequest) (string, erro
 	return data.OrderCode, nil
 }
 
+// CancelOrder cancels a shipping order that has not been picked up yet
+func (c *GHNClient) CancelOrder(orderCode string) error {
+	data, err := callGHNWithBody[[]CancelOrderData]("POST", fmt.Sprintf("%s/shiip/public-api/v2/switch-status/cancel", c.baseURL), c.token, map[string][]string{"order_codes": {orderCode}})
+	if err != nil {
+		return err
+	}
+	for _, result := range data {
+		if result.OrderCode == orderCode && !result.Result {
+			return fmt.Errorf("GHN refused to cancel order %s: %s", orderCode, result.Message)
+		}
+	}
+	return nil
+}
+
+// GetOrderDetail returns the current state of a shipping order
+func (c *GHNClient) GetOrderDetail(orderCode string) (*OrderDetailData, error) {
+	data, err := callGHNWithBody[OrderDetailData]("POST", fmt.Sprintf("%s/shiip/public-api/v2/shipping-order/detail", c.baseURL), c.token, map[string]string{"order_code": orderCode})
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
 func (c *GHNClient) CreateRequest(order model.Order, seller dto.UserResponse) (dto.GHNCreateOrderRequest, error) {
 
 	shippingAddress := order.ShippingAddress
@@ -0,0 +1,223 @@
+package momoclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"order-service/client/payment"
+	"order-service/config"
+	appError "order-service/error"
+	"order-service/model"
+
+	"github.com/google/uuid"
+)
+
+type MoMoClient struct {
+	config     *config.MoMoConfig
+	httpClient *http.Client
+}
+
+var _ payment.PaymentClient = (*MoMoClient)(nil)
+var _ payment.WebhookAcknowledger = (*MoMoClient)(nil)
+
+func NewMoMoClient(cfg *config.MoMoConfig) *MoMoClient {
+	return &MoMoClient{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type createPaymentRequest struct {
+	PartnerCode string `json:"partnerCode"`
+	RequestID   string `json:"requestId"`
+	Amount      int64  `json:"amount"`
+	OrderID     string `json:"orderId"`
+	OrderInfo   string `json:"orderInfo"`
+	RedirectURL string `json:"redirectUrl"`
+	IPNURL      string `json:"ipnUrl"`
+	RequestType string `json:"requestType"`
+	ExtraData   string `json:"extraData"`
+	Lang        string `json:"lang"`
+	Signature   string `json:"signature"`
+}
+
+type createPaymentResponse struct {
+	ResultCode int    `json:"resultCode"`
+	Message    string `json:"message"`
+	PayURL     string `json:"payUrl"`
+}
+
+// Notification is the body of a MoMo IPN. The return redirect carries the same
+// fields in its query string.
+type Notification struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	Amount       int64  `json:"amount"`
+	OrderInfo    string `json:"orderInfo"`
+	OrderType    string `json:"orderType"`
+	TransID      int64  `json:"transId"`
+	ResultCode   int    `json:"resultCode"`
+	Message      string `json:"message"`
+	PayType      string `json:"payType"`
+	ResponseTime int64  `json:"responseTime"`
+	ExtraData    string `json:"extraData"`
+	Signature    string `json:"signature"`
+}
+
+// CreatePayment creates a MoMo payment and returns its pay URL.
+// MoMo sends the buyer back to successURL and notifies the IPN URL.
+func (m *MoMoClient) CreatePayment(order *model.Order, successURL, cancelURL string) (string, error) {
+	if m.config.PartnerCode == "" || m.config.AccessKey == "" || m.config.SecretKey == "" {
+		return "", appError.NewAppError(500, "MoMo is not configured")
+	}
+
+	amount := order.AmountDue()
+	if amount.Currency != "VND" {
+		return "", appError.NewAppError(400, "MoMo only accepts VND payments")
+	}
+
+	request := createPaymentRequest{
+		PartnerCode: m.config.PartnerCode,
+		RequestID:   uuid.New().String(),
+		Amount:      amount.ToUnits(0),
+		OrderID:     payment.GatewayReference(order.ID),
+		OrderInfo:   "Thanh toan don hang " + order.ID,
+		RedirectURL: successURL,
+		IPNURL:      m.config.IPNURL,
+		RequestType: "captureWallet",
+		Lang:        "vi",
+	}
+	request.Signature = Sign(fmt.Sprintf(
+		"accessKey=%s&amount=%d&extraData=%s&ipnUrl=%s&orderId=%s&orderInfo=%s&partnerCode=%s&redirectUrl=%s&requestId=%s&requestType=%s",
+		m.config.AccessKey, request.Amount, request.ExtraData, request.IPNURL, request.OrderID,
+		request.OrderInfo, request.PartnerCode, request.RedirectURL, request.RequestID, request.RequestType,
+	), m.config.SecretKey)
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := m.httpClient.Post(m.config.Endpoint, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to call MoMo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var response createPaymentResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if response.ResultCode != 0 || response.PayURL == "" {
+		return "", appError.NewAppError(502, "MoMo rejected the payment: "+response.Message)
+	}
+
+	return response.PayURL, nil
+}
+
+// CancelPayment is a no-op: an unpaid MoMo payment simply expires
+func (m *MoMoClient) CancelPayment(ctx context.Context, paymentID string) error {
+	return nil
+}
+
+// RefundPayment is not supported through the gateway; refunds go to the buyer's wallet
+func (m *MoMoClient) RefundPayment(ctx context.Context, paymentID string) error {
+	return appError.NewAppError(501, "MoMo refunds are not supported, refund to the wallet instead")
+}
+
+// ConstructEvent verifies an IPN body (JSON) or a return URL query string.
+// The signature travels in the payload itself, so signature is unused.
+func (m *MoMoClient) ConstructEvent(payload []byte, _ string) (*payment.PaymentEvent, error) {
+	notification, err := parseNotification(payload)
+	if err != nil {
+		return nil, appError.NewAppErrorWithErr(400, "Invalid MoMo payload", err)
+	}
+	if !Verify(notification, m.config.AccessKey, m.config.SecretKey) {
+		return nil, appError.NewAppErrorWithErr(400, "Invalid MoMo signature", payment.ErrInvalidSignature)
+	}
+
+	event := &payment.PaymentEvent{
+		Type:      payment.EventPaymentFailed,
+		OrderID:   payment.OrderIDFromReference(notification.OrderID),
+		PaymentID: strconv.FormatInt(notification.TransID, 10),
+		Amount:    model.MoneyFromUnits(notification.Amount, 0, "VND"),
+		Verified:  true,
+	}
+	if notification.ResultCode == 0 {
+		event.Type = payment.EventPaymentSucceeded
+	}
+	return event, nil
+}
+
+// AcknowledgeWebhook replies with 204 No Content, which MoMo expects once the IPN is handled
+func (m *MoMoClient) AcknowledgeWebhook(err error) (int, any) {
+	if err != nil {
+		return http.StatusBadRequest, map[string]string{"message": err.Error()}
+	}
+	return http.StatusNoContent, nil
+}
+
+func parseNotification(payload []byte) (*Notification, error) {
+	var notification Notification
+	if trimmed := bytes.TrimSpace(payload); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &notification); err != nil {
+			return nil, err
+		}
+		return &notification, nil
+	}
+
+	query, err := url.ParseQuery(string(payload))
+	if err != nil {
+		return nil, err
+	}
+	notification = Notification{
+		PartnerCode: query.Get("partnerCode"),
+		OrderID:     query.Get("orderId"),
+		RequestID:   query.Get("requestId"),
+		OrderInfo:   query.Get("orderInfo"),
+		OrderType:   query.Get("orderType"),
+		Message:     query.Get("message"),
+		PayType:     query.Get("payType"),
+		ExtraData:   query.Get("extraData"),
+		Signature:   query.Get("signature"),
+	}
+	notification.Amount, _ = strconv.ParseInt(query.Get("amount"), 10, 64)
+	notification.TransID, _ = strconv.ParseInt(query.Get("transId"), 10, 64)
+	notification.ResultCode, _ = strconv.Atoi(query.Get("resultCode"))
+	notification.ResponseTime, _ = strconv.ParseInt(query.Get("responseTime"), 10, 64)
+	return &notification, nil
+}
+
+// Sign computes a MoMo signature: HMAC-SHA256 of the raw "key=value&..." string
+func Sign(raw, secretKey string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(raw))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of an IPN or return redirect
+func Verify(n *Notification, accessKey, secretKey string) bool {
+	raw := fmt.Sprintf(
+		"accessKey=%s&amount=%d&extraData=%s&message=%s&orderId=%s&orderInfo=%s&orderType=%s&partnerCode=%s&payType=%s&requestId=%s&responseTime=%d&resultCode=%d&transId=%d",
+		accessKey, n.Amount, n.ExtraData, n.Message, n.OrderID, n.OrderInfo, n.OrderType,
+		n.PartnerCode, n.PayType, n.RequestID, n.ResponseTime, n.ResultCode, n.TransID,
+	)
+	expected := Sign(raw, secretKey)
+	return hmac.Equal([]byte(expected), []byte(n.Signature))
+}
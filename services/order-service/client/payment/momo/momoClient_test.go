@@ -0,0 +1,191 @@
+package momoclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"order-service/client/payment"
+	"order-service/config"
+	appError "order-service/error"
+	"order-service/model"
+)
+
+// MoMo's public sandbox keys, which the payloads in testdata were signed with
+const (
+	testAccessKey = "F8BBA842ECF85"
+	testSecretKey = "K951B6PE1waDMi640xX08PD3vg6EkVlz"
+)
+
+const testOrderID = "3f2c9a1e-7b4d-4c1a-9e2f-5d6b8a0c1e2f"
+
+func newTestClient(endpoint string) *MoMoClient {
+	return NewMoMoClient(&config.MoMoConfig{
+		PartnerCode: "MOMOSBX01",
+		AccessKey:   testAccessKey,
+		SecretKey:   testSecretKey,
+		Endpoint:    endpoint,
+		IPNURL:      "https://shop.example/api/v1/orders/webhook/momo",
+	})
+}
+
+func readPayload(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestConstructEvent(t *testing.T) {
+	ipn := readPayload(t, "ipn_success.json")
+	denied := readPayload(t, "return_denied.txt")
+
+	tests := []struct {
+		name          string
+		payload       string
+		wantErr       error
+		wantType      string
+		wantPaymentID string
+		wantAmount    model.Money
+	}{
+		{
+			name:          "successful IPN",
+			payload:       ipn,
+			wantType:      payment.EventPaymentSucceeded,
+			wantPaymentID: "4088878653",
+			wantAmount:    model.NewMoney(150000, "VND"),
+		},
+		{
+			name:          "return after the buyer denied the payment",
+			payload:       denied,
+			wantType:      payment.EventPaymentFailed,
+			wantPaymentID: "4088879001",
+			wantAmount:    model.NewMoney(150000, "VND"),
+		},
+		{
+			name:    "tampered signature",
+			payload: strings.Replace(ipn, `"signature": "1`, `"signature": "2`, 1),
+			wantErr: payment.ErrInvalidSignature,
+		},
+		{
+			name:    "amount changed after signing",
+			payload: strings.Replace(ipn, `"amount": 150000`, `"amount": 15000`, 1),
+			wantErr: payment.ErrInvalidSignature,
+		},
+		{
+			name:    "denied payment reported as successful",
+			payload: strings.Replace(denied, "resultCode=1006", "resultCode=0", 1),
+			wantErr: payment.ErrInvalidSignature,
+		},
+		{
+			name:    "order swapped in the return URL",
+			payload: strings.Replace(denied, "orderId="+testOrderID, "orderId=another-order", 1),
+			wantErr: payment.ErrInvalidSignature,
+		},
+	}
+
+	client := newTestClient("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := client.ConstructEvent([]byte(tt.payload), "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if event.Type != tt.wantType {
+				t.Errorf("type = %s, want %s", event.Type, tt.wantType)
+			}
+			if event.OrderID != testOrderID {
+				t.Errorf("order ID = %s, want %s", event.OrderID, testOrderID)
+			}
+			if event.PaymentID != tt.wantPaymentID {
+				t.Errorf("payment ID = %s, want %s", event.PaymentID, tt.wantPaymentID)
+			}
+			if event.Amount != tt.wantAmount {
+				t.Errorf("amount = %v, want %v", event.Amount, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestConstructEventInvalidPayload(t *testing.T) {
+	_, err := newTestClient("").ConstructEvent([]byte(`{"amount": "not a number"`), "")
+
+	var appErr *appError.AppError
+	if !errors.As(err, &appErr) || appErr.Code != http.StatusBadRequest {
+		t.Fatalf("got error %v, want a 400 app error", err)
+	}
+}
+
+func TestCreatePaymentSignsRequest(t *testing.T) {
+	var received createPaymentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		json.NewEncoder(w).Encode(createPaymentResponse{ResultCode: 0, PayURL: "https://test-payment.momo.vn/pay/abc"})
+	}))
+	defer server.Close()
+
+	order := &model.Order{ID: testOrderID, Total: 140000, DeliveryFee: 20000, WalletAmount: 10000, Currency: "VND"}
+	payURL, err := newTestClient(server.URL).CreatePayment(order, "https://shop.example/payment/return", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payURL != "https://test-payment.momo.vn/pay/abc" {
+		t.Errorf("pay URL = %s", payURL)
+	}
+
+	if received.Amount != 150000 {
+		t.Errorf("amount = %d, want 150000 (amount due after the wallet part)", received.Amount)
+	}
+	if got := payment.OrderIDFromReference(received.OrderID); got != testOrderID {
+		t.Errorf("order ID = %s, want %s", got, testOrderID)
+	}
+	raw := fmt.Sprintf(
+		"accessKey=%s&amount=%d&extraData=%s&ipnUrl=%s&orderId=%s&orderInfo=%s&partnerCode=%s&redirectUrl=%s&requestId=%s&requestType=%s",
+		testAccessKey, received.Amount, received.ExtraData, received.IPNURL, received.OrderID,
+		received.OrderInfo, received.PartnerCode, received.RedirectURL, received.RequestID, received.RequestType,
+	)
+	if received.Signature != Sign(raw, testSecretKey) {
+		t.Errorf("request signature %s does not match its fields", received.Signature)
+	}
+}
+
+func TestCreatePaymentRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(createPaymentResponse{ResultCode: 41, Message: "OrderId is duplicated"})
+	}))
+	defer server.Close()
+
+	order := &model.Order{ID: testOrderID, Total: 150000, Currency: "VND"}
+	_, err := newTestClient(server.URL).CreatePayment(order, "https://shop.example/payment/return", "")
+
+	var appErr *appError.AppError
+	if !errors.As(err, &appErr) || appErr.Code != http.StatusBadGateway {
+		t.Fatalf("got error %v, want a 502 app error", err)
+	}
+}
+
+func TestAcknowledgeWebhook(t *testing.T) {
+	client := newTestClient("")
+
+	if status, _ := client.AcknowledgeWebhook(nil); status != http.StatusNoContent {
+		t.Errorf("handled IPN: status = %d, want %d", status, http.StatusNoContent)
+	}
+	if status, _ := client.AcknowledgeWebhook(payment.ErrInvalidSignature); status != http.StatusBadRequest {
+		t.Errorf("failed IPN: status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
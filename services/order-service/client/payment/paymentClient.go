@@ -2,9 +2,11 @@ package payment
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"order-service/model"
-
-	"github.com/stripe/stripe-go/v84"
+	"strings"
+	"time"
 )
 
 type CreatePaymentResult struct {
@@ -13,9 +15,51 @@ type CreatePaymentResult struct {
 	Status       string
 }
 
+// Payment event types reported by ConstructEvent
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventIgnored          = "ignored"
+)
+
+// ErrInvalidSignature is returned by ConstructEvent when the checksum does not match
+var ErrInvalidSignature = errors.New("invalid payment signature")
+
+// PaymentEvent is a gateway callback (webhook, IPN or return redirect) after its
+// signature has been verified, in a gateway-neutral form.
+type PaymentEvent struct {
+	Type      string
+	OrderID   string
+	PaymentID string // gateway transaction ID
+	SessionID string
+	Amount    model.Money // zero if the gateway did not report it
+	Metadata  map[string]string
+	Verified  bool // set by ConstructEvent once the gateway signature checked out
+}
+
 type PaymentClient interface {
 	CreatePayment(order *model.Order, successURL, cancelURL string) (string, error)
 	CancelPayment(ctx context.Context, paymentID string) error
 	RefundPayment(ctx context.Context, paymentID string) error
-	ConstructEvent(payload []byte, signature string) (*stripe.Event, error)
+	ConstructEvent(payload []byte, signature string) (*PaymentEvent, error)
+}
+
+// WebhookAcknowledger is implemented by gateways that expect a specific reply to their
+// callbacks. err is the result of handling the event, nil on success.
+type WebhookAcknowledger interface {
+	AcknowledgeWebhook(err error) (int, any)
+}
+
+// GatewayReference builds a merchant reference that is unique per payment attempt,
+// since gateways reject a reference that was already used when a buyer retries.
+func GatewayReference(orderID string) string {
+	return fmt.Sprintf("%s_%d", orderID, time.Now().Unix())
+}
+
+// OrderIDFromReference recovers the order ID from a GatewayReference
+func OrderIDFromReference(reference string) string {
+	if i := strings.LastIndex(reference, "_"); i > 0 {
+		return reference[:i]
+	}
+	return reference
 }
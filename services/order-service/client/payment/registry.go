@@ -0,0 +1,34 @@
+package payment
+
+import (
+	appError "order-service/error"
+	"strings"
+)
+
+// Registry maps a payment method (STRIPE, VNPAY, MOMO) to the gateway that handles it
+type Registry struct {
+	clients map[string]PaymentClient
+}
+
+func NewRegistry() *Registry {
+	return &Registry{clients: make(map[string]PaymentClient)}
+}
+
+func (r *Registry) Register(method string, client PaymentClient) {
+	r.clients[strings.ToUpper(method)] = client
+}
+
+// Get returns the gateway for a payment method. Methods without an online gateway, like COD, are rejected.
+func (r *Registry) Get(method string) (PaymentClient, error) {
+	client, ok := r.clients[strings.ToUpper(method)]
+	if !ok {
+		return nil, appError.NewAppError(400, "unsupported online payment method: "+method)
+	}
+	return client, nil
+}
+
+// Has reports whether the payment method is paid through an online gateway
+func (r *Registry) Has(method string) bool {
+	_, ok := r.clients[strings.ToUpper(method)]
+	return ok
+}
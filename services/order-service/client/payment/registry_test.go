@@ -0,0 +1,91 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	appError "order-service/error"
+	"order-service/model"
+)
+
+type fakeClient struct {
+	name string
+}
+
+func (f *fakeClient) CreatePayment(order *model.Order, successURL, cancelURL string) (string, error) {
+	return f.name, nil
+}
+
+func (f *fakeClient) CancelPayment(ctx context.Context, paymentID string) error {
+	return nil
+}
+
+func (f *fakeClient) RefundPayment(ctx context.Context, paymentID string) error {
+	return nil
+}
+
+func (f *fakeClient) ConstructEvent(payload []byte, signature string) (*PaymentEvent, error) {
+	return nil, nil
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("STRIPE", &fakeClient{name: "stripe"})
+	registry.Register("vnpay", &fakeClient{name: "vnpay"})
+	registry.Register("MoMo", &fakeClient{name: "momo"})
+
+	tests := []struct {
+		method     string
+		wantClient string // empty when the method has no gateway
+	}{
+		{method: "STRIPE", wantClient: "stripe"},
+		{method: "VNPAY", wantClient: "vnpay"},
+		{method: "vnpay", wantClient: "vnpay"},
+		{method: "MOMO", wantClient: "momo"},
+		{method: "COD"},
+		{method: "WALLET"},
+		{method: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if got := registry.Has(tt.method); got != (tt.wantClient != "") {
+				t.Errorf("Has(%q) = %v", tt.method, got)
+			}
+
+			client, err := registry.Get(tt.method)
+			if tt.wantClient == "" {
+				var appErr *appError.AppError
+				if !errors.As(err, &appErr) || appErr.Code != http.StatusBadRequest {
+					t.Fatalf("Get(%q): got error %v, want a 400 app error", tt.method, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get(%q): unexpected error: %v", tt.method, err)
+			}
+			if name, _ := client.CreatePayment(nil, "", ""); name != tt.wantClient {
+				t.Errorf("Get(%q) returned the %s client, want %s", tt.method, name, tt.wantClient)
+			}
+		})
+	}
+}
+
+func TestOrderIDFromReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      string
+	}{
+		{reference: GatewayReference("3f2c9a1e-7b4d-4c1a-9e2f-5d6b8a0c1e2f"), want: "3f2c9a1e-7b4d-4c1a-9e2f-5d6b8a0c1e2f"},
+		{reference: "order_with_underscores_1760843700", want: "order_with_underscores"},
+		{reference: "plain-order-id", want: "plain-order-id"},
+	}
+
+	for _, tt := range tests {
+		if got := OrderIDFromReference(tt.reference); got != tt.want {
+			t.Errorf("OrderIDFromReference(%q) = %s, want %s", tt.reference, got, tt.want)
+		}
+	}
+}
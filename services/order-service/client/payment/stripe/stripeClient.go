@@ -3,22 +3,46 @@ package stripeclient
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
+	"order-service/client/payment"
 	"order-service/config"
 	appError "order-service/error"
 	"order-service/model"
 
 	"github.com/stripe/stripe-go/v84"
 	"github.com/stripe/stripe-go/v84/checkout/session"
+	"github.com/stripe/stripe-go/v84/customer"
 	"github.com/stripe/stripe-go/v84/paymentintent"
+	"github.com/stripe/stripe-go/v84/paymentmethod"
 	"github.com/stripe/stripe-go/v84/refund"
+	"github.com/stripe/stripe-go/v84/setupintent"
+	"github.com/stripe/stripe-go/v84/webhook"
 )
 
+// Stripe expects amounts in its own smallest unit: whole units for these
+// zero-decimal currencies (VND included), hundredths for the rest.
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "JPY": true, "KMF": true,
+	"KRW": true, "MGA": true, "PYG": true, "RWF": true, "UGX": true, "VND": true,
+	"VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+func stripeDecimals(currency string) int {
+	if zeroDecimalCurrencies[model.NormalizeCurrency(currency)] {
+		return 0
+	}
+	return 2
+}
+
 type StripeClient struct {
 	config *config.StripeConfig
 }
 
+var _ payment.PaymentClient = (*StripeClient)(nil)
+
 func NewStripeClient(cfg *config.StripeConfig) *StripeClient {
 	cfg.Init()
 	return &StripeClient{config: cfg}
@@ -26,25 +50,24 @@ func NewStripeClient(cfg *config.StripeConfig) *StripeClient {
 
 func (s *StripeClient) CreatePayment(order *model.Order, successURL, cancelURL string) (string, error) {
 	var lineItems []*stripe.CheckoutSessionLineItemParams
-	
-	for _, item := range order.Items {
-		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
-			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-				Currency: stripe.String("usd"),
-				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-					Name: stripe.String(item.ProductName + " - " + item.VariantName),
-				},
-				UnitAmount: stripe.Int64(int64(item.Price * 100)),
-			},
-			Quantity: stripe.Int64(int64(item.Quantity)),
-		})
+
+	if order.WalletAmount > 0 {
+		// Part of the order was paid from the wallet: charge the rest as one line
+		lineItems = append(lineItems, LineItem("Order Total (after wallet payment)", order.AmountDue()))
+	} else {
+		// Order total (excluding delivery fee) and delivery fee as separate lines
+		if order.Total > 0 {
+			lineItems = append(lineItems, LineItem("Order Total", model.NewMoney(order.Total, order.Currency)))
+		}
+		if order.DeliveryFee > 0 {
+			lineItems = append(lineItems, LineItem("Delivery Fee", model.NewMoney(int64(order.DeliveryFee), order.Currency)))
+		}
 	}
 
 	params := &stripe.CheckoutSessionParams{
 		PaymentMethodTypes: stripe.StringSlice([]string{
 			"card",
 		}),
-		Currency: stripe.String("usd"),
 		LineItems:  lineItems,
 		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
 		SuccessURL: stripe.String(successURL),
@@ -52,9 +75,13 @@ func (s *StripeClient) CreatePayment(order *model.Order, successURL, cancelURL s
 		Metadata: map[string]string{
 			"order_id": order.ID,
 		},
+		PaymentIntentData: &stripe.CheckoutSessionPaymentIntentDataParams{
+			Metadata: map[string]string{
+				"order_id": order.ID,
+			},
+		},
 	}
 
-
 	sess, err := session.New(params)
 	if err != nil {
 		return "", fmt.Errorf("failed to create checkout session: %w", err)
@@ -83,10 +110,143 @@ func (s *StripeClient) RefundPayment(ctx context.Context, paymentID string) erro
 	return nil
 }
 
-func (s *StripeClient) ConstructEvent(payload []byte, _ string) (*stripe.Event, error) {
-	var event stripe.Event
-	if err := json.Unmarshal(payload, &event); err != nil {
-		return nil, appError.NewAppErrorWithErr(400, "Invalid webhook payload", err)
+// ConstructEvent verifies the Stripe-Signature header and maps the event to a
+// PaymentEvent. Webhooks are refused when no webhook secret is configured, as
+// their payload could not be trusted.
+func (s *StripeClient) ConstructEvent(payload []byte, signature string) (*payment.PaymentEvent, error) {
+	if s.config.WebhookSecret == "" {
+		return nil, appError.NewAppErrorWithErr(500, "Stripe webhooks are not configured", payment.ErrInvalidSignature)
+	}
+	event, err := webhook.ConstructEventWithOptions(payload, signature, s.config.WebhookSecret, webhook.ConstructEventOptions{
+		IgnoreAPIVersionMismatch: true,
+	})
+	if err != nil {
+		return nil, appError.NewAppErrorWithErr(400, "Invalid webhook signature", errors.Join(payment.ErrInvalidSignature, err))
+	}
+
+	switch event.Type {
+	case "checkout.session.completed":
+		var sess stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &sess); err != nil {
+			return nil, appError.NewAppErrorWithErr(400, "Invalid session payload", err)
+		}
+
+		paymentEvent := &payment.PaymentEvent{
+			Type:      payment.EventPaymentSucceeded,
+			OrderID:   sess.Metadata["order_id"],
+			SessionID: sess.ID,
+			Amount:    model.MoneyFromUnits(sess.AmountTotal, stripeDecimals(string(sess.Currency)), string(sess.Currency)),
+			Metadata:  sess.Metadata,
+			Verified:  true,
+		}
+		if sess.PaymentIntent != nil {
+			paymentEvent.PaymentID = sess.PaymentIntent.ID
+		}
+		return paymentEvent, nil
+
+	case "payment_intent.payment_failed":
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return nil, appError.NewAppErrorWithErr(400, "Invalid payment_intent payload", err)
+		}
+
+		return &payment.PaymentEvent{
+			Type:      payment.EventPaymentFailed,
+			OrderID:   pi.Metadata["order_id"],
+			PaymentID: pi.ID,
+			Metadata:  pi.Metadata,
+			Verified:  true,
+		}, nil
+	}
+
+	return &payment.PaymentEvent{Type: payment.EventIgnored, Verified: true}, nil
+}
+
+// LineItem builds a single-quantity Checkout line in the amount's currency
+func LineItem(name string, amount model.Money) *stripe.CheckoutSessionLineItemParams {
+	return &stripe.CheckoutSessionLineItemParams{
+		PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+			Currency: stripe.String(strings.ToLower(amount.Currency)),
+			ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+				Name: stripe.String(name),
+			},
+			UnitAmount: stripe.Int64(amount.ToUnits(stripeDecimals(amount.Currency))),
+		},
+		Quantity: stripe.Int64(1),
+	}
+}
+
+// CreateCustomer creates the Stripe customer a buyer's saved cards are attached to
+func (s *StripeClient) CreateCustomer(userID, email, name string) (string, error) {
+	params := &stripe.CustomerParams{
+		Email:    stripe.String(email),
+		Name:     stripe.String(name),
+		Metadata: map[string]string{"user_id": userID},
+	}
+	c, err := customer.New(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to create customer: %w", err)
+	}
+	return c.ID, nil
+}
+
+// CreateSetupIntent starts saving a card for off-session payments. The client
+// secret is used by the frontend to collect and confirm the card.
+func (s *StripeClient) CreateSetupIntent(customerID string) (string, error) {
+	params := &stripe.SetupIntentParams{
+		Customer:           stripe.String(customerID),
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+		Usage:              stripe.String(string(stripe.SetupIntentUsageOffSession)),
+	}
+	intent, err := setupintent.New(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to create setup intent: %w", err)
+	}
+	return intent.ClientSecret, nil
+}
+
+// PaymentMethodCustomer returns the customer a saved card is attached to, empty if none
+func (s *StripeClient) PaymentMethodCustomer(paymentMethodID string) (string, error) {
+	pm, err := paymentmethod.Get(paymentMethodID, nil)
+	if err != nil {
+		return "", appError.NewAppErrorWithErr(400, "Invalid payment method", err)
 	}
-	return &event, nil
-}
\ No newline at end of file
+	if pm.Customer == nil {
+		return "", nil
+	}
+	return pm.Customer.ID, nil
+}
+
+// ChargeOffSession charges what is left to pay on an order to a saved card
+// without the buyer present. A succeeded charge is returned as a payment
+// event; a declined one fails here and is also reported by the
+// payment_intent.payment_failed webhook.
+func (s *StripeClient) ChargeOffSession(order *model.Order, customerID, paymentMethodID string) (*payment.PaymentEvent, error) {
+	amount := order.AmountDue()
+	params := &stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(amount.ToUnits(stripeDecimals(amount.Currency))),
+		Currency:      stripe.String(strings.ToLower(amount.Currency)),
+		Customer:      stripe.String(customerID),
+		PaymentMethod: stripe.String(paymentMethodID),
+		OffSession:    stripe.Bool(true),
+		Confirm:       stripe.Bool(true),
+		Metadata: map[string]string{
+			"order_id": order.ID,
+		},
+	}
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to charge saved card: %w", err)
+	}
+	if pi.Status != stripe.PaymentIntentStatusSucceeded {
+		return nil, fmt.Errorf("saved card payment is %s", pi.Status)
+	}
+
+	return &payment.PaymentEvent{
+		Type:      payment.EventPaymentSucceeded,
+		OrderID:   order.ID,
+		PaymentID: pi.ID,
+		Amount:    model.MoneyFromUnits(pi.Amount, stripeDecimals(string(pi.Currency)), string(pi.Currency)),
+		Metadata:  pi.Metadata,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package stripeclient
+
+import (
+	"errors"
+	"testing"
+
+	"order-service/client/payment"
+	"order-service/config"
+	"order-service/model"
+
+	"github.com/stripe/stripe-go/v84/webhook"
+)
+
+const testWebhookSecret = "whsec_test_secret"
+
+const topUpPayload = `{
+  "id": "evt_1",
+  "object": "event",
+  "api_version": "2025-01-27.acacia",
+  "type": "checkout.session.completed",
+  "data": {"object": {
+    "id": "cs_test_1",
+    "object": "checkout.session",
+    "amount_total": 500000,
+    "currency": "vnd",
+    "metadata": {"wallet_user_id": "user-1"}
+  }}
+}`
+
+func TestConstructEvent(t *testing.T) {
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload: []byte(topUpPayload),
+		Secret:  testWebhookSecret,
+	})
+	forged := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload: []byte(topUpPayload),
+		Secret:  "whsec_another_secret",
+	})
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		wantErr   error
+	}{
+		{name: "signed event", secret: testWebhookSecret, signature: signed.Header},
+		{name: "signed with another secret", secret: testWebhookSecret, signature: forged.Header, wantErr: payment.ErrInvalidSignature},
+		{name: "unsigned event", secret: testWebhookSecret, wantErr: payment.ErrInvalidSignature},
+		{name: "no webhook secret configured", signature: signed.Header, wantErr: payment.ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &StripeClient{config: &config.StripeConfig{WebhookSecret: tt.secret}}
+			event, err := client.ConstructEvent([]byte(topUpPayload), tt.signature)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !event.Verified || event.Type != payment.EventPaymentSucceeded {
+				t.Errorf("event = %+v, want a verified successful payment", event)
+			}
+			if event.Metadata["wallet_user_id"] != "user-1" || event.Amount != model.NewMoney(500000, "VND") {
+				t.Errorf("event = %+v, want a 500000 VND top-up of user-1", event)
+			}
+		})
+	}
+}
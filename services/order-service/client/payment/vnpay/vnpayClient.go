@@ -0,0 +1,160 @@
+package vnpayclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-service/client/payment"
+	"order-service/config"
+	appError "order-service/error"
+	"order-service/model"
+)
+
+const (
+	vnpVersion = "2.1.0"
+	// Payment links expire after this long; VNPay also rejects them afterwards
+	paymentTimeout = 15 * time.Minute
+)
+
+// VNPay timestamps are in Vietnam time regardless of the server time zone
+var vietnamTime = time.FixedZone("ICT", 7*60*60)
+
+type VNPayClient struct {
+	config *config.VNPayConfig
+}
+
+var _ payment.PaymentClient = (*VNPayClient)(nil)
+var _ payment.WebhookAcknowledger = (*VNPayClient)(nil)
+
+func NewVNPayClient(cfg *config.VNPayConfig) *VNPayClient {
+	return &VNPayClient{config: cfg}
+}
+
+// CreatePayment builds the signed redirect URL to the VNPay payment page.
+// VNPay sends the buyer back to successURL with the result in the query string.
+func (v *VNPayClient) CreatePayment(order *model.Order, successURL, cancelURL string) (string, error) {
+	if v.config.TmnCode == "" || v.config.HashSecret == "" {
+		return "", appError.NewAppError(500, "VNPay is not configured")
+	}
+
+	amount := order.AmountDue()
+	if amount.Currency != "VND" {
+		return "", appError.NewAppError(400, "VNPay only accepts VND payments")
+	}
+
+	now := time.Now().In(vietnamTime)
+	params := url.Values{}
+	params.Set("vnp_Version", vnpVersion)
+	params.Set("vnp_Command", "pay")
+	params.Set("vnp_TmnCode", v.config.TmnCode)
+	params.Set("vnp_Amount", strconv.FormatInt(amount.ToUnits(2), 10)) // VNPay amounts carry two implied decimals
+	params.Set("vnp_CurrCode", "VND")
+	params.Set("vnp_TxnRef", payment.GatewayReference(order.ID))
+	params.Set("vnp_OrderInfo", "Thanh toan don hang "+order.ID)
+	params.Set("vnp_OrderType", "other")
+	params.Set("vnp_Locale", "vn")
+	params.Set("vnp_ReturnUrl", successURL)
+	params.Set("vnp_IpAddr", "127.0.0.1")
+	params.Set("vnp_CreateDate", now.Format("20060102150405"))
+	params.Set("vnp_ExpireDate", now.Add(paymentTimeout).Format("20060102150405"))
+
+	params.Set("vnp_SecureHash", Sign(params, v.config.HashSecret))
+	return v.config.PaymentURL + "?" + params.Encode(), nil
+}
+
+// CancelPayment is a no-op: an unpaid VNPay link simply expires
+func (v *VNPayClient) CancelPayment(ctx context.Context, paymentID string) error {
+	return nil
+}
+
+// RefundPayment is not supported through the gateway; refunds go to the buyer's wallet
+func (v *VNPayClient) RefundPayment(ctx context.Context, paymentID string) error {
+	return appError.NewAppError(501, "VNPay refunds are not supported, refund to the wallet instead")
+}
+
+// ConstructEvent verifies an IPN or return URL query string. The checksum travels
+// in the payload itself, so signature is unused.
+func (v *VNPayClient) ConstructEvent(payload []byte, _ string) (*payment.PaymentEvent, error) {
+	params, err := url.ParseQuery(string(payload))
+	if err != nil {
+		return nil, appError.NewAppErrorWithErr(400, "Invalid VNPay payload", err)
+	}
+	if !Verify(params, v.config.HashSecret) {
+		return nil, appError.NewAppErrorWithErr(400, "Invalid VNPay checksum", payment.ErrInvalidSignature)
+	}
+
+	amount, _ := strconv.ParseInt(params.Get("vnp_Amount"), 10, 64)
+	event := &payment.PaymentEvent{
+		Type:      payment.EventPaymentFailed,
+		OrderID:   payment.OrderIDFromReference(params.Get("vnp_TxnRef")),
+		PaymentID: params.Get("vnp_TransactionNo"),
+		Amount:    model.MoneyFromUnits(amount, 2, "VND"),
+		Verified:  true,
+	}
+	if params.Get("vnp_ResponseCode") == "00" && params.Get("vnp_TransactionStatus") == "00" {
+		event.Type = payment.EventPaymentSucceeded
+	}
+	return event, nil
+}
+
+// AcknowledgeWebhook replies in the format VNPay expects from an IPN endpoint.
+// VNPay retries the IPN unless RspCode is 00 or 02.
+func (v *VNPayClient) AcknowledgeWebhook(err error) (int, any) {
+	if err == nil {
+		return http.StatusOK, ipnResponse("00", "Confirm Success")
+	}
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		return http.StatusOK, ipnResponse("97", "Invalid Checksum")
+	}
+
+	var appErr *appError.AppError
+	if errors.As(err, &appErr) {
+		switch appErr.Code {
+		case http.StatusNotFound:
+			return http.StatusOK, ipnResponse("01", "Order not found")
+		case http.StatusConflict:
+			return http.StatusOK, ipnResponse("02", "Order already confirmed")
+		case http.StatusBadRequest:
+			return http.StatusOK, ipnResponse("04", "Invalid amount")
+		}
+	}
+	return http.StatusOK, ipnResponse("99", "Unknown error")
+}
+
+func ipnResponse(code, message string) map[string]string {
+	return map[string]string{"RspCode": code, "Message": message}
+}
+
+// Sign computes vnp_SecureHash: HMAC-SHA512 over the vnp_ parameters sorted by key
+// and URL-encoded, without the hash fields themselves.
+func Sign(params url.Values, secret string) string {
+	signed := url.Values{}
+	for key, values := range params {
+		if !strings.HasPrefix(key, "vnp_") || key == "vnp_SecureHash" || key == "vnp_SecureHashType" {
+			continue
+		}
+		if len(values) == 0 || values[0] == "" {
+			continue
+		}
+		signed.Set(key, values[0])
+	}
+
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write([]byte(signed.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks vnp_SecureHash against the other parameters
+func Verify(params url.Values, secret string) bool {
+	expected := Sign(params, secret)
+	received := strings.ToLower(params.Get("vnp_SecureHash"))
+	return hmac.Equal([]byte(expected), []byte(received))
+}
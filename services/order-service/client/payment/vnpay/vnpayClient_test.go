@@ -0,0 +1,192 @@
+package vnpayclient
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"order-service/client/payment"
+	"order-service/config"
+	appError "order-service/error"
+	"order-service/model"
+)
+
+// Secret the payloads in testdata were signed with
+const testHashSecret = "VNPAYSANDBOXSECRET0123456789ABCD"
+
+const testOrderID = "3f2c9a1e-7b4d-4c1a-9e2f-5d6b8a0c1e2f"
+
+func newTestClient() *VNPayClient {
+	return NewVNPayClient(&config.VNPayConfig{
+		TmnCode:    "SBXTMN01",
+		HashSecret: testHashSecret,
+		PaymentURL: "https://sandbox.vnpayment.vn/paymentv2/vpcpay.html",
+	})
+}
+
+func readPayload(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestConstructEvent(t *testing.T) {
+	success := readPayload(t, "ipn_success.txt")
+	cancelled := readPayload(t, "return_cancelled.txt")
+
+	tests := []struct {
+		name          string
+		payload       string
+		wantErr       error
+		wantType      string
+		wantPaymentID string
+		wantAmount    model.Money
+	}{
+		{
+			name:          "successful IPN",
+			payload:       success,
+			wantType:      payment.EventPaymentSucceeded,
+			wantPaymentID: "14226112",
+			wantAmount:    model.NewMoney(100000, "VND"),
+		},
+		{
+			name:          "return after the buyer cancelled",
+			payload:       cancelled,
+			wantType:      payment.EventPaymentFailed,
+			wantPaymentID: "0",
+			wantAmount:    model.NewMoney(100000, "VND"),
+		},
+		{
+			name:    "tampered signature",
+			payload: success[:len(success)-1] + flipHex(success[len(success)-1:]),
+			wantErr: payment.ErrInvalidSignature,
+		},
+		{
+			name:    "amount changed after signing",
+			payload: strings.Replace(success, "vnp_Amount=10000000", "vnp_Amount=100000", 1),
+			wantErr: payment.ErrInvalidSignature,
+		},
+		{
+			name:    "failed payment reported as successful",
+			payload: strings.Replace(cancelled, "vnp_ResponseCode=24", "vnp_ResponseCode=00", 1),
+			wantErr: payment.ErrInvalidSignature,
+		},
+		{
+			name:    "missing signature",
+			payload: success[:strings.Index(success, "&vnp_SecureHashType")],
+			wantErr: payment.ErrInvalidSignature,
+		},
+	}
+
+	client := newTestClient()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := client.ConstructEvent([]byte(tt.payload), "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if event.Type != tt.wantType {
+				t.Errorf("type = %s, want %s", event.Type, tt.wantType)
+			}
+			if event.OrderID != testOrderID {
+				t.Errorf("order ID = %s, want %s", event.OrderID, testOrderID)
+			}
+			if event.PaymentID != tt.wantPaymentID {
+				t.Errorf("payment ID = %s, want %s", event.PaymentID, tt.wantPaymentID)
+			}
+			if event.Amount != tt.wantAmount {
+				t.Errorf("amount = %v, want %v", event.Amount, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestConstructEventWrongSecret(t *testing.T) {
+	client := NewVNPayClient(&config.VNPayConfig{TmnCode: "SBXTMN01", HashSecret: "another-secret"})
+	_, err := client.ConstructEvent([]byte(readPayload(t, "ipn_success.txt")), "")
+	if !errors.Is(err, payment.ErrInvalidSignature) {
+		t.Fatalf("got error %v, want %v", err, payment.ErrInvalidSignature)
+	}
+}
+
+func TestCreatePaymentSignsURL(t *testing.T) {
+	order := &model.Order{ID: testOrderID, Total: 95000, DeliveryFee: 15000, WalletAmount: 10000, Currency: "VND"}
+
+	paymentURL, err := newTestClient().CreatePayment(order, "https://shop.example/payment/return", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := url.Parse(paymentURL)
+	if err != nil {
+		t.Fatalf("invalid payment URL %s: %v", paymentURL, err)
+	}
+	params := parsed.Query()
+
+	if !Verify(params, testHashSecret) {
+		t.Errorf("payment URL signature does not verify: %s", paymentURL)
+	}
+	if got := params.Get("vnp_Amount"); got != "10000000" {
+		t.Errorf("vnp_Amount = %s, want 10000000 (amount due with two implied decimals)", got)
+	}
+	if got := payment.OrderIDFromReference(params.Get("vnp_TxnRef")); got != testOrderID {
+		t.Errorf("vnp_TxnRef order ID = %s, want %s", got, testOrderID)
+	}
+}
+
+func TestCreatePaymentRejectsOtherCurrencies(t *testing.T) {
+	order := &model.Order{ID: testOrderID, Total: 1000, Currency: "USD"}
+	_, err := newTestClient().CreatePayment(order, "https://shop.example/payment/return", "")
+
+	var appErr *appError.AppError
+	if !errors.As(err, &appErr) || appErr.Code != http.StatusBadRequest {
+		t.Fatalf("got error %v, want a 400 app error", err)
+	}
+}
+
+func TestAcknowledgeWebhook(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{name: "handled", err: nil, wantCode: "00"},
+		{name: "invalid checksum", err: appError.NewAppErrorWithErr(400, "Invalid VNPay checksum", payment.ErrInvalidSignature), wantCode: "97"},
+		{name: "unknown order", err: appError.NewAppError(404, "order not found"), wantCode: "01"},
+		{name: "already confirmed", err: appError.NewAppError(409, "order is already paid"), wantCode: "02"},
+		{name: "wrong amount", err: appError.NewAppError(400, "paid amount does not match the order"), wantCode: "04"},
+		{name: "other failure", err: errors.New("database unavailable"), wantCode: "99"},
+	}
+
+	client := newTestClient()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := client.AcknowledgeWebhook(tt.err)
+			if status != http.StatusOK {
+				t.Errorf("status = %d, want %d", status, http.StatusOK)
+			}
+			if got := body.(map[string]string)["RspCode"]; got != tt.wantCode {
+				t.Errorf("RspCode = %s, want %s", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+// flipHex changes a hex digit so that a signature no longer matches
+func flipHex(digit string) string {
+	if digit == "0" {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,242 @@
+package walletclient
+
+import (
+	"context"
+	"fmt"
+
+	"order-service/client/payment"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+)
+
+// WalletClient pays orders from the platform wallet. Every balance change is a
+// double-entry posting in the wallet ledger, so the wallet part of an order can
+// be combined with a gateway payment for the rest.
+type WalletClient struct {
+	repo repository.WalletRepository
+}
+
+var _ payment.PaymentClient = (*WalletClient)(nil)
+
+func NewWalletClient(repo repository.WalletRepository) *WalletClient {
+	return &WalletClient{repo: repo}
+}
+
+// CreatePayment moves order.WalletAmount from the buyer's wallet to the order.
+// No redirect is needed, so the success URL is returned as is.
+func (w *WalletClient) CreatePayment(order *model.Order, successURL, cancelURL string) (string, error) {
+	amount := order.WalletAmount
+	if amount <= 0 {
+		return "", appError.NewAppError(400, "wallet amount must be greater than 0")
+	}
+
+	userAccount := model.UserWalletAccount(order.User.ID)
+	covered, err := w.postCovered(userAccount, model.WalletPayment, order.User.ID, order.ID, fmt.Sprintf("Payment for order %s", order.ID),
+		model.WalletEntry{Account: userAccount, Amount: -amount},
+		model.WalletEntry{Account: model.OrderWalletAccount(order.ID), Amount: amount},
+	)
+	if err != nil {
+		return "", err
+	}
+	if !covered {
+		return "", appError.NewAppError(409, "not enough wallet balance")
+	}
+
+	return successURL, nil
+}
+
+// CancelPayment gives back the wallet part of an order that was never created or paid.
+// paymentID is the order ID.
+func (w *WalletClient) CancelPayment(ctx context.Context, paymentID string) error {
+	paid, err := w.repo.FindTransactionByReference(model.WalletPayment, paymentID)
+	if err != nil {
+		return err
+	}
+	if paid == nil {
+		return nil
+	}
+
+	// Not covered when the order no longer holds the payment, e.g. it was refunded
+	_, err = w.postCovered(model.OrderWalletAccount(paymentID), model.WalletPaymentReversal, paid.UserID, paymentID,
+		fmt.Sprintf("Payment reversed for order %s", paymentID),
+		reverseEntries(paid.Entries)...,
+	)
+	return err
+}
+
+// RefundPayment moves everything the order holds, wallet and gateway parts alike,
+// back to the buyer's wallet. It is posted once per order. paymentID is the order ID.
+func (w *WalletClient) RefundPayment(ctx context.Context, paymentID string) error {
+	orderAccount := model.OrderWalletAccount(paymentID)
+	held, err := w.repo.GetAccountBalance(orderAccount)
+	if err != nil {
+		return err
+	}
+	if held <= 0 {
+		return nil
+	}
+
+	userID, err := w.orderOwner(paymentID)
+	if err != nil {
+		return err
+	}
+	if userID == "" {
+		return appError.NewAppError(404, "no wallet payment found for order")
+	}
+
+	covered, err := w.postCovered(orderAccount, model.WalletRefund, userID, paymentID, fmt.Sprintf("Refund for order %s", paymentID),
+		model.WalletEntry{Account: orderAccount, Amount: -held},
+		model.WalletEntry{Account: model.UserWalletAccount(userID), Amount: held},
+	)
+	if err != nil {
+		return err
+	}
+	if !covered {
+		// Part of what was held was refunded meanwhile: refund what is left
+		return w.RefundPayment(ctx, paymentID)
+	}
+	return nil
+}
+
+// RefundExcess moves what the order holds above keep back to the buyer's wallet,
+// e.g. after items were cancelled from a paid order. It is idempotent per reference
+// and returns the amount refunded.
+func (w *WalletClient) RefundExcess(ctx context.Context, orderID string, keep int, reference string) (int, error) {
+	existing, err := w.repo.FindTransactionByReference(model.WalletPartialRefund, reference)
+	if err != nil {
+		return 0, err
+	}
+	if existing != nil {
+		for _, entry := range existing.Entries {
+			if entry.Amount > 0 {
+				return entry.Amount, nil
+			}
+		}
+		return 0, nil
+	}
+
+	orderAccount := model.OrderWalletAccount(orderID)
+	held, err := w.repo.GetAccountBalance(orderAccount)
+	if err != nil {
+		return 0, err
+	}
+	excess := held - keep
+	if excess <= 0 {
+		return 0, nil
+	}
+
+	userID, err := w.orderOwner(orderID)
+	if err != nil {
+		return 0, err
+	}
+	if userID == "" {
+		return 0, appError.NewAppError(404, "no wallet payment found for order")
+	}
+
+	covered, err := w.postCovered(orderAccount, model.WalletPartialRefund, userID, reference, fmt.Sprintf("Partial refund for order %s", orderID),
+		model.WalletEntry{Account: orderAccount, Amount: -excess},
+		model.WalletEntry{Account: model.UserWalletAccount(userID), Amount: excess},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if !covered {
+		// The order was refunded meanwhile: work out the excess again
+		return w.RefundExcess(ctx, orderID, keep, reference)
+	}
+	return excess, nil
+}
+
+// ConstructEvent is not supported: wallet payments settle synchronously and have no webhook
+func (w *WalletClient) ConstructEvent(payload []byte, signature string) (*payment.PaymentEvent, error) {
+	return nil, appError.NewAppError(400, "wallet payments have no webhook events")
+}
+
+// Balance returns the current wallet balance of a user
+func (w *WalletClient) Balance(userID string) (int, error) {
+	return w.repo.GetAccountBalance(model.UserWalletAccount(userID))
+}
+
+// TopUp credits a completed Stripe top-up to the user's wallet. It is idempotent per session.
+func (w *WalletClient) TopUp(userID string, amount int, sessionID string) error {
+	return w.post(model.WalletTopUp, userID, sessionID, "Wallet top-up",
+		model.WalletEntry{Account: model.WalletAccountGatewayClearing, Amount: -amount},
+		model.WalletEntry{Account: model.UserWalletAccount(userID), Amount: amount},
+	)
+}
+
+// CreditGiftCard credits a claimed gift card to the user's wallet
+func (w *WalletClient) CreditGiftCard(userID string, giftCard *model.GiftCard) error {
+	return w.post(model.WalletGiftCard, userID, giftCard.Code, "Gift card redeemed",
+		model.WalletEntry{Account: model.WalletAccountGiftCards, Amount: -giftCard.Amount},
+		model.WalletEntry{Account: model.UserWalletAccount(userID), Amount: giftCard.Amount},
+	)
+}
+
+// RecordCardCapture records the part of an order paid through a gateway as held by the order, so a
+// later refund into the wallet covers it too. It is idempotent per order.
+func (w *WalletClient) RecordCardCapture(order *model.Order, amount int) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	return w.post(model.WalletCardCapture, order.User.ID, order.ID, fmt.Sprintf("Card payment for order %s", order.ID),
+		model.WalletEntry{Account: model.WalletAccountGatewayClearing, Amount: -amount},
+		model.WalletEntry{Account: model.OrderWalletAccount(order.ID), Amount: amount},
+	)
+}
+
+func (w *WalletClient) post(transactionType, userID, reference, description string, entries ...model.WalletEntry) error {
+	transaction, err := newTransaction(transactionType, userID, reference, description, entries)
+	if err != nil {
+		return err
+	}
+	return w.repo.CreateTransaction(transaction)
+}
+
+// postCovered posts a transaction that debits account, unless the account's
+// balance does not cover it; it reports false then
+func (w *WalletClient) postCovered(account, transactionType, userID, reference, description string, entries ...model.WalletEntry) (bool, error) {
+	transaction, err := newTransaction(transactionType, userID, reference, description, entries)
+	if err != nil {
+		return false, err
+	}
+	return w.repo.CreateCoveredTransaction(transaction, account)
+}
+
+func newTransaction(transactionType, userID, reference, description string, entries []model.WalletEntry) (*model.WalletTransaction, error) {
+	transaction := &model.WalletTransaction{
+		Type:        transactionType,
+		UserID:      userID,
+		Reference:   reference,
+		Entries:     entries,
+		Description: description,
+	}
+	if err := transaction.Validate(); err != nil {
+		return nil, appError.NewAppErrorWithErr(500, "invalid wallet transaction", err)
+	}
+	return transaction, nil
+}
+
+// orderOwner finds the buyer of an order from the postings that funded it
+func (w *WalletClient) orderOwner(orderID string) (string, error) {
+	for _, transactionType := range []string{model.WalletPayment, model.WalletCardCapture} {
+		transaction, err := w.repo.FindTransactionByReference(transactionType, orderID)
+		if err != nil {
+			return "", err
+		}
+		if transaction != nil {
+			return transaction.UserID, nil
+		}
+	}
+	return "", nil
+}
+
+func reverseEntries(entries []model.WalletEntry) []model.WalletEntry {
+	reversed := make([]model.WalletEntry, len(entries))
+	for i, entry := range entries {
+		reversed[i] = model.WalletEntry{Account: entry.Account, Amount: -entry.Amount}
+	}
+	return reversed
+}
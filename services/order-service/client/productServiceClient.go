@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"order-service/dto"
 	"os"
 	"time"
@@ -34,7 +35,8 @@ func NewProductServiceClient() *ProductServiceClient {
 
 // GetVariantsByIdsRequest represents the request to product-service
 type GetVariantsByIdsRequest struct {
-	VariantIDs []string `json:"variant_ids"`
+	VariantIDs      []string `json:"variant_ids"`
+	IncludeDisabled bool     `json:"include_disabled,omitempty"`
 }
 
 // GetVariantsByIdsResponse represents the response from product-service
@@ -44,13 +46,24 @@ type GetVariantsByIdsResponse struct {
 
 // GetVariantsByIds calls product-service to get variant details
 func (c *ProductServiceClient) GetVariantsByIds(variantIDs []string) ([]dto.ProductVariantDto, error) {
+	return c.getVariantsByIds(variantIDs, false)
+}
+
+// GetVariantsByIdsIncludingDisabled also returns the variants of disabled
+// products, flagged with IsDisabled. Variants it doesn't return were deleted.
+func (c *ProductServiceClient) GetVariantsByIdsIncludingDisabled(variantIDs []string) ([]dto.ProductVariantDto, error) {
+	return c.getVariantsByIds(variantIDs, true)
+}
+
+func (c *ProductServiceClient) getVariantsByIds(variantIDs []string, includeDisabled bool) ([]dto.ProductVariantDto, error) {
 	if len(variantIDs) == 0 {
 		return []dto.ProductVariantDto{}, nil
 	}
 
 	// Prepare request
 	requestBody := GetVariantsByIdsRequest{
-		VariantIDs: variantIDs,
+		VariantIDs:      variantIDs,
+		IncludeDisabled: includeDisabled,
 	}
 
 	jsonData, err := json.Marshal(requestBody)
@@ -88,6 +101,55 @@ func (c *ProductServiceClient) GetVariantsByIds(variantIDs []string) ([]dto.Prod
 	return response.Variants, nil
 }
 
+// GetProductsByIdsRequest represents the request to product-service
+type GetProductsByIdsRequest struct {
+	ProductIDs []string `json:"product_ids"`
+}
+
+// GetProductsByIdsResponse represents the response from product-service
+type GetProductsByIdsResponse struct {
+	Products []dto.ProductSummaryDto `json:"products"`
+}
+
+// GetProductsByIds calls product-service to get product summaries. Products it
+// doesn't return are disabled or deleted.
+func (c *ProductServiceClient) GetProductsByIds(productIDs []string) ([]dto.ProductSummaryDto, error) {
+	if len(productIDs) == 0 {
+		return []dto.ProductSummaryDto{}, nil
+	}
+
+	jsonData, err := json.Marshal(GetProductsByIdsRequest{ProductIDs: productIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/product/public/products/batch", c.baseURL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call product-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("product-service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response GetProductsByIdsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return response.Products, nil
+}
+
 func (c *ProductServiceClient) GetVoucherByID(voucherID string) (*dto.VoucherResponse, error) {
 	url := fmt.Sprintf("%s/api/product/vouchers/%s", c.baseURL, voucherID)
 	req, err := http.NewRequest("GET", url, nil)
@@ -117,6 +179,7 @@ func (c *ProductServiceClient) GetVoucherByID(voucherID string) (*dto.VoucherRes
 type ReserveStockItem struct {
 	VariantID string `json:"variant_id"`
 	Quantity  int    `json:"quantity"`
+	PreOrder  bool   `json:"pre_order,omitempty"` // draw on the pre-order quota instead of stock
 }
 
 // ReserveStockRequest represents the request to reserve stock
@@ -203,6 +266,43 @@ func (c *ProductServiceClient) ReleaseStock(orderID string) error {
 	return nil
 }
 
+// ReleaseStockItems calls product-service to release part of an order's reserved stock
+func (c *ProductServiceClient) ReleaseStockItems(orderID string, items []ReserveStockItem) error {
+	// Prepare request
+	requestBody := ReserveStockRequest{
+		OrderID: orderID,
+		Items:   items,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Make HTTP request
+	url := fmt.Sprintf("%s/api/product/stock/release-items", c.baseURL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call product-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("product-service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
 // UseVoucher calls product-service to use a voucher
 func (c *ProductServiceClient) UseVoucher(userID, voucherID string) (*dto.UseVoucherResponse, error) {
 	// Prepare request
@@ -321,3 +421,146 @@ func (c *ProductServiceClient) CheckProductsStatus(userID string, productIDs []s
 
 	return statusMap, nil
 }
+
+// GetBundleByID calls product-service to get a bundle with live component data
+func (c *ProductServiceClient) GetBundleByID(bundleID string) (*dto.BundleDto, error) {
+	url := fmt.Sprintf("%s/api/product/public/bundles/%s", c.baseURL, bundleID)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call product-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("product-service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response dto.BundleDto
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// GetBundlesByIdsRequest represents the request to product-service
+type GetBundlesByIdsRequest struct {
+	BundleIDs []string `json:"bundle_ids"`
+}
+
+// GetBundlesByIdsResponse represents the response from product-service
+type GetBundlesByIdsResponse struct {
+	Bundles []dto.BundleDto `json:"bundles"`
+}
+
+// GetBundlesByIds calls product-service to get bundles with live component
+// data, inactive ones included. Bundles it doesn't return were deleted.
+func (c *ProductServiceClient) GetBundlesByIds(bundleIDs []string) ([]dto.BundleDto, error) {
+	if len(bundleIDs) == 0 {
+		return []dto.BundleDto{}, nil
+	}
+
+	jsonData, err := json.Marshal(GetBundlesByIdsRequest{BundleIDs: bundleIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/product/public/bundles/batch", c.baseURL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call product-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("product-service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response GetBundlesByIdsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return response.Bundles, nil
+}
+
+// statsQuery encodes the time range of the stats endpoints of other services
+func statsQuery(from, to time.Time, timezone string) string {
+	query := url.Values{}
+	query.Set("from", from.Format(time.RFC3339))
+	query.Set("to", to.Format(time.RFC3339))
+	query.Set("tz", timezone)
+	return query.Encode()
+}
+
+// GetPlatformStats calls product-service for the catalog and report counts,
+// with the new products and reports of each day of [from, to)
+func (c *ProductServiceClient) GetPlatformStats(from, to time.Time, timezone string) (*dto.PlatformStatsDto, error) {
+	endpoint := fmt.Sprintf("%s/api/product/stats?%s", c.baseURL, statsQuery(from, to, timezone))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call product-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("product-service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response dto.PlatformStatsDto
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// GetCategories calls product-service for all platform categories
+func (c *ProductServiceClient) GetCategories() ([]dto.CategoryDto, error) {
+	endpoint := fmt.Sprintf("%s/api/product/public/category", c.baseURL)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call product-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("product-service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var categories []dto.CategoryDto
+	if err := json.NewDecoder(resp.Body).Decode(&categories); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return categories, nil
+}
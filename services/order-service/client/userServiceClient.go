@@ -54,3 +54,32 @@ func (c *UserServiceClient) GetUserByID(userID string) (*dto.UserResponse, error
 
 	return &response.Data, nil
 }
+
+// GetUserStats calls user-service for the account counts, with the signups of
+// each day of [from, to)
+func (c *UserServiceClient) GetUserStats(from, to time.Time, timezone string) (*dto.UserStatsDto, error) {
+	url := fmt.Sprintf("%s/api/user/stats?%s", c.baseURL, statsQuery(from, to, timezone))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call user-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user-service returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Data dto.UserStatsDto `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response.Data, nil
+}
@@ -0,0 +1,25 @@
+package config
+
+import "os"
+
+type MoMoConfig struct {
+	PartnerCode string
+	AccessKey   string
+	SecretKey   string
+	Endpoint    string
+	IPNURL      string
+}
+
+func NewMoMoConfig() *MoMoConfig {
+	endpoint := os.Getenv("MOMO_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "https://test-payment.momo.vn/v2/gateway/api/create"
+	}
+	return &MoMoConfig{
+		PartnerCode: os.Getenv("MOMO_PARTNER_CODE"),
+		AccessKey:   os.Getenv("MOMO_ACCESS_KEY"),
+		SecretKey:   os.Getenv("MOMO_SECRET_KEY"),
+		Endpoint:    endpoint,
+		IPNURL:      os.Getenv("MOMO_IPN_URL"),
+	}
+}
@@ -62,8 +62,8 @@ type UserInteractionMessage struct {
 }
 
 // PublishUserInteraction publishes a user–product interaction event to RabbitMQ.
-// action should be one of: "view", "add_to_cart", "purchase".
-// score is the weight for the action (view=1, add_to_cart=10, purchase=10).
+// action should be one of: "view", "add_to_wishlist", "add_to_cart", "purchase".
+// score is the weight for the action (view=1, add_to_wishlist=5, add_to_cart=10, purchase=10).
 // This function is best-effort: failures are logged but do not affect the main flow.
 func PublishUserInteraction(userID, productID, action string, score float64) {
 	if rabbitChannel == nil {
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+var S3Client *s3.S3
+var S3BucketName string
+
+func InitS3() {
+	region := os.Getenv("AWS_REGION")
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	bucketName := os.Getenv("AWS_S3_BUCKET_NAME")
+	endpoint := os.Getenv("AWS_S3_ENDPOINT") // Optional: for MinIO or LocalStack
+
+	if region == "" || accessKey == "" || secretKey == "" || bucketName == "" {
+		fmt.Println("Warning: missing AWS S3 configuration (AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_S3_BUCKET_NAME), invoices and exports won't be stored")
+		return
+	}
+
+	S3BucketName = bucketName
+
+	// Build AWS config with timeout
+	awsConfig := &aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.NewStaticCredentials(accessKey, secretKey, ""),
+		HTTPClient: &http.Client{
+			Timeout: 5 * time.Second, // 5 second timeout to prevent hanging
+		},
+	}
+
+	// Add endpoint if specified (for MinIO, LocalStack, etc.)
+	if endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true) // Required for MinIO and some S3-compatible services
+		awsConfig.DisableSSL = aws.Bool(true)       // Disable SSL for local development
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		fmt.Printf("Warning: failed to create AWS session: %v\n", err)
+		return
+	}
+
+	S3Client = s3.New(sess)
+}
@@ -0,0 +1,21 @@
+package config
+
+import "os"
+
+type VNPayConfig struct {
+	TmnCode    string
+	HashSecret string
+	PaymentURL string
+}
+
+func NewVNPayConfig() *VNPayConfig {
+	paymentURL := os.Getenv("VNPAY_PAYMENT_URL")
+	if paymentURL == "" {
+		paymentURL = "https://sandbox.vnpayment.vn/paymentv2/vpcpay.html"
+	}
+	return &VNPayConfig{
+		TmnCode:    os.Getenv("VNPAY_TMN_CODE"),
+		HashSecret: os.Getenv("VNPAY_HASH_SECRET"),
+		PaymentURL: paymentURL,
+	}
+}
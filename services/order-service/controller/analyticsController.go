@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsController struct {
+	service service.AnalyticsService
+}
+
+func NewAnalyticsController(service service.AnalyticsService) *AnalyticsController {
+	return &AnalyticsController{service: service}
+}
+
+func (c *AnalyticsController) GetSellerAnalytics(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+
+	var request dto.GetSellerAnalyticsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetSellerAnalytics(sellerID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *AnalyticsController) GetAdminOverview(ctx *gin.Context) {
+	var request dto.AdminAnalyticsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetAdminOverview(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *AnalyticsController) GetAdminDaily(ctx *gin.Context) {
+	var request dto.AdminAnalyticsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetAdminDaily(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
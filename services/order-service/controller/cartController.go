@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"order-service/dto"
 	appError "order-service/error"
+	"order-service/model"
 	"order-service/service"
 
 	"github.com/gin-gonic/gin"
@@ -20,14 +21,22 @@ func NewCartController(service service.CartService) *CartController {
 	return &CartController{service: service}
 }
 
+// Every cart operation exists for the logged-in user (X-User-Id) and for a guest
+// cart (X-Cart-Token). Both resolve the cart owner and share the same handler.
+
 func (c *CartController) AddCartItem(ctx *gin.Context) {
-	// Get user ID from header
-	userID := ctx.GetHeader("X-User-Id")
-	if userID == "" {
-		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found"))
-		return
+	if userID, ok := userCartOwner(ctx); ok {
+		c.addCartItem(ctx, userID)
 	}
+}
 
+func (c *CartController) AddGuestCartItem(ctx *gin.Context) {
+	if owner, ok := guestCartOwner(ctx); ok {
+		c.addCartItem(ctx, owner)
+	}
+}
+
+func (c *CartController) addCartItem(ctx *gin.Context, owner string) {
 	// Parse request body
 	var request dto.AddCartItemRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
@@ -42,7 +51,7 @@ func (c *CartController) AddCartItem(ctx *gin.Context) {
 	}
 
 	// Add cart item
-	response, err := c.service.AddCartItem(userID, request)
+	response, err := c.service.AddCartItem(owner, request)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -51,15 +60,49 @@ func (c *CartController) AddCartItem(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+func (c *CartController) AddBundleCartItem(ctx *gin.Context) {
+	if userID, ok := userCartOwner(ctx); ok {
+		c.addBundleCartItem(ctx, userID)
+	}
+}
 
-func (c *CartController) DeleteCartItem(ctx *gin.Context) {
-	// Get user ID from header
-	userID := ctx.GetHeader("X-User-Id")
-	if userID == "" {
-		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found"))
+func (c *CartController) AddGuestBundleCartItem(ctx *gin.Context) {
+	if owner, ok := guestCartOwner(ctx); ok {
+		c.addBundleCartItem(ctx, owner)
+	}
+}
+
+func (c *CartController) addBundleCartItem(ctx *gin.Context, owner string) {
+	// Parse request body
+	var request dto.AddBundleCartItemRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	// Add bundle cart item
+	response, err := c.service.AddBundleCartItem(owner, request)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *CartController) DeleteCartItem(ctx *gin.Context) {
+	if userID, ok := userCartOwner(ctx); ok {
+		c.deleteCartItem(ctx, userID)
+	}
+}
+
+func (c *CartController) DeleteGuestCartItem(ctx *gin.Context) {
+	if owner, ok := guestCartOwner(ctx); ok {
+		c.deleteCartItem(ctx, owner)
+	}
+}
+
+func (c *CartController) deleteCartItem(ctx *gin.Context, owner string) {
 	// Get cart item ID from URL parameter
 	cartItemID := ctx.Param("id")
 	if cartItemID == "" {
@@ -68,7 +111,7 @@ func (c *CartController) DeleteCartItem(ctx *gin.Context) {
 	}
 
 	// Delete cart item
-	err := c.service.DeleteCartItem(userID, cartItemID)
+	err := c.service.DeleteCartItem(owner, cartItemID)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -78,13 +121,18 @@ func (c *CartController) DeleteCartItem(ctx *gin.Context) {
 }
 
 func (c *CartController) UpdateCartItemQuantity(ctx *gin.Context) {
-	// Get user ID from header
-	userID := ctx.GetHeader("X-User-Id")
-	if userID == "" {
-		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found"))
-		return
+	if userID, ok := userCartOwner(ctx); ok {
+		c.updateCartItemQuantity(ctx, userID)
+	}
+}
+
+func (c *CartController) UpdateGuestCartItemQuantity(ctx *gin.Context) {
+	if owner, ok := guestCartOwner(ctx); ok {
+		c.updateCartItemQuantity(ctx, owner)
 	}
+}
 
+func (c *CartController) updateCartItemQuantity(ctx *gin.Context, owner string) {
 	// Get cart item ID from URL parameter
 	cartItemID := ctx.Param("id")
 	if cartItemID == "" {
@@ -106,7 +154,7 @@ func (c *CartController) UpdateCartItemQuantity(ctx *gin.Context) {
 	}
 
 	// Update cart item quantity
-	response, err := c.service.UpdateCartItemQuantity(userID, cartItemID, request.Quantity)
+	response, err := c.service.UpdateCartItemQuantity(owner, cartItemID, request.Quantity)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -116,38 +164,43 @@ func (c *CartController) UpdateCartItemQuantity(ctx *gin.Context) {
 }
 
 func (c *CartController) GetCartItems(ctx *gin.Context) {
-	// Get user ID from header
-	userID := ctx.GetHeader("X-User-Id")
-	if userID == "" {
-		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found"))
-		return
+	if userID, ok := userCartOwner(ctx); ok {
+		c.getCartItems(ctx, userID)
 	}
+}
 
-	// Get cart items from service
-	cartItems, err := c.service.GetCartItems(userID)
+func (c *CartController) GetGuestCartItems(ctx *gin.Context) {
+	if owner, ok := guestCartOwner(ctx); ok {
+		c.getCartItems(ctx, owner)
+	}
+}
+
+func (c *CartController) getCartItems(ctx *gin.Context, owner string) {
+	// Get the revalidated cart from service
+	response, err := c.service.GetCartItems(owner)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	// Build response
-	response := dto.GetCartItemsResponse{
-		CartItems: cartItems,
-	}
-
 	ctx.JSON(http.StatusOK, response)
 }
 
 func (c *CartController) GetCartItemCount(ctx *gin.Context) {
-	// Get user ID from header
-	userID := ctx.GetHeader("X-User-Id")
-	if userID == "" {
-		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found"))
-		return
+	if userID, ok := userCartOwner(ctx); ok {
+		c.getCartItemCount(ctx, userID)
 	}
+}
+
+func (c *CartController) GetGuestCartItemCount(ctx *gin.Context) {
+	if owner, ok := guestCartOwner(ctx); ok {
+		c.getCartItemCount(ctx, owner)
+	}
+}
 
+func (c *CartController) getCartItemCount(ctx *gin.Context, owner string) {
 	// Get cart item count from service
-	count, err := c.service.GetCartItemCount(userID)
+	count, err := c.service.GetCartItemCount(owner)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -156,3 +209,58 @@ func (c *CartController) GetCartItemCount(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"count": count})
 }
 
+// CreateGuestCart hands out a token for a new guest cart
+func (c *CartController) CreateGuestCart(ctx *gin.Context) {
+	response, err := c.service.CreateGuestCartToken()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("X-Cart-Token", response.CartToken)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// MergeGuestCart moves a guest cart into the logged-in user's cart, typically
+// right after login
+func (c *CartController) MergeGuestCart(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.MergeGuestCartRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.MergeGuestCart(userID, request.CartToken)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// userCartOwner returns the logged-in user's ID
+func userCartOwner(ctx *gin.Context) (string, bool) {
+	// Get user ID from header
+	userID := ctx.GetHeader("X-User-Id")
+	if userID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found"))
+		return "", false
+	}
+	return userID, true
+}
+
+// guestCartOwner returns the owner of the guest cart in the X-Cart-Token header
+func guestCartOwner(ctx *gin.Context) (string, bool) {
+	token := ctx.GetHeader("X-Cart-Token")
+	if !model.IsValidGuestCartToken(token) {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Missing or invalid cart token"))
+		return "", false
+	}
+	return model.GuestCartOwner(token), true
+}
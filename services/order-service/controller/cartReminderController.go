@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CartReminderController struct {
+	service service.CartReminderService
+}
+
+func NewCartReminderController(service service.CartReminderService) *CartReminderController {
+	return &CartReminderController{service: service}
+}
+
+// GetPreference tells whether the user gets abandoned cart reminders
+func (c *CartReminderController) GetPreference(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	response, err := c.service.GetPreference(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// UpdatePreference turns abandoned cart reminders on or off
+func (c *CartReminderController) UpdatePreference(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.UpdateCartReminderPreferenceRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.UpdatePreference(userID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CodReconciliationController struct {
+	service service.CodReconciliationService
+}
+
+func NewCodReconciliationController(service service.CodReconciliationService) *CodReconciliationController {
+	return &CodReconciliationController{service: service}
+}
+
+// ImportSettlement imports a carrier settlement report uploaded as the "file"
+// form field
+func (c *CodReconciliationController) ImportSettlement(ctx *gin.Context) {
+	adminID := ctx.GetHeader("X-User-Id")
+	if adminID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found in header"))
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Failed to get settlement file", err))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Failed to read settlement file", err))
+		return
+	}
+	defer file.Close()
+
+	response, err := c.service.ImportSettlement(adminID, fileHeader.Filename, file)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *CodReconciliationController) GetSettlements(ctx *gin.Context) {
+	var request dto.GetCodSettlementsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetSettlements(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetSettlement returns a settlement with its lines, optionally only those
+// with the status query parameter
+func (c *CodReconciliationController) GetSettlement(ctx *gin.Context) {
+	var request dto.GetCodSettlementRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetSettlement(ctx.Param("settlementId"), request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetMissingRemittances returns the COD parcels the carrier should have remitted by now
+func (c *CodReconciliationController) GetMissingRemittances(ctx *gin.Context) {
+	var request dto.GetMissingRemittancesRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetMissingRemittances(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
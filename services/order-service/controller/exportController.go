@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportController struct {
+	service service.ExportService
+}
+
+func NewExportController(service service.ExportService) *ExportController {
+	return &ExportController{service: service}
+}
+
+// ExportSellerOrders streams the seller's orders as CSV or XLSX, one row per item.
+// Large exports run as a background job instead and respond 202 with the job.
+func (c *ExportController) ExportSellerOrders(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+
+	var request dto.ExportSellerOrdersRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	job, err := c.service.ExportSellerOrders(sellerID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if job != nil {
+		ctx.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	format := service.ExportFormat(request.Format)
+	filename := fmt.Sprintf("orders-%s.%s", time.Now().Format("20060102-150405"), format)
+	ctx.Header("Content-Type", service.ExportContentType(format))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Status(http.StatusOK)
+
+	// Headers are already sent, so a failure can only cut the file short
+	if err := c.service.StreamSellerOrders(ctx.Request.Context(), sellerID, request, ctx.Writer); err != nil {
+		fmt.Printf("Warning: seller %s export failed: %v\n", sellerID, err)
+	}
+}
+
+func (c *ExportController) GetExportJob(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+
+	response, err := c.service.GetJob(sellerID, ctx.Param("jobId"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
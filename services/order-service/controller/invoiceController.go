@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	appError "order-service/error"
+	"order-service/middleware"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvoiceController struct {
+	service service.InvoiceService
+}
+
+func NewInvoiceController(service service.InvoiceService) *InvoiceController {
+	return &InvoiceController{service: service}
+}
+
+// GetInvoice returns the order's invoice as a PDF
+func (c *InvoiceController) GetInvoice(ctx *gin.Context) {
+	orderID := ctx.Param("orderId")
+	if orderID == "" {
+		ctx.Error(appError.NewAppError(http.StatusBadRequest, "Order ID is required"))
+		return
+	}
+
+	userID := ctx.GetHeader("X-User-Id")
+	if userID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found in header"))
+		return
+	}
+	isAdmin := ctx.GetHeader("X-User-Role") == middleware.RoleAdmin
+
+	data, invoice, err := c.service.GetInvoice(orderID, userID, isAdmin)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, invoice.Number))
+	ctx.Data(http.StatusOK, "application/pdf", data)
+}
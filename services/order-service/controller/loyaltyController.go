@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoyaltyController struct {
+	service service.LoyaltyService
+}
+
+func NewLoyaltyController(service service.LoyaltyService) *LoyaltyController {
+	return &LoyaltyController{service: service}
+}
+
+func (c *LoyaltyController) GetPointsHistory(ctx *gin.Context) {
+	userID := ctx.GetHeader("X-User-Id")
+	if userID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found in header"))
+		return
+	}
+
+	var request dto.GetLoyaltyHistoryRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetHistory(userID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *LoyaltyController) UpdateRate(ctx *gin.Context) {
+	var request dto.UpdateLoyaltyRateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.UpdateRate(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"encoding/json"
 	"io"
 	"net/http"
 	"order-service/client"
@@ -11,19 +10,20 @@ import (
 	"order-service/service"
 
 	"github.com/gin-gonic/gin"
-	"github.com/stripe/stripe-go/v84"
 )
 
 type OrderController struct {
 	service       service.OrderService
-	paymentClient payment.PaymentClient
+	payments      *payment.Registry
+	walletService service.WalletService
 	GHNClient     *client.GHNClient
 }
 
-func NewOrderController(service service.OrderService, paymentClient payment.PaymentClient) *OrderController {
+func NewOrderController(service service.OrderService, payments *payment.Registry, walletService service.WalletService) *OrderController {
 	return &OrderController{
 		service:       service,
-		paymentClient: paymentClient,
+		payments:      payments,
+		walletService: walletService,
 		GHNClient:     client.NewGHNClient(),
 	}
 }
@@ -50,76 +50,103 @@ func (c *OrderController) Checkout(ctx *gin.Context) {
 	ctx.JSON(200, response)
 }
 
-func (c *OrderController) StripeWebhook(ctx *gin.Context) {
+// PaymentWebhook handles gateway callbacks: Stripe webhooks, VNPay IPN (GET) and MoMo IPN (POST)
+func (c *OrderController) PaymentWebhook(ctx *gin.Context) {
 	const MaxBodyBytes = int64(65536)
 
-	// Stripe yêu cầu raw body
-	ctx.Request.Body = http.MaxBytesReader(
-		ctx.Writer,
-		ctx.Request.Body,
-		MaxBodyBytes,
-	)
-
-	payload, err := io.ReadAll(ctx.Request.Body)
+	gateway, err := c.payments.Get(ctx.Param("provider"))
 	if err != nil {
-		ctx.Error(appError.NewAppErrorWithErr(400, "Cannot read webhook body", err))
+		ctx.Error(err)
 		return
 	}
 
-	signature := ctx.GetHeader("Stripe-Signature")
+	// Gateways sign the raw body, or the raw query string for GET callbacks
+	var payload []byte
+	if ctx.Request.Method == http.MethodGet {
+		payload = []byte(ctx.Request.URL.RawQuery)
+	} else {
+		ctx.Request.Body = http.MaxBytesReader(
+			ctx.Writer,
+			ctx.Request.Body,
+			MaxBodyBytes,
+		)
+
+		payload, err = io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.Error(appError.NewAppErrorWithErr(400, "Cannot read webhook body", err))
+			return
+		}
+	}
+
+	_, err = c.handlePaymentEvent(ctx, gateway, payload, ctx.GetHeader("Stripe-Signature"))
+
+	// Some gateways expect a specific reply, and keep retrying otherwise
+	if acknowledger, ok := gateway.(payment.WebhookAcknowledger); ok {
+		status, body := acknowledger.AcknowledgeWebhook(err)
+		if body == nil {
+			ctx.Status(status)
+		} else {
+			ctx.JSON(status, body)
+		}
+		return
+	}
 
-	// Call stripeClient to construct event
-	event, err := c.paymentClient.ConstructEvent(payload, signature)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	switch event.Type {
+	ctx.JSON(200, gin.H{"status": "ok"})
+}
 
-	case "checkout.session.completed":
-		var session stripe.CheckoutSession
-		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
-			ctx.Error(appError.NewAppErrorWithErr(400, "Invalid session payload", err))
-			return
-		}
+// PaymentReturn verifies the query string a gateway appends when it sends the buyer back,
+// so the client can show the result. The payment is applied the same way as the IPN.
+func (c *OrderController) PaymentReturn(ctx *gin.Context) {
+	gateway, err := c.payments.Get(ctx.Param("provider"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
-		orderID := session.Metadata["order_id"]
-		if orderID == "" {
-			ctx.Error(appError.NewAppError(400, "Missing order_id in metadata"))
-			return
-		}
+	event, err := c.handlePaymentEvent(ctx, gateway, []byte(ctx.Request.URL.RawQuery), "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
-		err := c.service.HandleCheckoutSessionCompleted(
-			ctx,
-			orderID,
-			session.ID,
-			session.PaymentIntent.ID,
-		)
-		if err != nil {
-			ctx.Error(err)
-			return
-		}
+	ctx.JSON(200, dto.PaymentReturnResponse{
+		OrderID: event.OrderID,
+		Success: event.Type == payment.EventPaymentSucceeded,
+	})
+}
 
-	case "payment_intent.payment_failed":
-		var pi stripe.PaymentIntent
-		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
-			ctx.Error(appError.NewAppErrorWithErr(400, "Invalid payment_intent payload", err))
-			return
-		}
+func (c *OrderController) handlePaymentEvent(ctx *gin.Context, gateway payment.PaymentClient, payload []byte, signature string) (*payment.PaymentEvent, error) {
+	event, err := gateway.ConstructEvent(payload, signature)
+	if err != nil {
+		return nil, err
+	}
 
-		err := c.service.HandlePaymentFailed(ctx, pi.ID)
-		if err != nil {
-			ctx.Error(err)
-			return
+	switch event.Type {
+	case payment.EventPaymentSucceeded:
+		// Wallet top-ups share the webhook with order payments; they credit
+		// money, so only a signed event may trigger one
+		if userID := event.Metadata[service.WalletTopUpMetadataKey]; userID != "" {
+			if !event.Verified {
+				return nil, appError.NewAppErrorWithErr(400, "Unverified wallet top-up", payment.ErrInvalidSignature)
+			}
+			return event, c.walletService.HandleTopUpCompleted(event.SessionID, userID, event.Amount)
+		}
+		if event.OrderID == "" {
+			return nil, appError.NewAppError(400, "Missing order ID in payment event")
 		}
+		return event, c.service.HandlePaymentSucceeded(ctx, event)
 
-	default:
-		// Ignore other events
+	case payment.EventPaymentFailed:
+		return event, c.service.HandlePaymentFailed(ctx, event)
 	}
 
-	// Stripe cần 200 OK
-	ctx.JSON(200, gin.H{"status": "ok"})
+	// Ignore other events
+	return event, nil
 }
 
 func (c *OrderController) CreatePayment(ctx *gin.Context) {
@@ -210,6 +237,122 @@ func (c *OrderController) UpdateOrderStatus(ctx *gin.Context) {
 	ctx.JSON(200, "Updated order status successfully")
 }
 
+func (c *OrderController) RequestReturn(ctx *gin.Context) {
+	orderID := ctx.Param("orderId")
+	if orderID == "" {
+		ctx.Error(appError.NewAppError(400, "Order ID is required"))
+		return
+	}
+
+	userID := ctx.GetHeader("X-User-Id")
+	if userID == "" {
+		ctx.Error(appError.NewAppError(401, "User ID not found in header"))
+		return
+	}
+
+	var request dto.ReturnRequestRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(400, "Invalid request body", err))
+		return
+	}
+
+	err := c.service.RequestReturn(ctx, userID, orderID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, "Return requested successfully")
+}
+
+func (c *OrderController) CancelOrderItems(ctx *gin.Context) {
+	orderID := ctx.Param("orderId")
+	if orderID == "" {
+		ctx.Error(appError.NewAppError(400, "Order ID is required"))
+		return
+	}
+
+	userID := ctx.GetHeader("X-User-Id")
+	if userID == "" {
+		ctx.Error(appError.NewAppError(401, "User ID not found in header"))
+		return
+	}
+
+	var request dto.CancelOrderItemsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(400, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.CancelOrderItems(ctx, userID, orderID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (c *OrderController) CreateShipment(ctx *gin.Context) {
+	orderID := ctx.Param("orderId")
+	if orderID == "" {
+		ctx.Error(appError.NewAppError(400, "Order ID is required"))
+		return
+	}
+
+	userID := ctx.GetHeader("X-User-Id")
+	if userID == "" {
+		ctx.Error(appError.NewAppError(401, "User ID not found in header"))
+		return
+	}
+
+	// The body is optional: without items everything left is shipped
+	var request dto.CreateShipmentRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(appError.NewAppErrorWithErr(400, "Invalid request body", err))
+			return
+		}
+	}
+
+	response, err := c.service.CreateShipment(ctx, userID, orderID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (c *OrderController) UpdateShipmentStatus(ctx *gin.Context) {
+	orderID := ctx.Param("orderId")
+	shipmentID := ctx.Param("shipmentId")
+	if orderID == "" || shipmentID == "" {
+		ctx.Error(appError.NewAppError(400, "Order ID and shipment ID are required"))
+		return
+	}
+
+	userID := ctx.GetHeader("X-User-Id")
+	if userID == "" {
+		ctx.Error(appError.NewAppError(401, "User ID not found in header"))
+		return
+	}
+
+	var request dto.UpdateShipmentStatusRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(400, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.UpdateShipmentStatus(ctx, userID, orderID, shipmentID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
 func (c *OrderController) Test(ctx *gin.Context) {
 
 	district := ctx.Query("district")
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayoutController struct {
+	service service.PayoutService
+}
+
+func NewPayoutController(service service.PayoutService) *PayoutController {
+	return &PayoutController{service: service}
+}
+
+// GetBalance returns the calling seller's payout balance
+func (c *PayoutController) GetBalance(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+	c.balance(ctx, sellerID)
+}
+
+// GetSellerBalance returns any seller's payout balance, for admins
+func (c *PayoutController) GetSellerBalance(ctx *gin.Context) {
+	c.balance(ctx, ctx.Param("sellerId"))
+}
+
+func (c *PayoutController) balance(ctx *gin.Context, sellerID string) {
+	response, err := c.service.GetBalance(sellerID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetStatements returns the calling seller's statements
+func (c *PayoutController) GetStatements(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+	c.statements(ctx, sellerID)
+}
+
+// GetAllStatements returns the statements of all sellers, or of the seller_id
+// query parameter, for admins
+func (c *PayoutController) GetAllStatements(ctx *gin.Context) {
+	c.statements(ctx, ctx.Query("seller_id"))
+}
+
+func (c *PayoutController) statements(ctx *gin.Context, sellerID string) {
+	var request dto.GetStatementsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetStatements(sellerID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetStatement returns one of the calling seller's statements with its entries
+func (c *PayoutController) GetStatement(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+	c.statement(ctx, sellerID)
+}
+
+// GetAnyStatement returns any statement with its entries, for admins
+func (c *PayoutController) GetAnyStatement(ctx *gin.Context) {
+	c.statement(ctx, "")
+}
+
+func (c *PayoutController) statement(ctx *gin.Context, sellerID string) {
+	response, err := c.service.GetStatement(sellerID, ctx.Param("statementId"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *PayoutController) GenerateStatements(ctx *gin.Context) {
+	var request dto.GenerateStatementsRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+			return
+		}
+	}
+
+	response, err := c.service.GenerateStatements(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *PayoutController) MarkStatementPaid(ctx *gin.Context) {
+	var request dto.MarkStatementPaidRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.MarkStatementPaid(ctx.Param("statementId"), request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *PayoutController) Recompute(ctx *gin.Context) {
+	var request dto.RecomputePayoutsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.RecomputeOrders(ctx.Request.Context(), request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *PayoutController) GetCommissionRules(ctx *gin.Context) {
+	response, err := c.service.GetCommissionRules()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *PayoutController) UpdateCommissionRule(ctx *gin.Context) {
+	var request dto.UpdateCommissionRuleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.UpdateCommissionRule(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *PayoutController) DeleteCommissionRule(ctx *gin.Context) {
+	if err := c.service.DeleteCommissionRule(ctx.Param("ruleId")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Commission rule deleted"})
+}
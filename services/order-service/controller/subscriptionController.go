@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SubscriptionController struct {
+	service service.SubscriptionService
+}
+
+func NewSubscriptionController(service service.SubscriptionService) *SubscriptionController {
+	return &SubscriptionController{service: service}
+}
+
+// SetupPayment returns the secret the frontend uses to save a card for STRIPE subscriptions
+func (c *SubscriptionController) SetupPayment(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	response, err := c.service.SetupPayment(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *SubscriptionController) CreateSubscription(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.CreateSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.CreateSubscription(userID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *SubscriptionController) GetSubscriptions(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	response, err := c.service.GetSubscriptions(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"subscriptions": response})
+}
+
+func (c *SubscriptionController) GetSubscription(ctx *gin.Context) {
+	c.respond(ctx, c.service.GetSubscription)
+}
+
+// SkipNext skips the next delivery
+func (c *SubscriptionController) SkipNext(ctx *gin.Context) {
+	c.respond(ctx, c.service.SkipNext)
+}
+
+func (c *SubscriptionController) Pause(ctx *gin.Context) {
+	c.respond(ctx, c.service.Pause)
+}
+
+func (c *SubscriptionController) Resume(ctx *gin.Context) {
+	c.respond(ctx, c.service.Resume)
+}
+
+func (c *SubscriptionController) Cancel(ctx *gin.Context) {
+	c.respond(ctx, c.service.Cancel)
+}
+
+// respond runs an action on the subscription in the path and returns it
+func (c *SubscriptionController) respond(ctx *gin.Context, action func(userID, subscriptionID string) (*model.Subscription, error)) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	response, err := action(userID, ctx.Param("subscriptionId"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxController struct {
+	service service.TaxService
+}
+
+func NewTaxController(service service.TaxService) *TaxController {
+	return &TaxController{service: service}
+}
+
+func (c *TaxController) GetRates(ctx *gin.Context) {
+	response, err := c.service.GetRates()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *TaxController) UpdateRate(ctx *gin.Context) {
+	var request dto.UpdateTaxRateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.UpdateRate(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *TaxController) DeleteRate(ctx *gin.Context) {
+	if err := c.service.DeleteRate(ctx.Param("categoryId")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Tax rate deleted"})
+}
+
+func (c *TaxController) GetSellerSetting(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+
+	response, err := c.service.GetSellerSetting(sellerID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *TaxController) UpdateSellerSetting(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+
+	var request dto.UpdateSellerTaxSettingRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.UpdateSellerSetting(sellerID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetSummary returns the calling seller's monthly VAT summary
+func (c *TaxController) GetSummary(ctx *gin.Context) {
+	sellerID := ctx.GetHeader("X-User-Id")
+	if sellerID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "Seller ID not found in header"))
+		return
+	}
+	c.summary(ctx, sellerID)
+}
+
+// GetSellerSummary returns any seller's monthly VAT summary, for admins
+func (c *TaxController) GetSellerSummary(ctx *gin.Context) {
+	c.summary(ctx, ctx.Param("sellerId"))
+}
+
+func (c *TaxController) summary(ctx *gin.Context, sellerID string) {
+	var request dto.GetTaxSummaryRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetMonthlySummary(sellerID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	appError "order-service/error"
+	"order-service/middleware"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrackingController struct {
+	service service.TrackingService
+}
+
+func NewTrackingController(service service.TrackingService) *TrackingController {
+	return &TrackingController{service: service}
+}
+
+// GetOrderTracking returns the carrier tracking of every parcel of the order
+func (c *TrackingController) GetOrderTracking(ctx *gin.Context) {
+	orderID := ctx.Param("orderId")
+	if orderID == "" {
+		ctx.Error(appError.NewAppError(http.StatusBadRequest, "Order ID is required"))
+		return
+	}
+
+	userID := ctx.GetHeader("X-User-Id")
+	if userID == "" {
+		ctx.Error(appError.NewAppError(http.StatusUnauthorized, "User ID not found in header"))
+		return
+	}
+	isAdmin := ctx.GetHeader("X-User-Role") == middleware.RoleAdmin
+
+	response, err := c.service.GetOrderTracking(userID, orderID, isAdmin)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"net/http"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WishlistController struct {
+	service service.WishlistService
+}
+
+func NewWishlistController(service service.WishlistService) *WishlistController {
+	return &WishlistController{service: service}
+}
+
+// GetWishlists returns the user's lists, the default list first
+func (c *WishlistController) GetWishlists(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	response, err := c.service.GetWishlists(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"wishlists": response})
+}
+
+func (c *WishlistController) CreateWishlist(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.WishlistRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.CreateWishlist(userID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// GetWishlist returns a list with its items checked against live product data.
+// "default" can be used as the ID of the default list.
+func (c *WishlistController) GetWishlist(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	response, err := c.service.GetWishlist(userID, ctx.Param("wishlistId"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *WishlistController) RenameWishlist(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.WishlistRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.RenameWishlist(userID, ctx.Param("wishlistId"), request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *WishlistController) DeleteWishlist(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteWishlist(userID, ctx.Param("wishlistId")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Wishlist deleted successfully"})
+}
+
+func (c *WishlistController) AddItem(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.AddWishlistItemRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	response, err := c.service.AddItem(userID, ctx.Param("wishlistId"), request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *WishlistController) DeleteItem(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteItem(userID, ctx.Param("itemId")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Wishlist item deleted successfully"})
+}
+
+func (c *WishlistController) MoveItemToCart(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.MoveWishlistItemToCartRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+			return
+		}
+	}
+
+	response, err := c.service.MoveItemToCart(userID, ctx.Param("itemId"), request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *WishlistController) MoveCartItemToWishlist(ctx *gin.Context) {
+	userID, ok := userCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.MoveCartItemToWishlistRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(appError.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+			return
+		}
+	}
+
+	response, err := c.service.MoveCartItemToWishlist(userID, ctx.Param("id"), request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
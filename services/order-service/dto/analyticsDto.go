@@ -0,0 +1,188 @@
+package dto
+
+import "time"
+
+// GetSellerAnalyticsRequest selects the time range [from, to) of a seller dashboard.
+// It is compared with the range of the same length just before it.
+type GetSellerAnalyticsRequest struct {
+	From     time.Time `form:"from" binding:"required"`
+	To       time.Time `form:"to" binding:"required"`
+	Type     string    `form:"type" binding:"omitempty,oneof=day month"` // Optional breakdown
+	Currency string    `form:"currency"`                                 // Only orders in this currency are counted (default VND)
+	Limit    int       `form:"limit" binding:"omitempty,min=1,max=50"`   // Size of the top product lists (default 10)
+}
+
+// SellerAnalyticsPeriod holds a seller's totals over one time range. Revenue
+// only counts paid or completed orders.
+type SellerAnalyticsPeriod struct {
+	From              time.Time `json:"from"`
+	To                time.Time `json:"to"`
+	OrderCount        int       `json:"order_count"`         // All orders placed, whatever their status
+	RevenueOrderCount int       `json:"revenue_order_count"` // Paid or completed orders
+	Revenue           int64     `json:"revenue"`
+	AverageOrderValue int64     `json:"average_order_value"` // Revenue per paid or completed order
+	CancelledCount    int       `json:"cancelled_count"`
+	CancellationRate  float64   `json:"cancellation_rate"` // Percent of orders placed
+	ReturnedCount     int       `json:"returned_count"`
+	ReturnRate        float64   `json:"return_rate"` // Percent of delivered orders (completed or returned)
+	NewBuyers         int       `json:"new_buyers"`  // First purchase from the seller
+	RepeatBuyers      int       `json:"repeat_buyers"`
+	VoucherOrderCount int       `json:"voucher_order_count"`
+	VoucherRevenue    int64     `json:"voucher_revenue"` // Revenue of orders that used a voucher
+}
+
+// SellerAnalyticsChange compares the current period with the previous one.
+// Percent changes are null when the previous value was zero.
+type SellerAnalyticsChange struct {
+	Revenue           *float64 `json:"revenue"`             // Percent change
+	OrderCount        *float64 `json:"order_count"`         // Percent change
+	AverageOrderValue *float64 `json:"average_order_value"` // Percent change
+	CancellationRate  float64  `json:"cancellation_rate"`   // Difference in percentage points
+	ReturnRate        float64  `json:"return_rate"`         // Difference in percentage points
+}
+
+// TopItemDto is a product or variant ranked by sales. Revenue is before vouchers.
+type TopItemDto struct {
+	ProductID   string `json:"product_id"`
+	VariantID   string `json:"variant_id,omitempty"`
+	ProductName string `json:"product_name"`
+	VariantName string `json:"variant_name,omitempty"`
+	SKU         string `json:"sku,omitempty"`
+	Units       int    `json:"units"`
+	Revenue     int64  `json:"revenue"`
+}
+
+type TopItemsDto struct {
+	ByUnits   []TopItemDto `json:"by_units"`
+	ByRevenue []TopItemDto `json:"by_revenue"`
+}
+
+// SellerAnalyticsPoint holds the totals of one day or month
+type SellerAnalyticsPoint struct {
+	Period            string `json:"period"`
+	OrderCount        int    `json:"order_count"`
+	RevenueOrderCount int    `json:"revenue_order_count"`
+	Revenue           int64  `json:"revenue"`
+}
+
+// SellerAnalyticsResponse is a seller dashboard; all amounts are in Currency
+type SellerAnalyticsResponse struct {
+	Currency    string                 `json:"currency"`
+	Current     SellerAnalyticsPeriod  `json:"current"`
+	Previous    SellerAnalyticsPeriod  `json:"previous"`
+	Change      SellerAnalyticsChange  `json:"change"`
+	TopProducts TopItemsDto            `json:"top_products"`
+	TopVariants TopItemsDto            `json:"top_variants"`
+	Breakdown   []SellerAnalyticsPoint `json:"breakdown,omitempty"` // When type is specified
+}
+
+// AdminAnalyticsRequest selects whole days, from and to inclusive, as YYYY-MM-DD
+// in the analytics time zone
+type AdminAnalyticsRequest struct {
+	From     string `form:"from" binding:"required"`
+	To       string `form:"to" binding:"required"`
+	Currency string `form:"currency"`                               // Only orders in this currency are counted (default VND)
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=50"` // Size of the top seller list (default 10)
+}
+
+// StatusCountDto is the number of orders in one status
+type StatusCountDto struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+type TopSellerDto struct {
+	SellerID   string `json:"seller_id"`
+	SellerName string `json:"seller_name"`
+	OrderCount int    `json:"order_count"`
+	GMV        int64  `json:"gmv"`
+}
+
+// CategoryPerformanceDto holds the sales of one platform category. An item in
+// several categories counts in each; items without one are grouped under an empty ID.
+type CategoryPerformanceDto struct {
+	CategoryID   string `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Units        int    `json:"units"`
+	Revenue      int64  `json:"revenue"` // Before vouchers
+}
+
+// AdminUserStatsDto comes from user-service
+type AdminUserStatsDto struct {
+	TotalUsers   int64 `json:"total_users"`
+	TotalSellers int64 `json:"total_sellers"`
+	NewUsers     int64 `json:"new_users"`
+	NewSellers   int64 `json:"new_sellers"`
+}
+
+// AdminCatalogStatsDto comes from product-service
+type AdminCatalogStatsDto struct {
+	TotalProducts    int64 `json:"total_products"`
+	ActiveProducts   int64 `json:"active_products"`
+	DisabledProducts int64 `json:"disabled_products"`
+	OpenReports      int64 `json:"open_reports"`
+	NewProducts      int   `json:"new_products"`
+	NewReports       int   `json:"new_reports"`
+}
+
+// AdminOverviewResponse is the platform dashboard for a range of days. GMV is
+// what buyers paid, delivery included, for paid or completed orders. Users and
+// Catalog are null when their service could not be reached.
+type AdminOverviewResponse struct {
+	From              string                   `json:"from"`
+	To                string                   `json:"to"`
+	Currency          string                   `json:"currency"`
+	OrderCount        int                      `json:"order_count"`
+	RevenueOrderCount int                      `json:"revenue_order_count"`
+	GMV               int64                    `json:"gmv"`
+	AverageOrderValue int64                    `json:"average_order_value"`
+	Funnel            []StatusCountDto         `json:"funnel"` // Orders placed in the range by current status
+	TopSellers        []TopSellerDto           `json:"top_sellers"`
+	Categories        []CategoryPerformanceDto `json:"categories"`
+	Users             *AdminUserStatsDto       `json:"users"`
+	Catalog           *AdminCatalogStatsDto    `json:"catalog"`
+	GeneratedAt       time.Time                `json:"generated_at"`
+}
+
+// AdminDailyPoint holds the platform totals of one day
+type AdminDailyPoint struct {
+	Date              string `json:"date"`
+	OrderCount        int    `json:"order_count"`
+	RevenueOrderCount int    `json:"revenue_order_count"`
+	CancelledCount    int    `json:"cancelled_count"`
+	GMV               int64  `json:"gmv"`
+	NewUsers          int64  `json:"new_users"`
+	NewSellers        int64  `json:"new_sellers"`
+	NewProducts       int    `json:"new_products"`
+	NewReports        int    `json:"new_reports"`
+}
+
+type AdminDailyResponse struct {
+	Currency string            `json:"currency"`
+	Days     []AdminDailyPoint `json:"days"`
+}
+
+// PlatformStatsDto is the product-service stats response
+type PlatformStatsDto struct {
+	AdminCatalogStatsDto
+	Daily []struct {
+		Date        string `json:"date"`
+		NewProducts int    `json:"new_products"`
+		NewReports  int    `json:"new_reports"`
+	} `json:"daily"`
+}
+
+// UserStatsDto is the user-service stats response
+type UserStatsDto struct {
+	AdminUserStatsDto
+	Daily []struct {
+		Date       string `json:"date"`
+		NewUsers   int64  `json:"new_users"`
+		NewSellers int64  `json:"new_sellers"`
+	} `json:"daily"`
+}
+
+type CategoryDto struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+// BundleComponentDto mirrors product-service's BundleComponentDto
+type BundleComponentDto struct {
+	ProductID         string            `json:"product_id"`
+	ProductName       string            `json:"product_name"`
+	CategoryIds       []string          `json:"category_ids"`
+	SellerCategoryIds []string          `json:"seller_category_ids"`
+	Variant           VariantDto        `json:"variant"`
+	Quantity          int               `json:"quantity"`
+	Available         bool              `json:"available"`
+	PurchaseLimit     *PurchaseLimitDto `json:"purchase_limit,omitempty"` // the product's
+}
+
+// BundleDto mirrors product-service's BundleDetailResponse
+type BundleDto struct {
+	ID            string               `json:"id"`
+	SellerID      string               `json:"seller_id"`
+	Name          string               `json:"name"`
+	Image         string               `json:"image"`
+	IsActive      bool                 `json:"is_active"`
+	Components    []BundleComponentDto `json:"components"`
+	Currency      string               `json:"currency"`       // ISO 4217
+	OriginalPrice int                  `json:"original_price"` // minor units of Currency, like Price and Discount
+	Price         int                  `json:"price"`
+	Discount      int                  `json:"discount"`
+	Stock         int                  `json:"stock"`
+}
+
+// AddBundleCartItemRequest represents the request body for adding a bundle to the cart
+type AddBundleCartItemRequest struct {
+	BundleID string `json:"bundle_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
@@ -25,6 +25,7 @@ type CartItemResponse struct {
 	Seller    model.CartSeller `json:"seller"`
 	ProductID string           `json:"product_id"`
 	VariantID string           `json:"variant_id"`
+	BundleID  string           `json:"bundle_id,omitempty"`
 	Quantity  int              `json:"quantity"`
 	CreatedAt time.Time        `json:"created_at"`
 	UpdatedAt time.Time        `json:"updated_at"`
@@ -32,14 +33,37 @@ type CartItemResponse struct {
 
 // ToCartItemResponse converts a CartItem model to CartItemResponse DTO
 func ToCartItemResponse(item *model.CartItem) *CartItemResponse {
+	bundleID := ""
+	if item.Bundle != nil {
+		bundleID = item.Bundle.ID
+	}
 	return &CartItemResponse{
 		ID:        item.ID,
 		UserID:    item.UserID,
 		Seller:    item.Seller,
 		ProductID: item.Product.ID,
 		VariantID: item.Variant.ID,
+		BundleID:  bundleID,
 		Quantity:  item.Quantity,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
 	}
 }
+
+// GuestCartTokenResponse is a new guest cart token, sent back in the X-Cart-Token header
+type GuestCartTokenResponse struct {
+	CartToken string `json:"cart_token"`
+	ExpiresIn int64  `json:"expires_in"` // seconds of inactivity before the guest cart is deleted
+}
+
+// MergeGuestCartRequest identifies the guest cart to merge into the user's cart
+type MergeGuestCartRequest struct {
+	CartToken string `json:"cart_token" binding:"required"`
+}
+
+// MergeGuestCartResponse counts the guest cart lines by outcome
+type MergeGuestCartResponse struct {
+	MergedItems  int `json:"merged_items"`  // lines added to the user's cart, fully or in part
+	CappedItems  int `json:"capped_items"`  // merged lines whose quantity was lowered to the stock or purchase limit left
+	DroppedItems int `json:"dropped_items"` // lines out of stock or no longer available
+}
@@ -7,21 +7,57 @@ import (
 
 // VariantDto represents variant details from product-service
 type VariantDto struct {
-	ID      string            `json:"id"`
-	SKU     string            `json:"sku"`
-	Options map[string]string `json:"options"`
-	Price   int               `json:"price"`
-	Stock   int               `json:"stock"`
-	Image   string            `json:"image"`
+	ID            string            `json:"id"`
+	SKU           string            `json:"sku"`
+	Options       map[string]string `json:"options"`
+	Price         int               `json:"price"`    // minor units of Currency
+	Currency      string            `json:"currency"` // ISO 4217, empty means VND
+	Stock         int               `json:"stock"`
+	Image         string            `json:"image"`
+	PurchaseLimit *PurchaseLimitDto `json:"purchase_limit,omitempty"`
+	PreOrder      *PreOrderDto      `json:"pre_order,omitempty"`
+}
+
+// PreOrderDto is set on variants that can be ordered beyond their stock:
+// Quota more units can be pre-ordered, shipping from ShipDate
+type PreOrderDto struct {
+	Quota    int       `json:"quota"`
+	Reserved int       `json:"reserved"`
+	ShipDate time.Time `json:"ship_date"`
+}
+
+// MaxOrderable is how many units of the variant can be ordered at once: its
+// stock, or its pre-order quota when that is larger and the ship date is ahead
+func (v VariantDto) MaxOrderable() int {
+	if v.PreOrder != nil && v.PreOrder.ShipDate.After(time.Now()) {
+		return max(v.Stock, v.PreOrder.Quota)
+	}
+	return v.Stock
+}
+
+// IsPreOrder reports whether quantity units go beyond stock and are taken
+// from the pre-order quota instead
+func (v VariantDto) IsPreOrder(quantity int) bool {
+	return v.PreOrder != nil && v.Stock < quantity
+}
+
+// PurchaseLimitDto caps how many units one customer can buy, over the last
+// WindowDays days or, when it is 0, ever
+type PurchaseLimitDto struct {
+	MaxQuantity int `json:"max_quantity"`
+	WindowDays  int `json:"window_days,omitempty"`
 }
 
 // ProductVariantDto mirrors product-service's CartVariantDto
 type ProductVariantDto struct {
-	ProductName       string     `json:"product_name"`
-	CategoryIds       []string   `json:"category_ids"`
-	SellerID          string     `json:"seller_id"`
-	SellerCategoryIds []string   `json:"seller_category_ids"`
-	Variant           VariantDto `json:"variant"`
+	ProductID         string            `json:"product_id"`
+	ProductName       string            `json:"product_name"`
+	IsDisabled        bool              `json:"is_disabled"` // only returned when disabled products are requested
+	CategoryIds       []string          `json:"category_ids"`
+	SellerID          string            `json:"seller_id"`
+	SellerCategoryIds []string          `json:"seller_category_ids"`
+	Variant           VariantDto        `json:"variant"`
+	PurchaseLimit     *PurchaseLimitDto `json:"purchase_limit,omitempty"` // the product's, shared by its variants
 }
 
 // CartItemDetailDto represents enriched cart item with product info
@@ -33,8 +69,25 @@ type CartItemDetailDto struct {
 	VariantID string     `json:"variant_id"`
 	Quantity  int        `json:"quantity"`
 	Variant   VariantDto `json:"variant"`
+	Bundle    *BundleDto `json:"bundle,omitempty"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Checked against live product data every time the cart is read. Amounts are
+	// in the minor units of Currency.
+	Currency         string `json:"currency"`
+	UnitPrice        int    `json:"unit_price"`                  // live variant or bundle price
+	LineTotal        int64  `json:"line_total"`                  // unit price times quantity
+	PriceChanged     bool   `json:"price_changed"`               // the price or currency differs from the last time the cart was read
+	PreviousPrice    int    `json:"previous_price,omitempty"`    // set when the price changed
+	PreviousCurrency string `json:"previous_currency,omitempty"` // set when the currency changed; PreviousPrice is in it
+	Stock            int    `json:"stock"`                       // live variant or bundle stock
+	LowStock         bool   `json:"low_stock"`                   // few units left, or fewer than the quantity in the cart
+	OutOfStock       bool   `json:"out_of_stock"`
+	PreOrder         bool   `json:"pre_order"`        // the quantity goes beyond stock and is taken from the pre-order quota
+	ProductDisabled  bool   `json:"product_disabled"` // disabled product or inactive bundle
+	VariantDeleted   bool   `json:"variant_deleted"`  // the variant or bundle no longer exists
+	Available        bool   `json:"available"`        // can be checked out with its current quantity
 }
 
 type CartProductDto struct {
@@ -44,7 +97,17 @@ type CartProductDto struct {
 	SellerCategoryIDs []string `json:"seller_category_ids"`
 }
 
+// CartSellerSubtotalDto totals the available lines of one seller
+type CartSellerSubtotalDto struct {
+	SellerID   string `json:"seller_id"`
+	SellerName string `json:"seller_name"`
+	Currency   string `json:"currency"`
+	ItemCount  int    `json:"item_count"` // units, not lines
+	Subtotal   int64  `json:"subtotal"`
+}
+
 // GetCartItemsResponse represents the response for getting cart items
 type GetCartItemsResponse struct {
-	CartItems []CartItemDetailDto `json:"cart_items"`
+	CartItems []CartItemDetailDto     `json:"cart_items"`
+	Sellers   []CartSellerSubtotalDto `json:"sellers"`
 }
@@ -0,0 +1,10 @@
+package dto
+
+// CartReminderPreferenceResponse tells whether the user gets abandoned cart reminders
+type CartReminderPreferenceResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+type UpdateCartReminderPreferenceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
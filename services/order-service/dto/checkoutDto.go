@@ -3,8 +3,10 @@ package dto
 type CheckoutRequest struct {
 	CartItemIDs     []string           `json:"cart_item_ids" binding:"required,min=1"`
 	VoucherID       string             `json:"voucher_id"`
+	RedeemPoints    int                `json:"redeem_points" binding:"min=0"` // loyalty points to spend as a discount
+	WalletAmount    int                `json:"wallet_amount" binding:"min=0"` // part of the total paid from the wallet, with an online gateway
 	ShippingAddress ShippingAddressDto `json:"shipping_address" binding:"required"`
-	PaymentMethod   string             `json:"payment_method" binding:"required,oneof=COD STRIPE"`
+	PaymentMethod   string             `json:"payment_method" binding:"required,oneof=COD STRIPE VNPAY MOMO WALLET"`
 	DeliveryServiceID int `json:"delivery_service_id" binding:"required"`
 }
 
@@ -24,9 +26,16 @@ type ShippingAddressDto struct {
 }
 
 type CheckoutResponse struct {
-	OrderID      string  `json:"order_id"`
-	TotalAmount  float64 `json:"total_amount"`
-	Status       string  `json:"status"`
-	ClientSecret string  `json:"client_secret,omitempty"`
-	PaymentUrl   string  `json:"payment_url,omitempty"`
+	OrderID      string `json:"order_id"`
+	TotalAmount  int64  `json:"total_amount"` // minor units of Currency, delivery fee included
+	Currency     string `json:"currency"`
+	Status       string `json:"status"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	PaymentUrl   string `json:"payment_url,omitempty"`
+	// PreOrder is the separate order placed for the pre-ordered items of a
+	// checkout that also had items in stock
+	PreOrder *CheckoutResponse `json:"pre_order,omitempty"`
+	// PreOrderError explains why the pre-ordered items could not be ordered
+	// when the order of the items in stock was placed anyway
+	PreOrderError string `json:"pre_order_error,omitempty"`
 }
\ No newline at end of file
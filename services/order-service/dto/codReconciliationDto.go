@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"order-service/model"
+	"time"
+)
+
+// GetCodSettlementsRequest contains query parameters for the settlement list
+type GetCodSettlementsRequest struct {
+	Page  int `form:"page"`  // Page number (default: 1)
+	Limit int `form:"limit"` // Items per page (default: 10, max: 100)
+}
+
+type GetCodSettlementsResponse struct {
+	Settlements []*model.CodSettlement `json:"settlements"`
+	TotalCount  int64                  `json:"total_count"`
+	Page        int                    `json:"page"`
+	Limit       int                    `json:"limit"`
+	TotalPages  int                    `json:"total_pages"`
+}
+
+// GetCodSettlementRequest filters the lines of a settlement by status
+type GetCodSettlementRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=MATCHED MISMATCHED UNKNOWN DUPLICATE"`
+}
+
+// CodSettlementDetailResponse is a settlement with its lines
+type CodSettlementDetailResponse struct {
+	Settlement *model.CodSettlement       `json:"settlement"`
+	Lines      []*model.CodSettlementLine `json:"lines"`
+}
+
+// GetMissingRemittancesRequest contains query parameters for the parcels
+// delivered but not remitted by the carrier
+type GetMissingRemittancesRequest struct {
+	Page  int `form:"page"`  // Page number (default: 1)
+	Limit int `form:"limit"` // Items per page (default: 10, max: 100)
+}
+
+// MissingRemittanceDto is a COD parcel delivered long enough ago to have been
+// remitted
+type MissingRemittanceDto struct {
+	OrderID      string    `json:"order_id"`
+	SellerID     string    `json:"seller_id"`
+	ShipmentID   string    `json:"shipment_id"`
+	Carrier      string    `json:"carrier"`
+	TrackingCode string    `json:"tracking_code"`
+	CodAmount    int       `json:"cod_amount"`
+	DeliveredAt  time.Time `json:"delivered_at"`
+}
+
+// GetMissingRemittancesResponse is paged by order; an order can have several
+// parcels missing
+type GetMissingRemittancesResponse struct {
+	Parcels    []MissingRemittanceDto `json:"parcels"`
+	TotalCount int64                  `json:"total_count"`
+	Page       int                    `json:"page"`
+	Limit      int                    `json:"limit"`
+	TotalPages int                    `json:"total_pages"`
+}
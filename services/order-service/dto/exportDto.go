@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"order-service/model"
+	"time"
+)
+
+// ExportSellerOrdersRequest takes the seller order listing filters plus the file format
+type ExportSellerOrdersRequest struct {
+	Format        string     `form:"format" binding:"omitempty,oneof=csv xlsx"` // default: csv
+	Status        string     `form:"status"`
+	PaymentMethod string     `form:"payment_method"`
+	PaymentStatus string     `form:"payment_status"`
+	Search        string     `form:"search"`
+	StartDate     *time.Time `form:"start_date"`
+	EndDate       *time.Time `form:"end_date"`
+}
+
+// ExportJobResponse describes an async export; DownloadURL is set once it is done
+type ExportJobResponse struct {
+	*model.ExportJob
+	DownloadURL string `json:"download_url,omitempty"`
+}
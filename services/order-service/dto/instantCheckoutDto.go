@@ -10,7 +10,12 @@ type InstantCheckoutItem struct {
 type InstantCheckoutRequest struct {
 	Items             []InstantCheckoutItem `json:"items" binding:"required,min=1"`
 	VoucherID         string                `json:"voucher_id"`
+	RedeemPoints      int                   `json:"redeem_points" binding:"min=0"` // loyalty points to spend as a discount
+	WalletAmount      int                   `json:"wallet_amount" binding:"min=0"` // part of the total paid from the wallet, with an online gateway
 	ShippingAddress   ShippingAddressDto    `json:"shipping_address" binding:"required"`
-	PaymentMethod     string                `json:"payment_method" binding:"required,oneof=COD STRIPE"`
+	PaymentMethod     string                `json:"payment_method" binding:"required,oneof=COD STRIPE VNPAY MOMO WALLET"`
 	DeliveryServiceID int                   `json:"delivery_service_id" binding:"required"`
+	// OffSession is set by subscription renewals, which charge a saved card
+	// instead of sending the buyer a payment link
+	OffSession bool `json:"-"`
 }
@@ -0,0 +1,26 @@
+package dto
+
+import "order-service/model"
+
+// GetLoyaltyHistoryRequest contains query parameters for the points history
+type GetLoyaltyHistoryRequest struct {
+	Page  int `form:"page"`  // Page number (default: 1)
+	Limit int `form:"limit"` // Items per page (default: 10, max: 100)
+}
+
+// GetLoyaltyHistoryResponse contains the current balance and paginated ledger entries
+type GetLoyaltyHistoryResponse struct {
+	Balance      int                         `json:"balance"`
+	PointValue   int                         `json:"point_value"` // VND value of one point at checkout
+	Transactions []*model.LoyaltyTransaction `json:"transactions"`
+	TotalCount   int64                       `json:"total_count"`
+	Page         int                         `json:"page"`
+	Limit        int                         `json:"limit"`
+	TotalPages   int                         `json:"total_pages"`
+}
+
+// UpdateLoyaltyRateRequest sets the earn rate for a seller, or the platform when SellerID is empty
+type UpdateLoyaltyRateRequest struct {
+	SellerID string  `json:"seller_id"`
+	Rate     float64 `json:"rate" binding:"min=0,max=100"` // percent of the paid amount returned as points
+}
@@ -1,14 +1,20 @@
 package dto
 
-import "time"
+import (
+	"order-service/model"
+	"time"
+)
 
 // GetOrdersRequest contains query parameters for filtering and pagination
 type GetOrdersRequest struct {
-	Status    string `form:"status"`     // Filter by order status
-	Page      int    `form:"page"`       // Page number (default: 1)
-	Limit     int    `form:"limit"`      // Items per page (default: 10, max: 100)
-	SortBy    string `form:"sort_by"`    // Field to sort by: total, created_at
-	SortOrder string `form:"sort_order"` // Sort order: asc, desc (default: desc)
+	Status    string     `form:"status"`     // Filter by order status
+	Search    string     `form:"search"`     // Product name, SKU, seller name or order ID prefix; every word must match
+	StartDate *time.Time `form:"start_date"` // Filter orders created on or after this date (RFC3339)
+	EndDate   *time.Time `form:"end_date"`   // Filter orders created on or before this date (RFC3339)
+	Page      int        `form:"page"`       // Page number (default: 1)
+	Limit     int        `form:"limit"`      // Items per page (default: 10, max: 100)
+	SortBy    string     `form:"sort_by"`    // Field to sort by: total, created_at
+	SortOrder string     `form:"sort_order"` // Sort order: asc, desc (default: desc)
 }
 
 // GetOrdersResponse contains paginated order results
@@ -32,10 +38,24 @@ type OrderDto struct {
 	CreatedAt       time.Time          `json:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at"`
 	Voucher         *OrderVoucherDto   `json:"voucher"`
-	Total           float64            `json:"total"`
+	Currency        string             `json:"currency"`
+	Total           int64              `json:"total"`
+	TaxTotal        int64              `json:"tax_total"`
+	TaxInclusive    bool               `json:"tax_inclusive"`
+	RedeemedPoints  int                `json:"redeemed_points,omitempty"`
+	PointsDiscount  int                `json:"points_discount,omitempty"`
+	WalletAmount    int                `json:"wallet_amount,omitempty"`
 	Phone           string             `json:"phone"`
 	ShippingAddress OrderAddressDto    `json:"shipping_address"`
 	DeliveryCode    string             `json:"delivery_code"`
+	DeliveryFee     int                `json:"delivery_fee"`
+	Shipments       []model.Shipment   `json:"shipments,omitempty"` // parcels with their tracking codes
+	DeliveredAt     *time.Time         `json:"delivered_at,omitempty"`
+	ReturnRequest   *model.ReturnRequest      `json:"return_request,omitempty"`
+	StatusHistory   []model.OrderStatusChange `json:"status_history,omitempty"`
+	Cancellations   []model.ItemCancellation  `json:"cancellations,omitempty"`
+	CodRisk         *model.CodRisk            `json:"cod_risk,omitempty"` // seller views only
+	ExpectedShipDate *time.Time               `json:"expected_ship_date,omitempty"` // pre-orders only
 	ItemCount       int                `json:"item_count"` // Computed field
 	IsRated         bool               `json:"is_rated"`
 	IsReported      bool               `json:"is_reported"`
@@ -59,6 +79,13 @@ type OrderItemDto struct {
 	Price       int    `json:"price"`
 	Image       string `json:"image"`
 	Quantity    int    `json:"quantity"`
+	BundleID    string `json:"bundle_id,omitempty"`
+	BundleName  string `json:"bundle_name,omitempty"`
+	Discount    int    `json:"discount,omitempty"`
+	TaxRate       float64 `json:"tax_rate"`
+	TaxableAmount int     `json:"taxable_amount"`
+	TaxAmount     int     `json:"tax_amount"`
+	PreOrderShipDate *time.Time `json:"pre_order_ship_date,omitempty"`
 }
 
 // OrderAddressDto represents shipping address
@@ -84,7 +111,7 @@ type OrderVoucherDto struct {
 // GetOrdersBySellerRequest contains query parameters for seller orders with enhanced filtering
 type GetOrdersBySellerRequest struct {
 	Status        string     `form:"status"`         // Filter by order status
-	PaymentMethod string     `form:"payment_method"` // Filter by payment method (COD, STRIPE)
+	PaymentMethod string     `form:"payment_method"` // Filter by payment method (COD, STRIPE, VNPAY, MOMO, WALLET)
 	PaymentStatus string     `form:"payment_status"` // Filter by payment status (PENDING, PAID, FAILED)
 	Search        string     `form:"search"`         // Search by order ID or phone
 	StartDate     *time.Time `form:"start_date"`     // Filter orders created on or after this date (RFC3339)
@@ -7,3 +7,8 @@ type CreatePaymentRequest struct {
 type CreatePaymentResponse struct {
 	PaymentUrl string `json:"payment_url"`
 }
+
+type PaymentReturnResponse struct {
+	OrderID string `json:"order_id"`
+	Success bool   `json:"success"`
+}
@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"order-service/model"
+	"time"
+)
+
+// UpdateCommissionRuleRequest sets the commission rate of a seller or a platform
+// category, or the default rate when neither is given
+type UpdateCommissionRuleRequest struct {
+	SellerID   string  `json:"seller_id"`
+	CategoryID string  `json:"category_id"`
+	Rate       float64 `json:"rate" binding:"min=0,max=100"` // percent of the items sold
+}
+
+// PayoutBalanceDto holds a seller's amounts in one currency
+type PayoutBalanceDto struct {
+	Currency  string `json:"currency"`
+	Unsettled int64  `json:"unsettled"` // completed orders not yet in a statement
+	Payable   int64  `json:"payable"`   // statements awaiting payout
+	Paid      int64  `json:"paid"`
+}
+
+type PayoutBalanceResponse struct {
+	SellerID string             `json:"seller_id"`
+	Balances []PayoutBalanceDto `json:"balances"`
+}
+
+// GetStatementsRequest contains query parameters for the statement list
+type GetStatementsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=PENDING PAID"`
+	Page   int    `form:"page"`  // Page number (default: 1)
+	Limit  int    `form:"limit"` // Items per page (default: 10, max: 100)
+}
+
+type GetStatementsResponse struct {
+	Statements []*model.SettlementStatement `json:"statements"`
+	TotalCount int64                        `json:"total_count"`
+	Page       int                          `json:"page"`
+	Limit      int                          `json:"limit"`
+	TotalPages int                          `json:"total_pages"`
+}
+
+// StatementDetailResponse is a statement with the ledger entries it settles
+type StatementDetailResponse struct {
+	Statement *model.SettlementStatement `json:"statement"`
+	Entries   []*model.SellerLedgerEntry `json:"entries"`
+}
+
+// GenerateStatementsRequest selects the week to settle by any date in it,
+// as YYYY-MM-DD (default: last week). The week must be over.
+type GenerateStatementsRequest struct {
+	Week string `json:"week"`
+}
+
+type GenerateStatementsResponse struct {
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Statements  int       `json:"statements"`
+}
+
+type MarkStatementPaidRequest struct {
+	PaymentReference string `json:"payment_reference" binding:"required"` // bank transfer or payout ID
+}
+
+// RecomputePayoutsRequest rebuilds the ledger entries of the orders created in
+// [from, to), for one seller or all of them
+type RecomputePayoutsRequest struct {
+	SellerID string    `json:"seller_id"`
+	From     time.Time `json:"from" binding:"required"`
+	To       time.Time `json:"to" binding:"required"`
+}
+
+type RecomputePayoutsResponse struct {
+	OrderCount int `json:"order_count"`
+}
@@ -19,6 +19,6 @@ type PeriodStatistics struct {
 // GetSellerStatisticsResponse contains seller statistics
 type GetSellerStatisticsResponse struct {
 	OrderCount   int                `json:"order_count"`             // Total number of orders in the time range
-	TotalRevenue float64            `json:"total_revenue"`           // Sum of order totals for all orders
+	TotalRevenue float64            `json:"total_revenue"`           // Sum of order totals for paid or completed orders
 	Breakdown    []PeriodStatistics `json:"breakdown,omitempty"`     // Period-by-period breakdown when type is specified
 }
@@ -0,0 +1,17 @@
+package dto
+
+// CreateShipmentRequest lists the items of a new parcel. Without items, all
+// that is left to ship goes in the parcel.
+type CreateShipmentRequest struct {
+	Items []ShipmentItemRequest `json:"items" binding:"dive"`
+}
+
+type ShipmentItemRequest struct {
+	VariantID string `json:"variant_id" binding:"required"`
+	BundleID  string `json:"bundle_id"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+type UpdateShipmentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=SHIPPING DELIVERED CANCELLED"`
+}
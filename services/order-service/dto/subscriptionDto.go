@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+type SubscriptionItemRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	VariantID string `json:"variant_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+}
+
+// CreateSubscriptionRequest subscribes to variants of one seller. STRIPE
+// subscriptions need a card saved through the payment setup first.
+type CreateSubscriptionRequest struct {
+	SellerID          string                    `json:"seller_id" binding:"required"`
+	Items             []SubscriptionItemRequest `json:"items" binding:"required,min=1,dive"`
+	IntervalDays      int                       `json:"interval_days" binding:"required,min=1,max=365"`
+	ShippingAddress   ShippingAddressDto        `json:"shipping_address" binding:"required"`
+	PaymentMethod     string                    `json:"payment_method" binding:"required,oneof=COD STRIPE"`
+	PaymentMethodID   string                    `json:"payment_method_id"` // saved Stripe card, required for STRIPE
+	DeliveryServiceID int                       `json:"delivery_service_id" binding:"required"`
+	StartAt           *time.Time                `json:"start_at"` // first order, now when empty
+}
+
+// SubscriptionPaymentSetupResponse holds the Stripe SetupIntent secret the
+// frontend confirms to save a card for subscription renewals
+type SubscriptionPaymentSetupResponse struct {
+	ClientSecret string `json:"client_secret"`
+}
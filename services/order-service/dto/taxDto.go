@@ -0,0 +1,39 @@
+package dto
+
+// UpdateTaxRateRequest sets the VAT rate of a platform category, or the default
+// rate when CategoryID is empty
+type UpdateTaxRateRequest struct {
+	CategoryID string  `json:"category_id"`
+	Rate       float64 `json:"rate" binding:"min=0,max=100"` // percent of the net price
+}
+
+// UpdateSellerTaxSettingRequest sets whether the seller's prices include VAT
+type UpdateSellerTaxSettingRequest struct {
+	PricesIncludeTax *bool `json:"prices_include_tax" binding:"required"`
+}
+
+// GetTaxSummaryRequest selects the month of a tax summary (default: current month)
+type GetTaxSummaryRequest struct {
+	Year  int `form:"year"`
+	Month int `form:"month" binding:"omitempty,min=1,max=12"`
+}
+
+// TaxSummaryLineDto holds the VAT totals of one currency and rate
+type TaxSummaryLineDto struct {
+	Currency      string  `json:"currency"`
+	Rate          float64 `json:"rate"`
+	ItemCount     int     `json:"item_count"`
+	TaxableAmount int64   `json:"taxable_amount"` // net of VAT
+	TaxAmount     int64   `json:"tax_amount"`
+	GrossAmount   int64   `json:"gross_amount"` // taxable amount plus VAT
+}
+
+// TaxSummaryResponse is a seller's VAT summary for one month. Only orders that
+// were not cancelled or returned are counted.
+type TaxSummaryResponse struct {
+	SellerID   string              `json:"seller_id"`
+	Year       int                 `json:"year"`
+	Month      int                 `json:"month"`
+	OrderCount int                 `json:"order_count"`
+	Lines      []TaxSummaryLineDto `json:"lines"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "order-service/model"
+
+type OrderTrackingResponse struct {
+	OrderID string              `json:"order_id"`
+	Status  string              `json:"status"`
+	Parcels []ParcelTrackingDto `json:"parcels"`
+}
+
+// ParcelTrackingDto is a parcel of the order with what its carrier reported.
+// Tracking is nil for parcels the carrier has nothing on yet.
+type ParcelTrackingDto struct {
+	ShipmentID   string                  `json:"shipment_id"`
+	Carrier      string                  `json:"carrier"`
+	TrackingCode string                  `json:"tracking_code"`
+	Status       string                  `json:"status"`
+	Items        []model.ShipmentItem    `json:"items"`
+	Tracking     *model.ShipmentTracking `json:"tracking"`
+}
@@ -2,4 +2,22 @@ package dto
 
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status"`
-}
\ No newline at end of file
+}
+
+// ReturnRequestRequest opens a return on an order that is being delivered
+type ReturnRequestRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// CancelOrderItemsRequest takes items off an order that the seller has not confirmed yet
+type CancelOrderItemsRequest struct {
+	Items  []CancelOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	Reason string                   `json:"reason" binding:"max=1000"`
+}
+
+// CancelOrderItemRequest selects a line by variant, or a whole bundle by bundle ID
+type CancelOrderItemRequest struct {
+	VariantID string `json:"variant_id" binding:"required_without=BundleID"`
+	BundleID  string `json:"bundle_id"`
+	Quantity  int    `json:"quantity" binding:"omitempty,gt=0"` // 0 cancels the whole line
+}
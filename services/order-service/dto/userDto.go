@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type UserResponse struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
@@ -8,6 +10,7 @@ type UserResponse struct {
 	Address  Address `json:"address"`
 	Phone    string `json:"phone"`
 	Image    string `json:"image"`
+	CreatedAt *time.Time `json:"created_at,omitempty"` // account creation, unknown for old accounts
 }
 
 
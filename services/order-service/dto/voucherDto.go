@@ -6,9 +6,10 @@ type VoucherResponse struct {
 	ID                     string    `json:"id"`
 	Code                   string    `json:"code"`
 	DiscountType           string    `json:"discount_type"`
-	DiscountValue          int       `json:"discount_value"`
-	MinOrderValue          int       `json:"min_order_value"`
-	MaxDiscountValue       int       `json:"max_discount_value"`
+	DiscountValue          int       `json:"discount_value"`     // percent, or minor units of Currency for FIXED
+	MinOrderValue          int       `json:"min_order_value"`    // minor units of Currency
+	MaxDiscountValue       int       `json:"max_discount_value"` // minor units of Currency
+	Currency               string    `json:"currency"`           // ISO 4217
 	StartTime              time.Time `json:"start_time"`
 	EndTime                time.Time `json:"end_time"`
 	UsageLimit             int       `json:"usage_limit"`
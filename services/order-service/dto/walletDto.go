@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"order-service/model"
+	"time"
+)
+
+// GetWalletRequest contains query parameters for the wallet history
+type GetWalletRequest struct {
+	Page  int `form:"page"`  // Page number (default: 1)
+	Limit int `form:"limit"` // Items per page (default: 10, max: 100)
+}
+
+// WalletTransactionDto is a ledger posting seen from the buyer's wallet
+type WalletTransactionDto struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Reference   string    `json:"reference"`
+	Amount      int       `json:"amount"` // signed change of the wallet balance
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type GetWalletResponse struct {
+	Balance      int                    `json:"balance"`
+	Transactions []WalletTransactionDto `json:"transactions"`
+	TotalCount   int64                  `json:"total_count"`
+	Page         int                    `json:"page"`
+	Limit        int                    `json:"limit"`
+	TotalPages   int                    `json:"total_pages"`
+}
+
+type WalletTopUpRequest struct {
+	Amount int `json:"amount" binding:"required,min=10000"` // VND
+}
+
+type RedeemGiftCardRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type WalletBalanceResponse struct {
+	Balance int `json:"balance"`
+}
+
+type CreateGiftCardsRequest struct {
+	Amount    int        `json:"amount" binding:"required,min=1"`
+	Quantity  int        `json:"quantity" binding:"omitempty,min=1,max=1000"` // default: 1
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateGiftCardsResponse struct {
+	GiftCards []*model.GiftCard `json:"gift_cards"`
+}
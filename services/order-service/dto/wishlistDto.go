@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"order-service/model"
+	"time"
+)
+
+// ProductSummaryDto mirrors product-service's ProductSummaryDto
+type ProductSummaryDto struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	Image     string `json:"image"`
+	SellerID  string `json:"seller_id"`
+	MinPrice  int    `json:"min_price"`
+	MaxPrice  int    `json:"max_price"`
+	Currency  string `json:"currency"`
+	Stock     int    `json:"stock"` // across all variants
+}
+
+// WishlistRequest creates or renames a named wishlist
+type WishlistRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// WishlistDto is a wishlist with the number of items in it
+type WishlistDto struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	IsDefault bool      `json:"is_default"`
+	ItemCount int       `json:"item_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ToWishlistDto(wishlist *model.Wishlist, itemCount int) WishlistDto {
+	return WishlistDto{
+		ID:        wishlist.ID,
+		Name:      wishlist.Name,
+		IsDefault: wishlist.IsDefault,
+		ItemCount: itemCount,
+		CreatedAt: wishlist.CreatedAt,
+		UpdatedAt: wishlist.UpdatedAt,
+	}
+}
+
+// AddWishlistItemRequest saves a product, or one of its variants, to a wishlist
+type AddWishlistItemRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	VariantID string `json:"variant_id"` // optional: save the whole product when empty
+}
+
+// WishlistItemDto is a wishlist item checked against live product data
+type WishlistItemDto struct {
+	model.WishlistItem
+
+	CurrentPrice    int  `json:"current_price"`     // live variant price, or lowest product price
+	CurrentMaxPrice int  `json:"current_max_price"` // highest product price; equals current_price for a variant
+	PriceDropped    bool `json:"price_dropped"`     // cheaper than when it was added
+	Stock           int  `json:"stock"`             // live variant stock, or product stock across variants
+	OutOfStock      bool `json:"out_of_stock"`
+	ProductDisabled bool `json:"product_disabled"`
+	Deleted         bool `json:"deleted"`   // the product or variant can no longer be found
+	Available       bool `json:"available"` // can be added to the cart
+}
+
+// WishlistDetailResponse is a wishlist with its items
+type WishlistDetailResponse struct {
+	Wishlist WishlistDto       `json:"wishlist"`
+	Items    []WishlistItemDto `json:"items"`
+}
+
+// MoveWishlistItemToCartRequest moves a wishlist item into the cart. The
+// variant is required when the whole product was saved.
+type MoveWishlistItemToCartRequest struct {
+	VariantID string `json:"variant_id"`
+	Quantity  int    `json:"quantity" binding:"omitempty,min=1"` // default: 1
+}
+
+// MoveCartItemToWishlistRequest saves a cart line for later
+type MoveCartItemToWishlistRequest struct {
+	WishlistID string `json:"wishlist_id"` // default: the default wishlist
+}
@@ -29,3 +29,8 @@ func NewAppErrorWithErr(code int, message string, err error) *AppError {
 		Err:     err,
 	}
 }
+
+// Unwrap exposes the underlying error to errors.Is and errors.As
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
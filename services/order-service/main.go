@@ -3,13 +3,19 @@ package main
 import (
 	"fmt"
 	"order-service/client"
+	"order-service/client/payment"
+	momoclient "order-service/client/payment/momo"
 	stripeclient "order-service/client/payment/stripe"
+	vnpayclient "order-service/client/payment/vnpay"
+	walletclient "order-service/client/payment/wallet"
 	"order-service/config"
 	"order-service/controller"
+	"order-service/migration"
 	"order-service/repository"
 	"order-service/router"
 	"order-service/service"
 	"os"
+	_ "time/tzdata" // the runtime image has no time zone database (analytics days)
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +26,10 @@ func main() {
 	fmt.Println("Hello World")
 	// Kết nối DB
 	config.ConnectDatabase()
+	migration.Run(config.DB)
+
+	// Initialize S3 (invoices)
+	config.InitS3()
 
 	// Initialize RabbitMQ (best-effort; service continues if unavailable)
 	config.InitRabbitMQ()
@@ -34,26 +44,89 @@ func main() {
 	userClient := client.NewUserServiceClient()
 	stripeConfig := config.NewStripeConfig()
 	stripeClient := stripeclient.NewStripeClient(stripeConfig)
+	payments := payment.NewRegistry()
+	payments.Register("STRIPE", stripeClient)
+	payments.Register("VNPAY", vnpayclient.NewVNPayClient(config.NewVNPayConfig()))
+	payments.Register("MOMO", momoclient.NewMoMoClient(config.NewMoMoConfig()))
 	GHNClient := client.NewGHNClient()
 	notificationClient := client.NewNotificationServiceClient(os.Getenv("NOTIFICATION_SERVICE_URL"))
 
 	// Initialize layers
 	cartRepo := repository.NewCartRepository(config.DB)
 	orderRepo := repository.NewOrderRepository(config.DB)
+	loyaltyRepo := repository.NewLoyaltyRepository(config.DB)
+	walletRepo := repository.NewWalletRepository(config.DB)
+	taxRepo := repository.NewTaxRepository(config.DB)
+	invoiceRepo := repository.NewInvoiceRepository(config.DB)
+	exportRepo := repository.NewExportRepository(config.DB)
+	analyticsRepo := repository.NewAnalyticsRepository(config.DB)
+	payoutRepo := repository.NewPayoutRepository(config.DB)
+	wishlistRepo := repository.NewWishlistRepository(config.DB)
+	cartReminderRepo := repository.NewCartReminderRepository(config.DB)
+	trackingRepo := repository.NewTrackingRepository(config.DB)
+	codReconciliationRepo := repository.NewCodReconciliationRepository(config.DB)
+	codRiskRepo := repository.NewCodRiskRepository(config.DB)
+	purchaseLimitRepo := repository.NewPurchaseLimitRepository(config.DB)
+	subscriptionRepo := repository.NewSubscriptionRepository(config.DB)
+	jobLeaseRepo := repository.NewJobLeaseRepository(config.DB)
+	walletClient := walletclient.NewWalletClient(walletRepo)
 
-	cartService := service.NewCartService(cartRepo, productClient, userClient)
-	orderService := service.NewOrderService(orderRepo, cartRepo, productClient, userClient, stripeClient, GHNClient, notificationClient)
+	purchaseLimitService := service.NewPurchaseLimitService(purchaseLimitRepo)
+	cartService := service.NewCartService(cartRepo, productClient, userClient, purchaseLimitService)
+	cartReminderService := service.NewCartReminderService(cartReminderRepo, cartService, userClient, notificationClient)
+	cartReminderService.StartReminderJob(jobLeaseRepo)
+	loyaltyService := service.NewLoyaltyService(loyaltyRepo)
+	taxService := service.NewTaxService(taxRepo)
+	invoiceService := service.NewInvoiceService(invoiceRepo, orderRepo, userClient)
+	exportService := service.NewExportService(exportRepo, orderRepo)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, productClient, userClient)
+	analyticsService.StartSnapshotJob(jobLeaseRepo)
+	payoutService := service.NewPayoutService(payoutRepo)
+	payoutService.StartStatementJob(jobLeaseRepo)
+	trackingService := service.NewTrackingService(trackingRepo, orderRepo, GHNClient)
+	codRiskService := service.NewCodRiskService(codRiskRepo)
+	orderService := service.NewOrderService(orderRepo, cartRepo, productClient, userClient, payments, GHNClient, notificationClient, loyaltyService, walletClient, taxService, payoutService, trackingService, codRiskService, purchaseLimitService)
+	orderService.StartScheduler(jobLeaseRepo)
+	walletService := service.NewWalletService(walletRepo, walletClient)
+	codReconciliationService := service.NewCodReconciliationService(codReconciliationRepo, orderRepo)
+	wishlistService := service.NewWishlistService(wishlistRepo, cartRepo, cartService, productClient)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, orderRepo, orderService, productClient, userClient, stripeClient, notificationClient)
+	subscriptionService.StartRenewalJob(jobLeaseRepo)
 
 	cartController := controller.NewCartController(cartService)
-	orderController := controller.NewOrderController(orderService, stripeClient)
+	orderController := controller.NewOrderController(orderService, payments, walletService)
+	loyaltyController := controller.NewLoyaltyController(loyaltyService)
+	walletController := controller.NewWalletController(walletService)
+	taxController := controller.NewTaxController(taxService)
+	invoiceController := controller.NewInvoiceController(invoiceService)
+	exportController := controller.NewExportController(exportService)
+	analyticsController := controller.NewAnalyticsController(analyticsService)
+	payoutController := controller.NewPayoutController(payoutService)
+	wishlistController := controller.NewWishlistController(wishlistService)
+	cartReminderController := controller.NewCartReminderController(cartReminderService)
+	trackingController := controller.NewTrackingController(trackingService)
+	codReconciliationController := controller.NewCodReconciliationController(codReconciliationService)
+	subscriptionController := controller.NewSubscriptionController(subscriptionService)
 
 	r := gin.Default()
 	//r.Use(cors.Default())
 
 	// Setup routes
 	router.SetupRouter(r, &router.AppRouter{
-		CartController:  cartController,
-		OrderController: orderController,
+		CartController:              cartController,
+		OrderController:             orderController,
+		LoyaltyController:           loyaltyController,
+		WalletController:            walletController,
+		TaxController:               taxController,
+		InvoiceController:           invoiceController,
+		ExportController:            exportController,
+		AnalyticsController:         analyticsController,
+		PayoutController:            payoutController,
+		WishlistController:          wishlistController,
+		CartReminderController:      cartReminderController,
+		TrackingController:          trackingController,
+		CodReconciliationController: codReconciliationController,
+		SubscriptionController:      subscriptionController,
 	})
 
 	r.Run(":8085") 
@@ -0,0 +1,356 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"order-service/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// step is a one-off data migration. Applied steps are recorded in the
+// migrations collection so each one runs once per database.
+type step struct {
+	ID  string
+	Run func(ctx context.Context, db *mongo.Database) error
+}
+
+var steps = []step{
+	{ID: "2026-10-orders-currency", Run: backfillOrderCurrency},
+	{ID: "2026-10-cart-items-expiry-index", Run: createCartExpiryIndex},
+	{ID: "2026-10-wishlist-items-unique-index", Run: createWishlistItemIndex},
+	{ID: "2026-10-orders-shipments", Run: backfillOrderShipments},
+	{ID: "2026-10-subscriptions-due-index", Run: createSubscriptionDueIndex},
+	{ID: "2026-10-orders-user-index", Run: createOrderUserIndex},
+	{ID: "2026-10-wallet-transactions-unique-index", Run: createWalletTransactionIndex},
+	{ID: "2026-10-loyalty-transactions-unique-index", Run: createLoyaltyTransactionIndex},
+	{ID: "2026-10-orders-search-keywords", Run: backfillOrderSearchKeywords},
+	{ID: "2026-10-orders-search-indexes", Run: createOrderSearchIndexes},
+	{ID: "2026-10-cod-settlement-lines-unique-index", Run: createCodSettlementLineIndex},
+	{ID: "2026-10-orders-search-skus", Run: backfillOrderSearchSKUs},
+	{ID: "2026-10-orders-search-sku-index", Run: createOrderSearchSKUIndex},
+}
+
+// Run applies the steps that have not been applied yet. A failed step is
+// logged and retried on the next start; later steps still run.
+func Run(db *mongo.Database) {
+	if db == nil {
+		return
+	}
+	applied := db.Collection("migrations")
+
+	for _, s := range steps {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+
+		count, err := applied.CountDocuments(ctx, bson.M{"_id": s.ID})
+		if err != nil {
+			fmt.Printf("Warning: failed to check migration %s: %v\n", s.ID, err)
+			cancel()
+			continue
+		}
+		if count > 0 {
+			cancel()
+			continue
+		}
+
+		if err := s.Run(ctx, db); err != nil {
+			fmt.Printf("Warning: migration %s failed: %v\n", s.ID, err)
+			cancel()
+			continue
+		}
+		if _, err := applied.InsertOne(ctx, bson.M{"_id": s.ID, "applied_at": time.Now()}); err != nil {
+			fmt.Printf("Warning: failed to record migration %s: %v\n", s.ID, err)
+		}
+		fmt.Printf("Applied migration %s\n", s.ID)
+		cancel()
+	}
+}
+
+// backfillOrderCurrency records VND on orders created before currencies were
+// stored and turns their float totals into whole minor units.
+func backfillOrderCurrency(ctx context.Context, db *mongo.Database) error {
+	orders := db.Collection("orders")
+
+	if _, err := orders.UpdateMany(ctx,
+		bson.M{"currency": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"currency": "VND"}},
+	); err != nil {
+		return fmt.Errorf("failed to set order currency: %w", err)
+	}
+
+	if _, err := orders.UpdateMany(ctx,
+		bson.M{"total": bson.M{"$type": "double"}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"total": bson.M{"$toLong": bson.M{"$round": bson.A{"$total", 0}}},
+		}}}},
+	); err != nil {
+		return fmt.Errorf("failed to convert order totals: %w", err)
+	}
+
+	return nil
+}
+
+// createCartExpiryIndex lets MongoDB remove guest cart items once they expire.
+// User cart items have no expires_at and are never removed.
+func createCartExpiryIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("cart_items").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create cart expiry index: %w", err)
+	}
+	return nil
+}
+
+// createWishlistItemIndex keeps a product (or one of its variants) at most
+// once per wishlist
+func createWishlistItemIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("wishlist_items").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "wishlist_id", Value: 1},
+			{Key: "product_id", Value: 1},
+			{Key: "variant_id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create wishlist item index: %w", err)
+	}
+	return nil
+}
+
+// backfillOrderShipments turns the single delivery code of orders shipped
+// before parcels into one shipment holding every item. The shipment takes the
+// order ID and a status derived from the order's.
+func backfillOrderShipments(ctx context.Context, db *mongo.Database) error {
+	status := bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": bson.M{"$eq": bson.A{"$status", "COMPLETED"}}, "then": "DELIVERED"},
+			bson.M{"case": bson.M{"$eq": bson.A{"$status", "RETURNED"}}, "then": "RETURNED"},
+			bson.M{"case": bson.M{"$eq": bson.A{"$status", "CANCELLED"}}, "then": "CANCELLED"},
+			bson.M{"case": bson.M{"$eq": bson.A{"$status", "SHIPPING"}}, "then": bson.M{
+				"$cond": bson.A{bson.M{"$ifNull": bson.A{"$delivered_at", false}}, "DELIVERED", "SHIPPING"},
+			}},
+		},
+		"default": "TO_PICKUP",
+	}}
+
+	shipment := bson.M{
+		"_id":           "$_id",
+		"carrier":       "GHN",
+		"tracking_code": "$delivery_code",
+		"status":        status,
+		"items": bson.M{"$map": bson.M{
+			"input": "$items",
+			"as":    "item",
+			"in": bson.M{
+				"variant_id": "$$item.variant_id",
+				"bundle_id":  "$$item.bundle_id",
+				"quantity":   "$$item.quantity",
+			},
+		}},
+		"cod_amount": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$payment_method", "COD"}},
+			bson.M{"$add": bson.A{"$total", "$delivery_fee"}},
+			0,
+		}},
+		"created_at":   "$updated_at",
+		"updated_at":   "$updated_at",
+		"delivered_at": bson.M{"$ifNull": bson.A{"$delivered_at", "$completed_at"}},
+	}
+
+	if _, err := db.Collection("orders").UpdateMany(ctx,
+		bson.M{
+			"delivery_code": bson.M{"$nin": bson.A{"", nil}},
+			"shipments":     bson.M{"$exists": false},
+		},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"shipments": bson.A{shipment}}}}},
+	); err != nil {
+		return fmt.Errorf("failed to backfill order shipments: %w", err)
+	}
+
+	return nil
+}
+
+// createSubscriptionDueIndex backs the hourly lookup of due subscriptions
+func createSubscriptionDueIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("subscriptions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: "next_order_at", Value: 1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create subscription index: %w", err)
+	}
+	return nil
+}
+
+// createOrderUserIndex backs the buyer order listing and search, which always
+// filter on the buyer and sort by creation time
+func createOrderUserIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("orders").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user._id", Value: 1},
+			{Key: "created_at", Value: -1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create order user index: %w", err)
+	}
+	return nil
+}
+
+// createWalletTransactionIndex posts a wallet transaction at most once per
+// type and reference, e.g. one payment and one refund per order
+func createWalletTransactionIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("wallet_transactions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "type", Value: 1},
+			{Key: "reference", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create wallet transaction index: %w", err)
+	}
+	return nil
+}
+
+// createLoyaltyTransactionIndex keeps one loyalty entry per order and type, so
+// an order earns, reverses or redeems points once however often it is retried
+func createLoyaltyTransactionIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("loyalty_transactions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "order_id", Value: 1},
+			{Key: "type", Value: 1},
+		},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"order_id": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create loyalty transaction index: %w", err)
+	}
+	return nil
+}
+
+// backfillOrderSearchKeywords stores the lower-cased product and seller name
+// words the buyer order search matches on orders created before they were kept
+func backfillOrderSearchKeywords(ctx context.Context, db *mongo.Database) error {
+	return backfillOrderSearchFields(ctx, db, bson.M{"search_keywords": bson.M{"$exists": false}})
+}
+
+// backfillOrderSearchSKUs stores the lower-cased SKUs the buyer order search
+// matches on orders created before they were kept
+func backfillOrderSearchSKUs(ctx context.Context, db *mongo.Database) error {
+	return backfillOrderSearchFields(ctx, db, bson.M{"search_skus": bson.M{"$exists": false}})
+}
+
+// backfillOrderSearchFields sets the search keywords and SKUs of the orders
+// matching the filter
+func backfillOrderSearchFields(ctx context.Context, db *mongo.Database, filter bson.M) error {
+	orders := db.Collection("orders")
+
+	cursor, err := orders.Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"seller.name": 1, "items.product_name": 1, "items.sku": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find orders to backfill search fields: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		if _, err := orders.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("failed to backfill order search fields: %w", err)
+		}
+		updates = updates[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var order model.Order
+		if err := cursor.Decode(&order); err != nil {
+			return fmt.Errorf("failed to decode order: %w", err)
+		}
+		order.SetSearchKeywords()
+		set := bson.M{"search_keywords": order.SearchKeywords}
+		if len(order.SearchSKUs) > 0 {
+			set["search_skus"] = order.SearchSKUs
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": order.ID}).
+			SetUpdate(bson.M{"$set": set}))
+		if len(updates) == 500 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to read orders: %w", err)
+	}
+	return flush()
+}
+
+// createOrderSearchIndexes backs the anchored prefix matches of the buyer order
+// search on name keywords; the order ID prefix uses the _id index and the SKU
+// prefix the index of createOrderSearchSKUIndex
+func createOrderSearchIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("orders").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user._id", Value: 1}, {Key: "search_keywords", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create order search indexes: %w", err)
+	}
+	return nil
+}
+
+// createCodSettlementLineIndex allows a single matched settlement line per
+// parcel, so concurrent imports of the same report cannot both remit it
+func createCodSettlementLineIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("cod_settlement_lines").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "carrier", Value: 1},
+			{Key: "tracking_code", Value: 1},
+		},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": model.CodLineMatched}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create COD settlement line index: %w", err)
+	}
+	return nil
+}
+
+// createOrderSearchSKUIndex backs the SKU prefix match of the buyer order
+// search, which moved from the SKUs as entered to their lower-cased copies
+func createOrderSearchSKUIndex(ctx context.Context, db *mongo.Database) error {
+	indexes := db.Collection("orders").Indexes()
+	_, err := indexes.CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user._id", Value: 1}, {Key: "search_skus", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create order SKU search index: %w", err)
+	}
+
+	// Created by an earlier version of createOrderSearchIndexes
+	if _, err := indexes.DropOne(ctx, "user._id_1_items.sku_1"); err != nil {
+		var commandErr mongo.CommandError
+		if !errors.As(err, &commandErr) || commandErr.Name != "IndexNotFound" {
+			return fmt.Errorf("failed to drop order SKU index: %w", err)
+		}
+	}
+	return nil
+}
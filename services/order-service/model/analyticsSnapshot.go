@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// AnalyticsSnapshot holds the platform totals of one day, so the admin charts
+// don't aggregate every order again. It is written by the nightly job and
+// refreshed for recent days while their orders can still change.
+type AnalyticsSnapshot struct {
+	ID          string       `bson:"_id" json:"date"` // YYYY-MM-DD in the analytics time zone
+	Sales       []DailySales `bson:"sales" json:"sales"`
+	NewUsers    int64        `bson:"new_users" json:"new_users"`
+	NewSellers  int64        `bson:"new_sellers" json:"new_sellers"`
+	NewProducts int          `bson:"new_products" json:"new_products"`
+	NewReports  int          `bson:"new_reports" json:"new_reports"`
+	Complete    bool         `bson:"complete" json:"complete"` // false when user or product counts were unavailable
+	UpdatedAt   time.Time    `bson:"updated_at" json:"updated_at"`
+}
+
+// DailySales holds the orders of one day in one currency
+type DailySales struct {
+	Currency          string `bson:"currency" json:"currency"`
+	OrderCount        int    `bson:"order_count" json:"order_count"`
+	RevenueOrderCount int    `bson:"revenue_order_count" json:"revenue_order_count"`
+	CancelledCount    int    `bson:"cancelled_count" json:"cancelled_count"`
+	GMV               int64  `bson:"gmv" json:"gmv"`
+}
+
+// SalesIn returns the sales of the day in a currency
+func (s *AnalyticsSnapshot) SalesIn(currency string) DailySales {
+	for _, sales := range s.Sales {
+		if sales.Currency == currency {
+			return sales
+		}
+	}
+	return DailySales{Currency: currency}
+}
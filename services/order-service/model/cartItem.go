@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,13 +11,38 @@ import (
 // CartItem represents an item in a user's shopping cart
 type CartItem struct {
 	ID        string    `bson:"_id" json:"id"`
-	UserID    string    `bson:"user_id" json:"user_id"`
+	UserID    string    `bson:"user_id" json:"user_id"` // user ID, or GuestCartOwner(token) for a guest cart
 	Seller    CartSeller `bson:"seller" json:"seller"`
 	Product   CartProduct `bson:"product" json:"product"`
 	Variant   CartVariant `bson:"variant" json:"variant"`
+	Bundle    *CartBundle `bson:"bundle,omitempty" json:"bundle,omitempty"` // set when the line is a bundle instead of a single variant
 	Quantity  int         `bson:"quantity" json:"quantity"`
 	CreatedAt time.Time   `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time   `bson:"updated_at" json:"updated_at"`
+	ExpiresAt *time.Time  `bson:"expires_at,omitempty" json:"-"` // guest carts only; removed by a TTL index
+}
+
+// Guest carts are stored like user carts, owned by the cart token instead of a user ID
+const guestCartOwnerPrefix = "guest:"
+
+// GuestCartTokenLength is the length of a guest cart token: 32 random bytes, hex encoded
+const GuestCartTokenLength = 64
+
+func GuestCartOwner(token string) string {
+	return guestCartOwnerPrefix + token
+}
+
+func IsGuestCartOwner(owner string) bool {
+	return strings.HasPrefix(owner, guestCartOwnerPrefix)
+}
+
+// IsValidGuestCartToken checks that a token has the shape of the ones handed out
+func IsValidGuestCartToken(token string) bool {
+	if len(token) != GuestCartTokenLength {
+		return false
+	}
+	_, err := hex.DecodeString(token)
+	return err == nil
 }
 
 type CartSeller struct {
@@ -51,13 +78,37 @@ type CartProduct struct {
 	SellerCategoryIDs []string `bson:"seller_category_ids" json:"seller_category_ids"`
 }
 
+// CartVariant is a snapshot of the variant as the user last saw it in the cart
 type CartVariant struct {
-	ID      string            `bson:"id" json:"id"`
-	SKU     string            `bson:"sku" json:"sku"`
-	Options map[string]string `bson:"options" json:"options"`
-	Price   int               `bson:"price" json:"price"`
-	Stock   int               `bson:"stock" json:"stock"`
-	Image   string            `bson:"image" json:"image"`
+	ID       string            `bson:"id" json:"id"`
+	SKU      string            `bson:"sku" json:"sku"`
+	Options  map[string]string `bson:"options" json:"options"`
+	Price    int               `bson:"price" json:"price"`                           // minor units of Currency
+	Currency string            `bson:"currency,omitempty" json:"currency,omitempty"` // ISO 4217, empty on lines saved before it was recorded (VND)
+	Stock    int               `bson:"stock" json:"stock"`
+	Image    string            `bson:"image" json:"image"`
+}
+
+// CartBundle is a snapshot of a product bundle added to the cart as one line.
+// Each bundle unit contains every item with its own quantity.
+type CartBundle struct {
+	ID       string           `bson:"id" json:"id"`
+	Name     string           `bson:"name" json:"name"`
+	Image    string           `bson:"image" json:"image"`
+	Price    int              `bson:"price" json:"price"`                           // minor units of Currency
+	Currency string           `bson:"currency,omitempty" json:"currency,omitempty"` // ISO 4217, empty on lines saved before it was recorded (VND)
+	Items    []CartBundleItem `bson:"items" json:"items"`
+}
+
+type CartBundleItem struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	VariantID string `bson:"variant_id" json:"variant_id"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+}
+
+// IsBundle reports whether the cart line holds a bundle
+func (c *CartItem) IsBundle() bool {
+	return c.Bundle != nil
 }
 
 // BeforeCreate generates a new UUID for the ID field if not set and initializes timestamps
@@ -86,11 +137,20 @@ func (c *CartItem) Validate() error {
 	if c.Seller.ID == "" {
 		return &ValidationError{Field: "seller_id", Message: "seller_id is required"}
 	}
-	if c.Product.ID == "" {
-		return &ValidationError{Field: "product_id", Message: "product_id is required"}
-	}
-	if c.Variant.ID == "" {
-		return &ValidationError{Field: "variant_id", Message: "variant_id is required"}
+	if c.IsBundle() {
+		if c.Bundle.ID == "" {
+			return &ValidationError{Field: "bundle_id", Message: "bundle_id is required"}
+		}
+		if len(c.Bundle.Items) == 0 {
+			return &ValidationError{Field: "bundle_items", Message: "bundle must contain items"}
+		}
+	} else {
+		if c.Product.ID == "" {
+			return &ValidationError{Field: "product_id", Message: "product_id is required"}
+		}
+		if c.Variant.ID == "" {
+			return &ValidationError{Field: "variant_id", Message: "variant_id is required"}
+		}
 	}
 	if c.Quantity <= 0 {
 		return &ValidationError{Field: "quantity", Message: "quantity must be greater than 0"}
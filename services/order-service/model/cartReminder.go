@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cart reminder channels
+const (
+	ReminderChannelNotification = "NOTIFICATION"
+	ReminderChannelEmail        = "EMAIL"
+)
+
+// CartReminder records a reminder sent about an abandoned cart. A cart is
+// reminded about once: the next reminder needs activity after CartUpdatedAt.
+type CartReminder struct {
+	ID            string    `bson:"_id" json:"id"`
+	UserID        string    `bson:"user_id" json:"user_id"`
+	CartUpdatedAt time.Time `bson:"cart_updated_at" json:"cart_updated_at"` // last change to the cart when the reminder was sent
+	Channels      []string  `bson:"channels" json:"channels"`               // channels the reminder went out on
+	ItemCount     int       `bson:"item_count" json:"item_count"`           // available lines in the cart
+	PriceDrops    int       `bson:"price_drops" json:"price_drops"`
+	LowStock      int       `bson:"low_stock" json:"low_stock"`
+	SentAt        time.Time `bson:"sent_at" json:"sent_at"`
+}
+
+func (r *CartReminder) BeforeCreate() {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	if r.SentAt.IsZero() {
+		r.SentAt = time.Now()
+	}
+}
+
+// CartReminderPreference is a user's choice about abandoned cart reminders.
+// Users without one get reminders.
+type CartReminderPreference struct {
+	UserID    string    `bson:"_id" json:"user_id"`
+	OptedOut  bool      `bson:"opted_out" json:"opted_out"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
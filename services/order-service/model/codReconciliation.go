@@ -0,0 +1,104 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Settlement line statuses
+const (
+	CodLineMatched    = "MATCHED"    // the amount remitted is what the parcel collected
+	CodLineMismatched = "MISMATCHED" // the parcel was found but the amount differs
+	CodLineUnknown    = "UNKNOWN"    // no parcel has this tracking code
+	CodLineDuplicate  = "DUPLICATE"  // the parcel was already remitted
+)
+
+// CodSettlement is a carrier settlement report imported by an admin, listing
+// the cash on delivery the carrier remitted for each parcel
+type CodSettlement struct {
+	ID          string    `bson:"_id" json:"id"`
+	Carrier     string    `bson:"carrier" json:"carrier"`
+	FileName    string    `bson:"file_name" json:"file_name"`
+	ImportedBy  string    `bson:"imported_by" json:"imported_by"`
+	ImportedAt  time.Time `bson:"imported_at" json:"imported_at"`
+	LineCount   int       `bson:"line_count" json:"line_count"`
+	Matched     int       `bson:"matched" json:"matched"`
+	Mismatched  int       `bson:"mismatched" json:"mismatched"`
+	Unknown     int       `bson:"unknown" json:"unknown"`
+	Duplicate   int       `bson:"duplicate" json:"duplicate"`
+	TotalAmount int64     `bson:"total_amount" json:"total_amount"` // remitted according to the report
+}
+
+// CodSettlementLine is one remittance of a settlement report and what it was
+// matched with
+type CodSettlementLine struct {
+	ID           string     `bson:"_id" json:"id"`
+	SettlementID string     `bson:"settlement_id" json:"settlement_id"`
+	Line         int        `bson:"line" json:"line"` // in the imported file, header included
+	Carrier      string     `bson:"carrier" json:"carrier"`
+	TrackingCode string     `bson:"tracking_code" json:"tracking_code"`
+	OrderID      string     `bson:"order_id,omitempty" json:"order_id,omitempty"`
+	ShipmentID   string     `bson:"shipment_id,omitempty" json:"shipment_id,omitempty"`
+	Amount       int        `bson:"amount" json:"amount"`                         // remitted by the carrier
+	Expected     int        `bson:"expected,omitempty" json:"expected,omitempty"` // collected by the parcel
+	Status       string     `bson:"status" json:"status"`
+	SettledAt    *time.Time `bson:"settled_at,omitempty" json:"settled_at,omitempty"` // as reported by the carrier
+	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
+}
+
+func (s *CodSettlement) BeforeCreate() {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.ImportedAt.IsZero() {
+		s.ImportedAt = time.Now()
+	}
+}
+
+// CountLines sets the line counts of the settlement from the line statuses
+func (s *CodSettlement) CountLines(lines []*CodSettlementLine) {
+	s.LineCount = len(lines)
+	s.Matched, s.Mismatched, s.Unknown, s.Duplicate = 0, 0, 0, 0
+	for _, line := range lines {
+		switch line.Status {
+		case CodLineMatched:
+			s.Matched++
+		case CodLineMismatched:
+			s.Mismatched++
+		case CodLineUnknown:
+			s.Unknown++
+		case CodLineDuplicate:
+			s.Duplicate++
+		}
+	}
+}
+
+func (l *CodSettlementLine) BeforeCreate() {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	if l.CreatedAt.IsZero() {
+		l.CreatedAt = time.Now()
+	}
+}
+
+// CodRemitted reports whether the carrier remitted the cash of every COD
+// parcel of the order that was not cancelled
+func (o *Order) CodRemitted() bool {
+	if o.PaymentMethod != "COD" || len(o.UnshippedItems()) > 0 {
+		return false
+	}
+
+	remitted := false
+	for _, shipment := range o.Shipments {
+		if shipment.Status == ShipmentCancelled || shipment.CodAmount == 0 {
+			continue
+		}
+		if shipment.CodRemittedAt == nil {
+			return false
+		}
+		remitted = true
+	}
+	return remitted
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// COD risk reasons
+const (
+	CodRiskReturnedOrders  = "RETURNED_ORDERS"  // parcels refused at delivery or orders returned
+	CodRiskCancelledOrders = "CANCELLED_ORDERS" // COD orders the buyer cancelled
+	CodRiskNewAccount      = "NEW_ACCOUNT"
+	CodRiskFirstOrder      = "FIRST_ORDER"
+	CodRiskHighValue       = "HIGH_VALUE"     // well above what the buyer usually spends
+	CodRiskSharedAddress   = "SHARED_ADDRESS" // shipping address or phone used by other accounts
+)
+
+// CodRisk is how likely a COD order is to be refused or cancelled, assessed at
+// checkout from the buyer's history
+type CodRisk struct {
+	Score      int             `bson:"score" json:"score"` // 0 to 100
+	Reasons    []CodRiskReason `bson:"reasons" json:"reasons"`
+	AssessedAt time.Time       `bson:"assessed_at" json:"assessed_at"`
+}
+
+// CodRiskReason is one signal and the points it adds to the score
+type CodRiskReason struct {
+	Code    string `bson:"code" json:"code"`
+	Points  int    `bson:"points" json:"points"`
+	Message string `bson:"message" json:"message"`
+}
+
+// Add records a reason; the score never goes above 100
+func (r *CodRisk) Add(code string, points int, message string) {
+	r.Reasons = append(r.Reasons, CodRiskReason{Code: code, Points: points, Message: message})
+	r.Score = min(r.Score+points, 100)
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Export job statuses
+const (
+	ExportPending = "PENDING"
+	ExportRunning = "RUNNING"
+	ExportDone    = "DONE"
+	ExportFailed  = "FAILED"
+)
+
+// ExportJob is an order export too large to stream in the request; the file is
+// written in the background and stored in S3.
+type ExportJob struct {
+	ID         string            `bson:"_id" json:"id"`
+	SellerID   string            `bson:"seller_id" json:"seller_id"`
+	Format     string            `bson:"format" json:"format"` // csv, xlsx
+	Filter     ExportOrderFilter `bson:"filter" json:"filter"`
+	Status     string            `bson:"status" json:"status"`
+	OrderCount int64             `bson:"order_count" json:"order_count"`
+	RowCount   int64             `bson:"row_count" json:"row_count"`
+	FileKey    string            `bson:"file_key,omitempty" json:"-"`
+	Error      string            `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time         `bson:"created_at" json:"created_at"`
+	FinishedAt *time.Time        `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// ExportOrderFilter is the order listing filter an export job was created with
+type ExportOrderFilter struct {
+	Status        string     `bson:"status,omitempty" json:"status,omitempty"`
+	PaymentMethod string     `bson:"payment_method,omitempty" json:"payment_method,omitempty"`
+	PaymentStatus string     `bson:"payment_status,omitempty" json:"payment_status,omitempty"`
+	Search        string     `bson:"search,omitempty" json:"search,omitempty"`
+	StartDate     *time.Time `bson:"start_date,omitempty" json:"start_date,omitempty"`
+	EndDate       *time.Time `bson:"end_date,omitempty" json:"end_date,omitempty"`
+}
+
+func (j *ExportJob) BeforeCreate() {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = time.Now()
+	}
+	if j.Status == "" {
+		j.Status = ExportPending
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Invoice is the invoice issued for an order. Numbers are sequential per seller
+// and never reused; the parties are a snapshot taken when the invoice is issued.
+type Invoice struct {
+	ID       string       `bson:"_id" json:"id"` // the order ID: an order has one invoice
+	OrderID  string       `bson:"order_id" json:"order_id"`
+	SellerID string       `bson:"seller_id" json:"seller_id"`
+	Sequence int64        `bson:"sequence" json:"sequence"`
+	Number   string       `bson:"number" json:"number"` // empty until the sequence is allocated
+	Seller   InvoiceParty `bson:"seller" json:"seller"`
+	Buyer    InvoiceParty `bson:"buyer" json:"buyer"`
+	FileKey  string       `bson:"file_key,omitempty" json:"-"`
+	FileURL  string       `bson:"file_url,omitempty" json:"file_url,omitempty"`
+	IssuedAt time.Time    `bson:"issued_at" json:"issued_at"`
+}
+
+type InvoiceParty struct {
+	Name    string `bson:"name" json:"name"`
+	Email   string `bson:"email,omitempty" json:"email,omitempty"`
+	Phone   string `bson:"phone,omitempty" json:"phone,omitempty"`
+	Address string `bson:"address,omitempty" json:"address,omitempty"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Loyalty transaction types. The ledger is append-only: corrections are new
+// entries, never updates, and the balance is the sum of all entries.
+const (
+	LoyaltyEarn         = "EARN"          // order COMPLETED (positive)
+	LoyaltyReverse      = "REVERSE"       // order RETURNED, cancels an EARN (negative)
+	LoyaltyRedeem       = "REDEEM"        // points spent at checkout (negative)
+	LoyaltyRedeemCancel = "REDEEM_CANCEL" // redeemed points given back, e.g. order cancelled (positive)
+	LoyaltyExpire       = "EXPIRE"        // earned points that passed their expiry date (negative)
+)
+
+// LoyaltyTransaction is a single entry in a buyer's points ledger
+type LoyaltyTransaction struct {
+	ID          string     `bson:"_id" json:"id"`
+	UserID      string     `bson:"user_id" json:"user_id"`
+	OrderID     string     `bson:"order_id,omitempty" json:"order_id,omitempty"`
+	SellerID    string     `bson:"seller_id,omitempty" json:"seller_id,omitempty"`
+	Type        string     `bson:"type" json:"type"`
+	Points      int        `bson:"points" json:"points"` // signed: positive credits, negative debits
+	Description string     `bson:"description" json:"description"`
+	ExpiresAt   *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // set on EARN and its REVERSE
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// LoyaltyRate is the earn rate (percent of the paid amount returned as points)
+// for a seller. The platform-wide default uses the ID "platform".
+type LoyaltyRate struct {
+	ID        string    `bson:"_id" json:"id"`
+	Rate      float64   `bson:"rate" json:"rate"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+const PlatformLoyaltyRateID = "platform"
+
+func (t *LoyaltyTransaction) BeforeCreate() {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is used for documents created before currencies were recorded
+const DefaultCurrency = "VND"
+
+// currencyExponents holds the ISO 4217 number of minor-unit digits of the
+// currencies we accept. VND has no minor unit, so 1 VND is stored as 1.
+var currencyExponents = map[string]int{
+	"VND": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"USD": 2,
+	"EUR": 2,
+	"SGD": 2,
+	"THB": 2,
+}
+
+// Money is an amount in the minor units of its ISO 4217 currency.
+// Amounts are never floats, so totals add up exactly.
+type Money struct {
+	Amount   int64  `bson:"amount" json:"amount"`
+	Currency string `bson:"currency" json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: NormalizeCurrency(currency)}
+}
+
+// NormalizeCurrency upper-cases a currency code and falls back to DefaultCurrency when empty
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+// IsSupportedCurrency reports whether the currency code is known
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencyExponents[NormalizeCurrency(currency)]
+	return ok
+}
+
+// CurrencyExponent returns the ISO number of minor-unit digits of a currency
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[NormalizeCurrency(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.match(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.match(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Percent returns percent% of the amount, rounded down to a whole minor unit
+func (m Money) Percent(percent float64) Money {
+	return Money{Amount: int64(float64(m.Amount) * percent / 100), Currency: m.Currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// ToUnits converts the amount to a representation with the given number of decimals,
+// as payment providers define their own minor units (e.g. VNPay sends VND x 100).
+func (m Money) ToUnits(decimals int) int64 {
+	return m.shift(decimals - CurrencyExponent(m.Currency))
+}
+
+// String formats the amount with its currency, e.g. "150000 VND" or "12.50 USD"
+func (m Money) String() string {
+	exponent := CurrencyExponent(m.Currency)
+	if exponent == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	divisor := int64(1)
+	for i := 0; i < exponent; i++ {
+		divisor *= 10
+	}
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/divisor, exponent, amount%divisor, m.Currency)
+}
+
+// match guards against adding amounts of different currencies
+func (m Money) match(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("money: currency mismatch %s and %s", m.Currency, other.Currency)
+	}
+	return nil
+}
+
+// MoneyFromUnits is the inverse of ToUnits: it reads an amount a provider reported
+// with the given number of decimals
+func MoneyFromUnits(units int64, decimals int, currency string) Money {
+	currency = NormalizeCurrency(currency)
+	return Money{Amount: Money{Amount: units}.shift(CurrencyExponent(currency) - decimals), Currency: currency}
+}
+
+func (m Money) shift(digits int) int64 {
+	amount := m.Amount
+	for ; digits > 0; digits-- {
+		amount *= 10
+	}
+	for ; digits < 0; digits++ {
+		amount /= 10
+	}
+	return amount
+}
@@ -1,7 +1,10 @@
 package model
 
 import (
+	"slices"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 )
@@ -10,19 +13,78 @@ type Order struct {
 	ID              string        `bson:"_id" json:"id"`
 	Status          string        `bson:"status" json:"status"`
 	User            User          `bson:"user" json:"user"`
-	PaymentMethod   string        `bson:"payment_method" json:"payment_method"`      //COD, STRIPE
-	PaymentStatus   string        `bson:"payment_status" json:"payment_status"`      //PENDING, PAID, FAILED
+	PaymentMethod   string        `bson:"payment_method" json:"payment_method"`      //COD, STRIPE, VNPAY, MOMO, WALLET
+	PaymentStatus   string        `bson:"payment_status" json:"payment_status"`      //PENDING, PAID, FAILED, REFUNDED
 	Seller          User          `bson:"seller" json:"seller"`
 	Items           []OrderItem   `bson:"items" json:"items"`
 	CreatedAt       time.Time     `bson:"created_at" json:"created_at"`
 	UpdatedAt       time.Time     `bson:"updated_at" json:"updated_at"`
 	Voucher         *OrderVoucher `bson:"voucher" json:"voucher"`
-	Total           float64       `bson:"total" json:"total"`
+	Currency        string        `bson:"currency" json:"currency"` // ISO 4217; every amount on the order is in minor units of it
+	Total           int64         `bson:"total" json:"total"`       // items after discounts, excluding the delivery fee
+	TaxTotal        int64         `bson:"tax_total" json:"tax_total"`         // VAT on the items, included in Total
+	TaxInclusive    bool          `bson:"tax_inclusive" json:"tax_inclusive"` // whether the seller's prices already included the VAT
+	RedeemedPoints  int           `bson:"redeemed_points,omitempty" json:"redeemed_points,omitempty"` // loyalty points spent on this order
+	PointsDiscount  int           `bson:"points_discount,omitempty" json:"points_discount,omitempty"` // discount from redeemed points, already deducted from Total
+	WalletAmount    int           `bson:"wallet_amount,omitempty" json:"wallet_amount,omitempty"`     // part of Total + DeliveryFee paid from the wallet
+	PaymentID       string        `bson:"payment_id,omitempty" json:"payment_id,omitempty"`           // gateway transaction ID once paid
 	Phone           string        `bson:"phone" json:"phone"`
 	ShippingAddress OrderAddress  `bson:"shipping_address" json:"shipping_address"`
-	DeliveryCode    string        `bson:"delivery_code" json:"delivery_code"`
+	DeliveryCode    string        `bson:"delivery_code" json:"delivery_code"` // carrier code of orders shipped before parcels; see Shipments
 	DeliveryFee     int           `bson:"delivery_fee" json:"delivery_fee"`
 	DeliveryServiceID int        `bson:"delivery_service" json:"delivery_service"`
+	CompletedAt     *time.Time    `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	DeliveredAt     *time.Time    `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`     // when the carrier delivered the parcel
+	ReturnRequest   *ReturnRequest `bson:"return_request,omitempty" json:"return_request,omitempty"` // opened by the buyer before the order completes
+	StatusHistory   []OrderStatusChange `bson:"status_history,omitempty" json:"status_history,omitempty"`
+	Cancellations   []ItemCancellation  `bson:"cancellations,omitempty" json:"cancellations,omitempty"` // items taken off the order before it shipped
+	Shipments       []Shipment          `bson:"shipments,omitempty" json:"shipments,omitempty"`         // parcels, created once the seller confirms
+	CodRisk         *CodRisk            `bson:"cod_risk,omitempty" json:"cod_risk,omitempty"`           // assessed at checkout for COD orders
+	ExpectedShipDate *time.Time         `bson:"expected_ship_date,omitempty" json:"expected_ship_date,omitempty"` // set on pre-orders: the latest ship date of their items
+	SearchKeywords  []string            `bson:"search_keywords,omitempty" json:"-"`                              // lower-cased words of the product and seller names, see SetSearchKeywords
+	SearchSKUs      []string            `bson:"search_skus,omitempty" json:"-"`                                  // lower-cased item SKUs, see SetSearchKeywords
+}
+
+// Actors of an order status change
+const (
+	ActorBuyer  = "buyer"
+	ActorSeller = "seller"
+	ActorSystem = "system" // scheduled jobs and payment callbacks
+)
+
+// OrderStatusChange records who moved an order from one status to another
+type OrderStatusChange struct {
+	From    string    `bson:"from" json:"from"`
+	To      string    `bson:"to" json:"to"`
+	Actor   string    `bson:"actor" json:"actor"`
+	ActorID string    `bson:"actor_id,omitempty" json:"actor_id,omitempty"` // user ID, empty for the system
+	Reason  string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	At      time.Time `bson:"at" json:"at"`
+}
+
+// ReturnRequest is a buyer's request to return a delivered order. It keeps the
+// order from being completed automatically until the seller settles it.
+type ReturnRequest struct {
+	Reason      string    `bson:"reason" json:"reason"`
+	RequestedAt time.Time `bson:"requested_at" json:"requested_at"`
+}
+
+// ItemCancellation records items cancelled from an order that goes on with
+// the rest, and what the buyer got back for them
+type ItemCancellation struct {
+	Items          []CancelledItem `bson:"items" json:"items"`
+	Refund         int             `bson:"refund,omitempty" json:"refund,omitempty"`                   // moved back to the buyer's wallet
+	RemovedVoucher string          `bson:"removed_voucher,omitempty" json:"removed_voucher,omitempty"` // voucher code dropped because the order fell below its minimum
+	Actor          string          `bson:"actor" json:"actor"`
+	ActorID        string          `bson:"actor_id" json:"actor_id"`
+	Reason         string          `bson:"reason,omitempty" json:"reason,omitempty"`
+	At             time.Time       `bson:"at" json:"at"`
+}
+
+type CancelledItem struct {
+	VariantID string `bson:"variant_id" json:"variant_id"`
+	BundleID  string `bson:"bundle_id,omitempty" json:"bundle_id,omitempty"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
 }
 
 type OrderItem struct {
@@ -34,6 +96,19 @@ type OrderItem struct {
 	Price       int    `bson:"price" json:"price"` // Price at the time of purchase
 	Image       string `bson:"image" json:"image"`
 	Quantity    int    `bson:"quantity" json:"quantity"`
+	CategoryIDs []string `bson:"category_ids,omitempty" json:"category_ids,omitempty"` // platform categories of the product at checkout
+	BundleID    string `bson:"bundle_id,omitempty" json:"bundle_id,omitempty"`     // set when the item was bought as part of a bundle
+	BundleName  string `bson:"bundle_name,omitempty" json:"bundle_name,omitempty"`
+	Discount    int    `bson:"discount,omitempty" json:"discount,omitempty"` // bundle discount allocated to this line (whole line, not per unit)
+	TaxRate       float64 `bson:"tax_rate" json:"tax_rate"`             // VAT percent applied at checkout
+	TaxableAmount int     `bson:"taxable_amount" json:"taxable_amount"` // line amount after all discounts, excluding VAT
+	TaxAmount     int     `bson:"tax_amount" json:"tax_amount"`         // VAT on the line
+	PreOrderShipDate *time.Time `bson:"pre_order_ship_date,omitempty" json:"pre_order_ship_date,omitempty"` // set when the line was pre-ordered beyond stock
+}
+
+// LineTotal returns the amount paid for the line after any bundle discount
+func (i *OrderItem) LineTotal() int {
+	return i.Price*i.Quantity - i.Discount
 }
 
 type User struct {
@@ -59,9 +134,13 @@ type OrderAddress struct {
 }
 
 type OrderVoucher struct {
+	ID                     string   `bson:"id,omitempty" json:"id,omitempty"` // empty on orders placed before the terms were kept
 	Code                   string   `bson:"code" json:"code"`
 	DiscountType           string   `bson:"discount_type" json:"discount_type"`
 	DiscountValue          int      `bson:"discount_value" json:"discount_value"`
+	MinOrderValue          int      `bson:"min_order_value,omitempty" json:"min_order_value,omitempty"`
+	MaxDiscountValue       int      `bson:"max_discount_value,omitempty" json:"max_discount_value,omitempty"`
+	PlatformFunded         bool     `bson:"platform_funded,omitempty" json:"platform_funded,omitempty"` // the platform pays for the discount, not the seller
 }
 
 func (o *Order) BeforeCreate() {
@@ -77,4 +156,109 @@ func (o *Order) BeforeCreate() {
 	if o.Status == "" {
 		o.Status = "pending"
 	}
-}
\ No newline at end of file
+	o.SetSearchKeywords()
+}
+
+// SetSearchKeywords stores the distinct lower-cased words of the product names
+// and the seller name, and the lower-cased SKUs, so the buyer order search can
+// match them with an indexed prefix instead of a case-insensitive regex over
+// every order. Order IDs are lower-case UUIDs already.
+func (o *Order) SetSearchKeywords() {
+	names := []string{o.Seller.Name}
+	o.SearchSKUs = nil
+	for i := range o.Items {
+		names = append(names, o.Items[i].ProductName)
+		sku := strings.ToLower(strings.TrimSpace(o.Items[i].SKU))
+		if sku != "" && !slices.Contains(o.SearchSKUs, sku) {
+			o.SearchSKUs = append(o.SearchSKUs, sku)
+		}
+	}
+	o.SearchKeywords = SearchKeywords(names...)
+}
+
+// SearchKeywords splits text into distinct lower-cased words
+func SearchKeywords(texts ...string) []string {
+	var keywords []string
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), isKeywordSeparator) {
+			if !seen[word] {
+				seen[word] = true
+				keywords = append(keywords, word)
+			}
+		}
+	}
+	return keywords
+}
+
+func isKeywordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// SetStatus moves the order to a new status and records the change
+func (o *Order) SetStatus(status, actor, actorID, reason string) {
+	now := time.Now()
+	o.StatusHistory = append(o.StatusHistory, OrderStatusChange{
+		From:    o.Status,
+		To:      status,
+		Actor:   actor,
+		ActorID: actorID,
+		Reason:  reason,
+		At:      now,
+	})
+	o.Status = status
+	o.UpdatedAt = now
+	if status == "COMPLETED" {
+		o.CompletedAt = &now
+	}
+}
+
+// StatusSince returns when the order entered its current status. Orders
+// created before changes were recorded fall back to their creation time.
+func (o *Order) StatusSince() time.Time {
+	for i := len(o.StatusHistory) - 1; i >= 0; i-- {
+		if o.StatusHistory[i].To == o.Status {
+			return o.StatusHistory[i].At
+		}
+	}
+	return o.CreatedAt
+}
+
+// IsPreOrder reports whether the order holds pre-ordered items, which are
+// never mixed with items in stock
+func (o *Order) IsPreOrder() bool {
+	return o.ExpectedShipDate != nil
+}
+
+// GrandTotal returns the items total plus the delivery fee
+func (o *Order) GrandTotal() Money {
+	return NewMoney(o.Total+int64(o.DeliveryFee), o.Currency)
+}
+
+// AmountDue returns what is left to pay online after the wallet part, which is
+// held in the order currency
+func (o *Order) AmountDue() Money {
+	return NewMoney(o.Total+int64(o.DeliveryFee)-int64(o.WalletAmount), o.Currency)
+}
+
+// ItemsTotal returns the sum of the item lines after bundle discounts
+func (o *Order) ItemsTotal() int64 {
+	var total int64
+	for i := range o.Items {
+		total += int64(o.Items[i].LineTotal())
+	}
+	return total
+}
+
+// VoucherDiscount returns the order-level voucher discount. It is not stored,
+// so it is derived from the item lines and the discounted total.
+func (o *Order) VoucherDiscount() int64 {
+	total := o.Total + int64(o.PointsDiscount)
+	if !o.TaxInclusive {
+		total -= o.TaxTotal
+	}
+	if discount := o.ItemsTotal() - total; discount > 0 {
+		return discount
+	}
+	return 0
+}
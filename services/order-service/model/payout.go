@@ -0,0 +1,121 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Seller ledger entry types. Amounts are signed: positive amounts are owed to
+// the seller, negative ones are kept by the platform.
+const (
+	PayoutSale            = "SALE"             // what the buyer paid, delivery included
+	PayoutPlatformSubsidy = "PLATFORM_SUBSIDY" // platform-funded vouchers and loyalty points, paid by the platform
+	PayoutCommission      = "COMMISSION"       // platform commission on the items
+	PayoutShipping        = "SHIPPING"         // delivery fee passed on to the carrier
+	PayoutRefund          = "REFUND"           // takes back a settled order that was refunded afterwards
+)
+
+// PayoutEntryTypes lists the entry types in statement order
+var PayoutEntryTypes = []string{PayoutSale, PayoutPlatformSubsidy, PayoutCommission, PayoutShipping, PayoutRefund}
+
+// SellerLedgerEntry is one amount owed to or by a seller for an order. The
+// entries of an order are derived from the order itself, so they can be
+// recomputed at any time: entries not yet in a statement are replaced, and
+// settled ones are corrected with adjustment entries.
+type SellerLedgerEntry struct {
+	ID          string    `bson:"_id" json:"id"` // <order ID>:<type>, or a UUID for adjustments
+	SellerID    string    `bson:"seller_id" json:"seller_id"`
+	OrderID     string    `bson:"order_id" json:"order_id"`
+	Type        string    `bson:"type" json:"type"`
+	Currency    string    `bson:"currency" json:"currency"`
+	Amount      int64     `bson:"amount" json:"amount"`
+	Adjustment  bool      `bson:"adjustment,omitempty" json:"adjustment,omitempty"` // corrects an amount already in a statement
+	StatementID string    `bson:"statement_id" json:"statement_id,omitempty"`       // empty until the entry is settled
+	OccurredAt  time.Time `bson:"occurred_at" json:"occurred_at"`                   // completion of the order, or time of the adjustment
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+func PayoutEntryID(orderID, entryType string) string {
+	return orderID + ":" + entryType
+}
+
+func (e *SellerLedgerEntry) BeforeCreate() {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = e.CreatedAt
+	}
+}
+
+// CommissionRule is the platform commission rate (percent of the items sold)
+// for a seller, a platform category or, with the ID "default", everything else.
+// Seller rules take precedence over category rules.
+type CommissionRule struct {
+	ID         string    `bson:"_id" json:"id"`
+	SellerID   string    `bson:"seller_id,omitempty" json:"seller_id,omitempty"`
+	CategoryID string    `bson:"category_id,omitempty" json:"category_id,omitempty"`
+	Rate       float64   `bson:"rate" json:"rate"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+const DefaultCommissionRuleID = "default"
+
+func SellerCommissionRuleID(sellerID string) string {
+	return "seller:" + sellerID
+}
+
+func CategoryCommissionRuleID(categoryID string) string {
+	return "category:" + categoryID
+}
+
+// Settlement statement statuses
+const (
+	StatementPending = "PENDING" // awaiting payout
+	StatementPaid    = "PAID"
+)
+
+// SettlementStatement settles a seller's ledger entries of one currency for a
+// week, Monday to Monday. Entries recorded late for an earlier week are settled
+// in the next statement generated.
+type SettlementStatement struct {
+	ID               string     `bson:"_id" json:"id"` // <seller ID>:<currency>:<week start date>
+	SellerID         string     `bson:"seller_id" json:"seller_id"`
+	Currency         string     `bson:"currency" json:"currency"`
+	PeriodStart      time.Time  `bson:"period_start" json:"period_start"`
+	PeriodEnd        time.Time  `bson:"period_end" json:"period_end"`
+	OrderCount       int        `bson:"order_count" json:"order_count"`
+	EntryCount       int        `bson:"entry_count" json:"entry_count"`
+	Sales            int64      `bson:"sales" json:"sales"`
+	PlatformSubsidy  int64      `bson:"platform_subsidy" json:"platform_subsidy"`
+	Commission       int64      `bson:"commission" json:"commission"`
+	Shipping         int64      `bson:"shipping" json:"shipping"`
+	Refunds          int64      `bson:"refunds" json:"refunds"`
+	Payout           int64      `bson:"payout" json:"payout"` // sum of all entries
+	Status           string     `bson:"status" json:"status"`
+	PaymentReference string     `bson:"payment_reference,omitempty" json:"payment_reference,omitempty"`
+	PaidAt           *time.Time `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+	CreatedAt        time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+func SettlementStatementID(sellerID, currency string, periodStart time.Time) string {
+	return sellerID + ":" + currency + ":" + periodStart.Format("2006-01-02")
+}
+
+// SetTotals fills the statement totals from the sums of its entries per type
+func (s *SettlementStatement) SetTotals(sums map[string]int64) {
+	s.Sales = sums[PayoutSale]
+	s.PlatformSubsidy = sums[PayoutPlatformSubsidy]
+	s.Commission = sums[PayoutCommission]
+	s.Shipping = sums[PayoutShipping]
+	s.Refunds = sums[PayoutRefund]
+	s.Payout = 0
+	for _, amount := range sums {
+		s.Payout += amount
+	}
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Shipment statuses
+const (
+	ShipmentToPickup  = "TO_PICKUP"
+	ShipmentShipping  = "SHIPPING"
+	ShipmentDelivered = "DELIVERED"
+	ShipmentReturned  = "RETURNED"
+	ShipmentCancelled = "CANCELLED"
+)
+
+// CarrierGHN is Giao Hang Nhanh, the only carrier shipments are created with
+const CarrierGHN = "GHN"
+
+// Shipment is one parcel of an order. An order can be shipped in several
+// parcels; each has its own carrier order and status.
+type Shipment struct {
+	ID            string         `bson:"_id" json:"id"`
+	Carrier       string         `bson:"carrier" json:"carrier"`
+	TrackingCode  string         `bson:"tracking_code" json:"tracking_code"`
+	Status        string         `bson:"status" json:"status"`
+	Items         []ShipmentItem `bson:"items" json:"items"`
+	CodAmount     int            `bson:"cod_amount,omitempty" json:"cod_amount,omitempty"` // collected by the carrier on delivery
+	CreatedAt     time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time      `bson:"updated_at" json:"updated_at"`
+	DeliveredAt   *time.Time     `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	CodRemittedAt *time.Time     `bson:"cod_remitted_at,omitempty" json:"cod_remitted_at,omitempty"` // confirmed by a carrier settlement report
+}
+
+// ShipmentItem is a quantity of an order line, identified by variant and bundle
+type ShipmentItem struct {
+	VariantID string `bson:"variant_id" json:"variant_id"`
+	BundleID  string `bson:"bundle_id,omitempty" json:"bundle_id,omitempty"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+}
+
+func (s *Shipment) BeforeCreate() {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = now
+	}
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = now
+	}
+	if s.Status == "" {
+		s.Status = ShipmentToPickup
+	}
+}
+
+// SetStatus moves the parcel to a new status
+func (s *Shipment) SetStatus(status string, at time.Time) {
+	s.Status = status
+	s.UpdatedAt = time.Now()
+	if status == ShipmentDelivered && s.DeliveredAt == nil {
+		s.DeliveredAt = &at
+	}
+}
+
+// FindShipment returns the order's parcel with the given ID, or nil
+func (o *Order) FindShipment(id string) *Shipment {
+	for i := range o.Shipments {
+		if o.Shipments[i].ID == id {
+			return &o.Shipments[i]
+		}
+	}
+	return nil
+}
+
+// FindShipmentByTrackingCode returns the order's parcel with the given carrier
+// code, or nil
+func (o *Order) FindShipmentByTrackingCode(trackingCode string) *Shipment {
+	for i := range o.Shipments {
+		if o.Shipments[i].TrackingCode == trackingCode {
+			return &o.Shipments[i]
+		}
+	}
+	return nil
+}
+
+// UnshippedItems returns what is left of each order line once the parcels
+// that were not cancelled are taken off
+func (o *Order) UnshippedItems() []ShipmentItem {
+	shipped := make(map[[2]string]int)
+	for _, shipment := range o.Shipments {
+		if shipment.Status == ShipmentCancelled {
+			continue
+		}
+		for _, item := range shipment.Items {
+			shipped[[2]string{item.VariantID, item.BundleID}] += item.Quantity
+		}
+	}
+
+	var items []ShipmentItem
+	for _, line := range o.Items {
+		key := [2]string{line.VariantID, line.BundleID}
+		taken := min(shipped[key], line.Quantity)
+		shipped[key] -= taken
+		if quantity := line.Quantity - taken; quantity > 0 {
+			items = append(items, ShipmentItem{VariantID: line.VariantID, BundleID: line.BundleID, Quantity: quantity})
+		}
+	}
+	return items
+}
+
+// ShippingStatus derives the order status from its parcels: SHIPPING once any
+// parcel has left the seller, TO_PICKUP before that
+func (o *Order) ShippingStatus() string {
+	for _, shipment := range o.Shipments {
+		switch shipment.Status {
+		case ShipmentShipping, ShipmentDelivered, ShipmentReturned:
+			return "SHIPPING"
+		}
+	}
+	return "TO_PICKUP"
+}
+
+// ShipmentsDeliveredAt returns when the last parcel was delivered, or nil while
+// an item is not shipped yet or a parcel is still on its way
+func (o *Order) ShipmentsDeliveredAt() *time.Time {
+	if len(o.UnshippedItems()) > 0 {
+		return nil
+	}
+
+	var deliveredAt *time.Time
+	for _, shipment := range o.Shipments {
+		if shipment.Status == ShipmentCancelled {
+			continue
+		}
+		if shipment.Status != ShipmentDelivered || shipment.DeliveredAt == nil {
+			return nil
+		}
+		if deliveredAt == nil || shipment.DeliveredAt.After(*deliveredAt) {
+			deliveredAt = shipment.DeliveredAt
+		}
+	}
+	return deliveredAt
+}
+
+// TrackingCodes returns the carrier codes of the parcels that were not
+// cancelled, or the single delivery code of orders shipped before parcels
+func (o *Order) TrackingCodes() []string {
+	var codes []string
+	for _, shipment := range o.Shipments {
+		if shipment.Status != ShipmentCancelled && shipment.TrackingCode != "" {
+			codes = append(codes, shipment.TrackingCode)
+		}
+	}
+	if len(codes) == 0 && o.DeliveryCode != "" {
+		codes = append(codes, o.DeliveryCode)
+	}
+	return codes
+}
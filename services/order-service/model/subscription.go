@@ -0,0 +1,90 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription statuses
+const (
+	SubscriptionActive    = "ACTIVE"
+	SubscriptionPaused    = "PAUSED"
+	SubscriptionCancelled = "CANCELLED"
+)
+
+// Payment methods a subscription can renew with. Stripe renewals are charged
+// off-session to a card the buyer saved beforehand.
+const (
+	SubscriptionPayCOD    = "COD"
+	SubscriptionPayStripe = "STRIPE"
+)
+
+// Subscription reorders the same items from one seller every IntervalDays
+// days. Each renewal is a normal order, placed through the checkout.
+type Subscription struct {
+	ID                string             `bson:"_id" json:"id"`
+	UserID            string             `bson:"user_id" json:"user_id"`
+	SellerID          string             `bson:"seller_id" json:"seller_id"`
+	Items             []SubscriptionItem `bson:"items" json:"items"`
+	IntervalDays      int                `bson:"interval_days" json:"interval_days"`
+	ShippingAddress   OrderAddress       `bson:"shipping_address" json:"shipping_address"`
+	DeliveryServiceID int                `bson:"delivery_service" json:"delivery_service"`
+	PaymentMethod     string             `bson:"payment_method" json:"payment_method"` // COD or STRIPE
+	// Saved card for STRIPE renewals
+	StripeCustomerID      string     `bson:"stripe_customer_id,omitempty" json:"-"`
+	StripePaymentMethodID string     `bson:"stripe_payment_method_id,omitempty" json:"-"`
+	Status                string     `bson:"status" json:"status"`
+	NextOrderAt           time.Time  `bson:"next_order_at" json:"next_order_at"`
+	LastOrderID           string     `bson:"last_order_id,omitempty" json:"last_order_id,omitempty"`
+	LastOrderAt           *time.Time `bson:"last_order_at,omitempty" json:"last_order_at,omitempty"`
+	CreatedAt             time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt             time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// SubscriptionItem is one variant of a subscription. Price is the last price
+// the buyer was told about, so a change can be pointed out at the next renewal.
+type SubscriptionItem struct {
+	ProductID   string `bson:"product_id" json:"product_id"`
+	VariantID   string `bson:"variant_id" json:"variant_id"`
+	ProductName string `bson:"product_name" json:"product_name"`
+	Quantity    int    `bson:"quantity" json:"quantity"`
+	Price       int    `bson:"price" json:"price"`
+	Currency    string `bson:"currency" json:"currency"`
+}
+
+func (s *Subscription) BeforeCreate() {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = s.CreatedAt
+	}
+}
+
+// Interval returns the time between two renewals
+func (s *Subscription) Interval() time.Duration {
+	return time.Duration(s.IntervalDays) * 24 * time.Hour
+}
+
+// AdvanceNextOrder moves the next renewal on by whole intervals until it is
+// after now, so a subscription resumed late does not catch up on missed ones
+func (s *Subscription) AdvanceNextOrder(now time.Time) {
+	if s.IntervalDays < 1 {
+		return
+	}
+	for !s.NextOrderAt.After(now) {
+		s.NextOrderAt = s.NextOrderAt.Add(s.Interval())
+	}
+}
+
+// PaymentCustomer links a buyer to their customer at the card gateway, where
+// the cards saved for subscription renewals are kept
+type PaymentCustomer struct {
+	UserID           string    `bson:"_id" json:"user_id"`
+	StripeCustomerID string    `bson:"stripe_customer_id" json:"stripe_customer_id"`
+	CreatedAt        time.Time `bson:"created_at" json:"created_at"`
+}
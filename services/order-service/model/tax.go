@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// TaxRate is the VAT rate (percent of the net price) for a platform category.
+// The rate used when no category has one configured has the ID "default".
+type TaxRate struct {
+	ID        string    `bson:"_id" json:"category_id"`
+	Rate      float64   `bson:"rate" json:"rate"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+const DefaultTaxRateID = "default"
+
+// SellerTaxSetting records whether a seller's prices already include VAT.
+// Tax-exclusive sellers have the tax added on top of their prices at checkout.
+type SellerTaxSetting struct {
+	SellerID         string    `bson:"_id" json:"seller_id"`
+	PricesIncludeTax bool      `bson:"prices_include_tax" json:"prices_include_tax"`
+	UpdatedAt        time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// TaxBasisPoints converts a percent rate to hundredths of a percent, so tax is
+// computed in integer minor units
+func TaxBasisPoints(rate float64) int64 {
+	return int64(rate*100 + 0.5)
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// ShipmentTracking is the last state of a parcel fetched from its carrier. It
+// is served again while fresh, and whenever the carrier can't be reached.
+type ShipmentTracking struct {
+	TrackingCode     string          `bson:"_id" json:"tracking_code"`
+	OrderID          string          `bson:"order_id" json:"order_id"`
+	Carrier          string          `bson:"carrier" json:"carrier"`
+	Status           string          `bson:"status" json:"status"`                 // shipment status derived from the carrier's
+	CarrierStatus    string          `bson:"carrier_status" json:"carrier_status"` // as reported by the carrier
+	ExpectedDelivery *time.Time      `bson:"expected_delivery,omitempty" json:"expected_delivery,omitempty"`
+	Events           []TrackingEvent `bson:"events" json:"events"` // oldest first
+	FetchedAt        time.Time       `bson:"fetched_at" json:"fetched_at"`
+	Stale            bool            `bson:"-" json:"stale"` // the carrier could not be reached, so this is the last known state
+}
+
+// TrackingEvent is one step of a parcel's journey
+type TrackingEvent struct {
+	Status        string    `bson:"status,omitempty" json:"status,omitempty"` // shipment status, empty for steps that don't change it
+	CarrierStatus string    `bson:"carrier_status" json:"carrier_status"`
+	Description   string    `bson:"description" json:"description"`
+	At            time.Time `bson:"at" json:"at"`
+}
+
+// IsFinal reports whether the parcel will not move any more
+func (t *ShipmentTracking) IsFinal() bool {
+	return t.Status == ShipmentDelivered || t.Status == ShipmentCancelled || t.CarrierStatus == "returned"
+}
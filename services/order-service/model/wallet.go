@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Wallet transaction types
+const (
+	WalletTopUp           = "TOPUP"            // gateway clearing -> user wallet
+	WalletGiftCard        = "GIFT_CARD"        // gift card liability -> user wallet
+	WalletPayment         = "PAYMENT"          // user wallet -> order
+	WalletPaymentReversal = "PAYMENT_REVERSAL" // order -> user wallet, unpaid order abandoned before creation
+	WalletCardCapture     = "CARD_CAPTURE"     // gateway clearing -> order, gateway part of an order
+	WalletRefund          = "REFUND"           // order -> user wallet, cancelled or returned order
+	WalletPartialRefund   = "PARTIAL_REFUND"   // order -> user wallet, part of an order cancelled or overpaid
+)
+
+// WalletCurrency is the currency wallet balances are kept in
+const WalletCurrency = DefaultCurrency
+
+// Ledger accounts. User and order accounts are built with UserWalletAccount and OrderWalletAccount.
+const (
+	WalletAccountGatewayClearing = "platform:gateway_clearing"
+	WalletAccountGiftCards       = "platform:gift_cards"
+)
+
+func UserWalletAccount(userID string) string {
+	return "user:" + userID
+}
+
+func OrderWalletAccount(orderID string) string {
+	return "order:" + orderID
+}
+
+// WalletTransaction is one double-entry posting. All its entries are stored in a
+// single document so a posting is written atomically, and their amounts must sum to zero.
+type WalletTransaction struct {
+	ID          string        `bson:"_id" json:"id"`
+	Type        string        `bson:"type" json:"type"`
+	UserID      string        `bson:"user_id" json:"user_id"`     // buyer whose wallet or order is affected
+	Reference   string        `bson:"reference" json:"reference"` // order ID, gift card code or stripe session ID
+	Entries     []WalletEntry `bson:"entries" json:"entries"`
+	Description string        `bson:"description" json:"description"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+}
+
+// WalletEntry moves Amount (VND, signed) into Account. Debits are negative.
+type WalletEntry struct {
+	Account string `bson:"account" json:"account"`
+	Amount  int    `bson:"amount" json:"amount"`
+}
+
+// Validate checks that the posting balances
+func (t *WalletTransaction) Validate() error {
+	if len(t.Entries) < 2 {
+		return fmt.Errorf("a wallet transaction needs at least two entries")
+	}
+	sum := 0
+	for _, entry := range t.Entries {
+		if entry.Account == "" {
+			return fmt.Errorf("wallet entry account is required")
+		}
+		sum += entry.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("wallet transaction is unbalanced by %d", sum)
+	}
+	return nil
+}
+
+func (t *WalletTransaction) BeforeCreate() {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+}
+
+// Gift card statuses
+const (
+	GiftCardActive   = "ACTIVE"
+	GiftCardRedeemed = "REDEEMED"
+)
+
+// GiftCard is a one-time code that credits its amount to the redeemer's wallet
+type GiftCard struct {
+	ID         string     `bson:"_id" json:"id"`
+	Code       string     `bson:"code" json:"code"`
+	Amount     int        `bson:"amount" json:"amount"`
+	Status     string     `bson:"status" json:"status"` // ACTIVE, REDEEMED
+	CreatedBy  string     `bson:"created_by" json:"created_by"`
+	RedeemedBy string     `bson:"redeemed_by,omitempty" json:"redeemed_by,omitempty"`
+	RedeemedAt *time.Time `bson:"redeemed_at,omitempty" json:"redeemed_at,omitempty"`
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+}
+
+func (g *GiftCard) BeforeCreate() {
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	if g.Status == "" {
+		g.Status = GiftCardActive
+	}
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = time.Now()
+	}
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxWishlistsPerUser caps the named lists a user can create, the default list included
+const MaxWishlistsPerUser = 20
+
+// Wishlist is a list of products a user wants to keep an eye on. Every user has
+// a default list, created on first use, and may add named lists.
+type Wishlist struct {
+	ID        string    `bson:"_id" json:"id"` // DefaultWishlistID(user ID) for the default list, a UUID otherwise
+	UserID    string    `bson:"user_id" json:"user_id"`
+	Name      string    `bson:"name" json:"name"`
+	IsDefault bool      `bson:"is_default" json:"is_default"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+const DefaultWishlistName = "Wishlist"
+
+func DefaultWishlistID(userID string) string {
+	return "default:" + userID
+}
+
+func (w *Wishlist) BeforeCreate() {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = time.Now()
+	}
+	if w.UpdatedAt.IsZero() {
+		w.UpdatedAt = w.CreatedAt
+	}
+}
+
+// WishlistItem is a product, or one specific variant of it, saved to a list.
+// A product appears at most once per list for each variant (or without one).
+// The snapshot is taken when the item is added; the live price and stock are
+// looked up every time the list is read.
+type WishlistItem struct {
+	ID          string            `bson:"_id" json:"id"`
+	UserID      string            `bson:"user_id" json:"user_id"`
+	WishlistID  string            `bson:"wishlist_id" json:"wishlist_id"`
+	ProductID   string            `bson:"product_id" json:"product_id"`
+	VariantID   string            `bson:"variant_id" json:"variant_id,omitempty"` // empty when the whole product is saved
+	SellerID    string            `bson:"seller_id" json:"seller_id"`
+	ProductName string            `bson:"product_name" json:"product_name"`
+	Options     map[string]string `bson:"options,omitempty" json:"options,omitempty"`
+	Image       string            `bson:"image" json:"image"`
+	Price       int               `bson:"price" json:"price"` // variant price, or lowest product price, when added
+	Currency    string            `bson:"currency" json:"currency"`
+	CreatedAt   time.Time         `bson:"created_at" json:"created_at"`
+}
+
+func (i *WishlistItem) BeforeCreate() {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = time.Now()
+	}
+}
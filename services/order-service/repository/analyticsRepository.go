@@ -0,0 +1,547 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SellerPeriodSummary holds a seller's order totals over a time range
+type SellerPeriodSummary struct {
+	OrderCount        int   `bson:"order_count"`
+	CancelledCount    int   `bson:"cancelled_count"`
+	ReturnedCount     int   `bson:"returned_count"`
+	DeliveredCount    int   `bson:"delivered_count"` // completed or returned
+	RevenueOrderCount int   `bson:"revenue_order_count"`
+	Revenue           int64 `bson:"revenue"`
+	VoucherOrderCount int   `bson:"voucher_order_count"`
+	VoucherRevenue    int64 `bson:"voucher_revenue"` // revenue of orders that used a voucher
+}
+
+// SellerBuyerCounts splits the buyers of a time range by whether it was their
+// first purchase from the seller
+type SellerBuyerCounts struct {
+	NewBuyers    int `bson:"new_buyers"`
+	RepeatBuyers int `bson:"repeat_buyers"`
+}
+
+// TopItem is a product, or a variant when VariantID is set, ranked by sales
+type TopItem struct {
+	ProductID   string `bson:"product_id"`
+	VariantID   string `bson:"variant_id,omitempty"`
+	ProductName string `bson:"product_name"`
+	VariantName string `bson:"variant_name,omitempty"`
+	SKU         string `bson:"sku,omitempty"`
+	Units       int    `bson:"units"`
+	Revenue     int64  `bson:"revenue"`
+}
+
+// SellerTopItems holds the best-selling products and variants by units and by revenue
+type SellerTopItems struct {
+	ProductsByUnits   []TopItem `bson:"products_by_units"`
+	ProductsByRevenue []TopItem `bson:"products_by_revenue"`
+	VariantsByUnits   []TopItem `bson:"variants_by_units"`
+	VariantsByRevenue []TopItem `bson:"variants_by_revenue"`
+}
+
+// SellerPeriodPoint holds the totals of one day or month
+type SellerPeriodPoint struct {
+	Period            string `bson:"_id"`
+	OrderCount        int    `bson:"order_count"`
+	RevenueOrderCount int    `bson:"revenue_order_count"`
+	Revenue           int64  `bson:"revenue"`
+}
+
+// PlatformSummary holds the platform's order totals over a time range
+type PlatformSummary struct {
+	OrderCount        int   `bson:"order_count"`
+	RevenueOrderCount int   `bson:"revenue_order_count"`
+	GMV               int64 `bson:"gmv"`
+}
+
+// StatusCount is the number of orders in one status
+type StatusCount struct {
+	Status string `bson:"_id"`
+	Count  int    `bson:"count"`
+}
+
+// SellerRank holds a seller's sales for the top seller list
+type SellerRank struct {
+	SellerID   string `bson:"_id"`
+	SellerName string `bson:"seller_name"`
+	OrderCount int    `bson:"order_count"`
+	GMV        int64  `bson:"gmv"`
+}
+
+// CategorySales holds the item sales of one platform category
+type CategorySales struct {
+	CategoryID string `bson:"_id"`
+	Units      int    `bson:"units"`
+	Revenue    int64  `bson:"revenue"`
+}
+
+// PlatformSalesDay holds the orders of one day in one currency
+type PlatformSalesDay struct {
+	Date              string `bson:"date"`
+	Currency          string `bson:"currency"`
+	OrderCount        int    `bson:"order_count"`
+	RevenueOrderCount int    `bson:"revenue_order_count"`
+	CancelledCount    int    `bson:"cancelled_count"`
+	GMV               int64  `bson:"gmv"`
+}
+
+type AnalyticsRepository interface {
+	SellerSummary(sellerID, currency string, from, to time.Time) (*SellerPeriodSummary, error)
+	SellerBuyers(sellerID, currency string, from, to time.Time) (*SellerBuyerCounts, error)
+	SellerTopItems(sellerID, currency string, from, to time.Time, limit int) (*SellerTopItems, error)
+	SellerBreakdown(sellerID, currency string, from, to time.Time, dateFormat string) ([]SellerPeriodPoint, error)
+	PlatformSummary(currency string, from, to time.Time) (*PlatformSummary, []StatusCount, error)
+	TopSellers(currency string, from, to time.Time, limit int) ([]SellerRank, error)
+	CategorySales(currency string, from, to time.Time) ([]CategorySales, error)
+	PlatformSalesByDay(from, to time.Time, timezone string) ([]PlatformSalesDay, error)
+	FindSnapshots(fromDate, toDate string) ([]model.AnalyticsSnapshot, error)
+	UpsertSnapshot(snapshot *model.AnalyticsSnapshot) error
+}
+
+type analyticsRepository struct {
+	ordersCollection    *mongo.Collection
+	snapshotsCollection *mongo.Collection
+}
+
+func NewAnalyticsRepository(db *mongo.Database) AnalyticsRepository {
+	return &analyticsRepository{
+		ordersCollection:    db.Collection("orders"),
+		snapshotsCollection: db.Collection("analytics_snapshots"),
+	}
+}
+
+// revenueOrderMatch selects the orders that count as revenue: paid online, or
+// completed, since cash on delivery is collected on completion. Cancelled and
+// returned orders are left out even if their refund did not go through.
+func revenueOrderMatch() bson.M {
+	return bson.M{
+		"status": bson.M{"$nin": bson.A{"CANCELLED", "RETURNED"}},
+		"$or": bson.A{
+			bson.M{"payment_status": "PAID"},
+			bson.M{"status": "COMPLETED"},
+		},
+	}
+}
+
+// isRevenueOrder is revenueOrderMatch as an aggregation expression
+func isRevenueOrder() bson.M {
+	return bson.M{"$and": bson.A{
+		bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$status", bson.A{"CANCELLED", "RETURNED"}}}}},
+		bson.M{"$or": bson.A{
+			bson.M{"$eq": bson.A{"$payment_status", "PAID"}},
+			bson.M{"$eq": bson.A{"$status", "COMPLETED"}},
+		}},
+	}}
+}
+
+func sumIf(condition bson.M, value interface{}) bson.M {
+	return bson.M{"$sum": bson.M{"$cond": bson.A{condition, value, 0}}}
+}
+
+// grandTotal is what the buyer paid for an order, delivery included
+func grandTotal() bson.M {
+	return bson.M{"$add": bson.A{bson.M{"$toLong": "$total"}, bson.M{"$toLong": bson.M{"$ifNull": bson.A{"$delivery_fee", 0}}}}}
+}
+
+func platformOrdersMatch(currency string, from, to time.Time) bson.M {
+	return bson.M{
+		"currency":   currency,
+		"created_at": bson.M{"$gte": from, "$lt": to},
+	}
+}
+
+func sellerOrdersMatch(sellerID, currency string, from, to time.Time) bson.M {
+	return bson.M{
+		"seller._id": sellerID,
+		"currency":   currency,
+		"created_at": bson.M{"$gte": from, "$lt": to},
+	}
+}
+
+// SellerSummary totals the seller's orders created in [from, to) in one currency
+func (r *analyticsRepository) SellerSummary(sellerID, currency string, from, to time.Time) (*SellerPeriodSummary, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revenue := isRevenueOrder()
+	hasVoucher := bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$voucher.code", ""}}, ""}}
+	total := bson.M{"$toLong": "$total"}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: sellerOrdersMatch(sellerID, currency, from, to)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                 nil,
+			"order_count":         bson.M{"$sum": 1},
+			"cancelled_count":     sumIf(bson.M{"$eq": bson.A{"$status", "CANCELLED"}}, 1),
+			"returned_count":      sumIf(bson.M{"$eq": bson.A{"$status", "RETURNED"}}, 1),
+			"delivered_count":     sumIf(bson.M{"$in": bson.A{"$status", bson.A{"COMPLETED", "RETURNED"}}}, 1),
+			"revenue_order_count": sumIf(revenue, 1),
+			"revenue":             sumIf(revenue, total),
+			"voucher_order_count": sumIf(bson.M{"$and": bson.A{revenue, hasVoucher}}, 1),
+			"voucher_revenue":     sumIf(bson.M{"$and": bson.A{revenue, hasVoucher}}, total),
+		}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []SellerPeriodSummary
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &SellerPeriodSummary{}, nil
+	}
+	return &results[0], nil
+}
+
+// SellerBuyers counts the buyers with a revenue order in [from, to). A buyer is
+// new when that range holds their first revenue order from the seller.
+func (r *analyticsRepository) SellerBuyers(sellerID, currency string, from, to time.Time) (*SellerBuyerCounts, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	match := revenueOrderMatch()
+	match["seller._id"] = sellerID
+	match["currency"] = currency
+	match["created_at"] = bson.M{"$lt": to}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$user._id",
+			"first": bson.M{"$min": "$created_at"},
+			"last":  bson.M{"$max": "$created_at"},
+		}}},
+		{{Key: "$match", Value: bson.M{"last": bson.M{"$gte": from}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           nil,
+			"new_buyers":    sumIf(bson.M{"$gte": bson.A{"$first", from}}, 1),
+			"repeat_buyers": sumIf(bson.M{"$lt": bson.A{"$first", from}}, 1),
+		}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []SellerBuyerCounts
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &SellerBuyerCounts{}, nil
+	}
+	return &results[0], nil
+}
+
+// SellerTopItems ranks the products and variants of the seller's revenue orders
+// in [from, to). Item revenue is the line total after bundle discounts and
+// before the order's voucher.
+func (r *analyticsRepository) SellerTopItems(sellerID, currency string, from, to time.Time, limit int) (*SellerTopItems, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	match := revenueOrderMatch()
+	for key, value := range sellerOrdersMatch(sellerID, currency, from, to) {
+		match[key] = value
+	}
+
+	rank := func(groupBy string, sortBy ...string) bson.A {
+		group := bson.M{
+			"_id":          "$items." + groupBy,
+			"product_id":   bson.M{"$first": "$items.product_id"},
+			"product_name": bson.M{"$last": "$items.product_name"},
+			"units":        bson.M{"$sum": "$items.quantity"},
+			"revenue":      bson.M{"$sum": "$line_total"},
+		}
+		if groupBy == "variant_id" {
+			group["variant_id"] = bson.M{"$first": "$items.variant_id"}
+			group["variant_name"] = bson.M{"$last": "$items.variant_name"}
+			group["sku"] = bson.M{"$last": "$items.sku"}
+		}
+		sort := bson.D{}
+		for _, field := range sortBy {
+			sort = append(sort, bson.E{Key: field, Value: -1})
+		}
+		sort = append(sort, bson.E{Key: "_id", Value: 1})
+		return bson.A{
+			bson.M{"$group": group},
+			bson.M{"$sort": sort},
+			bson.M{"$limit": limit},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.M{"created_at": 1}}}, // so $last picks the latest names
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$set", Value: bson.M{
+			"line_total": bson.M{"$toLong": bson.M{"$subtract": bson.A{
+				bson.M{"$multiply": bson.A{"$items.price", "$items.quantity"}},
+				bson.M{"$ifNull": bson.A{"$items.discount", 0}},
+			}}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"products_by_units":   rank("product_id", "units", "revenue"),
+			"products_by_revenue": rank("product_id", "revenue", "units"),
+			"variants_by_units":   rank("variant_id", "units", "revenue"),
+			"variants_by_revenue": rank("variant_id", "revenue", "units"),
+		}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []SellerTopItems
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &SellerTopItems{}, nil
+	}
+	return &results[0], nil
+}
+
+// SellerBreakdown totals the seller's orders in [from, to) per period, using a
+// $dateToString format such as "%Y-%m-%d"
+func (r *analyticsRepository) SellerBreakdown(sellerID, currency string, from, to time.Time, dateFormat string) ([]SellerPeriodPoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revenue := isRevenueOrder()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: sellerOrdersMatch(sellerID, currency, from, to)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                 bson.M{"$dateToString": bson.M{"format": dateFormat, "date": "$created_at"}},
+			"order_count":         bson.M{"$sum": 1},
+			"revenue_order_count": sumIf(revenue, 1),
+			"revenue":             sumIf(revenue, bson.M{"$toLong": "$total"}),
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var points []SellerPeriodPoint
+	if err := cursor.All(ctx, &points); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// PlatformSummary totals all orders created in [from, to) in one currency, and
+// counts them by current status
+func (r *analyticsRepository) PlatformSummary(currency string, from, to time.Time) (*PlatformSummary, []StatusCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	revenue := isRevenueOrder()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: platformOrdersMatch(currency, from, to)}},
+		{{Key: "$facet", Value: bson.M{
+			"summary": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":                 nil,
+					"order_count":         bson.M{"$sum": 1},
+					"revenue_order_count": sumIf(revenue, 1),
+					"gmv":                 sumIf(revenue, grandTotal()),
+				}},
+			},
+			"statuses": bson.A{
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Summary  []PlatformSummary `bson:"summary"`
+		Statuses []StatusCount     `bson:"statuses"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, nil, err
+	}
+	summary := &PlatformSummary{}
+	if len(results) == 0 {
+		return summary, nil, nil
+	}
+	if len(results[0].Summary) > 0 {
+		summary = &results[0].Summary[0]
+	}
+	return summary, results[0].Statuses, nil
+}
+
+// TopSellers ranks sellers by the GMV of their revenue orders in [from, to)
+func (r *analyticsRepository) TopSellers(currency string, from, to time.Time, limit int) ([]SellerRank, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := revenueOrderMatch()
+	for key, value := range platformOrdersMatch(currency, from, to) {
+		match[key] = value
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.M{"created_at": 1}}}, // so $last picks the latest name
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$seller._id",
+			"seller_name": bson.M{"$last": "$seller.name"},
+			"order_count": bson.M{"$sum": 1},
+			"gmv":         bson.M{"$sum": grandTotal()},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "gmv", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sellers []SellerRank
+	if err := cursor.All(ctx, &sellers); err != nil {
+		return nil, err
+	}
+	return sellers, nil
+}
+
+// CategorySales totals the items of revenue orders in [from, to) per platform
+// category, by line total before vouchers. Orders placed before categories were
+// recorded on items are grouped under an empty category ID.
+func (r *analyticsRepository) CategorySales(currency string, from, to time.Time) ([]CategorySales, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := revenueOrderMatch()
+	for key, value := range platformOrdersMatch(currency, from, to) {
+		match[key] = value
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$unwind", Value: bson.M{"path": "$items.category_ids", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$items.category_ids", ""}},
+			"units": bson.M{"$sum": "$items.quantity"},
+			"revenue": bson.M{"$sum": bson.M{"$toLong": bson.M{"$subtract": bson.A{
+				bson.M{"$multiply": bson.A{"$items.price", "$items.quantity"}},
+				bson.M{"$ifNull": bson.A{"$items.discount", 0}},
+			}}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var categories []CategorySales
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// PlatformSalesByDay totals the orders created in [from, to) per day in the
+// given time zone and per currency
+func (r *analyticsRepository) PlatformSalesByDay(from, to time.Time, timezone string) ([]PlatformSalesDay, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	revenue := isRevenueOrder()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"date":     bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$created_at", "timezone": timezone}},
+				"currency": bson.M{"$ifNull": bson.A{"$currency", model.DefaultCurrency}},
+			},
+			"order_count":         bson.M{"$sum": 1},
+			"revenue_order_count": sumIf(revenue, 1),
+			"cancelled_count":     sumIf(bson.M{"$eq": bson.A{"$status", "CANCELLED"}}, 1),
+			"gmv":                 sumIf(revenue, grandTotal()),
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":                 0,
+			"date":                "$_id.date",
+			"currency":            "$_id.currency",
+			"order_count":         1,
+			"revenue_order_count": 1,
+			"cancelled_count":     1,
+			"gmv":                 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "date", Value: 1}, {Key: "currency", Value: 1}}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var days []PlatformSalesDay
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// FindSnapshots returns the stored days between two YYYY-MM-DD dates, inclusive
+func (r *analyticsRepository) FindSnapshots(fromDate, toDate string) ([]model.AnalyticsSnapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.snapshotsCollection.Find(ctx, bson.M{"_id": bson.M{"$gte": fromDate, "$lte": toDate}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var snapshots []model.AnalyticsSnapshot
+	if err := cursor.All(ctx, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func (r *analyticsRepository) UpsertSnapshot(snapshot *model.AnalyticsSnapshot) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshot.UpdatedAt = time.Now()
+	_, err := r.snapshotsCollection.ReplaceOne(ctx, bson.M{"_id": snapshot.ID}, snapshot, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"order-service/model"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The aggregations run against a real MongoDB given by TEST_MONGO_URI, e.g.
+// mongodb://localhost:27017. Each test uses a database of its own, dropped afterwards.
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", uri, err)
+	}
+	db := client.Database(fmt.Sprintf("order_service_test_%d", time.Now().UnixNano()))
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+	return db
+}
+
+var (
+	analyticsFrom = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	analyticsTo   = time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)
+)
+
+func analyticsDay(day int) time.Time {
+	return time.Date(2026, 10, day, 12, 0, 0, 0, time.UTC)
+}
+
+func analyticsItem(productID, variantID, productName string, price, quantity, discount int) model.OrderItem {
+	return model.OrderItem{
+		ProductID:   productID,
+		VariantID:   variantID,
+		ProductName: productName,
+		VariantName: "Variant " + variantID,
+		SKU:         "SKU-" + variantID,
+		Price:       price,
+		Quantity:    quantity,
+		Discount:    discount,
+	}
+}
+
+// seedAnalyticsOrders stores the orders of seller-1 the tests below add up.
+// Revenue orders are the completed o1 and the paid o2; the others are left out
+// for their status, seller, currency or date.
+func seedAnalyticsOrders(t *testing.T, db *mongo.Database) {
+	t.Helper()
+	order := func(id, sellerID, buyerID, status, paymentStatus string, total int64, createdAt time.Time, items ...model.OrderItem) *model.Order {
+		return &model.Order{
+			ID:            id,
+			Seller:        model.User{ID: sellerID},
+			User:          model.User{ID: buyerID},
+			Status:        status,
+			PaymentStatus: paymentStatus,
+			Currency:      "VND",
+			Total:         total,
+			DeliveryFee:   30000,
+			CreatedAt:     createdAt,
+			Items:         items,
+		}
+	}
+
+	o1 := order("o1", "seller-1", "buyer-1", "COMPLETED", "PENDING", 300000, analyticsDay(2),
+		analyticsItem("p1", "v1", "Tea", 100000, 2, 0),
+		analyticsItem("p2", "v2", "Coffee", 100000, 1, 20000),
+	)
+	o1.Voucher = &model.OrderVoucher{Code: "SALE10"}
+	o8 := order("o8", "seller-1", "buyer-1", "COMPLETED", "PAID", 10, analyticsDay(2), analyticsItem("p1", "v1", "Tea", 10, 1, 0))
+	o8.Currency = "USD"
+
+	orders := []interface{}{
+		o1,
+		order("o2", "seller-1", "buyer-2", "TO_CONFIRM", "PAID", 650000, analyticsDay(3),
+			analyticsItem("p1", "v1", "Tea", 100000, 2, 0),
+			analyticsItem("p2", "v4", "Coffee", 450000, 1, 0),
+		),
+		// Cancelled after payment, even though the refund is still pending
+		order("o3", "seller-1", "buyer-2", "CANCELLED", "PAID", 500000, analyticsDay(4), analyticsItem("p2", "v3", "Coffee", 100000, 5, 0)),
+		order("o4", "seller-1", "buyer-3", "RETURNED", "REFUNDED", 150000, analyticsDay(5), analyticsItem("p2", "v2", "Coffee", 150000, 1, 0)),
+		// Online order not paid yet
+		order("o5", "seller-1", "buyer-4", "TO_PAY", "PENDING", 100000, analyticsDay(6), analyticsItem("p1", "v1", "Tea", 100000, 1, 0)),
+		// Bought before the range: buyer-1 is a repeat buyer
+		order("o6", "seller-1", "buyer-1", "COMPLETED", "PAID", 100000, time.Date(2026, 9, 20, 12, 0, 0, 0, time.UTC), analyticsItem("p1", "v1", "Tea", 100000, 1, 0)),
+		order("o7", "seller-2", "buyer-1", "COMPLETED", "PAID", 900000, analyticsDay(2), analyticsItem("p9", "v9", "Other", 900000, 1, 0)),
+		o8,
+		order("o9", "seller-1", "buyer-5", "COMPLETED", "PAID", 700000, analyticsTo, analyticsItem("p1", "v1", "Tea", 700000, 1, 0)),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := db.Collection("orders").InsertMany(ctx, orders); err != nil {
+		t.Fatalf("failed to seed orders: %v", err)
+	}
+}
+
+func TestAnalyticsSellerSummary(t *testing.T) {
+	db := testDatabase(t)
+	seedAnalyticsOrders(t, db)
+	repo := NewAnalyticsRepository(db)
+
+	summary, err := repo.SellerSummary("seller-1", "VND", analyticsFrom, analyticsTo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := SellerPeriodSummary{
+		OrderCount:        5,
+		CancelledCount:    1,
+		ReturnedCount:     1,
+		DeliveredCount:    2,
+		RevenueOrderCount: 2,
+		Revenue:           950000,
+		VoucherOrderCount: 1,
+		VoucherRevenue:    300000,
+	}
+	if *summary != want {
+		t.Errorf("summary = %+v, want %+v", *summary, want)
+	}
+
+	nextDay, err := repo.SellerSummary("seller-1", "VND", analyticsTo, analyticsTo.AddDate(0, 0, 1).Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nextDay.OrderCount != 1 || nextDay.Revenue != 700000 {
+		t.Errorf("summary of the next day = %+v, want only o9", *nextDay)
+	}
+}
+
+func TestAnalyticsSellerBuyers(t *testing.T) {
+	db := testDatabase(t)
+	seedAnalyticsOrders(t, db)
+	repo := NewAnalyticsRepository(db)
+
+	buyers, err := repo.SellerBuyers("seller-1", "VND", analyticsFrom, analyticsTo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := SellerBuyerCounts{NewBuyers: 1, RepeatBuyers: 1}
+	if *buyers != want {
+		t.Errorf("buyers = %+v, want %+v", *buyers, want)
+	}
+}
+
+func TestAnalyticsSellerTopItems(t *testing.T) {
+	db := testDatabase(t)
+	seedAnalyticsOrders(t, db)
+	repo := NewAnalyticsRepository(db)
+
+	top, err := repo.SellerTopItems("seller-1", "VND", analyticsFrom, analyticsTo, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type ranked struct {
+		ID      string
+		Units   int
+		Revenue int64
+	}
+	products := func(items []TopItem) []ranked {
+		result := []ranked{}
+		for _, item := range items {
+			result = append(result, ranked{item.ProductID, item.Units, item.Revenue})
+		}
+		return result
+	}
+	variants := func(items []TopItem) []ranked {
+		result := []ranked{}
+		for _, item := range items {
+			result = append(result, ranked{item.VariantID, item.Units, item.Revenue})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name string
+		got  []ranked
+		want []ranked
+	}{
+		{"products by units", products(top.ProductsByUnits), []ranked{{"p1", 4, 400000}, {"p2", 2, 530000}}},
+		{"products by revenue", products(top.ProductsByRevenue), []ranked{{"p2", 2, 530000}, {"p1", 4, 400000}}},
+		{"variants by units", variants(top.VariantsByUnits), []ranked{{"v1", 4, 400000}, {"v4", 1, 450000}, {"v2", 1, 80000}}},
+		{"variants by revenue", variants(top.VariantsByRevenue), []ranked{{"v4", 1, 450000}, {"v1", 4, 400000}, {"v2", 1, 80000}}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %+v, want %+v", tt.name, tt.got, tt.want)
+		}
+	}
+	if len(top.VariantsByRevenue) == 0 {
+		t.Fatal("no variants ranked by revenue")
+	}
+	if v := top.VariantsByRevenue[0]; v.SKU != "SKU-v4" || v.VariantName != "Variant v4" || v.ProductName != "Coffee" {
+		t.Errorf("top variant = %+v, want the names and SKU of v4", v)
+	}
+
+	limited, err := repo.SellerTopItems("seller-1", "VND", analyticsFrom, analyticsTo, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(limited.ProductsByUnits) != 1 || len(limited.VariantsByRevenue) != 1 {
+		t.Errorf("limit 1 returned %d products and %d variants", len(limited.ProductsByUnits), len(limited.VariantsByRevenue))
+	}
+}
+
+func TestAnalyticsSellerBreakdown(t *testing.T) {
+	db := testDatabase(t)
+	seedAnalyticsOrders(t, db)
+	repo := NewAnalyticsRepository(db)
+
+	days, err := repo.SellerBreakdown("seller-1", "VND", analyticsFrom, analyticsTo, "%Y-%m-%d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []SellerPeriodPoint{
+		{Period: "2026-10-02", OrderCount: 1, RevenueOrderCount: 1, Revenue: 300000},
+		{Period: "2026-10-03", OrderCount: 1, RevenueOrderCount: 1, Revenue: 650000},
+		{Period: "2026-10-04", OrderCount: 1},
+		{Period: "2026-10-05", OrderCount: 1},
+		{Period: "2026-10-06", OrderCount: 1},
+	}
+	if !reflect.DeepEqual(days, want) {
+		t.Errorf("daily breakdown = %+v, want %+v", days, want)
+	}
+
+	months, err := repo.SellerBreakdown("seller-1", "VND", time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), analyticsTo, "%Y-%m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantMonths := []SellerPeriodPoint{
+		{Period: "2026-09", OrderCount: 1, RevenueOrderCount: 1, Revenue: 100000},
+		{Period: "2026-10", OrderCount: 5, RevenueOrderCount: 2, Revenue: 950000},
+	}
+	if !reflect.DeepEqual(months, wantMonths) {
+		t.Errorf("monthly breakdown = %+v, want %+v", months, wantMonths)
+	}
+}
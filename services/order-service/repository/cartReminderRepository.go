@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StaleCart is a user's cart with no change since LastActivity
+type StaleCart struct {
+	UserID       string    `bson:"_id"`
+	LastActivity time.Time `bson:"last_activity"`
+	ItemCount    int       `bson:"item_count"`
+}
+
+type CartReminderRepository interface {
+	FindStaleCarts(from, to time.Time) ([]StaleCart, error)
+	FindLatestReminder(userID string) (*model.CartReminder, error)
+	CreateReminder(reminder *model.CartReminder) error
+	FindPreference(userID string) (*model.CartReminderPreference, error)
+	UpsertPreference(preference *model.CartReminderPreference) error
+	HasOrderSince(userID string, since time.Time) (bool, error)
+}
+
+type cartReminderRepository struct {
+	db                    *mongo.Database
+	remindersCollection   *mongo.Collection
+	preferencesCollection *mongo.Collection
+	cartItemsCollection   *mongo.Collection
+	ordersCollection      *mongo.Collection
+}
+
+func NewCartReminderRepository(db *mongo.Database) CartReminderRepository {
+	return &cartReminderRepository{
+		db:                    db,
+		remindersCollection:   db.Collection("cart_reminders"),
+		preferencesCollection: db.Collection("cart_reminder_preferences"),
+		cartItemsCollection:   db.Collection("cart_items"),
+		ordersCollection:      db.Collection("orders"),
+	}
+}
+
+// FindStaleCarts returns the user carts whose last change falls in [from, to).
+// Guest carts have nobody to remind and are left out.
+func (r *cartReminderRepository) FindStaleCarts(from, to time.Time) ([]StaleCart, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": bson.M{"$not": primitive.Regex{Pattern: "^" + model.GuestCartOwner("")}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           "$user_id",
+			"last_activity": bson.M{"$max": "$updated_at"},
+			"item_count":    bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"last_activity": bson.M{"$gte": from, "$lt": to}}}},
+	}
+
+	cursor, err := r.cartItemsCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var carts []StaleCart
+	if err = cursor.All(ctx, &carts); err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+func (r *cartReminderRepository) FindLatestReminder(userID string) (*model.CartReminder, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "sent_at", Value: -1}})
+
+	var reminder model.CartReminder
+	err := r.remindersCollection.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&reminder)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+func (r *cartReminderRepository) CreateReminder(reminder *model.CartReminder) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reminder.BeforeCreate()
+	_, err := r.remindersCollection.InsertOne(ctx, reminder)
+	return err
+}
+
+func (r *cartReminderRepository) FindPreference(userID string) (*model.CartReminderPreference, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var preference model.CartReminderPreference
+	err := r.preferencesCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&preference)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &preference, nil
+}
+
+func (r *cartReminderRepository) UpsertPreference(preference *model.CartReminderPreference) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	preference.UpdatedAt = time.Now()
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.preferencesCollection.ReplaceOne(ctx, bson.M{"_id": preference.UserID}, preference, opts)
+	return err
+}
+
+// HasOrderSince reports whether the user placed an order at or after since
+func (r *cartReminderRepository) HasOrderSince(userID string, since time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user._id": userID, "created_at": bson.M{"$gte": since}}
+	count, err := r.ordersCollection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -11,12 +11,16 @@ import (
 
 type CartRepository interface {
 	FindCartItemByUserAndVariant(userID, variantID string) (*model.CartItem, error)
+	FindCartItemByUserAndBundle(userID, bundleID string) (*model.CartItem, error)
 	CreateCartItem(item *model.CartItem) error
 	UpdateCartItemQuantity(id string, quantity int) error
+	UpdateCartItemSnapshot(item *model.CartItem) error
 	FindCartItemByID(id string) (*model.CartItem, error)
 	DeleteCartItem(id string) error
 	FindCartItemsByUser(userID string) ([]model.CartItem, error)
 	GetCartItemCount(userID string) (int64, error)
+	ExtendCartExpiry(userID string, expiresAt time.Time) error
+	DeleteCartItemsByUser(userID string) error
 }
 
 type cartRepository struct {
@@ -52,6 +56,27 @@ func (r *cartRepository) FindCartItemByUserAndVariant(userID, variantID string)
 	return &cartItem, nil
 }
 
+func (r *cartRepository) FindCartItemByUserAndBundle(userID, bundleID string) (*model.CartItem, error) {
+	var cartItem model.CartItem
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"user_id":   userID,
+		"bundle.id": bundleID,
+	}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&cartItem)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // No existing cart item found
+		}
+		return nil, err
+	}
+
+	return &cartItem, nil
+}
+
 func (r *cartRepository) CreateCartItem(item *model.CartItem) error {
 	item.BeforeCreate()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -77,6 +102,24 @@ func (r *cartRepository) UpdateCartItemQuantity(id string, quantity int) error {
 	return err
 }
 
+// UpdateCartItemSnapshot saves the product, variant and bundle data copied
+// from product-service. It is not a change by the user, so updated_at is kept.
+func (r *cartRepository) UpdateCartItemSnapshot(item *model.CartItem) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fields := bson.M{
+		"product": item.Product,
+		"variant": item.Variant,
+	}
+	if item.Bundle != nil {
+		fields["bundle"] = item.Bundle
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": item.ID}, bson.M{"$set": fields})
+	return err
+}
+
 func (r *cartRepository) FindCartItemByID(id string) (*model.CartItem, error) {
 	var cartItem model.CartItem
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -161,3 +204,20 @@ func (r *cartRepository) GetCartItemCount(userID string) (int64, error) {
 
 	return 0, nil
 }
+
+// ExtendCartExpiry sets when all of a guest cart's items expire
+func (r *cartRepository) ExtendCartExpiry(userID string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateMany(ctx, bson.M{"user_id": userID}, bson.M{"$set": bson.M{"expires_at": expiresAt}})
+	return err
+}
+
+func (r *cartRepository) DeleteCartItemsByUser(userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CodReconciliationRepository interface {
+	// CreateSettlement stores a settlement with its lines and counts them. A
+	// parcel has at most one matched line, so a line matched by a concurrent
+	// import of the same parcel is stored as a duplicate.
+	CreateSettlement(settlement *model.CodSettlement, lines []*model.CodSettlementLine) error
+	FindSettlementByID(id string) (*model.CodSettlement, error)
+	FindSettlements(page, limit int) ([]*model.CodSettlement, int64, error)
+	FindLines(settlementID, status string) ([]*model.CodSettlementLine, error)
+	FindOrderByTrackingCode(trackingCode string) (*model.Order, error)
+	// MarkShipmentRemitted records the remittance of a parcel unless one was
+	// recorded already, and reports whether it did
+	MarkShipmentRemitted(orderID, shipmentID string, remittedAt time.Time) (bool, error)
+	// MarkCodOrderPaid sets a COD order as paid if it is not already
+	MarkCodOrderPaid(orderID string) error
+	// FindUnremittedOrders returns the COD orders with a parcel delivered before
+	// the given time whose cash was not remitted yet
+	FindUnremittedOrders(deliveredBefore time.Time, page, limit int) ([]*model.Order, int64, error)
+}
+
+type codReconciliationRepository struct {
+	db                    *mongo.Database
+	settlementsCollection *mongo.Collection
+	linesCollection       *mongo.Collection
+	ordersCollection      *mongo.Collection
+}
+
+func NewCodReconciliationRepository(db *mongo.Database) CodReconciliationRepository {
+	return &codReconciliationRepository{
+		db:                    db,
+		settlementsCollection: db.Collection("cod_settlements"),
+		linesCollection:       db.Collection("cod_settlement_lines"),
+		ordersCollection:      db.Collection("orders"),
+	}
+}
+
+func (r *codReconciliationRepository) CreateSettlement(settlement *model.CodSettlement, lines []*model.CodSettlementLine) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	settlement.BeforeCreate()
+	if len(lines) > 0 {
+		docs := make([]interface{}, len(lines))
+		for i, line := range lines {
+			line.SettlementID = settlement.ID
+			line.BeforeCreate()
+			docs[i] = line
+		}
+		_, err := r.linesCollection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+		if err != nil {
+			if !isOnlyDuplicateKeyErrors(err) {
+				return err
+			}
+			// Rejected by the unique index on the matched line of a parcel
+			retries := []interface{}{}
+			for _, writeErr := range err.(mongo.BulkWriteException).WriteErrors {
+				line := lines[writeErr.Index]
+				line.Status = model.CodLineDuplicate
+				retries = append(retries, line)
+			}
+			if _, err := r.linesCollection.InsertMany(ctx, retries); err != nil {
+				return err
+			}
+		}
+	}
+
+	settlement.CountLines(lines)
+	_, err := r.settlementsCollection.InsertOne(ctx, settlement)
+	return err
+}
+
+func (r *codReconciliationRepository) FindSettlementByID(id string) (*model.CodSettlement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var settlement model.CodSettlement
+	err := r.settlementsCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&settlement)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+func (r *codReconciliationRepository) FindSettlements(page, limit int) ([]*model.CodSettlement, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	totalCount, err := r.settlementsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "imported_at", Value: -1}, {Key: "_id", Value: 1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	cursor, err := r.settlementsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var settlements []*model.CodSettlement
+	if err := cursor.All(ctx, &settlements); err != nil {
+		return nil, 0, err
+	}
+	return settlements, totalCount, nil
+}
+
+func (r *codReconciliationRepository) FindLines(settlementID, status string) ([]*model.CodSettlementLine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"settlement_id": settlementID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := r.linesCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "line", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var lines []*model.CodSettlementLine
+	if err := cursor.All(ctx, &lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func (r *codReconciliationRepository) FindOrderByTrackingCode(trackingCode string) (*model.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var order model.Order
+	err := r.ordersCollection.FindOne(ctx, bson.M{"shipments.tracking_code": trackingCode}).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *codReconciliationRepository) MarkShipmentRemitted(orderID, shipmentID string, remittedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.ordersCollection.UpdateOne(ctx,
+		bson.M{
+			"_id": orderID,
+			"shipments": bson.M{"$elemMatch": bson.M{
+				"_id":             shipmentID,
+				"cod_remitted_at": bson.M{"$exists": false},
+			}},
+		},
+		bson.M{"$set": bson.M{
+			"shipments.$.cod_remitted_at": remittedAt,
+			"updated_at":                  time.Now(),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *codReconciliationRepository) MarkCodOrderPaid(orderID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.ordersCollection.UpdateOne(ctx,
+		bson.M{"_id": orderID, "payment_method": "COD", "payment_status": bson.M{"$ne": "PAID"}},
+		bson.M{"$set": bson.M{"payment_status": "PAID", "updated_at": time.Now()}},
+	)
+	return err
+}
+
+func (r *codReconciliationRepository) FindUnremittedOrders(deliveredBefore time.Time, page, limit int) ([]*model.Order, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"payment_method": "COD",
+		"shipments": bson.M{"$elemMatch": bson.M{
+			"status":          model.ShipmentDelivered,
+			"cod_amount":      bson.M{"$gt": 0},
+			"delivered_at":    bson.M{"$lt": deliveredBefore},
+			"cod_remitted_at": bson.M{"$exists": false},
+		}},
+	}
+
+	totalCount, err := r.ordersCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	cursor, err := r.ordersCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*model.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, 0, err
+	}
+	return orders, totalCount, nil
+}
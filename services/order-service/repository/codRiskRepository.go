@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BuyerOrderHistory counts a buyer's past orders
+type BuyerOrderHistory struct {
+	ReturnedCount  int   `bson:"returned_count"`  // refused at delivery or returned
+	CancelledCount int   `bson:"cancelled_count"` // COD orders the buyer cancelled
+	CompletedCount int   `bson:"completed_count"`
+	CompletedTotal int64 `bson:"completed_total"` // paid for the completed orders, delivery included
+}
+
+type CodRiskRepository interface {
+	FindBuyerHistory(userID string) (*BuyerOrderHistory, error)
+	// CountAccountsSharingAddress counts the other buyers who had orders shipped
+	// to the same address or phone
+	CountAccountsSharingAddress(userID string, address model.OrderAddress) (int, error)
+}
+
+type codRiskRepository struct {
+	db               *mongo.Database
+	ordersCollection *mongo.Collection
+}
+
+func NewCodRiskRepository(db *mongo.Database) CodRiskRepository {
+	return &codRiskRepository{
+		db:               db,
+		ordersCollection: db.Collection("orders"),
+	}
+}
+
+func (r *codRiskRepository) FindBuyerHistory(userID string) (*BuyerOrderHistory, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	returned := bson.M{"$or": bson.A{
+		bson.M{"$eq": bson.A{"$status", "RETURNED"}},
+		bson.M{"$in": bson.A{model.ShipmentReturned, bson.M{"$ifNull": bson.A{"$shipments.status", bson.A{}}}}},
+	}}
+	cancelledByBuyer := bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$status", "CANCELLED"}},
+		bson.M{"$eq": bson.A{"$payment_method", "COD"}},
+		bson.M{"$in": bson.A{model.ActorBuyer, bson.M{"$map": bson.M{
+			"input": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$status_history", bson.A{}}},
+				"cond":  bson.M{"$eq": bson.A{"$$this.to", "CANCELLED"}},
+			}},
+			"in": "$$this.actor",
+		}}}},
+	}}
+	completed := bson.M{"$eq": bson.A{"$status", "COMPLETED"}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user._id": userID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":             nil,
+			"returned_count":  sumIf(returned, 1),
+			"cancelled_count": sumIf(cancelledByBuyer, 1),
+			"completed_count": sumIf(completed, 1),
+			"completed_total": sumIf(completed, grandTotal()),
+		}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []BuyerOrderHistory
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &BuyerOrderHistory{}, nil
+	}
+	return &results[0], nil
+}
+
+func (r *codRiskRepository) CountAccountsSharingAddress(userID string, address model.OrderAddress) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var matches bson.A
+	if phone := strings.TrimSpace(address.Phone); phone != "" {
+		matches = append(matches, bson.M{"shipping_address.phone": phone})
+	}
+	if line := strings.TrimSpace(address.AddressLine); line != "" && address.WardCode != "" {
+		// Same street address in the same ward, ignoring case
+		matches = append(matches, bson.M{
+			"shipping_address.ward_code":    address.WardCode,
+			"shipping_address.address_line": primitive.Regex{Pattern: "^\\s*" + regexp.QuoteMeta(line) + "\\s*$", Options: "i"},
+		})
+	}
+	if len(matches) == 0 {
+		return 0, nil
+	}
+
+	userIDs, err := r.ordersCollection.Distinct(ctx, "user._id", bson.M{
+		"user._id": bson.M{"$ne": userID},
+		"$or":      matches,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(userIDs), nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ExportRepository interface {
+	CreateJob(job *model.ExportJob) error
+	FindJobByID(id string) (*model.ExportJob, error)
+	UpdateJob(job *model.ExportJob) error
+}
+
+type exportRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExportRepository(db *mongo.Database) ExportRepository {
+	return &exportRepository{
+		collection: db.Collection("export_jobs"),
+	}
+}
+
+func (r *exportRepository) CreateJob(job *model.ExportJob) error {
+	job.BeforeCreate()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, job)
+	return err
+}
+
+func (r *exportRepository) FindJobByID(id string) (*model.ExportJob, error) {
+	var job model.ExportJob
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *exportRepository) UpdateJob(job *model.ExportJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	return err
+}
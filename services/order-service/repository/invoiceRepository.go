@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvoiceRepository interface {
+	FindByOrderID(orderID string) (*model.Invoice, error)
+	// Create inserts the invoice and reports false when the order already has one
+	Create(invoice *model.Invoice) (bool, error)
+	Delete(orderID string) error
+	NextSequence(sellerID string) (int64, error)
+	SetNumber(orderID string, sequence int64, number string) error
+	SetFile(orderID, key, url string) error
+}
+
+type invoiceRepository struct {
+	db                 *mongo.Database
+	invoicesCollection *mongo.Collection
+	countersCollection *mongo.Collection
+}
+
+func NewInvoiceRepository(db *mongo.Database) InvoiceRepository {
+	return &invoiceRepository{
+		db:                 db,
+		invoicesCollection: db.Collection("invoices"),
+		countersCollection: db.Collection("invoice_counters"),
+	}
+}
+
+func (r *invoiceRepository) FindByOrderID(orderID string) (*model.Invoice, error) {
+	var invoice model.Invoice
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := r.invoicesCollection.FindOne(ctx, bson.M{"_id": orderID}).Decode(&invoice)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) Create(invoice *model.Invoice) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.invoicesCollection.InsertOne(ctx, invoice)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *invoiceRepository) Delete(orderID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.invoicesCollection.DeleteOne(ctx, bson.M{"_id": orderID})
+	return err
+}
+
+// NextSequence atomically increments and returns the seller's invoice counter
+func (r *invoiceRepository) NextSequence(sellerID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var counter struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	err := r.countersCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": sellerID},
+		bson.M{"$inc": bson.M{"sequence": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+	return counter.Sequence, nil
+}
+
+func (r *invoiceRepository) SetNumber(orderID string, sequence int64, number string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.invoicesCollection.UpdateOne(ctx,
+		bson.M{"_id": orderID},
+		bson.M{"$set": bson.M{"sequence": sequence, "number": number}},
+	)
+	return err
+}
+
+func (r *invoiceRepository) SetFile(orderID, key, url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.invoicesCollection.UpdateOne(ctx,
+		bson.M{"_id": orderID},
+		bson.M{"$set": bson.M{"file_key": key, "file_url": url}},
+	)
+	return err
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type JobLeaseRepository interface {
+	// AcquireJobLease reports whether no other instance took the job's lease
+	// within the last ttl; the lease is left to expire rather than released, so
+	// a job runs once per ttl however many instances try it
+	AcquireJobLease(job string, ttl time.Duration) (bool, error)
+}
+
+type jobLeaseRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewJobLeaseRepository(db *mongo.Database) JobLeaseRepository {
+	return &jobLeaseRepository{
+		db:         db,
+		collection: db.Collection("job_leases"),
+	}
+}
+
+func (r *jobLeaseRepository) AcquireJobLease(job string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Takes over an expired lease, or creates one; a live lease makes the
+	// upsert collide with its _id
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": job, "expires_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"expires_at": now.Add(ttl), "acquired_at": now}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
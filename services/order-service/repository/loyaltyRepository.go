@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoyaltyPointSums holds ledger totals per transaction type for one user.
+// Expired holds the part of Total whose expires_at is already in the past.
+type LoyaltyPointSums struct {
+	Total   map[string]int
+	Expired map[string]int
+}
+
+type LoyaltyRepository interface {
+	// CreateTransaction appends a ledger entry. An entry hitting a unique key,
+	// i.e. one of the same type for the order or with the same ID, was
+	// appended already, which counts as done.
+	CreateTransaction(transaction *model.LoyaltyTransaction) error
+	FindTransactionsByUser(userID string, page, limit int) ([]*model.LoyaltyTransaction, int64, error)
+	FindTransactionByOrderAndType(orderID, transactionType string) (*model.LoyaltyTransaction, error)
+	SumPointsByOrder(orderID string, types []string) (int, error)
+	GetBalance(userID string) (int, error)
+	SumPointsByType(userID string, now time.Time) (*LoyaltyPointSums, error)
+	FindRate(id string) (*model.LoyaltyRate, error)
+	UpsertRate(rate *model.LoyaltyRate) error
+}
+
+type loyaltyRepository struct {
+	db                     *mongo.Database
+	transactionsCollection *mongo.Collection
+	ratesCollection        *mongo.Collection
+}
+
+func NewLoyaltyRepository(db *mongo.Database) LoyaltyRepository {
+	return &loyaltyRepository{
+		db:                     db,
+		transactionsCollection: db.Collection("loyalty_transactions"),
+		ratesCollection:        db.Collection("loyalty_rates"),
+	}
+}
+
+func (r *loyaltyRepository) CreateTransaction(transaction *model.LoyaltyTransaction) error {
+	transaction.BeforeCreate()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.transactionsCollection.InsertOne(ctx, transaction)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *loyaltyRepository) FindTransactionsByUser(userID string, page, limit int) ([]*model.LoyaltyTransaction, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+
+	totalCount, err := r.transactionsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	cursor, err := r.transactionsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*model.LoyaltyTransaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, totalCount, nil
+}
+
+func (r *loyaltyRepository) FindTransactionByOrderAndType(orderID, transactionType string) (*model.LoyaltyTransaction, error) {
+	var transaction model.LoyaltyTransaction
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := r.transactionsCollection.FindOne(ctx, bson.M{"order_id": orderID, "type": transactionType}).Decode(&transaction)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// SumPointsByOrder sums the points of all ledger entries of the given types for an order
+func (r *loyaltyRepository) SumPointsByOrder(orderID string, types []string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"order_id": orderID, "type": bson.M{"$in": types}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$points"}}}},
+	}
+
+	return r.sumPipeline(ctx, pipeline)
+}
+
+// GetBalance derives the current balance from the ledger
+func (r *loyaltyRepository) GetBalance(userID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$points"}}}},
+	}
+
+	return r.sumPipeline(ctx, pipeline)
+}
+
+func (r *loyaltyRepository) sumPipeline(ctx context.Context, pipeline mongo.Pipeline) (int, error) {
+	cursor, err := r.transactionsCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total int `bson:"total"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
+
+func (r *loyaltyRepository) SumPointsByType(userID string, now time.Time) (*LoyaltyPointSums, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$type",
+			"total": bson.M{"$sum": "$points"},
+			"expired": bson.M{"$sum": bson.M{
+				"$cond": bson.A{
+					bson.M{"$and": bson.A{
+						bson.M{"$gt": bson.A{"$expires_at", nil}},
+						bson.M{"$lte": bson.A{"$expires_at", now}},
+					}},
+					"$points",
+					0,
+				},
+			}},
+		}}},
+	}
+
+	cursor, err := r.transactionsCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Type    string `bson:"_id"`
+		Total   int    `bson:"total"`
+		Expired int    `bson:"expired"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	sums := &LoyaltyPointSums{
+		Total:   make(map[string]int),
+		Expired: make(map[string]int),
+	}
+	for _, result := range results {
+		sums.Total[result.Type] = result.Total
+		sums.Expired[result.Type] = result.Expired
+	}
+	return sums, nil
+}
+
+func (r *loyaltyRepository) FindRate(id string) (*model.LoyaltyRate, error) {
+	var rate model.LoyaltyRate
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := r.ratesCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&rate)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *loyaltyRepository) UpsertRate(rate *model.LoyaltyRate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rate.UpdatedAt = time.Now()
+	_, err := r.ratesCollection.ReplaceOne(ctx, bson.M{"_id": rate.ID}, rate, options.Replace().SetUpsert(true))
+	return err
+}
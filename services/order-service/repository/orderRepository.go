@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"order-service/model"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,8 +17,20 @@ type OrderRepository interface {
 	CreateOrder(order *model.Order) error
 	FindOrderByID(id string) (*model.Order, error)
 	UpdateOrder(order *model.Order) error
-	FindOrdersByUser(userID string, status string, page, limit int, sortBy, sortOrder string) ([]*model.Order, int64, error)
+	TransitionOrder(order *model.Order, from string) (bool, error)
+	ReplaceOrderIf(order *model.Order, from string, updatedAt time.Time) (bool, error)
+	SetCancellationRefund(orderID string, index int, refund int) error
+	MarkPaymentFailed(orderID string) (bool, error)
+	SetReturnRequest(orderID string, request *model.ReturnRequest) (bool, error)
+	SetPreOrderShipDates(order *model.Order) error
+	FindOrdersByUser(userID string, status string, search string, startDate, endDate *time.Time, page, limit int, sortBy, sortOrder string) ([]*model.Order, int64, error)
 	FindOrdersBySeller(sellerID string, status string, paymentMethod string, paymentStatus string, search string, startDate, endDate *time.Time, page, limit int, sortBy, sortOrder string) ([]*model.Order, int64, error)
+	CountOrdersBySeller(filter SellerOrderFilter) (int64, error)
+	EachOrderBySeller(ctx context.Context, filter SellerOrderFilter, fn func(order *model.Order) error) error
+	FindOrdersByStatus(status string, createdBefore time.Time) ([]*model.Order, error)
+	FindOpenPreOrders() ([]*model.Order, error)
+	FindOrdersMissingItemCategories(limit int) ([]*model.Order, error)
+	SetItemCategories(orderID string, categories map[string][]string) error
 	VerifyVariantPurchase(userID, productID, variantID string) (bool, error)
 	GetSellerStatistics(sellerID string, from, to time.Time, groupBy string) (int, float64, []map[string]interface{}, error)
 }
@@ -56,12 +70,144 @@ func (r *orderRepository) UpdateOrder(order *model.Order) error {
 	return err
 }
 
-func (r *orderRepository) FindOrdersByUser(userID string, status string, page, limit int, sortBy, sortOrder string) ([]*model.Order, int64, error) {
-	// Build filter
-	filter := bson.M{"user._id": userID}
-	if status != "" {
-		filter["status"] = status
+// TransitionOrder writes an order's status and the fields that change with it
+// (payment, parcels and delivery), only while the order is still in status from
+// and its return request is as it was read. It reports false when the order
+// moved on in the meantime, e.g. it was paid or confirmed concurrently.
+func (r *orderRepository) TransitionOrder(order *model.Order, from string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":            order.ID,
+		"status":         from,
+		"return_request": bson.M{"$exists": order.ReturnRequest != nil},
+	}
+	set := bson.M{
+		"status":         order.Status,
+		"status_history": order.StatusHistory,
+		"payment_status": order.PaymentStatus,
+		"shipments":      order.Shipments,
+		"updated_at":     order.UpdatedAt,
+	}
+	unset := bson.M{}
+	if order.PaymentID != "" {
+		set["payment_id"] = order.PaymentID
+	}
+	if order.CompletedAt != nil {
+		set["completed_at"] = order.CompletedAt
+	} else {
+		unset["completed_at"] = ""
+	}
+	if order.DeliveredAt != nil {
+		set["delivered_at"] = order.DeliveredAt
+	} else {
+		unset["delivered_at"] = ""
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set, "$unset": unset})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// ReplaceOrderIf replaces the whole order only while it is as it was read: in
+// status from and last updated at updatedAt. It reports false when the order
+// changed in the meantime. UpdatedAt is kept at the millisecond precision
+// Mongo stores, so the order can be written conditionally again.
+func (r *orderRepository) ReplaceOrderIf(order *model.Order, from string, updatedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	order.UpdatedAt = order.UpdatedAt.Truncate(time.Millisecond)
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": order.ID, "status": from, "updated_at": updatedAt}, order)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// SetCancellationRefund records what was refunded for an item cancellation
+// once the refund went through
+// MarkPaymentFailed records a failed payment while the order still awaits
+// it. It reports false when the order was paid or moved on in the meantime.
+func (r *orderRepository) MarkPaymentFailed(orderID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": orderID, "status": "TO_PAY", "payment_status": bson.M{"$ne": "PAID"}},
+		bson.M{"$set": bson.M{"payment_status": "FAILED", "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
 	}
+	return result.MatchedCount == 1, nil
+}
+
+// SetReturnRequest opens a return on an order being delivered that has none
+// yet. It reports false when the order moved on or a return was opened in the
+// meantime.
+func (r *orderRepository) SetReturnRequest(orderID string, request *model.ReturnRequest) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": orderID, "status": "SHIPPING", "return_request": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"return_request": request, "updated_at": request.RequestedAt}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *orderRepository) SetCancellationRefund(orderID string, index int, refund int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": orderID},
+		bson.M{"$set": bson.M{fmt.Sprintf("cancellations.%d.refund", index): refund}},
+	)
+	return err
+}
+
+// SetPreOrderShipDates writes the ship dates of an order's pre-ordered items
+// and its expected ship date, leaving the rest of the order as it is
+func (r *orderRepository) SetPreOrderShipDates(order *model.Order) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{"expected_ship_date": order.ExpectedShipDate}
+	var arrayFilters []interface{}
+	for i, item := range order.Items {
+		if item.PreOrderShipDate == nil {
+			continue
+		}
+		name := fmt.Sprintf("v%d", i)
+		set["items.$["+name+"].pre_order_ship_date"] = item.PreOrderShipDate
+		arrayFilters = append(arrayFilters, bson.M{name + ".variant_id": item.VariantID})
+	}
+
+	opts := options.Update()
+	if len(arrayFilters) > 0 {
+		opts.SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": order.ID}, bson.M{"$set": set}, opts)
+	return err
+}
+
+func (r *orderRepository) FindOrdersByUser(userID string, status string, search string, startDate, endDate *time.Time, page, limit int, sortBy, sortOrder string) ([]*model.Order, int64, error) {
+	// Build filter
+	filter := UserOrderFilter{
+		UserID:    userID,
+		Status:    status,
+		Search:    search,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}.toBson()
 
 	// Count total documents
 	totalCount, err := r.collection.CountDocuments(context.Background(), filter)
@@ -112,51 +258,158 @@ func (r *orderRepository) FindOrdersByUser(userID string, status string, page, l
 
 func (r *orderRepository) FindOrdersBySeller(sellerID string, status string, paymentMethod string, paymentStatus string, search string, startDate, endDate *time.Time, page, limit int, sortBy, sortOrder string) ([]*model.Order, int64, error) {
 	// Build filter
-	filter := bson.M{"seller._id": sellerID}
+	filter := SellerOrderFilter{
+		SellerID:      sellerID,
+		Status:        status,
+		PaymentMethod: paymentMethod,
+		PaymentStatus: paymentStatus,
+		Search:        search,
+		StartDate:     startDate,
+		EndDate:       endDate,
+	}.toBson()
+
+	// Count total documents
+	totalCount, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Build sort options
+	sortOptions := sellerOrderSort(sortBy, sortOrder)
+
+	// Calculate skip
+	skip := int64((page - 1) * limit)
+
+	// Build find options
+	findOptions := options.Find()
+	findOptions.SetSort(sortOptions)
+	findOptions.SetSkip(skip)
+	findOptions.SetLimit(int64(limit))
+
+	// Execute query
+	cursor, err := r.collection.Find(context.Background(), filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
 
-	if status != "" {
-		filter["status"] = status
+	// Decode results
+	var orders []*model.Order
+	if err = cursor.All(context.Background(), &orders); err != nil {
+		return nil, 0, err
 	}
 
-	if paymentMethod != "" {
-		filter["payment_method"] = paymentMethod
+	return orders, totalCount, nil
+}
+
+// SellerOrderFilter holds the filters of a seller's order listing
+type SellerOrderFilter struct {
+	SellerID      string
+	Status        string
+	PaymentMethod string
+	PaymentStatus string
+	Search        string
+	StartDate     *time.Time
+	EndDate       *time.Time
+}
+
+func (f SellerOrderFilter) toBson() bson.M {
+	filter := bson.M{"seller._id": f.SellerID}
+
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+
+	if f.PaymentMethod != "" {
+		filter["payment_method"] = f.PaymentMethod
 	}
 
-	if paymentStatus != "" {
-		filter["payment_status"] = paymentStatus
+	if f.PaymentStatus != "" {
+		filter["payment_status"] = f.PaymentStatus
 	}
 
 	// Date range filter on created_at
-	if startDate != nil || endDate != nil {
-		dateFilter := bson.M{}
-		if startDate != nil {
-			dateFilter["$gte"] = startDate
-		}
-		if endDate != nil {
-			// Include the full end day by moving to the start of the next day
-			end := endDate.Add(24*time.Hour - time.Nanosecond)
-			dateFilter["$lte"] = end
-		}
+	if dateFilter := createdAtRange(f.StartDate, f.EndDate); dateFilter != nil {
 		filter["created_at"] = dateFilter
 	}
 
 	// Search by order ID or customer name
-	if search != "" {
+	if f.Search != "" {
 		filter["$or"] = []bson.M{
-			{"_id": bson.M{"$regex": search, "$options": "i"}},
-			{"shipping_address.phone": bson.M{"$regex": search, "$options": "i"}},
-			{"user.name": bson.M{"$regex": search, "$options": "i"}},
+			{"_id": bson.M{"$regex": f.Search, "$options": "i"}},
+			{"shipping_address.phone": bson.M{"$regex": f.Search, "$options": "i"}},
+			{"user.name": bson.M{"$regex": f.Search, "$options": "i"}},
 		}
 	}
 
-	// Count total documents
-	totalCount, err := r.collection.CountDocuments(context.Background(), filter)
-	if err != nil {
-		return nil, 0, err
+	return filter
+}
+
+// UserOrderFilter holds the filters of a buyer's order listing
+type UserOrderFilter struct {
+	UserID    string
+	Status    string
+	Search    string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// toBson always filters on the buyer first, so the search only scans their
+// orders through the user._id, created_at index
+func (f UserOrderFilter) toBson() bson.M {
+	filter := bson.M{"user._id": f.UserID}
+
+	if f.Status != "" {
+		filter["status"] = f.Status
 	}
 
-	// Build sort options
-	sortOptions := bson.D{}
+	if dateFilter := createdAtRange(f.StartDate, f.EndDate); dateFilter != nil {
+		filter["created_at"] = dateFilter
+	}
+
+	// Every word must be the start of the order ID, a SKU or a word of a product
+	// or seller name, so "blue jack" finds "Jacket - Blue". The patterns are
+	// anchored and case-sensitive so they run as index range scans, so the query
+	// is lower-cased and matched against the lower-case order ID, SKUs and
+	// search keywords.
+	var terms []bson.M
+	for _, word := range strings.Fields(strings.ToLower(f.Search)) {
+		prefix := "^" + regexp.QuoteMeta(word)
+		keywordTerms := []bson.M{
+			{"_id": bson.M{"$regex": prefix}},
+			{"search_skus": bson.M{"$regex": prefix}},
+		}
+		for _, keyword := range model.SearchKeywords(word) {
+			keywordTerms = append(keywordTerms, bson.M{"search_keywords": bson.M{"$regex": "^" + regexp.QuoteMeta(keyword)}})
+		}
+		terms = append(terms, bson.M{"$or": keywordTerms})
+	}
+	if len(terms) > 0 {
+		filter["$and"] = terms
+	}
+
+	return filter
+}
+
+// createdAtRange builds a created_at filter from an optional date range, nil
+// without one. The end date is inclusive of its whole day.
+func createdAtRange(startDate, endDate *time.Time) bson.M {
+	if startDate == nil && endDate == nil {
+		return nil
+	}
+	dateFilter := bson.M{}
+	if startDate != nil {
+		dateFilter["$gte"] = startDate
+	}
+	if endDate != nil {
+		// Include the full end day by moving to the start of the next day
+		end := endDate.Add(24*time.Hour - time.Nanosecond)
+		dateFilter["$lte"] = end
+	}
+	return dateFilter
+}
+
+func sellerOrderSort(sortBy, sortOrder string) bson.D {
 	sortDirection := -1 // default descending
 	if sortOrder == "asc" {
 		sortDirection = 1
@@ -164,36 +417,131 @@ func (r *orderRepository) FindOrdersBySeller(sellerID string, status string, pay
 
 	switch sortBy {
 	case "total":
-		sortOptions = bson.D{{Key: "total", Value: sortDirection}}
-	case "created_at":
-		sortOptions = bson.D{{Key: "created_at", Value: sortDirection}}
+		return bson.D{{Key: "total", Value: sortDirection}}
 	default:
-		sortOptions = bson.D{{Key: "created_at", Value: sortDirection}} // default field, honour sortDirection
+		return bson.D{{Key: "created_at", Value: sortDirection}} // default field, honour sortDirection
 	}
+}
 
-	// Calculate skip
-	skip := int64((page - 1) * limit)
+func (r *orderRepository) CountOrdersBySeller(filter SellerOrderFilter) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	// Build find options
-	findOptions := options.Find()
-	findOptions.SetSort(sortOptions)
-	findOptions.SetSkip(skip)
-	findOptions.SetLimit(int64(limit))
+	return r.collection.CountDocuments(ctx, filter.toBson())
+}
 
-	// Execute query
-	cursor, err := r.collection.Find(context.Background(), filter, findOptions)
+// EachOrderBySeller calls fn for every order matching the filter, oldest first,
+// decoding one document at a time so large ranges are never held in memory
+func (r *orderRepository) EachOrderBySeller(ctx context.Context, filter SellerOrderFilter, fn func(order *model.Order) error) error {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetBatchSize(500)
+
+	cursor, err := r.collection.Find(ctx, filter.toBson(), findOptions)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var order model.Order
+		if err := cursor.Decode(&order); err != nil {
+			return err
+		}
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// FindOrdersByStatus returns the orders in a status created before a time, oldest first
+func (r *orderRepository) FindOrdersByStatus(status string, createdBefore time.Time) ([]*model.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"status": status, "created_at": bson.M{"$lt": createdBefore}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-	// Decode results
 	var orders []*model.Order
-	if err = cursor.All(context.Background(), &orders); err != nil {
-		return nil, 0, err
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
 	}
+	return orders, nil
+}
 
-	return orders, totalCount, nil
+// FindOpenPreOrders returns the pre-orders the seller has not confirmed yet
+func (r *orderRepository) FindOpenPreOrders() ([]*model.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status":             bson.M{"$in": []string{"TO_PAY", "TO_CONFIRM"}},
+		"expected_ship_date": bson.M{"$exists": true},
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*model.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// FindOrdersMissingItemCategories returns orders with items stored without
+// their platform categories
+func (r *orderRepository) FindOrdersMissingItemCategories(limit int) ([]*model.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"items": bson.M{"$elemMatch": bson.M{"category_ids": bson.M{"$exists": false}}}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*model.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// SetItemCategories fills in the categories of an order's items by variant ID,
+// leaving items that already have theirs as they are
+func (r *orderRepository) SetItemCategories(orderID string, categories map[string][]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{}
+	var arrayFilters []interface{}
+	i := 0
+	for variantID, categoryIDs := range categories {
+		name := fmt.Sprintf("v%d", i)
+		set["items.$["+name+"].category_ids"] = categoryIDs
+		arrayFilters = append(arrayFilters, bson.M{
+			name + ".variant_id":   variantID,
+			name + ".category_ids": bson.M{"$exists": false},
+		})
+		i++
+	}
+	if len(set) == 0 {
+		return nil
+	}
+
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": orderID}, bson.M{"$set": set}, opts)
+	return err
 }
 
 func (r *orderRepository) VerifyVariantPurchase(userID, productID, variantID string) (bool, error) {
@@ -216,7 +564,8 @@ func (r *orderRepository) VerifyVariantPurchase(userID, productID, variantID str
 }
 
 func (r *orderRepository) GetSellerStatistics(sellerID string, from, to time.Time, groupBy string) (int, float64, []map[string]interface{}, error) {
-	// Build filter for seller and time range
+	// Build filter for seller and time range. Every order is counted, but only
+	// paid or completed orders count towards revenue.
 	filter := bson.M{
 		"seller._id": sellerID,
 		"created_at": bson.M{
@@ -237,7 +586,7 @@ func (r *orderRepository) GetSellerStatistics(sellerID string, from, to time.Tim
 				"$group": bson.M{
 					"_id": nil,
 					"count": bson.M{"$sum": 1},
-					"revenue": bson.M{"$sum": "$total"},
+					"revenue": bson.M{"$sum": bson.M{"$cond": bson.A{isRevenueOrder(), bson.M{"$toDouble": "$total"}, 0.0}}},
 				},
 			},
 		}
@@ -265,7 +614,7 @@ func (r *orderRepository) GetSellerStatistics(sellerID string, from, to time.Tim
 						},
 					},
 					"count": bson.M{"$sum": 1},
-					"revenue": bson.M{"$sum": "$total"},
+					"revenue": bson.M{"$sum": bson.M{"$cond": bson.A{isRevenueOrder(), bson.M{"$toDouble": "$total"}, 0.0}}},
 				},
 			},
 			{
@@ -0,0 +1,508 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PayoutBalance holds a seller's amounts in one currency: entries not yet in a
+// statement, statements awaiting payout and statements paid
+type PayoutBalance struct {
+	Currency  string `bson:"_id"`
+	Unsettled int64  `bson:"unsettled"`
+	Payable   int64  `bson:"payable"`
+	Paid      int64  `bson:"paid"`
+}
+
+// PayoutAccount is a seller and currency with entries not yet in a statement
+type PayoutAccount struct {
+	SellerID string `bson:"seller_id"`
+	Currency string `bson:"currency"`
+}
+
+type PayoutRepository interface {
+	FindCommissionRules() ([]*model.CommissionRule, error)
+	UpsertCommissionRule(rule *model.CommissionRule) error
+	DeleteCommissionRule(id string) error
+	FindEntriesByOrder(orderID string) ([]*model.SellerLedgerEntry, error)
+	FindEntriesByStatement(statementID string) ([]*model.SellerLedgerEntry, error)
+	InsertEntries(entries []*model.SellerLedgerEntry) error
+	DeleteUnsettledEntries(orderID string) error
+	FindUnsettledAccounts(before time.Time) ([]PayoutAccount, error)
+	SettleStatement(statementID string, account PayoutAccount, before time.Time) (*model.SettlementStatement, error)
+	FindBalances(sellerID string) ([]PayoutBalance, error)
+	CreateStatementIfMissing(statement *model.SettlementStatement) error
+	FindStatementByID(id string) (*model.SettlementStatement, error)
+	MarkStatementPaid(statementID, paymentReference string, paidAt time.Time) (*model.SettlementStatement, error)
+	FindStatements(sellerID, status string, page, limit int) ([]*model.SettlementStatement, int64, error)
+	EachOrderToSettle(ctx context.Context, sellerID string, from, to time.Time, fn func(order *model.Order) error) error
+}
+
+type payoutRepository struct {
+	db                   *mongo.Database
+	rulesCollection      *mongo.Collection
+	entriesCollection    *mongo.Collection
+	statementsCollection *mongo.Collection
+	ordersCollection     *mongo.Collection
+}
+
+func NewPayoutRepository(db *mongo.Database) PayoutRepository {
+	return &payoutRepository{
+		db:                   db,
+		rulesCollection:      db.Collection("commission_rules"),
+		entriesCollection:    db.Collection("seller_ledger"),
+		statementsCollection: db.Collection("settlement_statements"),
+		ordersCollection:     db.Collection("orders"),
+	}
+}
+
+func (r *payoutRepository) FindCommissionRules() ([]*model.CommissionRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.rulesCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []*model.CommissionRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *payoutRepository) UpsertCommissionRule(rule *model.CommissionRule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rule.UpdatedAt = time.Now()
+	_, err := r.rulesCollection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *payoutRepository) DeleteCommissionRule(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.rulesCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *payoutRepository) findEntries(filter bson.M) ([]*model.SellerLedgerEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.entriesCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*model.SellerLedgerEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *payoutRepository) FindEntriesByOrder(orderID string) ([]*model.SellerLedgerEntry, error) {
+	return r.findEntries(bson.M{"order_id": orderID})
+}
+
+func (r *payoutRepository) FindEntriesByStatement(statementID string) ([]*model.SellerLedgerEntry, error) {
+	return r.findEntries(bson.M{"statement_id": statementID})
+}
+
+// InsertEntries stores new entries. Entries whose ID already exists are left
+// as they are, so recording the same order twice is harmless.
+func (r *payoutRepository) InsertEntries(entries []*model.SellerLedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	documents := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		entry.BeforeCreate()
+		documents = append(documents, entry)
+	}
+
+	_, err := r.entriesCollection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil && !isOnlyDuplicateKeyErrors(err) {
+		return err
+	}
+	return nil
+}
+
+func isOnlyDuplicateKeyErrors(err error) bool {
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *payoutRepository) DeleteUnsettledEntries(orderID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.entriesCollection.DeleteMany(ctx, bson.M{"order_id": orderID, "statement_id": ""})
+	return err
+}
+
+func (r *payoutRepository) FindUnsettledAccounts(before time.Time) ([]PayoutAccount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"statement_id": "", "occurred_at": bson.M{"$lt": before}}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"seller_id": "$seller_id", "currency": "$currency"}}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "seller_id": "$_id.seller_id", "currency": "$_id.currency"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "seller_id", Value: 1}, {Key: "currency", Value: 1}}}},
+	}
+
+	cursor, err := r.entriesCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []PayoutAccount
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// SettleEntries puts the account's unsettled entries that occurred before the
+// given time into a statement
+// SettleStatement moves the account's unsettled entries before the given time
+// into the statement and refreshes its totals, as long as the statement is
+// still pending. It returns nil when the statement is missing or already paid.
+func (r *payoutRepository) SettleStatement(statementID string, account PayoutAccount, before time.Time) (*model.SettlementStatement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	result, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		// Touching the pending statement first makes a concurrent payout of it
+		// conflict with this transaction
+		var statement model.SettlementStatement
+		err := r.statementsCollection.FindOneAndUpdate(sc,
+			bson.M{"_id": statementID, "status": model.StatementPending},
+			bson.M{"$set": bson.M{"updated_at": time.Now()}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&statement)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		if _, err := r.entriesCollection.UpdateMany(sc,
+			bson.M{
+				"seller_id":    account.SellerID,
+				"currency":     account.Currency,
+				"statement_id": "",
+				"occurred_at":  bson.M{"$lt": before},
+			},
+			bson.M{"$set": bson.M{"statement_id": statementID}},
+		); err != nil {
+			return nil, err
+		}
+
+		sums, entryCount, orderCount, err := r.sumStatementEntries(sc, statementID)
+		if err != nil {
+			return nil, err
+		}
+		statement.SetTotals(sums)
+		statement.EntryCount = entryCount
+		statement.OrderCount = orderCount
+		if _, err := r.statementsCollection.ReplaceOne(sc,
+			bson.M{"_id": statementID, "status": model.StatementPending},
+			&statement,
+		); err != nil {
+			return nil, err
+		}
+		return &statement, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	statement, _ := result.(*model.SettlementStatement)
+	return statement, nil
+}
+
+// sumStatementEntries returns the sum of a statement's entries per type, the
+// number of entries and the number of orders they belong to
+func (r *payoutRepository) sumStatementEntries(ctx context.Context, statementID string) (map[string]int64, int, int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"statement_id": statementID}}},
+		{{Key: "$facet", Value: bson.M{
+			"types": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":    "$type",
+					"amount": bson.M{"$sum": "$amount"},
+					"count":  bson.M{"$sum": 1},
+				}},
+			},
+			"orders": bson.A{
+				bson.M{"$group": bson.M{"_id": "$order_id"}},
+				bson.M{"$count": "count"},
+			},
+		}}},
+	}
+
+	cursor, err := r.entriesCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Types []struct {
+			Type   string `bson:"_id"`
+			Amount int64  `bson:"amount"`
+			Count  int    `bson:"count"`
+		} `bson:"types"`
+		Orders []struct {
+			Count int `bson:"count"`
+		} `bson:"orders"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, 0, err
+	}
+
+	sums := make(map[string]int64)
+	entryCount, orderCount := 0, 0
+	if len(results) > 0 {
+		for _, t := range results[0].Types {
+			sums[t.Type] = t.Amount
+			entryCount += t.Count
+		}
+		if len(results[0].Orders) > 0 {
+			orderCount = results[0].Orders[0].Count
+		}
+	}
+	return sums, entryCount, orderCount, nil
+}
+
+// FindBalances returns the seller's balances per currency
+func (r *payoutRepository) FindBalances(sellerID string) ([]PayoutBalance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	isPaid := bson.M{"$eq": bson.A{"$status", model.StatementPaid}}
+	queries := []struct {
+		collection *mongo.Collection
+		pipeline   mongo.Pipeline
+	}{
+		{r.entriesCollection, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"seller_id": sellerID, "statement_id": ""}}},
+			{{Key: "$group", Value: bson.M{"_id": "$currency", "unsettled": bson.M{"$sum": "$amount"}}}},
+		}},
+		{r.statementsCollection, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"seller_id": sellerID}}},
+			{{Key: "$group", Value: bson.M{
+				"_id":     "$currency",
+				"payable": bson.M{"$sum": bson.M{"$cond": bson.A{isPaid, 0, "$payout"}}},
+				"paid":    bson.M{"$sum": bson.M{"$cond": bson.A{isPaid, "$payout", 0}}},
+			}}},
+		}},
+	}
+
+	byCurrency := make(map[string]*PayoutBalance)
+	var currencies []string
+	for _, query := range queries {
+		cursor, err := query.collection.Aggregate(ctx, query.pipeline)
+		if err != nil {
+			return nil, err
+		}
+		var results []PayoutBalance
+		err = cursor.All(ctx, &results)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range results {
+			balance, ok := byCurrency[result.Currency]
+			if !ok {
+				balance = &PayoutBalance{Currency: result.Currency}
+				byCurrency[result.Currency] = balance
+				currencies = append(currencies, result.Currency)
+			}
+			balance.Unsettled += result.Unsettled
+			balance.Payable += result.Payable
+			balance.Paid += result.Paid
+		}
+	}
+
+	sort.Strings(currencies)
+	balances := make([]PayoutBalance, 0, len(currencies))
+	for _, currency := range currencies {
+		balances = append(balances, *byCurrency[currency])
+	}
+	return balances, nil
+}
+
+// CreateStatementIfMissing inserts the statement unless one with the same ID
+// exists already
+func (r *payoutRepository) CreateStatementIfMissing(statement *model.SettlementStatement) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	statement.CreatedAt = now
+	statement.UpdatedAt = now
+
+	// The ID comes from the filter; it can't be set again on insert
+	raw, err := bson.Marshal(statement)
+	if err != nil {
+		return err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	delete(fields, "_id")
+
+	_, err = r.statementsCollection.UpdateOne(ctx,
+		bson.M{"_id": statement.ID},
+		bson.M{"$setOnInsert": fields},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *payoutRepository) FindStatementByID(id string) (*model.SettlementStatement, error) {
+	var statement model.SettlementStatement
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := r.statementsCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&statement)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// MarkStatementPaid marks the statement paid if it is still pending. It
+// returns nil when the statement is missing or was paid already.
+func (r *payoutRepository) MarkStatementPaid(statementID, paymentReference string, paidAt time.Time) (*model.SettlementStatement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var statement model.SettlementStatement
+	err := r.statementsCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": statementID, "status": model.StatementPending},
+		bson.M{"$set": bson.M{
+			"status":            model.StatementPaid,
+			"payment_reference": paymentReference,
+			"paid_at":           paidAt,
+			"updated_at":        time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&statement)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// FindStatements returns statements, newest period first. Empty filters match all.
+func (r *payoutRepository) FindStatements(sellerID, status string, page, limit int) ([]*model.SettlementStatement, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if sellerID != "" {
+		filter["seller_id"] = sellerID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	totalCount, err := r.statementsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "period_start", Value: -1}, {Key: "_id", Value: 1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	cursor, err := r.statementsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var statements []*model.SettlementStatement
+	if err := cursor.All(ctx, &statements); err != nil {
+		return nil, 0, err
+	}
+	return statements, totalCount, nil
+}
+
+// EachOrderToSettle calls fn for every order created in [from, to) that may
+// have ledger entries: completed ones, and cancelled or returned ones that
+// could have been settled before. An empty seller ID matches all sellers.
+func (r *payoutRepository) EachOrderToSettle(ctx context.Context, sellerID string, from, to time.Time, fn func(order *model.Order) error) error {
+	filter := bson.M{
+		"status":     bson.M{"$in": bson.A{"COMPLETED", "CANCELLED", "RETURNED"}},
+		"created_at": bson.M{"$gte": from, "$lt": to},
+	}
+	if sellerID != "" {
+		filter["seller._id"] = sellerID
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetBatchSize(500)
+
+	cursor, err := r.ordersCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var order model.Order
+		if err := cursor.Decode(&order); err != nil {
+			return err
+		}
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
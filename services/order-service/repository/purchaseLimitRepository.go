@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PurchaseLimitRepository interface {
+	// SumPurchased adds up the units of the given variants or products the user
+	// ordered since the given time (zero for ever), leaving out cancelled orders.
+	// The result is keyed by variant or product ID.
+	SumPurchased(userID string, field string, ids []string, since time.Time) (map[string]int, error)
+	// AcquireCheckoutLease takes the user's checkout lease and returns its
+	// token, or an empty token when another checkout holds it. The lease ends
+	// when released or after the TTL.
+	AcquireCheckoutLease(userID string, ttl time.Duration) (string, error)
+	// ReleaseCheckoutLease ends the lease with the given token; a lease taken
+	// over by another checkout after it expired is left alone
+	ReleaseCheckoutLease(userID, token string) error
+}
+
+// Fields SumPurchased can count by
+const (
+	PurchasedByVariant = "variant_id"
+	PurchasedByProduct = "product_id"
+)
+
+type purchaseLimitRepository struct {
+	db               *mongo.Database
+	ordersCollection *mongo.Collection
+	leasesCollection *mongo.Collection
+}
+
+func NewPurchaseLimitRepository(db *mongo.Database) PurchaseLimitRepository {
+	return &purchaseLimitRepository{
+		db:               db,
+		ordersCollection: db.Collection("orders"),
+		leasesCollection: db.Collection("checkout_leases"),
+	}
+}
+
+func (r *purchaseLimitRepository) SumPurchased(userID string, field string, ids []string, since time.Time) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	match := bson.M{
+		"user._id":       userID,
+		"status":         bson.M{"$ne": "CANCELLED"},
+		"items." + field: bson.M{"$in": ids},
+	}
+	if !since.IsZero() {
+		match["created_at"] = bson.M{"$gte": since}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$match", Value: bson.M{"items." + field: bson.M{"$in": ids}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$items." + field,
+			"quantity": bson.M{"$sum": "$items.quantity"},
+		}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ID       string `bson:"_id"`
+		Quantity int    `bson:"quantity"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	purchased := make(map[string]int, len(results))
+	for _, result := range results {
+		purchased[result.ID] = result.Quantity
+	}
+	return purchased, nil
+}
+
+func (r *purchaseLimitRepository) AcquireCheckoutLease(userID string, ttl time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Takes over an expired lease, or creates one; a live lease makes the
+	// upsert collide with its _id
+	now := time.Now()
+	token := uuid.New().String()
+	_, err := r.leasesCollection.UpdateOne(ctx,
+		bson.M{"_id": userID, "expires_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"token": token, "expires_at": now.Add(ttl)}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (r *purchaseLimitRepository) ReleaseCheckoutLease(userID, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.leasesCollection.DeleteOne(ctx, bson.M{"_id": userID, "token": token})
+	return err
+}
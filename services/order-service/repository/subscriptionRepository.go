@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SubscriptionRepository interface {
+	CreateSubscription(subscription *model.Subscription) error
+	FindSubscriptionByID(id string) (*model.Subscription, error)
+	FindSubscriptionsByUser(userID string) ([]*model.Subscription, error)
+	UpdateSubscription(subscription *model.Subscription) error
+	FindDueSubscriptions(now time.Time) ([]*model.Subscription, error)
+	ClaimRenewal(id string, dueAt, nextOrderAt time.Time) (bool, error)
+	RecordRenewal(id string, items []model.SubscriptionItem, orderID string) error
+	FindPaymentCustomer(userID string) (*model.PaymentCustomer, error)
+	CreatePaymentCustomer(customer *model.PaymentCustomer) error
+}
+
+type subscriptionRepository struct {
+	db                  *mongo.Database
+	collection          *mongo.Collection
+	customersCollection *mongo.Collection
+}
+
+func NewSubscriptionRepository(db *mongo.Database) SubscriptionRepository {
+	return &subscriptionRepository{
+		db:                  db,
+		collection:          db.Collection("subscriptions"),
+		customersCollection: db.Collection("payment_customers"),
+	}
+}
+
+func (r *subscriptionRepository) CreateSubscription(subscription *model.Subscription) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	subscription.BeforeCreate()
+	_, err := r.collection.InsertOne(ctx, subscription)
+	return err
+}
+
+func (r *subscriptionRepository) FindSubscriptionByID(id string) (*model.Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var subscription model.Subscription
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&subscription)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// FindSubscriptionsByUser returns the user's subscriptions, newest first
+func (r *subscriptionRepository) FindSubscriptionsByUser(userID string) ([]*model.Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var subscriptions []*model.Subscription
+	if err = cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *subscriptionRepository) UpdateSubscription(subscription *model.Subscription) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	subscription.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": subscription.ID}, subscription)
+	return err
+}
+
+// FindDueSubscriptions returns the active subscriptions whose next renewal is due, oldest first
+func (r *subscriptionRepository) FindDueSubscriptions(now time.Time) ([]*model.Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"status": model.SubscriptionActive, "next_order_at": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "next_order_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var subscriptions []*model.Subscription
+	if err = cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// ClaimRenewal moves an active subscription's next renewal from dueAt to
+// nextOrderAt. It reports false when the subscription changed in the meantime,
+// e.g. it was paused, skipped or renewed by another instance.
+func (r *subscriptionRepository) ClaimRenewal(id string, dueAt, nextOrderAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": model.SubscriptionActive, "next_order_at": dueAt}
+	update := bson.M{"$set": bson.M{"next_order_at": nextOrderAt, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RecordRenewal stores the prices seen at a renewal and, when an order was
+// placed, its ID. Only these fields are written so that a pause or cancel
+// made during the renewal is kept.
+func (r *subscriptionRepository) RecordRenewal(id string, items []model.SubscriptionItem, orderID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{"items": items, "updated_at": now}
+	if orderID != "" {
+		set["last_order_id"] = orderID
+		set["last_order_at"] = now
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (r *subscriptionRepository) FindPaymentCustomer(userID string) (*model.PaymentCustomer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var customer model.PaymentCustomer
+	err := r.customersCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&customer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *subscriptionRepository) CreatePaymentCustomer(customer *model.PaymentCustomer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if customer.CreatedAt.IsZero() {
+		customer.CreatedAt = time.Now()
+	}
+	_, err := r.customersCollection.InsertOne(ctx, customer)
+	return err
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TaxSummaryLine holds the VAT totals of one currency and rate
+type TaxSummaryLine struct {
+	Currency      string  `bson:"currency"`
+	Rate          float64 `bson:"rate"`
+	ItemCount     int     `bson:"item_count"`
+	TaxableAmount int64   `bson:"taxable_amount"`
+	TaxAmount     int64   `bson:"tax_amount"`
+}
+
+type TaxRepository interface {
+	FindAllRates() ([]*model.TaxRate, error)
+	UpsertRate(rate *model.TaxRate) error
+	DeleteRate(id string) error
+	FindSellerSetting(sellerID string) (*model.SellerTaxSetting, error)
+	UpsertSellerSetting(setting *model.SellerTaxSetting) error
+	SumTaxBySeller(sellerID string, from, to time.Time, statuses []string) ([]TaxSummaryLine, int, error)
+}
+
+type taxRepository struct {
+	db                 *mongo.Database
+	ratesCollection    *mongo.Collection
+	settingsCollection *mongo.Collection
+	ordersCollection   *mongo.Collection
+}
+
+func NewTaxRepository(db *mongo.Database) TaxRepository {
+	return &taxRepository{
+		db:                 db,
+		ratesCollection:    db.Collection("tax_rates"),
+		settingsCollection: db.Collection("seller_tax_settings"),
+		ordersCollection:   db.Collection("orders"),
+	}
+}
+
+func (r *taxRepository) FindAllRates() ([]*model.TaxRate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.ratesCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rates []*model.TaxRate
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (r *taxRepository) UpsertRate(rate *model.TaxRate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rate.UpdatedAt = time.Now()
+	_, err := r.ratesCollection.ReplaceOne(ctx, bson.M{"_id": rate.ID}, rate, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *taxRepository) DeleteRate(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.ratesCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *taxRepository) FindSellerSetting(sellerID string) (*model.SellerTaxSetting, error) {
+	var setting model.SellerTaxSetting
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := r.settingsCollection.FindOne(ctx, bson.M{"_id": sellerID}).Decode(&setting)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *taxRepository) UpsertSellerSetting(setting *model.SellerTaxSetting) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	setting.UpdatedAt = time.Now()
+	_, err := r.settingsCollection.ReplaceOne(ctx, bson.M{"_id": setting.SellerID}, setting, options.Replace().SetUpsert(true))
+	return err
+}
+
+// SumTaxBySeller totals the VAT of a seller's order items created in [from, to)
+// with one of the given statuses, per currency and rate. It also returns the
+// number of orders counted.
+func (r *taxRepository) SumTaxBySeller(sellerID string, from, to time.Time, statuses []string) ([]TaxSummaryLine, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	match := bson.M{
+		"seller._id": sellerID,
+		"status":     bson.M{"$in": statuses},
+		"created_at": bson.M{"$gte": from, "$lt": to},
+	}
+
+	orderCount, err := r.ordersCollection.CountDocuments(ctx, match)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"currency": bson.M{"$ifNull": bson.A{"$currency", model.DefaultCurrency}},
+				"rate":     bson.M{"$ifNull": bson.A{"$items.tax_rate", 0}},
+			},
+			"item_count":     bson.M{"$sum": "$items.quantity"},
+			"taxable_amount": bson.M{"$sum": bson.M{"$toLong": bson.M{"$ifNull": bson.A{"$items.taxable_amount", 0}}}},
+			"tax_amount":     bson.M{"$sum": bson.M{"$toLong": bson.M{"$ifNull": bson.A{"$items.tax_amount", 0}}}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":            0,
+			"currency":       "$_id.currency",
+			"rate":           bson.M{"$toDouble": "$_id.rate"},
+			"item_count":     1,
+			"taxable_amount": 1,
+			"tax_amount":     1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "currency", Value: 1}, {Key: "rate", Value: 1}}}},
+	}
+
+	cursor, err := r.ordersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var lines []TaxSummaryLine
+	if err := cursor.All(ctx, &lines); err != nil {
+		return nil, 0, err
+	}
+	return lines, int(orderCount), nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TrackingRepository interface {
+	FindByTrackingCode(trackingCode string) (*model.ShipmentTracking, error)
+	Upsert(tracking *model.ShipmentTracking) error
+}
+
+type trackingRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewTrackingRepository(db *mongo.Database) TrackingRepository {
+	return &trackingRepository{
+		db:         db,
+		collection: db.Collection("shipment_tracking"),
+	}
+}
+
+func (r *trackingRepository) FindByTrackingCode(trackingCode string) (*model.ShipmentTracking, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var tracking model.ShipmentTracking
+	err := r.collection.FindOne(ctx, bson.M{"_id": trackingCode}).Decode(&tracking)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tracking, nil
+}
+
+func (r *trackingRepository) Upsert(tracking *model.ShipmentTracking) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": tracking.TrackingCode}, tracking, opts)
+	return err
+}
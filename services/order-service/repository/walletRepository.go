@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WalletRepository interface {
+	// CreateTransaction posts a transaction. A transaction of the same type and
+	// reference was posted already when the insert hits the unique index, which
+	// counts as done.
+	CreateTransaction(transaction *model.WalletTransaction) error
+	// CreateCoveredTransaction posts a transaction on condition that it leaves
+	// account with a balance of zero or more; it reports false when it does not.
+	// A transaction already posted under the same type and reference counts as done.
+	CreateCoveredTransaction(transaction *model.WalletTransaction, account string) (bool, error)
+	FindTransactionByReference(transactionType, reference string) (*model.WalletTransaction, error)
+	FindTransactionsByAccount(account string, page, limit int) ([]*model.WalletTransaction, int64, error)
+	GetAccountBalance(account string) (int, error)
+	CreateGiftCard(giftCard *model.GiftCard) error
+	ClaimGiftCard(code, userID string) (*model.GiftCard, error)
+	ReleaseGiftCard(code string) error
+}
+
+type walletRepository struct {
+	db                     *mongo.Database
+	transactionsCollection *mongo.Collection
+	locksCollection        *mongo.Collection
+	giftCardsCollection    *mongo.Collection
+}
+
+func NewWalletRepository(db *mongo.Database) WalletRepository {
+	return &walletRepository{
+		db:                     db,
+		transactionsCollection: db.Collection("wallet_transactions"),
+		locksCollection:        db.Collection("wallet_account_locks"),
+		giftCardsCollection:    db.Collection("gift_cards"),
+	}
+}
+
+func (r *walletRepository) CreateTransaction(transaction *model.WalletTransaction) error {
+	transaction.BeforeCreate()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.transactionsCollection.InsertOne(ctx, transaction)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *walletRepository) CreateCoveredTransaction(transaction *model.WalletTransaction, account string) (bool, error) {
+	transaction.BeforeCreate()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	amount := 0
+	for _, entry := range transaction.Entries {
+		if entry.Account == account {
+			amount += entry.Amount
+		}
+	}
+
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return false, err
+	}
+	defer session.EndSession(ctx)
+
+	covered, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		// Every covered posting writes the account's lock document, so concurrent
+		// ones conflict and the losing side is retried on the new balance
+		_, err := r.locksCollection.UpdateOne(sc,
+			bson.M{"_id": account},
+			bson.M{"$inc": bson.M{"postings": 1}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return false, err
+		}
+
+		balance, err := r.accountBalance(sc, account)
+		if err != nil {
+			return false, err
+		}
+		if balance+amount < 0 {
+			return false, nil
+		}
+
+		if _, err := r.transactionsCollection.InsertOne(sc, transaction); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return covered.(bool), nil
+}
+
+func (r *walletRepository) FindTransactionByReference(transactionType, reference string) (*model.WalletTransaction, error) {
+	var transaction model.WalletTransaction
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := r.transactionsCollection.FindOne(ctx, bson.M{"type": transactionType, "reference": reference}).Decode(&transaction)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (r *walletRepository) FindTransactionsByAccount(account string, page, limit int) ([]*model.WalletTransaction, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"entries.account": account}
+
+	totalCount, err := r.transactionsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	cursor, err := r.transactionsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*model.WalletTransaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, totalCount, nil
+}
+
+// GetAccountBalance sums every entry posted to the account
+func (r *walletRepository) GetAccountBalance(account string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.accountBalance(ctx, account)
+}
+
+func (r *walletRepository) accountBalance(ctx context.Context, account string) (int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"entries.account": account}}},
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$match", Value: bson.M{"entries.account": account}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$entries.amount"}}}},
+	}
+
+	cursor, err := r.transactionsCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total int `bson:"total"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
+
+func (r *walletRepository) CreateGiftCard(giftCard *model.GiftCard) error {
+	giftCard.BeforeCreate()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.giftCardsCollection.InsertOne(ctx, giftCard)
+	return err
+}
+
+// ClaimGiftCard atomically marks an active, unexpired gift card as redeemed by the user.
+// It returns nil if no such card exists.
+func (r *walletRepository) ClaimGiftCard(code, userID string) (*model.GiftCard, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"code":   code,
+		"status": model.GiftCardActive,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":      model.GiftCardRedeemed,
+		"redeemed_by": userID,
+		"redeemed_at": now,
+	}}
+
+	var giftCard model.GiftCard
+	err := r.giftCardsCollection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&giftCard)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &giftCard, nil
+}
+
+// ReleaseGiftCard makes a claimed gift card active again, used when crediting the wallet failed
+func (r *walletRepository) ReleaseGiftCard(code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.giftCardsCollection.UpdateOne(ctx,
+		bson.M{"code": code},
+		bson.M{
+			"$set":   bson.M{"status": model.GiftCardActive},
+			"$unset": bson.M{"redeemed_by": "", "redeemed_at": ""},
+		},
+	)
+	return err
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"order-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WishlistRepository interface {
+	EnsureDefaultWishlist(userID string) (*model.Wishlist, error)
+	CreateWishlist(wishlist *model.Wishlist) error
+	FindWishlistByID(id string) (*model.Wishlist, error)
+	FindWishlistsByUser(userID string) ([]*model.Wishlist, error)
+	CountWishlistsByUser(userID string) (int64, error)
+	UpdateWishlistName(id, name string) error
+	DeleteWishlist(id string) error
+	CountItemsByWishlist(userID string) (map[string]int, error)
+	CreateItem(item *model.WishlistItem) (bool, error)
+	FindItem(wishlistID, productID, variantID string) (*model.WishlistItem, error)
+	FindItemByID(id string) (*model.WishlistItem, error)
+	FindItemsByWishlist(wishlistID string) ([]*model.WishlistItem, error)
+	DeleteItem(id string) error
+}
+
+type wishlistRepository struct {
+	db                  *mongo.Database
+	wishlistsCollection *mongo.Collection
+	itemsCollection     *mongo.Collection
+}
+
+func NewWishlistRepository(db *mongo.Database) WishlistRepository {
+	return &wishlistRepository{
+		db:                  db,
+		wishlistsCollection: db.Collection("wishlists"),
+		itemsCollection:     db.Collection("wishlist_items"),
+	}
+}
+
+// EnsureDefaultWishlist returns the user's default list, creating it on first use
+func (r *wishlistRepository) EnsureDefaultWishlist(userID string) (*model.Wishlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	update := bson.M{"$setOnInsert": bson.M{
+		"user_id":    userID,
+		"name":       model.DefaultWishlistName,
+		"is_default": true,
+		"created_at": now,
+		"updated_at": now,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var wishlist model.Wishlist
+	err := r.wishlistsCollection.FindOneAndUpdate(ctx, bson.M{"_id": model.DefaultWishlistID(userID)}, update, opts).Decode(&wishlist)
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (r *wishlistRepository) CreateWishlist(wishlist *model.Wishlist) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	wishlist.BeforeCreate()
+	_, err := r.wishlistsCollection.InsertOne(ctx, wishlist)
+	return err
+}
+
+func (r *wishlistRepository) FindWishlistByID(id string) (*model.Wishlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wishlist model.Wishlist
+	err := r.wishlistsCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&wishlist)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+// FindWishlistsByUser returns the default list first, then the others oldest first
+func (r *wishlistRepository) FindWishlistsByUser(userID string) ([]*model.Wishlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.wishlistsCollection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var wishlists []*model.Wishlist
+	if err = cursor.All(ctx, &wishlists); err != nil {
+		return nil, err
+	}
+	return wishlists, nil
+}
+
+func (r *wishlistRepository) CountWishlistsByUser(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.wishlistsCollection.CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+func (r *wishlistRepository) UpdateWishlistName(id, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"name": name, "updated_at": time.Now()}}
+	_, err := r.wishlistsCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// DeleteWishlist deletes a list together with its items
+func (r *wishlistRepository) DeleteWishlist(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := r.itemsCollection.DeleteMany(ctx, bson.M{"wishlist_id": id}); err != nil {
+		return err
+	}
+	_, err := r.wishlistsCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// CountItemsByWishlist returns the number of items in each of the user's lists
+func (r *wishlistRepository) CountItemsByWishlist(userID string) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$wishlist_id", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := r.itemsCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		WishlistID string `bson:"_id"`
+		Count      int    `bson:"count"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.WishlistID] = row.Count
+	}
+	return counts, nil
+}
+
+// CreateItem adds an item to a list. It returns false when the product (or
+// variant) is already in the list.
+func (r *wishlistRepository) CreateItem(item *model.WishlistItem) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	item.BeforeCreate()
+	_, err := r.itemsCollection.InsertOne(ctx, item)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *wishlistRepository) FindItem(wishlistID, productID, variantID string) (*model.WishlistItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"wishlist_id": wishlistID,
+		"product_id":  productID,
+		"variant_id":  variantID,
+	}
+
+	var item model.WishlistItem
+	err := r.itemsCollection.FindOne(ctx, filter).Decode(&item)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *wishlistRepository) FindItemByID(id string) (*model.WishlistItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var item model.WishlistItem
+	err := r.itemsCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&item)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// FindItemsByWishlist returns the items of a list, most recently added first
+func (r *wishlistRepository) FindItemsByWishlist(wishlistID string) ([]*model.WishlistItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.itemsCollection.Find(ctx, bson.M{"wishlist_id": wishlistID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var items []*model.WishlistItem
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *wishlistRepository) DeleteItem(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.itemsCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAnalyticsRoutes(rg *gin.RouterGroup, c controller.AnalyticsController) {
+	analytics := rg.Group("/analytics")
+	{
+		analytics.GET("/seller", middleware.RequireSeller(), c.GetSellerAnalytics)
+		analytics.GET("/admin/overview", middleware.RequireAdmin(), c.GetAdminOverview)
+		analytics.GET("/admin/daily", middleware.RequireAdmin(), c.GetAdminDaily)
+	}
+}
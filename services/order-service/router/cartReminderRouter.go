@@ -0,0 +1,17 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCartReminderRoutes(rg *gin.RouterGroup, c controller.CartReminderController) {
+	reminder := rg.Group("")
+	{
+		// Protected routes - require customer authentication
+		reminder.GET("/cart-reminders/preference", middleware.RequireCustomer(), c.GetPreference)
+		reminder.PUT("/cart-reminders/preference", middleware.RequireCustomer(), c.UpdatePreference)
+	}
+}
@@ -14,7 +14,18 @@ func RegisterCartRoutes(rg *gin.RouterGroup, c controller.CartController) {
 		cart.GET("/cart", middleware.RequireCustomer(), c.GetCartItems)
 		cart.GET("/cart/count", middleware.RequireCustomer(), c.GetCartItemCount)
 		cart.POST("/cart", middleware.RequireCustomer(), c.AddCartItem)
+		cart.POST("/cart/bundle", middleware.RequireCustomer(), c.AddBundleCartItem)
+		cart.POST("/cart/merge", middleware.RequireCustomer(), c.MergeGuestCart)
 		cart.PUT("/cart/:id", middleware.RequireCustomer(), c.UpdateCartItemQuantity)
 		cart.DELETE("/cart/:id", middleware.RequireCustomer(), c.DeleteCartItem)
+
+		// Public guest cart routes - identified by the X-Cart-Token header
+		cart.POST("/public/cart", c.CreateGuestCart)
+		cart.GET("/public/cart/items", c.GetGuestCartItems)
+		cart.GET("/public/cart/count", c.GetGuestCartItemCount)
+		cart.POST("/public/cart/items", c.AddGuestCartItem)
+		cart.POST("/public/cart/bundle", c.AddGuestBundleCartItem)
+		cart.PUT("/public/cart/items/:id", c.UpdateGuestCartItemQuantity)
+		cart.DELETE("/public/cart/items/:id", c.DeleteGuestCartItem)
 	}
 }
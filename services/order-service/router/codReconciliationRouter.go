@@ -0,0 +1,18 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCodReconciliationRoutes(rg *gin.RouterGroup, c controller.CodReconciliationController) {
+	reconciliation := rg.Group("/cod-reconciliation")
+	{
+		reconciliation.POST("/settlements", middleware.RequireAdmin(), c.ImportSettlement)
+		reconciliation.GET("/settlements", middleware.RequireAdmin(), c.GetSettlements)
+		reconciliation.GET("/settlements/:settlementId", middleware.RequireAdmin(), c.GetSettlement)
+		reconciliation.GET("/missing", middleware.RequireAdmin(), c.GetMissingRemittances)
+	}
+}
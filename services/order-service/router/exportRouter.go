@@ -0,0 +1,16 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterExportRoutes(rg *gin.RouterGroup, c controller.ExportController) {
+	export := rg.Group("/seller")
+	{
+		export.GET("/export", middleware.RequireSeller(), c.ExportSellerOrders)
+		export.GET("/exports/:jobId", middleware.RequireSeller(), c.GetExportJob)
+	}
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterInvoiceRoutes(rg *gin.RouterGroup, c controller.InvoiceController) {
+	invoice := rg.Group("")
+	{
+		invoice.GET("/:orderId/invoice", middleware.RequireAuthenticated(), c.GetInvoice)
+	}
+}
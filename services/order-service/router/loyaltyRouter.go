@@ -0,0 +1,16 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterLoyaltyRoutes(rg *gin.RouterGroup, c controller.LoyaltyController) {
+	loyalty := rg.Group("/loyalty")
+	{
+		loyalty.GET("/points", middleware.RequireCustomer(), c.GetPointsHistory)
+		loyalty.PUT("/rates", middleware.RequireAdmin(), c.UpdateRate)
+	}
+}
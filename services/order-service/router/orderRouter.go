@@ -17,7 +17,13 @@ func RegisterOrderRoutes(rg *gin.RouterGroup, c controller.OrderController) {
 		order.POST("/checkout", c.Checkout)
 		order.POST("/instant-checkout", c.InstantCheckout)
 		order.POST("/:orderId/payment", c.CreatePayment)
-		order.POST("/public/webhook/stripe", c.StripeWebhook)
+		order.POST("/:orderId/return-request", middleware.RequireCustomer(), c.RequestReturn)
+		order.POST("/:orderId/cancel-items", c.CancelOrderItems)
+		order.POST("/:orderId/shipments", middleware.RequireSeller(), c.CreateShipment)
+		order.PUT("/:orderId/shipments/:shipmentId", middleware.RequireSeller(), c.UpdateShipmentStatus)
+		order.POST("/public/webhook/:provider", c.PaymentWebhook)
+		order.GET("/public/webhook/:provider", c.PaymentWebhook)
+		order.GET("/public/payment/:provider/return", c.PaymentReturn)
 		order.POST("/verify-purchase", c.VerifyPurchase)
 		order.GET("/test", c.Test)
 	}
@@ -0,0 +1,27 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPayoutRoutes(rg *gin.RouterGroup, c controller.PayoutController) {
+	payouts := rg.Group("/payouts")
+	{
+		payouts.GET("/balance", middleware.RequireSeller(), c.GetBalance)
+		payouts.GET("/statements", middleware.RequireSeller(), c.GetStatements)
+		payouts.GET("/statements/:statementId", middleware.RequireSeller(), c.GetStatement)
+
+		payouts.GET("/sellers/:sellerId/balance", middleware.RequireAdmin(), c.GetSellerBalance)
+		payouts.GET("/admin/statements", middleware.RequireAdmin(), c.GetAllStatements)
+		payouts.GET("/admin/statements/:statementId", middleware.RequireAdmin(), c.GetAnyStatement)
+		payouts.PUT("/admin/statements/:statementId/paid", middleware.RequireAdmin(), c.MarkStatementPaid)
+		payouts.POST("/admin/statements/generate", middleware.RequireAdmin(), c.GenerateStatements)
+		payouts.POST("/admin/recompute", middleware.RequireAdmin(), c.Recompute)
+		payouts.GET("/admin/commission-rules", middleware.RequireAdmin(), c.GetCommissionRules)
+		payouts.PUT("/admin/commission-rules", middleware.RequireAdmin(), c.UpdateCommissionRule)
+		payouts.DELETE("/admin/commission-rules/:ruleId", middleware.RequireAdmin(), c.DeleteCommissionRule)
+	}
+}
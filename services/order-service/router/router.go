@@ -9,8 +9,20 @@ import (
 
 // AppRouter holds all controllers for dependency injection
 type AppRouter struct {
-	CartController  *controller.CartController
-	OrderController *controller.OrderController
+	CartController              *controller.CartController
+	OrderController             *controller.OrderController
+	LoyaltyController           *controller.LoyaltyController
+	WalletController            *controller.WalletController
+	TaxController               *controller.TaxController
+	InvoiceController           *controller.InvoiceController
+	ExportController            *controller.ExportController
+	AnalyticsController         *controller.AnalyticsController
+	PayoutController            *controller.PayoutController
+	WishlistController          *controller.WishlistController
+	CartReminderController      *controller.CartReminderController
+	TrackingController          *controller.TrackingController
+	CodReconciliationController *controller.CodReconciliationController
+	SubscriptionController      *controller.SubscriptionController
 }
 
 // SetupRouter builds the main Gin router and registers all module routes
@@ -26,6 +38,18 @@ func SetupRouter(engine *gin.Engine, appRouter *AppRouter) *gin.Engine {
 		// Each controller registers its own routes
 		RegisterCartRoutes(api, *appRouter.CartController)
 		RegisterOrderRoutes(api, *appRouter.OrderController)
+		RegisterLoyaltyRoutes(api, *appRouter.LoyaltyController)
+		RegisterWalletRoutes(api, *appRouter.WalletController)
+		RegisterTaxRoutes(api, *appRouter.TaxController)
+		RegisterInvoiceRoutes(api, *appRouter.InvoiceController)
+		RegisterExportRoutes(api, *appRouter.ExportController)
+		RegisterAnalyticsRoutes(api, *appRouter.AnalyticsController)
+		RegisterPayoutRoutes(api, *appRouter.PayoutController)
+		RegisterWishlistRoutes(api, *appRouter.WishlistController)
+		RegisterCartReminderRoutes(api, *appRouter.CartReminderController)
+		RegisterTrackingRoutes(api, *appRouter.TrackingController)
+		RegisterCodReconciliationRoutes(api, *appRouter.CodReconciliationController)
+		RegisterSubscriptionRoutes(api, *appRouter.SubscriptionController)
 	}
 
 	//publicApi := engine.Group("/api/public")
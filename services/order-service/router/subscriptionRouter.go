@@ -0,0 +1,23 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSubscriptionRoutes(rg *gin.RouterGroup, c controller.SubscriptionController) {
+	subscriptions := rg.Group("/subscriptions")
+	{
+		// Protected routes - require customer authentication
+		subscriptions.POST("/payment-setup", middleware.RequireCustomer(), c.SetupPayment)
+		subscriptions.GET("", middleware.RequireCustomer(), c.GetSubscriptions)
+		subscriptions.POST("", middleware.RequireCustomer(), c.CreateSubscription)
+		subscriptions.GET("/:subscriptionId", middleware.RequireCustomer(), c.GetSubscription)
+		subscriptions.POST("/:subscriptionId/skip", middleware.RequireCustomer(), c.SkipNext)
+		subscriptions.POST("/:subscriptionId/pause", middleware.RequireCustomer(), c.Pause)
+		subscriptions.POST("/:subscriptionId/resume", middleware.RequireCustomer(), c.Resume)
+		subscriptions.POST("/:subscriptionId/cancel", middleware.RequireCustomer(), c.Cancel)
+	}
+}
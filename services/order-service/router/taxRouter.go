@@ -0,0 +1,21 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTaxRoutes(rg *gin.RouterGroup, c controller.TaxController) {
+	tax := rg.Group("/tax")
+	{
+		tax.GET("/rates", middleware.RequireAdmin(), c.GetRates)
+		tax.PUT("/rates", middleware.RequireAdmin(), c.UpdateRate)
+		tax.DELETE("/rates/:categoryId", middleware.RequireAdmin(), c.DeleteRate)
+		tax.GET("/settings", middleware.RequireSeller(), c.GetSellerSetting)
+		tax.PUT("/settings", middleware.RequireSeller(), c.UpdateSellerSetting)
+		tax.GET("/summary", middleware.RequireSeller(), c.GetSummary)
+		tax.GET("/sellers/:sellerId/summary", middleware.RequireAdmin(), c.GetSellerSummary)
+	}
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTrackingRoutes(rg *gin.RouterGroup, c controller.TrackingController) {
+	tracking := rg.Group("")
+	{
+		tracking.GET("/:orderId/tracking", middleware.RequireAuthenticated(), c.GetOrderTracking)
+	}
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWalletRoutes(rg *gin.RouterGroup, c controller.WalletController) {
+	wallet := rg.Group("/wallet")
+	{
+		wallet.GET("", middleware.RequireCustomer(), c.GetWallet)
+		wallet.POST("/topup", middleware.RequireCustomer(), c.TopUp)
+		wallet.POST("/gift-cards/redeem", middleware.RequireCustomer(), c.RedeemGiftCard)
+		wallet.POST("/gift-cards", middleware.RequireAdmin(), c.CreateGiftCards)
+	}
+}
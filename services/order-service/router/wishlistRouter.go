@@ -0,0 +1,26 @@
+package router
+
+import (
+	"order-service/controller"
+	"order-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWishlistRoutes(rg *gin.RouterGroup, c controller.WishlistController) {
+	wishlist := rg.Group("")
+	{
+		// Protected routes - require customer authentication
+		wishlist.GET("/wishlists", middleware.RequireCustomer(), c.GetWishlists)
+		wishlist.POST("/wishlists", middleware.RequireCustomer(), c.CreateWishlist)
+		wishlist.GET("/wishlists/:wishlistId", middleware.RequireCustomer(), c.GetWishlist)
+		wishlist.PUT("/wishlists/:wishlistId", middleware.RequireCustomer(), c.RenameWishlist)
+		wishlist.DELETE("/wishlists/:wishlistId", middleware.RequireCustomer(), c.DeleteWishlist)
+		wishlist.POST("/wishlists/:wishlistId/items", middleware.RequireCustomer(), c.AddItem)
+		wishlist.DELETE("/wishlists/items/:itemId", middleware.RequireCustomer(), c.DeleteItem)
+		wishlist.POST("/wishlists/items/:itemId/move-to-cart", middleware.RequireCustomer(), c.MoveItemToCart)
+
+		// Save for later - moves a cart line into a wishlist
+		wishlist.POST("/cart/:id/move-to-wishlist", middleware.RequireCustomer(), c.MoveCartItemToWishlist)
+	}
+}
@@ -0,0 +1,553 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"order-service/client"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTopItemsLimit  = 10
+	maxAdminAnalyticsDays = 366
+	dateLayout            = "2006-01-02"
+)
+
+type AnalyticsService interface {
+	GetSellerAnalytics(sellerID string, request dto.GetSellerAnalyticsRequest) (*dto.SellerAnalyticsResponse, error)
+	GetAdminOverview(request dto.AdminAnalyticsRequest) (*dto.AdminOverviewResponse, error)
+	GetAdminDaily(request dto.AdminAnalyticsRequest) (*dto.AdminDailyResponse, error)
+	// StartSnapshotJob stores the daily snapshots every night in the background
+	StartSnapshotJob(leases repository.JobLeaseRepository)
+}
+
+type analyticsService struct {
+	repo          repository.AnalyticsRepository
+	productClient *client.ProductServiceClient
+	userClient    *client.UserServiceClient
+	location      *time.Location
+	cacheTTL      time.Duration
+	refreshDays   int // recent days whose snapshots are recomputed every night
+
+	cacheMu sync.Mutex
+	cache   map[string]analyticsCacheEntry
+}
+
+type analyticsCacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+func NewAnalyticsService(repo repository.AnalyticsRepository, productClient *client.ProductServiceClient, userClient *client.UserServiceClient) AnalyticsService {
+	timezone := os.Getenv("ANALYTICS_TIMEZONE")
+	if timezone == "" {
+		timezone = "Asia/Ho_Chi_Minh"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		fmt.Printf("Warning: unknown ANALYTICS_TIMEZONE %q, using UTC\n", timezone)
+		location = time.UTC
+	}
+	cacheMinutes, err := strconv.Atoi(os.Getenv("ANALYTICS_CACHE_MINUTES"))
+	if err != nil || cacheMinutes < 0 {
+		cacheMinutes = 10
+	}
+	refreshDays, err := strconv.Atoi(os.Getenv("ANALYTICS_SNAPSHOT_REFRESH_DAYS"))
+	if err != nil || refreshDays < 1 {
+		refreshDays = 7
+	}
+
+	return &analyticsService{
+		repo:          repo,
+		productClient: productClient,
+		userClient:    userClient,
+		location:      location,
+		cacheTTL:      time.Duration(cacheMinutes) * time.Minute,
+		refreshDays:   refreshDays,
+		cache:         make(map[string]analyticsCacheEntry),
+	}
+}
+
+// GetSellerAnalytics builds the seller dashboard for [from, to) and compares it
+// with the range of the same length just before it
+func (s *analyticsService) GetSellerAnalytics(sellerID string, request dto.GetSellerAnalyticsRequest) (*dto.SellerAnalyticsResponse, error) {
+	if !request.From.Before(request.To) {
+		return nil, appError.NewAppError(400, "invalid time range: 'from' must be before 'to'")
+	}
+	currency := model.NormalizeCurrency(request.Currency)
+	if !model.IsSupportedCurrency(currency) {
+		return nil, appError.NewAppError(400, "unsupported currency: "+request.Currency)
+	}
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultTopItemsLimit
+	}
+
+	current, err := s.sellerPeriod(sellerID, currency, request.From, request.To)
+	if err != nil {
+		return nil, err
+	}
+	previousFrom := request.From.Add(-request.To.Sub(request.From))
+	previous, err := s.sellerPeriod(sellerID, currency, previousFrom, request.From)
+	if err != nil {
+		return nil, err
+	}
+
+	top, err := s.repo.SellerTopItems(sellerID, currency, request.From, request.To, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
+
+	response := &dto.SellerAnalyticsResponse{
+		Currency: currency,
+		Current:  *current,
+		Previous: *previous,
+		Change: dto.SellerAnalyticsChange{
+			Revenue:           percentChange(float64(previous.Revenue), float64(current.Revenue)),
+			OrderCount:        percentChange(float64(previous.OrderCount), float64(current.OrderCount)),
+			AverageOrderValue: percentChange(float64(previous.AverageOrderValue), float64(current.AverageOrderValue)),
+			CancellationRate:  roundPercent(current.CancellationRate - previous.CancellationRate),
+			ReturnRate:        roundPercent(current.ReturnRate - previous.ReturnRate),
+		},
+		TopProducts: dto.TopItemsDto{
+			ByUnits:   convertTopItems(top.ProductsByUnits),
+			ByRevenue: convertTopItems(top.ProductsByRevenue),
+		},
+		TopVariants: dto.TopItemsDto{
+			ByUnits:   convertTopItems(top.VariantsByUnits),
+			ByRevenue: convertTopItems(top.VariantsByRevenue),
+		},
+	}
+
+	if request.Type != "" {
+		dateFormat := "%Y-%m-%d"
+		if request.Type == "month" {
+			dateFormat = "%Y-%m"
+		}
+		points, err := s.repo.SellerBreakdown(sellerID, currency, request.From, request.To, dateFormat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get breakdown: %w", err)
+		}
+		response.Breakdown = make([]dto.SellerAnalyticsPoint, 0, len(points))
+		for _, point := range points {
+			response.Breakdown = append(response.Breakdown, dto.SellerAnalyticsPoint{
+				Period:            point.Period,
+				OrderCount:        point.OrderCount,
+				RevenueOrderCount: point.RevenueOrderCount,
+				Revenue:           point.Revenue,
+			})
+		}
+	}
+
+	return response, nil
+}
+
+func (s *analyticsService) sellerPeriod(sellerID, currency string, from, to time.Time) (*dto.SellerAnalyticsPeriod, error) {
+	summary, err := s.repo.SellerSummary(sellerID, currency, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order summary: %w", err)
+	}
+	buyers, err := s.repo.SellerBuyers(sellerID, currency, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buyers: %w", err)
+	}
+
+	period := &dto.SellerAnalyticsPeriod{
+		From:              from,
+		To:                to,
+		OrderCount:        summary.OrderCount,
+		RevenueOrderCount: summary.RevenueOrderCount,
+		Revenue:           summary.Revenue,
+		CancelledCount:    summary.CancelledCount,
+		ReturnedCount:     summary.ReturnedCount,
+		NewBuyers:         buyers.NewBuyers,
+		RepeatBuyers:      buyers.RepeatBuyers,
+		VoucherOrderCount: summary.VoucherOrderCount,
+		VoucherRevenue:    summary.VoucherRevenue,
+	}
+	if summary.RevenueOrderCount > 0 {
+		period.AverageOrderValue = summary.Revenue / int64(summary.RevenueOrderCount)
+	}
+	if summary.OrderCount > 0 {
+		period.CancellationRate = roundPercent(100 * float64(summary.CancelledCount) / float64(summary.OrderCount))
+	}
+	if summary.DeliveredCount > 0 {
+		period.ReturnRate = roundPercent(100 * float64(summary.ReturnedCount) / float64(summary.DeliveredCount))
+	}
+	return period, nil
+}
+
+func convertTopItems(items []repository.TopItem) []dto.TopItemDto {
+	result := make([]dto.TopItemDto, 0, len(items))
+	for _, item := range items {
+		result = append(result, dto.TopItemDto{
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			ProductName: item.ProductName,
+			VariantName: item.VariantName,
+			SKU:         item.SKU,
+			Units:       item.Units,
+			Revenue:     item.Revenue,
+		})
+	}
+	return result
+}
+
+// percentChange returns the change from previous to current in percent, or nil
+// when there is nothing to compare with
+func percentChange(previous, current float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := roundPercent(100 * (current - previous) / previous)
+	return &change
+}
+
+// roundPercent rounds to two decimals
+func roundPercent(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// GetAdminOverview returns the platform dashboard for whole days. It is
+// aggregated from the orders and the other services, then cached for a while.
+func (s *analyticsService) GetAdminOverview(request dto.AdminAnalyticsRequest) (*dto.AdminOverviewResponse, error) {
+	from, to, currency, err := s.adminRange(request)
+	if err != nil {
+		return nil, err
+	}
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultTopItemsLimit
+	}
+
+	key := fmt.Sprintf("overview|%s|%s|%s|%d", request.From, request.To, currency, limit)
+	if cached, ok := s.cached(key); ok {
+		return cached.(*dto.AdminOverviewResponse), nil
+	}
+
+	summary, statuses, err := s.repo.PlatformSummary(currency, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order summary: %w", err)
+	}
+	sellers, err := s.repo.TopSellers(currency, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top sellers: %w", err)
+	}
+	categories, err := s.repo.CategorySales(currency, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category sales: %w", err)
+	}
+
+	response := &dto.AdminOverviewResponse{
+		From:              from.Format(dateLayout),
+		To:                to.AddDate(0, 0, -1).Format(dateLayout),
+		Currency:          currency,
+		OrderCount:        summary.OrderCount,
+		RevenueOrderCount: summary.RevenueOrderCount,
+		GMV:               summary.GMV,
+		Funnel:            make([]dto.StatusCountDto, 0, len(statuses)),
+		TopSellers:        make([]dto.TopSellerDto, 0, len(sellers)),
+		Categories:        make([]dto.CategoryPerformanceDto, 0, len(categories)),
+		GeneratedAt:       time.Now(),
+	}
+	if summary.RevenueOrderCount > 0 {
+		response.AverageOrderValue = summary.GMV / int64(summary.RevenueOrderCount)
+	}
+	for _, status := range statuses {
+		response.Funnel = append(response.Funnel, dto.StatusCountDto{Status: status.Status, Count: status.Count})
+	}
+	for _, seller := range sellers {
+		response.TopSellers = append(response.TopSellers, dto.TopSellerDto{
+			SellerID:   seller.SellerID,
+			SellerName: seller.SellerName,
+			OrderCount: seller.OrderCount,
+			GMV:        seller.GMV,
+		})
+	}
+
+	complete := true
+	categoryNames := make(map[string]string)
+	if all, err := s.productClient.GetCategories(); err == nil {
+		for _, category := range all {
+			categoryNames[category.ID] = category.Name
+		}
+	} else {
+		complete = false
+		fmt.Printf("Warning: failed to get category names for analytics: %v\n", err)
+	}
+	for _, category := range categories {
+		response.Categories = append(response.Categories, dto.CategoryPerformanceDto{
+			CategoryID:   category.CategoryID,
+			CategoryName: categoryNames[category.CategoryID],
+			Units:        category.Units,
+			Revenue:      category.Revenue,
+		})
+	}
+
+	if users, err := s.userClient.GetUserStats(from, to, s.location.String()); err == nil {
+		response.Users = &users.AdminUserStatsDto
+	} else {
+		complete = false
+		fmt.Printf("Warning: failed to get user stats for analytics: %v\n", err)
+	}
+	if catalog, err := s.productClient.GetPlatformStats(from, to, s.location.String()); err == nil {
+		response.Catalog = &catalog.AdminCatalogStatsDto
+	} else {
+		complete = false
+		fmt.Printf("Warning: failed to get catalog stats for analytics: %v\n", err)
+	}
+
+	// Don't keep a partial dashboard; the next request tries the other services again
+	if complete {
+		s.store(key, response)
+	}
+	return response, nil
+}
+
+// GetAdminDaily returns the platform totals of each day. Past days come from the
+// stored snapshots; missing ones are computed and stored, and today is computed
+// live and cached.
+func (s *analyticsService) GetAdminDaily(request dto.AdminAnalyticsRequest) (*dto.AdminDailyResponse, error) {
+	from, to, currency, err := s.adminRange(request)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("daily|%s|%s|%s", request.From, request.To, currency)
+	if cached, ok := s.cached(key); ok {
+		return cached.(*dto.AdminDailyResponse), nil
+	}
+
+	stored, err := s.repo.FindSnapshots(from.Format(dateLayout), to.AddDate(0, 0, -1).Format(dateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics snapshots: %w", err)
+	}
+	snapshots := make(map[string]model.AnalyticsSnapshot, len(stored))
+	for _, snapshot := range stored {
+		if snapshot.Complete {
+			snapshots[snapshot.ID] = snapshot
+		}
+	}
+
+	// Compute the span from the first to the last missing day in one pass
+	today := s.startOfDay(time.Now())
+	var missingFrom, missingTo time.Time
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if _, ok := snapshots[day.Format(dateLayout)]; ok {
+			continue
+		}
+		if missingFrom.IsZero() {
+			missingFrom = day
+		}
+		missingTo = day.AddDate(0, 0, 1)
+	}
+	if !missingFrom.IsZero() {
+		computed, err := s.buildSnapshots(missingFrom, missingTo)
+		if err != nil {
+			return nil, err
+		}
+		for i := range computed {
+			snapshot := &computed[i]
+			if _, ok := snapshots[snapshot.ID]; ok {
+				continue // stored days inside the span are kept
+			}
+			snapshots[snapshot.ID] = *snapshot
+			day, _ := time.ParseInLocation(dateLayout, snapshot.ID, s.location)
+			if !day.Before(today) {
+				continue // today is not over yet
+			}
+			if err := s.repo.UpsertSnapshot(snapshot); err != nil {
+				fmt.Printf("Warning: failed to store analytics snapshot %s: %v\n", snapshot.ID, err)
+			}
+		}
+	}
+
+	response := &dto.AdminDailyResponse{Currency: currency}
+	complete := true
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		snapshot := snapshots[day.Format(dateLayout)]
+		complete = complete && snapshot.Complete
+		sales := snapshot.SalesIn(currency)
+		response.Days = append(response.Days, dto.AdminDailyPoint{
+			Date:              day.Format(dateLayout),
+			OrderCount:        sales.OrderCount,
+			RevenueOrderCount: sales.RevenueOrderCount,
+			CancelledCount:    sales.CancelledCount,
+			GMV:               sales.GMV,
+			NewUsers:          snapshot.NewUsers,
+			NewSellers:        snapshot.NewSellers,
+			NewProducts:       snapshot.NewProducts,
+			NewReports:        snapshot.NewReports,
+		})
+	}
+
+	if complete {
+		s.store(key, response)
+	}
+	return response, nil
+}
+
+func (s *analyticsService) StartSnapshotJob(leases repository.JobLeaseRepository) {
+	go func() {
+		for {
+			// Shortly after midnight, once the previous day is over
+			now := time.Now().In(s.location)
+			next := s.startOfDay(now).AddDate(0, 0, 1).Add(15 * time.Minute)
+			time.Sleep(time.Until(next))
+
+			runJob(leases, "analytics-snapshots", 12*time.Hour, func() {
+				if err := s.refreshSnapshots(); err != nil {
+					fmt.Printf("Warning: failed to store analytics snapshots: %v\n", err)
+				}
+			})
+		}
+	}()
+}
+
+// refreshSnapshots stores the snapshots of the last refreshDays days. Orders
+// keep changing status for a while, so recent days are recomputed every night.
+func (s *analyticsService) refreshSnapshots() error {
+	to := s.startOfDay(time.Now())
+	from := to.AddDate(0, 0, -s.refreshDays)
+
+	snapshots, err := s.buildSnapshots(from, to)
+	if err != nil {
+		return err
+	}
+	for i := range snapshots {
+		if err := s.repo.UpsertSnapshot(&snapshots[i]); err != nil {
+			return fmt.Errorf("failed to store snapshot %s: %w", snapshots[i].ID, err)
+		}
+	}
+	fmt.Printf("Stored analytics snapshots from %s to %s\n", from.Format(dateLayout), to.AddDate(0, 0, -1).Format(dateLayout))
+	return nil
+}
+
+// buildSnapshots computes the snapshot of every day in [from, to). User and
+// product counts are best-effort; without them the snapshots are marked
+// incomplete and computed again on the next request.
+func (s *analyticsService) buildSnapshots(from, to time.Time) ([]model.AnalyticsSnapshot, error) {
+	timezone := s.location.String()
+	sales, err := s.repo.PlatformSalesByDay(from, to, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily sales: %w", err)
+	}
+
+	var snapshots []model.AnalyticsSnapshot
+	index := make(map[string]*model.AnalyticsSnapshot)
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		snapshots = append(snapshots, model.AnalyticsSnapshot{ID: day.Format(dateLayout), Sales: []model.DailySales{}, Complete: true})
+	}
+	for i := range snapshots {
+		index[snapshots[i].ID] = &snapshots[i]
+	}
+
+	for _, day := range sales {
+		if snapshot := index[day.Date]; snapshot != nil {
+			snapshot.Sales = append(snapshot.Sales, model.DailySales{
+				Currency:          day.Currency,
+				OrderCount:        day.OrderCount,
+				RevenueOrderCount: day.RevenueOrderCount,
+				CancelledCount:    day.CancelledCount,
+				GMV:               day.GMV,
+			})
+		}
+	}
+
+	if users, err := s.userClient.GetUserStats(from, to, timezone); err == nil {
+		for _, day := range users.Daily {
+			if snapshot := index[day.Date]; snapshot != nil {
+				snapshot.NewUsers = day.NewUsers
+				snapshot.NewSellers = day.NewSellers
+			}
+		}
+	} else {
+		markIncomplete(snapshots)
+		fmt.Printf("Warning: failed to get user stats for analytics: %v\n", err)
+	}
+	if catalog, err := s.productClient.GetPlatformStats(from, to, timezone); err == nil {
+		for _, day := range catalog.Daily {
+			if snapshot := index[day.Date]; snapshot != nil {
+				snapshot.NewProducts = day.NewProducts
+				snapshot.NewReports = day.NewReports
+			}
+		}
+	} else {
+		markIncomplete(snapshots)
+		fmt.Printf("Warning: failed to get catalog stats for analytics: %v\n", err)
+	}
+
+	return snapshots, nil
+}
+
+func markIncomplete(snapshots []model.AnalyticsSnapshot) {
+	for i := range snapshots {
+		snapshots[i].Complete = false
+	}
+}
+
+// adminRange parses the inclusive day range of a request into [from, to)
+func (s *analyticsService) adminRange(request dto.AdminAnalyticsRequest) (time.Time, time.Time, string, error) {
+	from, err := time.ParseInLocation(dateLayout, request.From, s.location)
+	if err != nil {
+		return time.Time{}, time.Time{}, "", appError.NewAppError(400, "invalid 'from' date, expected YYYY-MM-DD")
+	}
+	last, err := time.ParseInLocation(dateLayout, request.To, s.location)
+	if err != nil {
+		return time.Time{}, time.Time{}, "", appError.NewAppError(400, "invalid 'to' date, expected YYYY-MM-DD")
+	}
+	if last.Before(from) {
+		return time.Time{}, time.Time{}, "", appError.NewAppError(400, "invalid time range: 'from' must not be after 'to'")
+	}
+	to := last.AddDate(0, 0, 1)
+	if to.Sub(from) > maxAdminAnalyticsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, "", appError.NewAppError(400, fmt.Sprintf("the time range can't exceed %d days", maxAdminAnalyticsDays))
+	}
+
+	currency := model.NormalizeCurrency(request.Currency)
+	if !model.IsSupportedCurrency(currency) {
+		return time.Time{}, time.Time{}, "", appError.NewAppError(400, "unsupported currency: "+request.Currency)
+	}
+	return from, to, currency, nil
+}
+
+func (s *analyticsService) startOfDay(t time.Time) time.Time {
+	t = t.In(s.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+}
+
+func (s *analyticsService) cached(key string) (any, bool) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(s.cache, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (s *analyticsService) store(key string, value any) {
+	if s.cacheTTL <= 0 {
+		return
+	}
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	// Drop expired entries so the cache can't grow with every range ever asked for
+	now := time.Now()
+	for k, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = analyticsCacheEntry{value: value, expiresAt: now.Add(s.cacheTTL)}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+)
+
+// fakeAnalyticsRepository answers the seller queries from fixed totals keyed
+// by the start of the requested range
+type fakeAnalyticsRepository struct {
+	repository.AnalyticsRepository
+
+	summaries  map[time.Time]*repository.SellerPeriodSummary
+	buyers     map[time.Time]*repository.SellerBuyerCounts
+	top        *repository.SellerTopItems
+	points     []repository.SellerPeriodPoint
+	dateFormat string
+}
+
+func (f *fakeAnalyticsRepository) SellerSummary(sellerID, currency string, from, to time.Time) (*repository.SellerPeriodSummary, error) {
+	if summary, ok := f.summaries[from]; ok {
+		return summary, nil
+	}
+	return &repository.SellerPeriodSummary{}, nil
+}
+
+func (f *fakeAnalyticsRepository) SellerBuyers(sellerID, currency string, from, to time.Time) (*repository.SellerBuyerCounts, error) {
+	if buyers, ok := f.buyers[from]; ok {
+		return buyers, nil
+	}
+	return &repository.SellerBuyerCounts{}, nil
+}
+
+func (f *fakeAnalyticsRepository) SellerTopItems(sellerID, currency string, from, to time.Time, limit int) (*repository.SellerTopItems, error) {
+	if f.top == nil {
+		return &repository.SellerTopItems{}, nil
+	}
+	return f.top, nil
+}
+
+func (f *fakeAnalyticsRepository) SellerBreakdown(sellerID, currency string, from, to time.Time, dateFormat string) ([]repository.SellerPeriodPoint, error) {
+	f.dateFormat = dateFormat
+	return f.points, nil
+}
+
+func TestGetSellerAnalytics(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	previousFrom := from.AddDate(0, 0, -7)
+
+	tests := []struct {
+		name         string
+		current      *repository.SellerPeriodSummary
+		previous     *repository.SellerPeriodSummary
+		wantAOV      int64
+		wantCancel   float64
+		wantReturn   float64
+		wantRevenue  *float64
+		wantOrders   *float64
+		wantAOVDelta *float64
+		wantCancelPP float64
+	}{
+		{
+			name: "compared with the previous period",
+			current: &repository.SellerPeriodSummary{
+				OrderCount: 8, CancelledCount: 2, ReturnedCount: 1, DeliveredCount: 4,
+				RevenueOrderCount: 5, Revenue: 1500000,
+				VoucherOrderCount: 2, VoucherRevenue: 500000,
+			},
+			previous: &repository.SellerPeriodSummary{
+				OrderCount: 4, CancelledCount: 0, DeliveredCount: 3,
+				RevenueOrderCount: 4, Revenue: 1000000,
+			},
+			wantAOV:      300000,
+			wantCancel:   25,
+			wantReturn:   25,
+			wantRevenue:  ptr(50.0),
+			wantOrders:   ptr(100.0),
+			wantAOVDelta: ptr(20.0),
+			wantCancelPP: 25,
+		},
+		{
+			name: "nothing to compare with",
+			current: &repository.SellerPeriodSummary{
+				OrderCount: 3, CancelledCount: 1, RevenueOrderCount: 2, Revenue: 250001,
+			},
+			previous:     &repository.SellerPeriodSummary{},
+			wantAOV:      125000,
+			wantCancel:   33.33,
+			wantCancelPP: 33.33,
+		},
+		{
+			name: "only cancelled orders",
+			current: &repository.SellerPeriodSummary{
+				OrderCount: 2, CancelledCount: 2,
+			},
+			previous: &repository.SellerPeriodSummary{
+				OrderCount: 1, RevenueOrderCount: 1, Revenue: 200000, DeliveredCount: 1,
+			},
+			wantCancel:   100,
+			wantRevenue:  ptr(-100.0),
+			wantOrders:   ptr(100.0),
+			wantAOVDelta: ptr(-100.0),
+			wantCancelPP: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAnalyticsRepository{
+				summaries: map[time.Time]*repository.SellerPeriodSummary{from: tt.current, previousFrom: tt.previous},
+				buyers:    map[time.Time]*repository.SellerBuyerCounts{from: {NewBuyers: 3, RepeatBuyers: 2}},
+			}
+			service := NewAnalyticsService(repo, nil, nil)
+
+			response, err := service.GetSellerAnalytics("seller-1", dto.GetSellerAnalyticsRequest{From: from, To: to})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := response.Current
+			if response.Currency != model.DefaultCurrency {
+				t.Errorf("currency = %s, want %s", response.Currency, model.DefaultCurrency)
+			}
+			if current.Revenue != tt.current.Revenue || current.VoucherRevenue != tt.current.VoucherRevenue {
+				t.Errorf("revenue = %d (voucher %d), want %d (voucher %d)", current.Revenue, current.VoucherRevenue, tt.current.Revenue, tt.current.VoucherRevenue)
+			}
+			if current.AverageOrderValue != tt.wantAOV {
+				t.Errorf("average order value = %d, want %d", current.AverageOrderValue, tt.wantAOV)
+			}
+			if current.CancellationRate != tt.wantCancel {
+				t.Errorf("cancellation rate = %v, want %v", current.CancellationRate, tt.wantCancel)
+			}
+			if current.ReturnRate != tt.wantReturn {
+				t.Errorf("return rate = %v, want %v", current.ReturnRate, tt.wantReturn)
+			}
+			if current.NewBuyers != 3 || current.RepeatBuyers != 2 {
+				t.Errorf("buyers = %d new, %d repeat, want 3 and 2", current.NewBuyers, current.RepeatBuyers)
+			}
+			if !response.Previous.From.Equal(previousFrom) || !response.Previous.To.Equal(from) {
+				t.Errorf("previous period = [%v, %v), want [%v, %v)", response.Previous.From, response.Previous.To, previousFrom, from)
+			}
+
+			change := response.Change
+			assertChange(t, "revenue change", change.Revenue, tt.wantRevenue)
+			assertChange(t, "order count change", change.OrderCount, tt.wantOrders)
+			assertChange(t, "average order value change", change.AverageOrderValue, tt.wantAOVDelta)
+			if change.CancellationRate != tt.wantCancelPP {
+				t.Errorf("cancellation rate change = %v, want %v", change.CancellationRate, tt.wantCancelPP)
+			}
+		})
+	}
+}
+
+func TestGetSellerAnalyticsTopItemsAndBreakdown(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 3, 0)
+
+	repo := &fakeAnalyticsRepository{
+		top: &repository.SellerTopItems{
+			ProductsByUnits: []repository.TopItem{{ProductID: "p1", ProductName: "Tea", Units: 12, Revenue: 600000}},
+			VariantsByRevenue: []repository.TopItem{
+				{ProductID: "p2", VariantID: "v2", ProductName: "Coffee", VariantName: "1kg", SKU: "CF-1KG", Units: 2, Revenue: 900000},
+			},
+		},
+		points: []repository.SellerPeriodPoint{
+			{Period: "2026-01", OrderCount: 4, RevenueOrderCount: 3, Revenue: 700000},
+			{Period: "2026-02", OrderCount: 1},
+		},
+	}
+	service := NewAnalyticsService(repo, nil, nil)
+
+	response, err := service.GetSellerAnalytics("seller-1", dto.GetSellerAnalyticsRequest{From: from, To: to, Type: "month"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if repo.dateFormat != "%Y-%m" {
+		t.Errorf("breakdown date format = %q, want %%Y-%%m", repo.dateFormat)
+	}
+	if len(response.Breakdown) != 2 || response.Breakdown[0].Period != "2026-01" || response.Breakdown[0].Revenue != 700000 {
+		t.Errorf("unexpected breakdown: %+v", response.Breakdown)
+	}
+	if len(response.TopProducts.ByUnits) != 1 || response.TopProducts.ByUnits[0].Units != 12 {
+		t.Errorf("unexpected top products by units: %+v", response.TopProducts.ByUnits)
+	}
+	if response.TopProducts.ByRevenue == nil || len(response.TopProducts.ByRevenue) != 0 {
+		t.Errorf("empty ranking should be an empty list, got %#v", response.TopProducts.ByRevenue)
+	}
+	if got := response.TopVariants.ByRevenue; len(got) != 1 || got[0].SKU != "CF-1KG" || got[0].VariantName != "1kg" {
+		t.Errorf("unexpected top variants by revenue: %+v", got)
+	}
+}
+
+func TestGetSellerAnalyticsInvalidRequest(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		request dto.GetSellerAnalyticsRequest
+	}{
+		{name: "empty range", request: dto.GetSellerAnalyticsRequest{From: from, To: from}},
+		{name: "reversed range", request: dto.GetSellerAnalyticsRequest{From: from, To: from.AddDate(0, 0, -1)}},
+		{name: "unsupported currency", request: dto.GetSellerAnalyticsRequest{From: from, To: from.AddDate(0, 0, 1), Currency: "XYZ"}},
+	}
+
+	service := NewAnalyticsService(&fakeAnalyticsRepository{}, nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetSellerAnalytics("seller-1", tt.request)
+			var appErr *appError.AppError
+			if !errors.As(err, &appErr) || appErr.Code != http.StatusBadRequest {
+				t.Fatalf("got error %v, want a 400 app error", err)
+			}
+		})
+	}
+}
+
+func assertChange(t *testing.T, name string, got, want *float64) {
+	t.Helper()
+	switch {
+	case want == nil && got != nil:
+		t.Errorf("%s = %v, want none", name, *got)
+	case want != nil && got == nil:
+		t.Errorf("%s = none, want %v", name, *want)
+	case want != nil && *got != *want:
+		t.Errorf("%s = %v, want %v", name, *got, *want)
+	}
+}
+
+func ptr(value float64) *float64 {
+	return &value
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"fmt"
+	"order-service/client"
+	"order-service/dto"
+	"order-service/model"
+	"order-service/repository"
+	"order-service/utils"
+	"os"
+	"strconv"
+	"time"
+)
+
+type CartReminderService interface {
+	GetPreference(userID string) (*dto.CartReminderPreferenceResponse, error)
+	UpdatePreference(userID string, request dto.UpdateCartReminderPreferenceRequest) (*dto.CartReminderPreferenceResponse, error)
+	// StartReminderJob sends abandoned cart reminders every hour in the background
+	StartReminderJob(leases repository.JobLeaseRepository)
+}
+
+type cartReminderService struct {
+	repo               repository.CartReminderRepository
+	cartService        CartService
+	userClient         *client.UserServiceClient
+	notificationClient *client.NotificationServiceClient
+	remindAfter        time.Duration // carts untouched this long are abandoned
+	maxAge             time.Duration // carts untouched longer are not reminded about any more
+	minInterval        time.Duration // between two reminders to the same user
+}
+
+func NewCartReminderService(
+	repo repository.CartReminderRepository,
+	cartService CartService,
+	userClient *client.UserServiceClient,
+	notificationClient *client.NotificationServiceClient,
+) CartReminderService {
+	afterHours, err := strconv.Atoi(os.Getenv("CART_REMINDER_AFTER_HOURS"))
+	if err != nil || afterHours < 1 {
+		afterHours = 24
+	}
+	maxAgeDays, err := strconv.Atoi(os.Getenv("CART_REMINDER_MAX_AGE_DAYS"))
+	if err != nil || maxAgeDays < 1 {
+		maxAgeDays = 7
+	}
+	intervalDays, err := strconv.Atoi(os.Getenv("CART_REMINDER_MIN_INTERVAL_DAYS"))
+	if err != nil || intervalDays < 0 {
+		intervalDays = 3
+	}
+
+	return &cartReminderService{
+		repo:               repo,
+		cartService:        cartService,
+		userClient:         userClient,
+		notificationClient: notificationClient,
+		remindAfter:        time.Duration(afterHours) * time.Hour,
+		maxAge:             time.Duration(maxAgeDays) * 24 * time.Hour,
+		minInterval:        time.Duration(intervalDays) * 24 * time.Hour,
+	}
+}
+
+func (s *cartReminderService) GetPreference(userID string) (*dto.CartReminderPreferenceResponse, error) {
+	preference, err := s.repo.FindPreference(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.CartReminderPreferenceResponse{Enabled: preference == nil || !preference.OptedOut}, nil
+}
+
+func (s *cartReminderService) UpdatePreference(userID string, request dto.UpdateCartReminderPreferenceRequest) (*dto.CartReminderPreferenceResponse, error) {
+	preference := &model.CartReminderPreference{
+		UserID:   userID,
+		OptedOut: !*request.Enabled,
+	}
+	if err := s.repo.UpsertPreference(preference); err != nil {
+		return nil, err
+	}
+	return &dto.CartReminderPreferenceResponse{Enabled: *request.Enabled}, nil
+}
+
+func (s *cartReminderService) StartReminderJob(leases repository.JobLeaseRepository) {
+	go func() {
+		for {
+			// At the start of every hour
+			next := time.Now().Truncate(time.Hour).Add(time.Hour)
+			time.Sleep(time.Until(next))
+
+			runJob(leases, "cart-reminders", 30*time.Minute, s.sendReminders)
+		}
+	}()
+}
+
+// sendReminders reminds the owners of the carts that went stale within maxAge
+func (s *cartReminderService) sendReminders() {
+	now := time.Now()
+	carts, err := s.repo.FindStaleCarts(now.Add(-s.maxAge), now.Add(-s.remindAfter))
+	if err != nil {
+		fmt.Printf("Warning: failed to find abandoned carts: %v\n", err)
+		return
+	}
+
+	for _, cart := range carts {
+		if err := s.remind(cart); err != nil {
+			fmt.Printf("Warning: failed to send cart reminder to user %s: %v\n", cart.UserID, err)
+		}
+	}
+}
+
+// remind sends one reminder about a stale cart, unless the user opted out,
+// was already reminded about it recently, or has checked out since
+func (s *cartReminderService) remind(cart repository.StaleCart) error {
+	preference, err := s.repo.FindPreference(cart.UserID)
+	if err != nil {
+		return err
+	}
+	if preference != nil && preference.OptedOut {
+		return nil
+	}
+
+	latest, err := s.repo.FindLatestReminder(cart.UserID)
+	if err != nil {
+		return err
+	}
+	if latest != nil && (!latest.CartUpdatedAt.Before(cart.LastActivity) || time.Since(latest.SentAt) < s.minInterval) {
+		return nil
+	}
+
+	// Items left over after a checkout are not an abandoned cart
+	ordered, err := s.repo.HasOrderSince(cart.UserID, cart.LastActivity)
+	if err != nil {
+		return err
+	}
+	if ordered {
+		return nil
+	}
+
+	// Check the cart against live prices and stock, leaving the snapshot the
+	// user compares against untouched
+	items, err := s.cartService.PreviewCartItems(cart.UserID)
+	if err != nil {
+		return err
+	}
+
+	reminder := &model.CartReminder{
+		UserID:        cart.UserID,
+		CartUpdatedAt: cart.LastActivity,
+	}
+	var priceDrops, lowStock []string
+	for _, item := range items.CartItems {
+		if !item.Available {
+			continue
+		}
+		reminder.ItemCount++
+
+		if item.PriceChanged && item.PreviousCurrency == "" && item.UnitPrice < item.PreviousPrice {
+			priceDrops = append(priceDrops, fmt.Sprintf("%s: %s → %s", item.Product.Name,
+				model.NewMoney(int64(item.PreviousPrice), item.Currency),
+				model.NewMoney(int64(item.UnitPrice), item.Currency)))
+		}
+		if item.LowStock {
+			lowStock = append(lowStock, fmt.Sprintf("%s (còn %d)", item.Product.Name, item.Stock))
+		}
+	}
+	if reminder.ItemCount == 0 {
+		// Nothing left that can be bought
+		return nil
+	}
+	reminder.PriceDrops = len(priceDrops)
+	reminder.LowStock = len(lowStock)
+
+	// In-app notification
+	message := fmt.Sprintf("You have %d items waiting in your cart", reminder.ItemCount)
+	if reminder.PriceDrops > 0 {
+		message += fmt.Sprintf(", %d of them cheaper now", reminder.PriceDrops)
+	} else if reminder.LowStock > 0 {
+		message += fmt.Sprintf(", %d of them almost sold out", reminder.LowStock)
+	}
+	err = s.notificationClient.CreateNotification(client.CreateNotificationRequest{
+		UserID:  cart.UserID,
+		Type:    "promotion",
+		Title:   "Your cart is waiting",
+		Message: message,
+		Data: map[string]interface{}{
+			"itemCount":  reminder.ItemCount,
+			"priceDrops": reminder.PriceDrops,
+			"lowStock":   reminder.LowStock,
+		},
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to send cart reminder notification to user %s: %v\n", cart.UserID, err)
+	} else {
+		reminder.Channels = append(reminder.Channels, model.ReminderChannelNotification)
+	}
+
+	// Email
+	user, err := s.userClient.GetUserByID(cart.UserID)
+	if err != nil {
+		fmt.Printf("Warning: failed to get user %s for cart reminder email: %v\n", cart.UserID, err)
+	} else if user.Email != "" {
+		name := user.Name
+		if name == "" {
+			name = user.Username
+		}
+		body := utils.BuildCartReminderEmailContent(name, reminder.ItemCount, priceDrops, lowStock)
+		if err := utils.SendEmail([]string{user.Email}, "Giỏ hàng của bạn đang chờ", body); err != nil {
+			fmt.Printf("Warning: failed to send cart reminder email to user %s: %v\n", cart.UserID, err)
+		} else {
+			reminder.Channels = append(reminder.Channels, model.ReminderChannelEmail)
+		}
+	}
+
+	if len(reminder.Channels) == 0 {
+		// Try again on the next run
+		return fmt.Errorf("no channel accepted the reminder")
+	}
+	return s.repo.CreateReminder(reminder)
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"order-service/client"
 	"order-service/config"
@@ -8,31 +10,58 @@ import (
 	appError "order-service/error"
 	"order-service/model"
 	"order-service/repository"
+	"os"
+	"strconv"
+	"time"
 )
 
 type CartService interface {
 	AddCartItem(userID string, request dto.AddCartItemRequest) (*dto.CartItemResponse, error)
+	AddBundleCartItem(userID string, request dto.AddBundleCartItemRequest) (*dto.CartItemResponse, error)
 	DeleteCartItem(userID, cartItemID string) error
 	UpdateCartItemQuantity(userID, cartItemID string, quantity int) (*dto.CartItemResponse, error)
-	GetCartItems(userID string) ([]dto.CartItemDetailDto, error)
+	GetCartItems(userID string) (*dto.GetCartItemsResponse, error)
+	// PreviewCartItems checks the cart like GetCartItems without saving the
+	// fresh snapshot, so the price changes stay flagged for the user
+	PreviewCartItems(userID string) (*dto.GetCartItemsResponse, error)
 	GetCartItemCount(userID string) (int64, error)
+	CreateGuestCartToken() (*dto.GuestCartTokenResponse, error)
+	// MergeGuestCart moves a guest cart into the user's cart and deletes it
+	MergeGuestCart(userID, token string) (*dto.MergeGuestCartResponse, error)
 }
 
 type cartService struct {
-	repo          repository.CartRepository
-	productClient *client.ProductServiceClient
-	userClient    *client.UserServiceClient
+	repo              repository.CartRepository
+	productClient     *client.ProductServiceClient
+	userClient        *client.UserServiceClient
+	purchaseLimits    PurchaseLimitService
+	lowStockThreshold int // lines with this many units left or fewer are flagged
+	guestCartTTL      time.Duration
 }
 
 func NewCartService(
 	cartRepo repository.CartRepository,
 	productClient *client.ProductServiceClient,
 	userClient *client.UserServiceClient,
+	purchaseLimits PurchaseLimitService,
 ) CartService {
+	lowStockThreshold, err := strconv.Atoi(os.Getenv("CART_LOW_STOCK_THRESHOLD"))
+	if err != nil || lowStockThreshold < 0 {
+		lowStockThreshold = 5
+	}
+
+	guestCartDays, err := strconv.Atoi(os.Getenv("CART_GUEST_TTL_DAYS"))
+	if err != nil || guestCartDays < 1 {
+		guestCartDays = 30
+	}
+
 	return &cartService{
-		repo:          cartRepo,
-		productClient: productClient,
-		userClient:    userClient,
+		repo:              cartRepo,
+		productClient:     productClient,
+		userClient:        userClient,
+		purchaseLimits:    purchaseLimits,
+		lowStockThreshold: lowStockThreshold,
+		guestCartTTL:      time.Duration(guestCartDays) * 24 * time.Hour,
 	}
 }
 
@@ -47,18 +76,33 @@ func (s *cartService) AddCartItem(userID string, request dto.AddCartItemRequest)
 		return nil, appError.NewAppError(404, "variant not found")
 	}
 
-	stock := productVariants[0].Variant.Stock
+	stock := productVariants[0].Variant.MaxOrderable() // pre-orderable variants go beyond their stock
 	if stock < request.Quantity {
 		return nil, appError.NewAppError(409, "not enough stock")
 	}
 
-	
 	// Check if cart item with same variant already exists
 	existingItem, err := s.repo.FindCartItemByUserAndVariant(userID, request.VariantID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Purchase limits count what is already in the cart
+	cartQuantity := request.Quantity
+	if existingItem != nil {
+		cartQuantity += existingItem.Quantity
+	}
+	var cartItems []model.CartItem
+	if productVariants[0].PurchaseLimit != nil {
+		cartItems, err = s.repo.FindCartItemsByUser(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.purchaseLimits.CheckCart(userID, productVariants[0], cartQuantity, cartItems); err != nil {
+		return nil, err
+	}
+
 	// If exists, update quantity
 	if existingItem != nil {
 		newQuantity := existingItem.Quantity + request.Quantity
@@ -73,6 +117,7 @@ func (s *cartService) AddCartItem(userID string, request dto.AddCartItemRequest)
 
 		// Update the existing item's quantity for response
 		existingItem.Quantity = newQuantity
+		s.touchGuestCart(userID)
 		return dto.ToCartItemResponse(existingItem), nil
 	}
 
@@ -91,22 +136,7 @@ func (s *cartService) AddCartItem(userID string, request dto.AddCartItemRequest)
 			Username: seller.Username,
 			Image:    seller.Image,
 			Phone:    seller.Phone,
-			Address: model.Address{
-				FullName:    seller.Address.FullName,
-				Phone:       seller.Address.Phone,
-				AddressLine: seller.Address.AddressLine,
-				Ward:        seller.Address.Ward,
-				District:    seller.Address.District,
-				Province:    seller.Address.Province,
-				WardCode:    seller.Address.WardCode,
-				ProvinceCode: seller.Address.ProvinceCode,
-				DistrictID:  seller.Address.DistrictID,
-				ProvinceID:  seller.Address.ProvinceID,
-				Country:     seller.Address.Country,
-				Latitude:    seller.Address.Latitude,
-				Longitude:   seller.Address.Longitude,
-				Default:     seller.Address.Default,
-			},
+			Address:  toCartAddress(seller.Address),
 		},
 		Product: model.CartProduct{
 			ID:                request.ProductID,
@@ -118,9 +148,10 @@ func (s *cartService) AddCartItem(userID string, request dto.AddCartItemRequest)
 			ID:      request.VariantID,
 			SKU:     productVariants[0].Variant.SKU,
 			Options: productVariants[0].Variant.Options,
-			Price:   productVariants[0].Variant.Price,
-			Stock:   productVariants[0].Variant.Stock,
-			Image:   productVariants[0].Variant.Image,
+			Price:    productVariants[0].Variant.Price,
+			Currency: model.NormalizeCurrency(productVariants[0].Variant.Currency),
+			Stock:    productVariants[0].Variant.Stock,
+			Image:    productVariants[0].Variant.Image,
 		},
 		Quantity: request.Quantity,
 	}
@@ -137,11 +168,134 @@ func (s *cartService) AddCartItem(userID string, request dto.AddCartItemRequest)
 	}
 
 	// Publish add_to_cart interaction to ai-service via RabbitMQ (best-effort)
-	go config.PublishUserInteraction(userID, request.ProductID, "add_to_cart", 10)
+	if model.IsGuestCartOwner(userID) {
+		s.touchGuestCart(userID)
+	} else {
+		go config.PublishUserInteraction(userID, request.ProductID, "add_to_cart", 10)
+	}
+
+	return dto.ToCartItemResponse(newItem), nil
+}
+
+func (s *cartService) AddBundleCartItem(userID string, request dto.AddBundleCartItemRequest) (*dto.CartItemResponse, error) {
+	//get bundle information
+	bundle, err := s.productClient.GetBundleByID(request.BundleID)
+	if err != nil {
+		return nil, appError.NewAppError(500, "failed to get bundle details")
+	}
+
+	if bundle == nil || !bundle.IsActive {
+		return nil, appError.NewAppError(404, "bundle not found")
+	}
+
+	if bundle.Stock < request.Quantity {
+		return nil, appError.NewAppError(409, "not enough stock")
+	}
+
+	// Check if the same bundle is already in the cart
+	existingItem, err := s.repo.FindCartItemByUserAndBundle(userID, request.BundleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// If exists, update quantity
+	if existingItem != nil {
+		newQuantity := existingItem.Quantity + request.Quantity
+		if newQuantity > bundle.Stock {
+			return nil, appError.NewAppError(409, "not enough stock")
+		}
+		err = s.repo.UpdateCartItemQuantity(existingItem.ID, newQuantity)
+		if err != nil {
+			return nil, err
+		}
+
+		existingItem.Quantity = newQuantity
+		s.touchGuestCart(userID)
+		return dto.ToCartItemResponse(existingItem), nil
+	}
+
+	// Fetch seller info
+	seller, err := s.userClient.GetUserByID(bundle.SellerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller info: %w", err)
+	}
+
+	bundleItems := make([]model.CartBundleItem, len(bundle.Components))
+	for i, component := range bundle.Components {
+		bundleItems[i] = model.CartBundleItem{
+			ProductID: component.ProductID,
+			VariantID: component.Variant.ID,
+			Quantity:  component.Quantity,
+		}
+	}
+
+	newItem := &model.CartItem{
+		UserID: userID,
+		Seller: model.CartSeller{
+			ID:       seller.ID,
+			Name:     seller.Name,
+			Username: seller.Username,
+			Image:    seller.Image,
+			Phone:    seller.Phone,
+			Address:  toCartAddress(seller.Address),
+		},
+		Product: model.CartProduct{
+			Name:     bundle.Name,
+			SellerID: bundle.SellerID,
+		},
+		Bundle: &model.CartBundle{
+			ID:       bundle.ID,
+			Name:     bundle.Name,
+			Image:    bundle.Image,
+			Price:    bundle.Price,
+			Currency: model.NormalizeCurrency(bundle.Currency),
+			Items:    bundleItems,
+		},
+		Quantity: request.Quantity,
+	}
+
+	// Validate the cart item
+	if err := newItem.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Create the cart item
+	err = s.repo.CreateCartItem(newItem)
+	if err != nil {
+		return nil, err
+	}
+
+	// Publish add_to_cart interactions for every bundled product (best-effort)
+	if model.IsGuestCartOwner(userID) {
+		s.touchGuestCart(userID)
+	} else {
+		for _, item := range bundleItems {
+			go config.PublishUserInteraction(userID, item.ProductID, "add_to_cart", 10)
+		}
+	}
 
 	return dto.ToCartItemResponse(newItem), nil
 }
 
+func toCartAddress(address dto.Address) model.Address {
+	return model.Address{
+		FullName:     address.FullName,
+		Phone:        address.Phone,
+		AddressLine:  address.AddressLine,
+		Ward:         address.Ward,
+		District:     address.District,
+		Province:     address.Province,
+		WardCode:     address.WardCode,
+		ProvinceCode: address.ProvinceCode,
+		DistrictID:   address.DistrictID,
+		ProvinceID:   address.ProvinceID,
+		Country:      address.Country,
+		Latitude:     address.Latitude,
+		Longitude:    address.Longitude,
+		Default:      address.Default,
+	}
+}
+
 func (s *cartService) DeleteCartItem(userID, cartItemID string) error {
 	// Find the cart item by ID
 	cartItem, err := s.repo.FindCartItemByID(cartItemID)
@@ -180,17 +334,29 @@ func (s *cartService) UpdateCartItemQuantity(userID, cartItemID string, quantity
 		return nil, appError.NewAppError(401, "unauthorized: you can only update your own cart items")
 	}
 
-	//get variant information to validate stock
-	productVariants, err := s.productClient.GetVariantsByIds([]string{cartItem.Variant.ID})
-	if err != nil {
-		return nil, appError.NewAppError(500, "failed to get variant details")
-	}
+	//get variant (or bundle) information to validate stock
+	var stock int // units that can be ordered
+	if cartItem.IsBundle() {
+		bundle, err := s.productClient.GetBundleByID(cartItem.Bundle.ID)
+		if err != nil {
+			return nil, appError.NewAppError(500, "failed to get bundle details")
+		}
+		if bundle == nil || !bundle.IsActive {
+			return nil, appError.NewAppError(404, "bundle not found")
+		}
+		stock = bundle.Stock
+	} else {
+		productVariants, err := s.productClient.GetVariantsByIds([]string{cartItem.Variant.ID})
+		if err != nil {
+			return nil, appError.NewAppError(500, "failed to get variant details")
+		}
 
-	if len(productVariants) == 0 {
-		return nil, appError.NewAppError(404, "variant not found")
+		if len(productVariants) == 0 {
+			return nil, appError.NewAppError(404, "variant not found")
+		}
+		stock = productVariants[0].Variant.MaxOrderable()
 	}
 
-	stock := productVariants[0].Variant.Stock
 	if stock < quantity {
 		return nil, appError.NewAppError(409, "not enough stock")
 	}
@@ -203,29 +369,50 @@ func (s *cartService) UpdateCartItemQuantity(userID, cartItemID string, quantity
 
 	// Update the cart item for response
 	cartItem.Quantity = quantity
+	s.touchGuestCart(userID)
 	return dto.ToCartItemResponse(cartItem), nil
 }
 
-func (s *cartService) GetCartItems(userID string) ([]dto.CartItemDetailDto, error) {
+// GetCartItems returns the cart checked against live product data. Every line
+// says whether its price changed and whether it can still be bought; the new
+// prices and stock are saved on the cart lines for the next comparison.
+func (s *cartService) GetCartItems(userID string) (*dto.GetCartItemsResponse, error) {
+	return s.getCartItems(userID, true)
+}
+
+func (s *cartService) PreviewCartItems(userID string) (*dto.GetCartItemsResponse, error) {
+	return s.getCartItems(userID, false)
+}
+
+func (s *cartService) getCartItems(userID string, save bool) (*dto.GetCartItemsResponse, error) {
 	// Get cart items from repository
 	cartItems, err := s.repo.FindCartItemsByUser(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// If no cart items, return empty array
+	// If no cart items, return empty arrays
 	if len(cartItems) == 0 {
-		return []dto.CartItemDetailDto{}, nil
+		return &dto.GetCartItemsResponse{
+			CartItems: []dto.CartItemDetailDto{},
+			Sellers:   []dto.CartSellerSubtotalDto{},
+		}, nil
 	}
 
-	// Extract variant IDs
-	variantIDs := make([]string, len(cartItems))
-	for i, item := range cartItems {
-		variantIDs[i] = item.Variant.ID
+	// Extract variant and bundle IDs
+	variantIDs := make([]string, 0, len(cartItems))
+	var bundleIDs []string
+	for _, item := range cartItems {
+		if item.IsBundle() {
+			bundleIDs = append(bundleIDs, item.Bundle.ID)
+		} else {
+			variantIDs = append(variantIDs, item.Variant.ID)
+		}
 	}
 
-	// Get variant details from product-service
-	productVariants, err := s.productClient.GetVariantsByIds(variantIDs)
+	// Get variant details from product-service in one batch, disabled products
+	// included so they can be told apart from deleted variants
+	productVariants, err := s.productClient.GetVariantsByIdsIncludingDisabled(variantIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get variant details: %w", err)
 	}
@@ -236,16 +423,29 @@ func (s *cartService) GetCartItems(userID string) ([]dto.CartItemDetailDto, erro
 		variantMap[pv.Variant.ID] = pv
 	}
 
+	// Bundles in one batch too; without them the bundle lines are left
+	// unchecked rather than failing the whole cart
+	bundleMap := make(map[string]dto.BundleDto)
+	bundles, bundlesErr := s.productClient.GetBundlesByIds(bundleIDs)
+	if bundlesErr != nil {
+		fmt.Printf("Warning: failed to get bundles: %v\n", bundlesErr)
+	}
+	for _, bundle := range bundles {
+		bundleMap[bundle.ID] = bundle
+	}
+
 	// Build enriched cart item details
 	result := make([]dto.CartItemDetailDto, 0, len(cartItems))
-	for _, item := range cartItems {
-		productVariant, exists := variantMap[item.Variant.ID]
+	var subtotals []dto.CartSellerSubtotalDto
+	subtotalIndex := make(map[string]int)
+	for i := range cartItems {
+		item := &cartItems[i]
 
 		// Create cart item detail
 		cartItemDetail := dto.CartItemDetailDto{
-			ID:       item.ID,
-			UserID:   item.UserID,
-			Seller:   item.Seller,
+			ID:     item.ID,
+			UserID: item.UserID,
+			Seller: item.Seller,
 			Product: dto.CartProductDto{
 				ID:                item.Product.ID,
 				Name:              item.Product.Name,
@@ -258,18 +458,321 @@ func (s *cartService) GetCartItems(userID string) ([]dto.CartItemDetailDto, erro
 			UpdatedAt: item.UpdatedAt,
 		}
 
-		// Add product info if available
-		if exists {
-			cartItemDetail.Variant = productVariant.Variant
+		var changed bool
+		if item.IsBundle() {
+			if bundlesErr != nil {
+				result = append(result, cartItemDetail)
+				continue
+			}
+			var live *dto.BundleDto
+			if bundle, exists := bundleMap[item.Bundle.ID]; exists {
+				live = &bundle
+			}
+			cartItemDetail.Bundle = live
+			changed = s.revalidateBundle(item, live, &cartItemDetail)
+		} else {
+			productVariant, exists := variantMap[item.Variant.ID]
+			var live *dto.ProductVariantDto
+			if exists {
+				live = &productVariant
+				cartItemDetail.Variant = productVariant.Variant
+			}
+			changed = s.revalidateVariant(item, live, &cartItemDetail)
+		}
+
+		// Save the fresh snapshot (best-effort)
+		if changed && save {
+			if err := s.repo.UpdateCartItemSnapshot(item); err != nil {
+				fmt.Printf("Warning: failed to refresh cart item %s: %v\n", item.ID, err)
+			}
+		}
+
+		if cartItemDetail.Available {
+			key := item.Seller.ID + ":" + cartItemDetail.Currency
+			index, ok := subtotalIndex[key]
+			if !ok {
+				index = len(subtotals)
+				subtotalIndex[key] = index
+				subtotals = append(subtotals, dto.CartSellerSubtotalDto{
+					SellerID:   item.Seller.ID,
+					SellerName: item.Seller.Name,
+					Currency:   cartItemDetail.Currency,
+				})
+			}
+			subtotals[index].ItemCount += item.Quantity
+			subtotals[index].Subtotal += cartItemDetail.LineTotal
 		}
 
 		result = append(result, cartItemDetail)
 	}
 
-	return result, nil
+	if subtotals == nil {
+		subtotals = []dto.CartSellerSubtotalDto{}
+	}
+	s.touchGuestCart(userID)
+	return &dto.GetCartItemsResponse{CartItems: result, Sellers: subtotals}, nil
+}
+
+// revalidateVariant compares a variant line with the live variant, nil when it
+// was deleted, fills the line flags and refreshes the snapshot on the item. It
+// reports whether the snapshot changed.
+func (s *cartService) revalidateVariant(item *model.CartItem, live *dto.ProductVariantDto, detail *dto.CartItemDetailDto) bool {
+	detail.Currency = model.NormalizeCurrency(item.Variant.Currency)
+	detail.UnitPrice = item.Variant.Price
+	if live == nil {
+		detail.VariantDeleted = true
+		return false
+	}
+
+	variant := live.Variant
+	detail.Currency = model.NormalizeCurrency(variant.Currency)
+	detail.UnitPrice = variant.Price
+	detail.LineTotal = int64(variant.Price) * int64(item.Quantity)
+	detail.Stock = variant.Stock
+	detail.ProductDisabled = live.IsDisabled
+	detail.PreOrder = variant.IsPreOrder(item.Quantity)
+	s.setStockFlags(detail, item.Quantity, variant.MaxOrderable())
+	setPriceChange(detail, item.Variant.Price, item.Variant.Currency)
+
+	snapshot := model.CartVariant{
+		ID:       variant.ID,
+		SKU:      variant.SKU,
+		Options:  variant.Options,
+		Price:    variant.Price,
+		Currency: detail.Currency,
+		Stock:    variant.Stock,
+		Image:    variant.Image,
+	}
+	changed := detail.PriceChanged || item.Variant.Stock != snapshot.Stock ||
+		item.Variant.SKU != snapshot.SKU || item.Variant.Image != snapshot.Image ||
+		item.Product.Name != live.ProductName
+	item.Variant = snapshot
+	item.Product.Name = live.ProductName
+	item.Product.SellerCategoryIDs = live.SellerCategoryIds
+	return changed
+}
+
+// revalidateBundle does the same for a bundle line; nil means the bundle was deleted
+func (s *cartService) revalidateBundle(item *model.CartItem, bundle *dto.BundleDto, detail *dto.CartItemDetailDto) bool {
+	detail.Currency = model.NormalizeCurrency(item.Bundle.Currency)
+	detail.UnitPrice = item.Bundle.Price
+	if bundle == nil {
+		detail.VariantDeleted = true
+		return false
+	}
+
+	detail.Currency = model.NormalizeCurrency(bundle.Currency)
+	detail.UnitPrice = bundle.Price
+	detail.LineTotal = int64(bundle.Price) * int64(item.Quantity)
+	detail.Stock = bundle.Stock
+	detail.ProductDisabled = !bundle.IsActive
+	s.setStockFlags(detail, item.Quantity, detail.Stock)
+	setPriceChange(detail, item.Bundle.Price, item.Bundle.Currency)
+
+	changed := detail.PriceChanged || item.Bundle.Name != bundle.Name || item.Bundle.Image != bundle.Image
+	item.Bundle.Price = bundle.Price
+	item.Bundle.Currency = detail.Currency
+	item.Bundle.Name = bundle.Name
+	item.Bundle.Image = bundle.Image
+	item.Product.Name = bundle.Name
+	return changed
+}
+
+// setPriceChange flags the line when its live price differs from the snapshot
+// price, a change of currency included
+func setPriceChange(detail *dto.CartItemDetailDto, previousPrice int, previousCurrency string) {
+	previousCurrency = model.NormalizeCurrency(previousCurrency)
+	if detail.UnitPrice == previousPrice && detail.Currency == previousCurrency {
+		return
+	}
+	detail.PriceChanged = true
+	detail.PreviousPrice = previousPrice
+	if detail.Currency != previousCurrency {
+		detail.PreviousCurrency = previousCurrency
+	}
+}
+
+// setStockFlags flags the line from its live stock; maxOrderable differs from
+// the stock for variants that can be pre-ordered
+func (s *cartService) setStockFlags(detail *dto.CartItemDetailDto, quantity, maxOrderable int) {
+	detail.OutOfStock = detail.Stock <= 0
+	detail.LowStock = !detail.OutOfStock && !detail.PreOrder && (detail.Stock <= s.lowStockThreshold || detail.Stock < quantity)
+	detail.Available = maxOrderable > 0 && !detail.ProductDisabled && !detail.VariantDeleted && maxOrderable >= quantity
 }
 
 func (s *cartService) GetCartItemCount(userID string) (int64, error) {
 	return s.repo.GetCartItemCount(userID)
 }
 
+// CreateGuestCartToken hands out a token for a new guest cart. The cart itself
+// is created with its first item.
+func (s *cartService) CreateGuestCartToken() (*dto.GuestCartTokenResponse, error) {
+	token := make([]byte, model.GuestCartTokenLength/2)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate cart token: %w", err)
+	}
+
+	return &dto.GuestCartTokenResponse{
+		CartToken: hex.EncodeToString(token),
+		ExpiresIn: int64(s.guestCartTTL.Seconds()),
+	}, nil
+}
+
+// touchGuestCart pushes back the expiry of a guest cart after it was used
+// (best-effort). User carts don't expire.
+func (s *cartService) touchGuestCart(owner string) {
+	if !model.IsGuestCartOwner(owner) {
+		return
+	}
+	if err := s.repo.ExtendCartExpiry(owner, time.Now().Add(s.guestCartTTL)); err != nil {
+		fmt.Printf("Warning: failed to extend guest cart expiry: %v\n", err)
+	}
+}
+
+// MergeGuestCart adds every guest cart line to the user's cart. Quantities of
+// lines already in the user's cart are added up; either way they are capped by
+// the live stock and the purchase limits, and lines that are no longer
+// available are dropped. Each
+// guest line is deleted once merged, so a failed merge can simply be retried.
+func (s *cartService) MergeGuestCart(userID, token string) (*dto.MergeGuestCartResponse, error) {
+	if !model.IsValidGuestCartToken(token) {
+		return nil, appError.NewAppError(400, "invalid cart token")
+	}
+	owner := model.GuestCartOwner(token)
+
+	guestItems, err := s.repo.FindCartItemsByUser(owner)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.MergeGuestCartResponse{}
+	if len(guestItems) == 0 {
+		return response, nil
+	}
+
+	// Live stock of the variant lines in one batch; disabled products are left out
+	variantIDs := make([]string, 0, len(guestItems))
+	var bundleIDs []string
+	for _, item := range guestItems {
+		if item.IsBundle() {
+			bundleIDs = append(bundleIDs, item.Bundle.ID)
+		} else {
+			variantIDs = append(variantIDs, item.Variant.ID)
+		}
+	}
+	productVariants, err := s.productClient.GetVariantsByIds(variantIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant details: %w", err)
+	}
+	variantsByID := make(map[string]dto.ProductVariantDto, len(productVariants))
+	limited := false
+	for _, pv := range productVariants {
+		variantsByID[pv.Variant.ID] = pv
+		if pv.Variant.PurchaseLimit != nil || pv.PurchaseLimit != nil {
+			limited = true
+		}
+	}
+
+	// And of the bundle lines
+	bundles, err := s.productClient.GetBundlesByIds(bundleIDs)
+	if err != nil {
+		return nil, appError.NewAppError(500, "failed to get bundle details")
+	}
+	bundlesByID := make(map[string]dto.BundleDto, len(bundles))
+	for _, bundle := range bundles {
+		bundlesByID[bundle.ID] = bundle
+	}
+
+	// Purchase limits count the user's other cart lines, kept up to date below
+	var cartItems []model.CartItem
+	if limited {
+		cartItems, err = s.repo.FindCartItemsByUser(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range guestItems {
+		item := &guestItems[i]
+
+		var stock int
+		var existing *model.CartItem
+		if item.IsBundle() {
+			if bundle, ok := bundlesByID[item.Bundle.ID]; ok && bundle.IsActive {
+				stock = bundle.Stock
+			}
+			existing, err = s.repo.FindCartItemByUserAndBundle(userID, item.Bundle.ID)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			variant, ok := variantsByID[item.Variant.ID]
+			if ok {
+				stock = variant.Variant.MaxOrderable()
+				limit, err := s.purchaseLimits.MaxCartQuantity(userID, variant, cartItems)
+				if err != nil {
+					return nil, err
+				}
+				if limit >= 0 {
+					stock = min(stock, limit)
+				}
+			}
+			existing, err = s.repo.FindCartItemByUserAndVariant(userID, item.Variant.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		current := 0
+		if existing != nil {
+			current = existing.Quantity
+		}
+		quantity := current + item.Quantity
+		if quantity > stock {
+			quantity = stock
+		}
+
+		switch {
+		case quantity <= current:
+			response.DroppedItems++
+		case existing != nil:
+			if err := s.repo.UpdateCartItemQuantity(existing.ID, quantity); err != nil {
+				return nil, err
+			}
+			for j := range cartItems {
+				if cartItems[j].ID == existing.ID {
+					cartItems[j].Quantity = quantity
+				}
+			}
+		default:
+			merged := *item
+			merged.ID = ""
+			merged.UserID = userID
+			merged.Quantity = quantity
+			merged.ExpiresAt = nil
+			merged.CreatedAt = time.Time{}
+			merged.UpdatedAt = time.Time{}
+			if err := s.repo.CreateCartItem(&merged); err != nil {
+				return nil, err
+			}
+			if limited {
+				cartItems = append(cartItems, merged)
+			}
+		}
+		if quantity > current {
+			response.MergedItems++
+			if quantity-current < item.Quantity {
+				response.CappedItems++
+			}
+		}
+
+		if err := s.repo.DeleteCartItem(item.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Anything added to the guest cart meanwhile goes too
+	if err := s.repo.DeleteCartItemsByUser(owner); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
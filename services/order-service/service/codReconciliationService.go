@@ -0,0 +1,389 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column names accepted in settlement reports, in GHN's English and Vietnamese
+// exports and our own template
+var (
+	codCodeColumns   = []string{"order_code", "tracking_code", "delivery_code", "mã đơn hàng", "mã vận đơn"}
+	codAmountColumns = []string{"cod_amount", "cod", "amount", "tiền thu hộ", "tiền cod"}
+	codDateColumns   = []string{"settled_at", "date", "ngày đối soát"}
+)
+
+var codDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "02/01/2006 15:04:05", "02/01/2006 15:04", "02/01/2006"}
+
+type CodReconciliationService interface {
+	// ImportSettlement matches a carrier settlement report (CSV) with the COD
+	// parcels and marks the orders whose cash was fully remitted as paid
+	ImportSettlement(adminID string, fileName string, file io.Reader) (*dto.CodSettlementDetailResponse, error)
+	GetSettlements(request dto.GetCodSettlementsRequest) (*dto.GetCodSettlementsResponse, error)
+	GetSettlement(settlementID string, request dto.GetCodSettlementRequest) (*dto.CodSettlementDetailResponse, error)
+	// GetMissingRemittances lists the COD parcels delivered longer ago than the
+	// carrier takes to remit that no settlement report confirmed
+	GetMissingRemittances(request dto.GetMissingRemittancesRequest) (*dto.GetMissingRemittancesResponse, error)
+}
+
+type codReconciliationService struct {
+	repo           repository.CodReconciliationRepository
+	orderRepo      repository.OrderRepository
+	remittanceDays int
+}
+
+func NewCodReconciliationService(repo repository.CodReconciliationRepository, orderRepo repository.OrderRepository) CodReconciliationService {
+	remittanceDays, err := strconv.Atoi(os.Getenv("COD_REMITTANCE_DAYS"))
+	if err != nil || remittanceDays < 0 {
+		remittanceDays = 7
+	}
+
+	return &codReconciliationService{
+		repo:           repo,
+		orderRepo:      orderRepo,
+		remittanceDays: remittanceDays,
+	}
+}
+
+func (s *codReconciliationService) ImportSettlement(adminID string, fileName string, file io.Reader) (*dto.CodSettlementDetailResponse, error) {
+	// The whole report is checked before anything is matched
+	lines, err := parseSettlementReport(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, appError.NewAppError(400, "The settlement report has no lines")
+	}
+
+	settlement := &model.CodSettlement{
+		Carrier:    model.CarrierGHN,
+		FileName:   fileName,
+		ImportedBy: adminID,
+		ImportedAt: time.Now(),
+	}
+
+	orders := make(map[string]*model.Order)
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		line.Carrier = settlement.Carrier
+		settlement.TotalAmount += int64(line.Amount)
+
+		if err := s.matchLine(line, seen, orders); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.CreateSettlement(settlement, lines); err != nil {
+		return nil, fmt.Errorf("failed to save settlement: %w", err)
+	}
+
+	// Each parcel is marked remitted on its own, so the orders are never
+	// written back whole over changes made since they were loaded
+	remitted := make(map[string]bool)
+	for _, line := range lines {
+		if line.Status != model.CodLineMatched {
+			continue
+		}
+		remittedAt := settlement.ImportedAt
+		if line.SettledAt != nil {
+			remittedAt = *line.SettledAt
+		}
+		marked, err := s.repo.MarkShipmentRemitted(line.OrderID, line.ShipmentID, remittedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record remittance of order %s: %w", line.OrderID, err)
+		}
+		if marked {
+			remitted[line.OrderID] = true
+		}
+	}
+
+	for orderID := range remitted {
+		order, err := s.orderRepo.FindOrderByID(orderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order %s: %w", orderID, err)
+		}
+		if order == nil || !order.CodRemitted() || order.PaymentStatus == "PAID" {
+			continue
+		}
+		if err := s.repo.MarkCodOrderPaid(orderID); err != nil {
+			return nil, fmt.Errorf("failed to mark order %s as paid: %w", orderID, err)
+		}
+	}
+
+	return &dto.CodSettlementDetailResponse{
+		Settlement: settlement,
+		Lines:      lines,
+	}, nil
+}
+
+// matchLine finds the parcel of a settlement line and sets the line status
+func (s *codReconciliationService) matchLine(line *model.CodSettlementLine, seen map[string]bool, orders map[string]*model.Order) error {
+	if seen[line.TrackingCode] {
+		line.Status = model.CodLineDuplicate
+		return nil
+	}
+	seen[line.TrackingCode] = true
+
+	var order *model.Order
+	for _, loaded := range orders {
+		if loaded.FindShipmentByTrackingCode(line.TrackingCode) != nil {
+			order = loaded
+			break
+		}
+	}
+	if order == nil {
+		found, err := s.repo.FindOrderByTrackingCode(line.TrackingCode)
+		if err != nil {
+			return fmt.Errorf("failed to find parcel %s: %w", line.TrackingCode, err)
+		}
+		if found != nil {
+			order = found
+			orders[order.ID] = order
+		}
+	}
+
+	var shipment *model.Shipment
+	if order != nil {
+		shipment = order.FindShipmentByTrackingCode(line.TrackingCode)
+	}
+	if shipment == nil || shipment.Carrier != line.Carrier {
+		line.Status = model.CodLineUnknown
+		return nil
+	}
+
+	line.OrderID = order.ID
+	line.ShipmentID = shipment.ID
+	line.Expected = shipment.CodAmount
+	switch {
+	case shipment.CodRemittedAt != nil:
+		line.Status = model.CodLineDuplicate
+	case line.Amount != shipment.CodAmount:
+		line.Status = model.CodLineMismatched
+	default:
+		line.Status = model.CodLineMatched
+	}
+	return nil
+}
+
+func (s *codReconciliationService) GetSettlements(request dto.GetCodSettlementsRequest) (*dto.GetCodSettlementsResponse, error) {
+	page, limit := codPage(request.Page, request.Limit)
+
+	settlements, totalCount, err := s.repo.FindSettlements(page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settlements: %w", err)
+	}
+	if settlements == nil {
+		settlements = []*model.CodSettlement{}
+	}
+
+	return &dto.GetCodSettlementsResponse{
+		Settlements: settlements,
+		TotalCount:  totalCount,
+		Page:        page,
+		Limit:       limit,
+		TotalPages:  codTotalPages(totalCount, limit),
+	}, nil
+}
+
+func (s *codReconciliationService) GetSettlement(settlementID string, request dto.GetCodSettlementRequest) (*dto.CodSettlementDetailResponse, error) {
+	settlement, err := s.repo.FindSettlementByID(settlementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settlement: %w", err)
+	}
+	if settlement == nil {
+		return nil, appError.NewAppError(404, "Settlement not found")
+	}
+
+	lines, err := s.repo.FindLines(settlementID, request.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settlement lines: %w", err)
+	}
+	if lines == nil {
+		lines = []*model.CodSettlementLine{}
+	}
+
+	return &dto.CodSettlementDetailResponse{
+		Settlement: settlement,
+		Lines:      lines,
+	}, nil
+}
+
+func (s *codReconciliationService) GetMissingRemittances(request dto.GetMissingRemittancesRequest) (*dto.GetMissingRemittancesResponse, error) {
+	page, limit := codPage(request.Page, request.Limit)
+	deliveredBefore := time.Now().AddDate(0, 0, -s.remittanceDays)
+
+	orders, totalCount, err := s.repo.FindUnremittedOrders(deliveredBefore, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get missing remittances: %w", err)
+	}
+
+	parcels := []dto.MissingRemittanceDto{}
+	for _, order := range orders {
+		for _, shipment := range order.Shipments {
+			if shipment.Status != model.ShipmentDelivered || shipment.CodAmount <= 0 || shipment.CodRemittedAt != nil ||
+				shipment.DeliveredAt == nil || !shipment.DeliveredAt.Before(deliveredBefore) {
+				continue
+			}
+			parcels = append(parcels, dto.MissingRemittanceDto{
+				OrderID:      order.ID,
+				SellerID:     order.Seller.ID,
+				ShipmentID:   shipment.ID,
+				Carrier:      shipment.Carrier,
+				TrackingCode: shipment.TrackingCode,
+				CodAmount:    shipment.CodAmount,
+				DeliveredAt:  *shipment.DeliveredAt,
+			})
+		}
+	}
+
+	return &dto.GetMissingRemittancesResponse{
+		Parcels:    parcels,
+		TotalCount: totalCount,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: codTotalPages(totalCount, limit),
+	}, nil
+}
+
+// parseSettlementReport reads the lines of a CSV settlement report. Every line
+// needs a tracking code and an amount; the settlement date is optional.
+func parseSettlementReport(file io.Reader) ([]*model.CodSettlementLine, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, appError.NewAppError(400, "The settlement report is empty")
+		}
+		return nil, appError.NewAppErrorWithErr(400, "Invalid settlement report", err)
+	}
+
+	codeColumn, amountColumn, dateColumn := -1, -1, -1
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch {
+		case codeColumn < 0 && slices.Contains(codCodeColumns, name):
+			codeColumn = i
+		case amountColumn < 0 && slices.Contains(codAmountColumns, name):
+			amountColumn = i
+		case dateColumn < 0 && slices.Contains(codDateColumns, name):
+			dateColumn = i
+		}
+	}
+	if codeColumn < 0 || amountColumn < 0 {
+		return nil, appError.NewAppError(400, "The settlement report needs an order_code and a cod_amount column")
+	}
+
+	var lines []*model.CodSettlementLine
+	for number := 2; ; number++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, appError.NewAppErrorWithErr(400, fmt.Sprintf("Invalid settlement report at line %d", number), err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		code := strings.ToUpper(strings.TrimSpace(csvField(record, codeColumn)))
+		if code == "" {
+			return nil, appError.NewAppError(400, fmt.Sprintf("Line %d has no order code", number))
+		}
+		amount, err := parseCodAmount(csvField(record, amountColumn))
+		if err != nil {
+			return nil, appError.NewAppError(400, fmt.Sprintf("Line %d has an invalid amount: %s", number, csvField(record, amountColumn)))
+		}
+
+		line := &model.CodSettlementLine{
+			Line:         number,
+			TrackingCode: code,
+			Amount:       amount,
+		}
+		if value := strings.TrimSpace(csvField(record, dateColumn)); value != "" {
+			settledAt, err := parseCodDate(value)
+			if err != nil {
+				return nil, appError.NewAppError(400, fmt.Sprintf("Line %d has an invalid date: %s", number, value))
+			}
+			line.SettledAt = &settledAt
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// parseCodAmount reads an amount in dong, with or without thousand separators
+// and currency sign
+func parseCodAmount(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, symbol := range []string{"vnd", "đ", "₫", ",", ".", " "} {
+		value = strings.ReplaceAll(value, symbol, "")
+	}
+	amount, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if amount < 0 {
+		return 0, fmt.Errorf("negative amount %d", amount)
+	}
+	return amount, nil
+}
+
+func parseCodDate(value string) (time.Time, error) {
+	for _, layout := range codDateLayouts {
+		if at, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return at, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", value)
+}
+
+func csvField(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return record[column]
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func codPage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100 // max limit
+	}
+	return page, limit
+}
+
+func codTotalPages(totalCount int64, limit int) int {
+	totalPages := int(totalCount) / limit
+	if int(totalCount)%limit > 0 {
+		totalPages++
+	}
+	return totalPages
+}
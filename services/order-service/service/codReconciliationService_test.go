@@ -0,0 +1,38 @@
+package service
+
+import "testing"
+
+func TestParseCodAmount(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "150000", want: 150000},
+		{value: "150.000 đ", want: 150000},
+		{value: "1,250,000 VND", want: 1250000},
+		{value: " 45 000₫ ", want: 45000},
+		{value: "0", want: 0},
+		{value: "", wantErr: true},
+		{value: "-5000", wantErr: true},
+		{value: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseCodAmount(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseCodAmount(%q) = %d, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCodAmount(%q): unexpected error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseCodAmount(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"order-service/dto"
+	"order-service/model"
+	"order-service/repository"
+	"os"
+	"strconv"
+	"time"
+)
+
+type CodRiskService interface {
+	// Assess scores a COD order before it is created
+	Assess(order *model.Order, buyer *dto.UserResponse) (*model.CodRisk, error)
+	// Blocks reports whether COD is refused for an order with this risk
+	Blocks(risk *model.CodRisk) bool
+}
+
+type codRiskService struct {
+	repo       repository.CodRiskRepository
+	blockScore int // COD is refused above this score; 100 never refuses it
+}
+
+func NewCodRiskService(repo repository.CodRiskRepository) CodRiskService {
+	blockScore, err := strconv.Atoi(os.Getenv("COD_RISK_BLOCK_SCORE"))
+	if err != nil || blockScore < 0 || blockScore > 100 {
+		blockScore = 100
+	}
+
+	return &codRiskService{
+		repo:       repo,
+		blockScore: blockScore,
+	}
+}
+
+func (s *codRiskService) Assess(order *model.Order, buyer *dto.UserResponse) (*model.CodRisk, error) {
+	risk := &model.CodRisk{
+		Reasons:    []model.CodRiskReason{},
+		AssessedAt: time.Now(),
+	}
+
+	history, err := s.repo.FindBuyerHistory(buyer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order history: %w", err)
+	}
+	if history.ReturnedCount > 0 {
+		risk.Add(model.CodRiskReturnedOrders, min(20*history.ReturnedCount, 40),
+			fmt.Sprintf("%d earlier orders were refused at delivery or returned", history.ReturnedCount))
+	}
+	if history.CancelledCount > 0 {
+		risk.Add(model.CodRiskCancelledOrders, min(10*history.CancelledCount, 30),
+			fmt.Sprintf("The buyer cancelled %d earlier COD orders", history.CancelledCount))
+	}
+
+	// Accounts created before sign-up dates were recorded are not new
+	if buyer.CreatedAt != nil {
+		if age := time.Since(*buyer.CreatedAt); age < 7*24*time.Hour {
+			risk.Add(model.CodRiskNewAccount, 20, "The account was created less than a week ago")
+		} else if age < 30*24*time.Hour {
+			risk.Add(model.CodRiskNewAccount, 10, "The account was created less than a month ago")
+		}
+	}
+
+	value := order.GrandTotal().Amount
+	if history.CompletedCount == 0 {
+		risk.Add(model.CodRiskFirstOrder, 10, "The buyer has no completed order yet")
+	} else if typical := history.CompletedTotal / int64(history.CompletedCount); typical > 0 {
+		if value > 3*typical {
+			risk.Add(model.CodRiskHighValue, 20, fmt.Sprintf("The order is worth %d times what the buyer usually spends", value/typical))
+		} else if value > 2*typical {
+			risk.Add(model.CodRiskHighValue, 10, "The order is worth more than twice what the buyer usually spends")
+		}
+	}
+
+	accounts, err := s.repo.CountAccountsSharingAddress(buyer.ID, order.ShippingAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check shipping address: %w", err)
+	}
+	if accounts >= 3 {
+		risk.Add(model.CodRiskSharedAddress, 30, fmt.Sprintf("The shipping address or phone was used by %d other accounts", accounts))
+	} else if accounts > 0 {
+		risk.Add(model.CodRiskSharedAddress, 15, fmt.Sprintf("The shipping address or phone was used by %d other accounts", accounts))
+	}
+
+	return risk, nil
+}
+
+func (s *codRiskService) Blocks(risk *model.CodRisk) bool {
+	return risk != nil && risk.Score > s.blockScore
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"order-service/config"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"order-service/utils"
+	"order-service/utils/xlsx"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	exportJobTimeout  = time.Hour        // a job still running after this was interrupted, e.g. by a restart
+	exportLinkExpiry  = 15 * time.Minute // lifetime of a download link
+	exportContentCSV  = "text/csv; charset=utf-8"
+	exportContentXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var exportHeader = []any{
+	"Order ID", "Created At", "Status", "Payment Method", "Payment Status", "Currency",
+	"Buyer", "Phone", "Address", "Ward", "District", "Province", "Country",
+	"Voucher Code", "Voucher Discount", "Delivery Code", "Delivery Fee", "Order Total",
+	"Product ID", "Product", "Variant", "SKU", "Quantity", "Unit Price", "Item Discount", "Line Total",
+	"VAT Rate", "VAT Amount",
+}
+
+type ExportService interface {
+	// ExportSellerOrders starts an async job when the export is too large to stream
+	// in the request. It returns nil when the caller should stream it with StreamSellerOrders.
+	ExportSellerOrders(sellerID string, request dto.ExportSellerOrdersRequest) (*dto.ExportJobResponse, error)
+	StreamSellerOrders(ctx context.Context, sellerID string, request dto.ExportSellerOrdersRequest, w io.Writer) error
+	GetJob(sellerID, jobID string) (*dto.ExportJobResponse, error)
+}
+
+type exportService struct {
+	repo          repository.ExportRepository
+	orderRepo     repository.OrderRepository
+	syncMaxOrders int64
+}
+
+func NewExportService(repo repository.ExportRepository, orderRepo repository.OrderRepository) ExportService {
+	syncMaxOrders, err := strconv.ParseInt(os.Getenv("EXPORT_SYNC_MAX_ORDERS"), 10, 64)
+	if err != nil || syncMaxOrders <= 0 {
+		syncMaxOrders = 2000
+	}
+
+	return &exportService{
+		repo:          repo,
+		orderRepo:     orderRepo,
+		syncMaxOrders: syncMaxOrders,
+	}
+}
+
+func (s *exportService) ExportSellerOrders(sellerID string, request dto.ExportSellerOrdersRequest) (*dto.ExportJobResponse, error) {
+	filter := exportFilter(request)
+	count, err := s.orderRepo.CountOrdersBySeller(sellerOrderFilter(sellerID, filter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders: %w", err)
+	}
+	if count <= s.syncMaxOrders {
+		return nil, nil
+	}
+
+	if config.S3Client == nil {
+		return nil, appError.NewAppError(503, "This export is too large to download directly, please narrow the date range")
+	}
+
+	job := &model.ExportJob{
+		SellerID:   sellerID,
+		Format:     ExportFormat(request.Format),
+		Filter:     filter,
+		OrderCount: count,
+	}
+	if err := s.repo.CreateJob(job); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	go s.runJob(job)
+
+	return &dto.ExportJobResponse{ExportJob: job}, nil
+}
+
+func (s *exportService) StreamSellerOrders(ctx context.Context, sellerID string, request dto.ExportSellerOrdersRequest, w io.Writer) error {
+	_, err := s.writeExport(ctx, sellerOrderFilter(sellerID, exportFilter(request)), ExportFormat(request.Format), w)
+	return err
+}
+
+func (s *exportService) GetJob(sellerID, jobID string) (*dto.ExportJobResponse, error) {
+	job, err := s.repo.FindJobByID(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+	if job == nil || job.SellerID != sellerID {
+		return nil, appError.NewAppError(404, "Export job not found")
+	}
+
+	if (job.Status == model.ExportPending || job.Status == model.ExportRunning) && time.Since(job.CreatedAt) > exportJobTimeout {
+		s.finishJob(job, fmt.Errorf("the export was interrupted, please start it again"))
+	}
+
+	response := &dto.ExportJobResponse{ExportJob: job}
+	if job.Status == model.ExportDone {
+		url, err := utils.PresignS3URL(job.FileKey, exportLinkExpiry)
+		if err != nil {
+			return nil, appError.NewAppErrorWithErr(503, "Failed to create download link", err)
+		}
+		response.DownloadURL = url
+	}
+	return response, nil
+}
+
+// runJob writes the export to a temporary file and uploads it, so neither the
+// orders nor the file are held in memory
+func (s *exportService) runJob(job *model.ExportJob) {
+	job.Status = model.ExportRunning
+	if err := s.repo.UpdateJob(job); err != nil {
+		fmt.Printf("Warning: failed to update export job %s: %v\n", job.ID, err)
+	}
+
+	file, err := os.CreateTemp("", "order-export-*."+job.Format)
+	if err != nil {
+		s.finishJob(job, fmt.Errorf("failed to create temporary file: %w", err))
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportJobTimeout)
+	defer cancel()
+
+	rows, err := s.writeExport(ctx, sellerOrderFilter(job.SellerID, job.Filter), job.Format, file)
+	if err != nil {
+		s.finishJob(job, err)
+		return
+	}
+	job.RowCount = rows
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		s.finishJob(job, err)
+		return
+	}
+	key := fmt.Sprintf("exports/%s/%s.%s", job.SellerID, job.ID, job.Format)
+	if _, err := utils.UploadReaderToS3(file, key, ExportContentType(job.Format)); err != nil {
+		s.finishJob(job, err)
+		return
+	}
+	job.FileKey = key
+
+	s.finishJob(job, nil)
+}
+
+func (s *exportService) finishJob(job *model.ExportJob, jobErr error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = model.ExportDone
+	if jobErr != nil {
+		job.Status = model.ExportFailed
+		job.Error = jobErr.Error()
+		fmt.Printf("Warning: export job %s failed: %v\n", job.ID, jobErr)
+	}
+	if err := s.repo.UpdateJob(job); err != nil {
+		fmt.Printf("Warning: failed to update export job %s: %v\n", job.ID, err)
+	}
+}
+
+// writeExport writes one row per order item and returns the number of rows
+func (s *exportService) writeExport(ctx context.Context, filter repository.SellerOrderFilter, format string, w io.Writer) (int64, error) {
+	rows, err := newRowWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := rows.WriteRow(exportHeader); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = s.orderRepo.EachOrderBySeller(ctx, filter, func(order *model.Order) error {
+		for _, row := range exportRows(order) {
+			if err := rows.WriteRow(row); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to export orders: %w", err)
+	}
+
+	return count, rows.Close()
+}
+
+func exportRows(order *model.Order) [][]any {
+	voucherCode := ""
+	if order.Voucher != nil {
+		voucherCode = order.Voucher.Code
+	}
+	address := order.ShippingAddress
+	// One code per parcel
+	deliveryCode := strings.Join(order.TrackingCodes(), " ")
+
+	rows := make([][]any, 0, len(order.Items))
+	for _, item := range order.Items {
+		rows = append(rows, []any{
+			order.ID, order.CreatedAt.Format(time.RFC3339), order.Status, order.PaymentMethod, order.PaymentStatus, model.NormalizeCurrency(order.Currency),
+			address.FullName, address.Phone, address.AddressLine, address.Ward, address.District, address.Province, address.Country,
+			voucherCode, order.VoucherDiscount(), deliveryCode, order.DeliveryFee, order.GrandTotal().Amount,
+			item.ProductID, item.ProductName, item.VariantName, item.SKU, item.Quantity, item.Price, item.Discount, item.LineTotal(),
+			item.TaxRate, item.TaxAmount,
+		})
+	}
+	return rows
+}
+
+type rowWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+func newRowWriter(format string, w io.Writer) (rowWriter, error) {
+	if format == "xlsx" {
+		return xlsx.NewWriter(w, "Orders")
+	}
+
+	// A byte order mark makes Excel open the file as UTF-8
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	return &csvRowWriter{writer: csv.NewWriter(w)}, nil
+}
+
+type csvRowWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (c *csvRowWriter) WriteRow(values []any) error {
+	c.record = c.record[:0]
+	for _, value := range values {
+		c.record = append(c.record, fmt.Sprint(value))
+	}
+	return c.writer.Write(c.record)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func exportFilter(request dto.ExportSellerOrdersRequest) model.ExportOrderFilter {
+	return model.ExportOrderFilter{
+		Status:        request.Status,
+		PaymentMethod: request.PaymentMethod,
+		PaymentStatus: request.PaymentStatus,
+		Search:        request.Search,
+		StartDate:     request.StartDate,
+		EndDate:       request.EndDate,
+	}
+}
+
+func sellerOrderFilter(sellerID string, filter model.ExportOrderFilter) repository.SellerOrderFilter {
+	return repository.SellerOrderFilter{
+		SellerID:      sellerID,
+		Status:        filter.Status,
+		PaymentMethod: filter.PaymentMethod,
+		PaymentStatus: filter.PaymentStatus,
+		Search:        filter.Search,
+		StartDate:     filter.StartDate,
+		EndDate:       filter.EndDate,
+	}
+}
+
+// ExportFormat returns the file format of a request, csv by default
+func ExportFormat(format string) string {
+	if format == "xlsx" {
+		return "xlsx"
+	}
+	return "csv"
+}
+
+// ExportContentType returns the MIME type of an export format
+func ExportContentType(format string) string {
+	if format == "xlsx" {
+		return exportContentXLSX
+	}
+	return exportContentCSV
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"fmt"
+	"order-service/client"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"order-service/utils"
+	"order-service/utils/pdf"
+	"strings"
+	"time"
+)
+
+var paymentMethodNames = map[string]string{
+	"COD":    "Cash on delivery",
+	"STRIPE": "Card (Stripe)",
+	"VNPAY":  "VNPay",
+	"MOMO":   "MoMo",
+	"WALLET": "Wallet",
+}
+
+type InvoiceService interface {
+	GetInvoice(orderID, userID string, isAdmin bool) ([]byte, *model.Invoice, error)
+}
+
+type invoiceService struct {
+	repo       repository.InvoiceRepository
+	orderRepo  repository.OrderRepository
+	userClient *client.UserServiceClient
+}
+
+func NewInvoiceService(repo repository.InvoiceRepository, orderRepo repository.OrderRepository, userClient *client.UserServiceClient) InvoiceService {
+	return &invoiceService{
+		repo:       repo,
+		orderRepo:  orderRepo,
+		userClient: userClient,
+	}
+}
+
+// GetInvoice returns the PDF invoice of an order to its buyer, its seller or an admin.
+// The invoice is issued on first request; the PDF is stored in S3 and served
+// from there afterwards, or rendered again if S3 is unavailable.
+func (s *invoiceService) GetInvoice(orderID, userID string, isAdmin bool) ([]byte, *model.Invoice, error) {
+	order, err := s.orderRepo.FindOrderByID(orderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if order == nil {
+		return nil, nil, appError.NewAppError(404, "Order not found")
+	}
+	if !isAdmin && order.User.ID != userID && order.Seller.ID != userID {
+		return nil, nil, appError.NewAppError(403, "You don't have permission to view this invoice")
+	}
+	// Items can still be cancelled before the seller confirms, so the invoice waits until then
+	if order.Status == "TO_PAY" || order.Status == "TO_CONFIRM" || order.Status == "CANCELLED" {
+		return nil, nil, appError.NewAppError(400, "An invoice is only available for confirmed orders")
+	}
+
+	invoice, err := s.issue(order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if invoice.FileKey != "" {
+		data, err := utils.DownloadFileFromS3(invoice.FileKey)
+		if err == nil {
+			return data, invoice, nil
+		}
+		fmt.Printf("Warning: failed to download invoice %s, rendering it again: %v\n", invoice.Number, err)
+	}
+
+	data := renderInvoice(order, invoice)
+
+	key := fmt.Sprintf("invoices/%s/%s.pdf", invoice.SellerID, invoice.Number)
+	url, err := utils.UploadFileToS3(data, key, "application/pdf")
+	if err != nil {
+		fmt.Printf("Warning: failed to store invoice %s: %v\n", invoice.Number, err)
+		return data, invoice, nil
+	}
+	if err := s.repo.SetFile(order.ID, key, url); err != nil {
+		fmt.Printf("Warning: failed to save invoice %s file: %v\n", invoice.Number, err)
+	}
+	invoice.FileKey, invoice.FileURL = key, url
+
+	return data, invoice, nil
+}
+
+// issue returns the order's invoice, creating it and allocating its number the
+// first time. The request that creates the invoice is the only one to take a
+// number, so concurrent requests can't leave gaps in the sequence.
+func (s *invoiceService) issue(order *model.Order) (*model.Invoice, error) {
+	invoice, err := s.repo.FindByOrderID(order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if invoice != nil {
+		if invoice.Number == "" {
+			return nil, appError.NewAppError(409, "The invoice is being issued, please retry")
+		}
+		return invoice, nil
+	}
+
+	invoice = &model.Invoice{
+		ID:       order.ID,
+		OrderID:  order.ID,
+		SellerID: order.Seller.ID,
+		Seller:   model.InvoiceParty{Name: order.Seller.Name},
+		Buyer: model.InvoiceParty{
+			Name:    order.ShippingAddress.FullName,
+			Phone:   order.ShippingAddress.Phone,
+			Address: formatAddress(order.ShippingAddress.AddressLine, order.ShippingAddress.Ward, order.ShippingAddress.District, order.ShippingAddress.Province),
+		},
+		IssuedAt: time.Now(),
+	}
+	if invoice.Buyer.Name == "" {
+		invoice.Buyer.Name = order.User.Name
+	}
+	if seller, err := s.userClient.GetUserByID(order.Seller.ID); err == nil && seller != nil {
+		invoice.Seller = model.InvoiceParty{
+			Name:    seller.Name,
+			Email:   seller.Email,
+			Phone:   seller.Phone,
+			Address: formatAddress(seller.Address.AddressLine, seller.Address.Ward, seller.Address.District, seller.Address.Province),
+		}
+	} else {
+		fmt.Printf("Warning: failed to get seller details for invoice of order %s: %v\n", order.ID, err)
+	}
+
+	created, err := s.repo.Create(invoice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+	if !created {
+		// Another request issued it first
+		return s.issue(order)
+	}
+
+	sequence, err := s.repo.NextSequence(order.Seller.ID)
+	if err != nil {
+		_ = s.repo.Delete(order.ID)
+		return nil, fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+	number := fmt.Sprintf("INV-%s-%06d", sellerPrefix(order.Seller.ID), sequence)
+	if err := s.repo.SetNumber(order.ID, sequence, number); err != nil {
+		return nil, fmt.Errorf("failed to save invoice number: %w", err)
+	}
+	invoice.Sequence, invoice.Number = sequence, number
+
+	return invoice, nil
+}
+
+// renderInvoice lays out an A4 invoice
+func renderInvoice(order *model.Order, invoice *model.Invoice) []byte {
+	const (
+		left    = 40.0
+		right   = pdf.PageWidth - 40
+		bottom  = pdf.PageHeight - 60
+		colQty  = 340.0
+		colUnit = 420.0
+		colTax  = 465.0
+	)
+	currency := model.NormalizeCurrency(order.Currency)
+	money := func(amount int64) string {
+		return formatMoney(model.NewMoney(amount, currency))
+	}
+
+	doc := pdf.New()
+
+	// Header
+	doc.Text(left, 60, 22, true, "INVOICE")
+	doc.TextRight(right, 50, 10, true, "No. "+invoice.Number)
+	doc.TextRight(right, 64, 9, false, "Issued: "+invoice.IssuedAt.Format("2006-01-02"))
+	doc.TextRight(right, 76, 9, false, "Order: "+order.ID)
+	doc.TextRight(right, 88, 9, false, "Order date: "+order.CreatedAt.Format("2006-01-02"))
+
+	// Parties
+	y := 120.0
+	party := func(x float64, title string, p model.InvoiceParty) {
+		doc.Text(x, y, 9, true, title)
+		line := y + 14
+		for _, text := range []string{p.Name, p.Address, p.Phone, p.Email} {
+			if text == "" {
+				continue
+			}
+			doc.Text(x, line, 9, false, pdf.Truncate(text, 240, 9, false))
+			line += 12
+		}
+	}
+	party(left, "SELLER", invoice.Seller)
+	party(pdf.PageWidth/2+10, "BILL TO", invoice.Buyer)
+
+	// Items
+	y = 200
+	header := func() {
+		doc.Box(left, y-12, right-left, 18, 0.9)
+		doc.Text(left+4, y, 9, true, "Item")
+		doc.TextRight(colQty, y, 9, true, "Qty")
+		doc.TextRight(colUnit, y, 9, true, "Unit price")
+		doc.TextRight(colTax, y, 9, true, "VAT")
+		doc.TextRight(right-4, y, 9, true, "Amount")
+		y += 20
+	}
+	header()
+
+	for _, item := range order.Items {
+		if y > bottom {
+			doc.AddPage()
+			y = 60
+			header()
+		}
+
+		name := item.ProductName
+		if item.VariantName != "" {
+			name += " (" + item.VariantName + ")"
+		}
+		doc.Text(left+4, y, 9, false, pdf.Truncate(name, colQty-left-40, 9, false))
+		doc.TextRight(colQty, y, 9, false, fmt.Sprintf("%d", item.Quantity))
+		doc.TextRight(colUnit, y, 9, false, money(int64(item.Price)))
+		doc.TextRight(colTax, y, 9, false, formatRate(item.TaxRate))
+		doc.TextRight(right-4, y, 9, false, money(int64(item.LineTotal())))
+		if item.BundleName != "" {
+			y += 11
+			doc.Text(left+12, y, 8, false, pdf.Truncate(fmt.Sprintf("Bundle %s, discount %s", item.BundleName, money(int64(item.Discount))), colQty-left-40, 8, false))
+		}
+		y += 16
+	}
+
+	// Totals
+	if y > bottom-150 {
+		doc.AddPage()
+		y = 60
+	}
+	doc.Line(left, y-8, right, y-8)
+	y += 6
+
+	total := func(label, value string, bold bool) {
+		doc.Text(colUnit-100, y, 9, bold, label)
+		doc.TextRight(right-4, y, 9, bold, value)
+		y += 14
+	}
+
+	total("Subtotal", money(order.ItemsTotal()), false)
+	if discount := order.VoucherDiscount(); discount > 0 {
+		label := "Voucher discount"
+		if order.Voucher != nil {
+			label += " (" + order.Voucher.Code + ")"
+		}
+		total(label, "-"+money(discount), false)
+	}
+	if order.TaxInclusive {
+		total("VAT (included)", money(order.TaxTotal), false)
+	} else {
+		total("VAT", money(order.TaxTotal), false)
+	}
+	if order.PointsDiscount > 0 {
+		total(fmt.Sprintf("Loyalty points (%d)", order.RedeemedPoints), "-"+money(int64(order.PointsDiscount)), false)
+	}
+	total("Shipping fee", money(int64(order.DeliveryFee)), false)
+	doc.Line(colUnit-100, y-8, right, y-8)
+	y += 4
+	total("TOTAL", money(order.GrandTotal().Amount), true)
+	if order.WalletAmount > 0 {
+		total("Paid from wallet", money(int64(order.WalletAmount)), false)
+	}
+
+	// Payment
+	y += 10
+	method := paymentMethodNames[order.PaymentMethod]
+	if method == "" {
+		method = order.PaymentMethod
+	}
+	doc.Text(left, y, 9, true, "Payment method: ")
+	doc.Text(left+pdf.TextWidth("Payment method: ", 9, true), y, 9, false, method)
+	y += 14
+	doc.Text(left, y, 9, true, "Payment status: ")
+	doc.Text(left+pdf.TextWidth("Payment status: ", 9, true), y, 9, false, order.PaymentStatus)
+
+	doc.Text(left, pdf.PageHeight-40, 8, false, fmt.Sprintf("All amounts in %s. Thank you for your purchase.", currency))
+
+	return doc.Bytes()
+}
+
+func formatAddress(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, ", ")
+}
+
+// formatMoney formats an amount with thousands separators, e.g. "1,250,000 VND"
+func formatMoney(m model.Money) string {
+	text := m.String()
+	number, suffix, _ := strings.Cut(text, " ")
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(number, ".")
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	if hasFraction {
+		grouped.WriteString("." + fraction)
+	}
+	return sign + grouped.String() + " " + suffix
+}
+
+func formatRate(rate float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", rate), "0"), ".") + "%"
+}
+
+// sellerPrefix shortens a seller ID for invoice numbers
+func sellerPrefix(sellerID string) string {
+	prefix := strings.ToUpper(strings.ReplaceAll(sellerID, "-", ""))
+	if len(prefix) > 8 {
+		prefix = prefix[:8]
+	}
+	return prefix
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"fmt"
+	"order-service/repository"
+	"time"
+)
+
+// runJob runs a background job unless another instance already ran it within
+// ttl. Every instance starts the jobs; the lease makes only one of them work.
+func runJob(leases repository.JobLeaseRepository, job string, ttl time.Duration, run func()) {
+	acquired, err := leases.AcquireJobLease(job, ttl)
+	if err != nil {
+		fmt.Printf("Warning: failed to acquire the lease of job %s: %v\n", job, err)
+		return
+	}
+	if acquired {
+		run()
+	}
+}
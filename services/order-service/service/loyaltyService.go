@@ -0,0 +1,278 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"os"
+	"strconv"
+	"time"
+)
+
+type LoyaltyService interface {
+	GetHistory(userID string, request dto.GetLoyaltyHistoryRequest) (*dto.GetLoyaltyHistoryResponse, error)
+	GetBalance(userID string) (int, error)
+	UpdateRate(request dto.UpdateLoyaltyRateRequest) (*model.LoyaltyRate, error)
+	EarnForOrder(order *model.Order) error
+	ReverseForOrder(order *model.Order) error
+	Redeem(userID, orderID string, points int, orderTotal int64) (int, error)
+	CancelRedemption(userID, orderID string) error
+}
+
+type loyaltyService struct {
+	repo         repository.LoyaltyRepository
+	defaultRate  float64 // percent of the paid amount returned as points
+	pointValue   int     // VND discount per redeemed point
+	expiryMonths int
+}
+
+func NewLoyaltyService(repo repository.LoyaltyRepository) LoyaltyService {
+	defaultRate, err := strconv.ParseFloat(os.Getenv("LOYALTY_EARN_RATE"), 64)
+	if err != nil || defaultRate < 0 {
+		defaultRate = 1
+	}
+	pointValue, err := strconv.Atoi(os.Getenv("LOYALTY_POINT_VALUE"))
+	if err != nil || pointValue <= 0 {
+		pointValue = 1
+	}
+	expiryMonths, err := strconv.Atoi(os.Getenv("LOYALTY_EXPIRY_MONTHS"))
+	if err != nil || expiryMonths <= 0 {
+		expiryMonths = 12
+	}
+
+	return &loyaltyService{
+		repo:         repo,
+		defaultRate:  defaultRate,
+		pointValue:   pointValue,
+		expiryMonths: expiryMonths,
+	}
+}
+
+func (s *loyaltyService) GetHistory(userID string, request dto.GetLoyaltyHistoryRequest) (*dto.GetLoyaltyHistoryResponse, error) {
+	page := request.Page
+	if page < 1 {
+		page = 1
+	}
+
+	limit := request.Limit
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100 // max limit
+	}
+
+	balance, err := s.GetBalance(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, totalCount, err := s.repo.FindTransactionsByUser(userID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	if transactions == nil {
+		transactions = []*model.LoyaltyTransaction{}
+	}
+
+	totalPages := int(totalCount) / limit
+	if int(totalCount)%limit > 0 {
+		totalPages++
+	}
+
+	return &dto.GetLoyaltyHistoryResponse{
+		Balance:      balance,
+		PointValue:   s.pointValue,
+		Transactions: transactions,
+		TotalCount:   totalCount,
+		Page:         page,
+		Limit:        limit,
+		TotalPages:   totalPages,
+	}, nil
+}
+
+// GetBalance expires any due points first, then derives the balance from the ledger
+func (s *loyaltyService) GetBalance(userID string) (int, error) {
+	if err := s.expireDuePoints(userID); err != nil {
+		return 0, err
+	}
+	return s.repo.GetBalance(userID)
+}
+
+func (s *loyaltyService) UpdateRate(request dto.UpdateLoyaltyRateRequest) (*model.LoyaltyRate, error) {
+	id := request.SellerID
+	if id == "" {
+		id = model.PlatformLoyaltyRateID
+	}
+
+	rate := &model.LoyaltyRate{
+		ID:   id,
+		Rate: request.Rate,
+	}
+	if err := s.repo.UpsertRate(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// EarnForOrder credits points for a COMPLETED order. It is idempotent per
+// order: the ledger keeps one entry per order and type.
+func (s *loyaltyService) EarnForOrder(order *model.Order) error {
+	rate, err := s.rateForSeller(order.Seller.ID)
+	if err != nil {
+		return err
+	}
+
+	points := int(math.Floor(float64(order.Total) * rate / 100 / float64(s.pointValue)))
+	if points <= 0 {
+		return nil
+	}
+
+	expiresAt := time.Now().AddDate(0, s.expiryMonths, 0)
+	return s.repo.CreateTransaction(&model.LoyaltyTransaction{
+		UserID:      order.User.ID,
+		OrderID:     order.ID,
+		SellerID:    order.Seller.ID,
+		Type:        model.LoyaltyEarn,
+		Points:      points,
+		Description: fmt.Sprintf("Earned from order %s", order.ID),
+		ExpiresAt:   &expiresAt,
+	})
+}
+
+// ReverseForOrder takes back the points earned by a RETURNED order. It is
+// idempotent per order, like EarnForOrder.
+func (s *loyaltyService) ReverseForOrder(order *model.Order) error {
+	earned, err := s.repo.FindTransactionByOrderAndType(order.ID, model.LoyaltyEarn)
+	if err != nil {
+		return err
+	}
+	if earned == nil {
+		return nil
+	}
+
+	return s.repo.CreateTransaction(&model.LoyaltyTransaction{
+		UserID:      earned.UserID,
+		OrderID:     order.ID,
+		SellerID:    earned.SellerID,
+		Type:        model.LoyaltyReverse,
+		Points:      -earned.Points,
+		Description: fmt.Sprintf("Reversed for returned order %s", order.ID),
+		ExpiresAt:   earned.ExpiresAt,
+	})
+}
+
+// Redeem spends points on an order and returns the discount amount in VND.
+// Points are capped so the discount never exceeds the order total.
+func (s *loyaltyService) Redeem(userID, orderID string, points int, orderTotal int64) (int, error) {
+	if points <= 0 {
+		return 0, appError.NewAppError(400, "points to redeem must be greater than 0")
+	}
+
+	maxPoints := int(orderTotal) / s.pointValue
+	if points > maxPoints {
+		points = maxPoints
+	}
+	if points <= 0 {
+		return 0, nil
+	}
+
+	balance, err := s.GetBalance(userID)
+	if err != nil {
+		return 0, err
+	}
+	if balance < points {
+		return 0, appError.NewAppError(409, "not enough loyalty points")
+	}
+
+	err = s.repo.CreateTransaction(&model.LoyaltyTransaction{
+		UserID:      userID,
+		OrderID:     orderID,
+		Type:        model.LoyaltyRedeem,
+		Points:      -points,
+		Description: fmt.Sprintf("Redeemed on order %s", orderID),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Re-check after writing: a concurrent checkout may have spent the same points.
+	// The ledger is append-only, so the losing side is compensated by a new entry.
+	balance, err = s.repo.GetBalance(userID)
+	if err != nil {
+		return 0, err
+	}
+	if balance < 0 {
+		_ = s.CancelRedemption(userID, orderID)
+		return 0, appError.NewAppError(409, "not enough loyalty points")
+	}
+
+	return points * s.pointValue, nil
+}
+
+// CancelRedemption gives back the points redeemed on an order, e.g. when it is cancelled
+func (s *loyaltyService) CancelRedemption(userID, orderID string) error {
+	outstanding, err := s.repo.SumPointsByOrder(orderID, []string{model.LoyaltyRedeem, model.LoyaltyRedeemCancel})
+	if err != nil {
+		return err
+	}
+	if outstanding >= 0 {
+		return nil
+	}
+
+	return s.repo.CreateTransaction(&model.LoyaltyTransaction{
+		UserID:      userID,
+		OrderID:     orderID,
+		Type:        model.LoyaltyRedeemCancel,
+		Points:      -outstanding,
+		Description: fmt.Sprintf("Refunded from cancelled order %s", orderID),
+	})
+}
+
+func (s *loyaltyService) rateForSeller(sellerID string) (float64, error) {
+	for _, id := range []string{sellerID, model.PlatformLoyaltyRateID} {
+		rate, err := s.repo.FindRate(id)
+		if err != nil {
+			return 0, err
+		}
+		if rate != nil {
+			return rate.Rate, nil
+		}
+	}
+	return s.defaultRate, nil
+}
+
+// expireDuePoints appends an EXPIRE entry for earned points that passed their
+// expiry date and were not spent yet. Spending is assumed to consume the
+// oldest points first, so only the unspent part of expired lots is removed.
+// The entry's ID is derived from the points expired before it, so concurrent
+// balance reads that saw the same ledger append it only once.
+func (s *loyaltyService) expireDuePoints(userID string) error {
+	sums, err := s.repo.SumPointsByType(userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	expiredLots := sums.Expired[model.LoyaltyEarn] + sums.Expired[model.LoyaltyReverse]
+	spent := -(sums.Total[model.LoyaltyRedeem] + sums.Total[model.LoyaltyRedeemCancel])
+	alreadyExpired := -sums.Total[model.LoyaltyExpire]
+
+	due := expiredLots - spent
+	if due < 0 {
+		due = 0
+	}
+	due -= alreadyExpired
+	if due <= 0 {
+		return nil
+	}
+
+	return s.repo.CreateTransaction(&model.LoyaltyTransaction{
+		ID:          fmt.Sprintf("expire:%s:%d", userID, alreadyExpired),
+		UserID:      userID,
+		Type:        model.LoyaltyExpire,
+		Points:      -due,
+		Description: "Points expired",
+	})
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"order-service/client"
+	"order-service/model"
+	"order-service/repository"
+	"slices"
+	"time"
+)
+
+// orderSchedulerInterval is how often overdue orders are looked for
+const orderSchedulerInterval = 10 * time.Minute
+
+func (s *orderService) StartScheduler(leases repository.JobLeaseRepository) {
+	go func() {
+		runJob(leases, "order-item-categories", time.Hour, s.backfillItemCategories)
+
+		for {
+			time.Sleep(orderSchedulerInterval)
+
+			runJob(leases, "order-scheduler", orderSchedulerInterval-time.Minute, func() {
+				s.cancelUnpaidOrders()
+				s.cancelUnconfirmedOrders()
+				s.checkPreOrderShipDates()
+				s.trackShipments()
+				s.completeDeliveredOrders()
+			})
+		}
+	}()
+}
+
+// cancelUnpaidOrders cancels TO_PAY orders once the payment window is over and
+// gives the stock back. A payment arriving later is refunded to the wallet.
+func (s *orderService) cancelUnpaidOrders() {
+	orders, err := s.repo.FindOrdersByStatus("TO_PAY", time.Now().Add(-s.paymentWindow))
+	if err != nil {
+		fmt.Printf("Warning: failed to find unpaid orders: %v\n", err)
+		return
+	}
+
+	for _, order := range orders {
+		s.autoChangeStatus(order, "CANCELLED", "not paid within the payment window",
+			"Order cancelled", fmt.Sprintf("Your order %s was cancelled because it was not paid in time", order.ID))
+	}
+}
+
+// cancelUnconfirmedOrders cancels TO_CONFIRM orders the seller did not confirm
+// in time; paid orders are refunded to the buyer's wallet
+func (s *orderService) cancelUnconfirmedOrders() {
+	cutoff := time.Now().Add(-s.confirmWindow)
+	orders, err := s.repo.FindOrdersByStatus("TO_CONFIRM", cutoff)
+	if err != nil {
+		fmt.Printf("Warning: failed to find unconfirmed orders: %v\n", err)
+		return
+	}
+
+	for _, order := range orders {
+		// Online orders wait for confirmation from the time they were paid,
+		// pre-orders from the time they can ship
+		if order.StatusSince().After(cutoff) {
+			continue
+		}
+		if order.IsPreOrder() && order.ExpectedShipDate.After(cutoff) {
+			continue
+		}
+		s.autoChangeStatus(order, "CANCELLED", "not confirmed by the seller in time",
+			"Order cancelled", fmt.Sprintf("Your order %s was cancelled because the seller did not confirm it in time", order.ID))
+	}
+}
+
+// checkPreOrderShipDates follows the ship dates of pre-ordered variants into
+// the open pre-orders, and tells the buyer when their order will ship later
+func (s *orderService) checkPreOrderShipDates() {
+	orders, err := s.repo.FindOpenPreOrders()
+	if err != nil {
+		fmt.Printf("Warning: failed to find pre-orders: %v\n", err)
+		return
+	}
+	if len(orders) == 0 {
+		return
+	}
+
+	var variantIDs []string
+	for _, order := range orders {
+		for _, item := range order.Items {
+			if item.PreOrderShipDate != nil && !slices.Contains(variantIDs, item.VariantID) {
+				variantIDs = append(variantIDs, item.VariantID)
+			}
+		}
+	}
+	variants, err := s.productClient.GetVariantsByIds(variantIDs)
+	if err != nil {
+		fmt.Printf("Warning: failed to get pre-ordered variants: %v\n", err)
+		return
+	}
+	shipDates := make(map[string]time.Time, len(variants))
+	for _, v := range variants {
+		if v.Variant.PreOrder != nil {
+			shipDates[v.Variant.ID] = v.Variant.PreOrder.ShipDate
+		}
+	}
+
+	for _, order := range orders {
+		previous := *order.ExpectedShipDate
+		changed := false
+		for i := range order.Items {
+			item := &order.Items[i]
+			shipDate, ok := shipDates[item.VariantID]
+			if item.PreOrderShipDate == nil || !ok || shipDate.Equal(*item.PreOrderShipDate) {
+				continue
+			}
+			item.PreOrderShipDate = &shipDate
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+
+		expected, _ := latestPreOrderShipDate(order.Items)
+		order.ExpectedShipDate = expected
+		if err := s.repo.SetPreOrderShipDates(order); err != nil {
+			fmt.Printf("Warning: failed to update ship date of pre-order %s: %v\n", order.ID, err)
+			continue
+		}
+		if !expected.After(previous) {
+			continue
+		}
+
+		err := s.notificationClient.CreateNotification(client.CreateNotificationRequest{
+			UserID: order.User.ID,
+			Type:   "order",
+			Title:  "Pre-order delayed",
+			Message: fmt.Sprintf("Your pre-order %s is now expected to ship on %s instead of %s",
+				order.ID, expected.Format("2006-01-02"), previous.Format("2006-01-02")),
+			Data: map[string]interface{}{
+				"orderId":          order.ID,
+				"expectedShipDate": expected,
+			},
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to send notification to buyer: %v\n", err)
+		}
+	}
+}
+
+// trackShipments follows the parcels of orders being fulfilled with the
+// carrier. An order moves to SHIPPING once a parcel is picked up, and its
+// delivery time is recorded once every parcel has arrived.
+func (s *orderService) trackShipments() {
+	for _, status := range []string{"TO_PICKUP", "SHIPPING"} {
+		orders, err := s.repo.FindOrdersByStatus(status, time.Now())
+		if err != nil {
+			fmt.Printf("Warning: failed to find %s orders: %v\n", status, err)
+			continue
+		}
+
+		for _, order := range orders {
+			if order.DeliveredAt != nil || !s.refreshShipments(order) {
+				continue
+			}
+			s.applyShipmentChanges(order, model.ActorSystem, "")
+			// Skipped when the order changed meanwhile; the next run tracks it again
+			if _, err := s.repo.TransitionOrder(order, status); err != nil {
+				fmt.Printf("Warning: failed to record shipments of order %s: %v\n", order.ID, err)
+			}
+		}
+	}
+}
+
+// completeDeliveredOrders completes SHIPPING orders some days after every
+// parcel was delivered, unless the buyer asked for a return
+func (s *orderService) completeDeliveredOrders() {
+	cutoff := time.Now().Add(-s.completeAfter)
+	orders, err := s.repo.FindOrdersByStatus("SHIPPING", cutoff)
+	if err != nil {
+		fmt.Printf("Warning: failed to find orders being delivered: %v\n", err)
+		return
+	}
+
+	for _, order := range orders {
+		if order.ReturnRequest != nil || order.DeliveredAt == nil || order.DeliveredAt.After(cutoff) {
+			continue
+		}
+		s.autoChangeStatus(order, "COMPLETED", "delivered and no return requested",
+			"Order completed", fmt.Sprintf("Your order %s was completed automatically after delivery", order.ID))
+	}
+}
+
+// itemCategoryBackfillBatch is how many orders backfillItemCategories handles at once
+const itemCategoryBackfillBatch = 100
+
+// backfillItemCategories fills in the platform categories of order items that
+// were stored without them. Old orders get the product's current categories,
+// or none when the variant is gone, so that they are not looked at again.
+func (s *orderService) backfillItemCategories() {
+	seen := make(map[string]bool) // stops the loop should an order not be updated
+	for {
+		orders, err := s.repo.FindOrdersMissingItemCategories(itemCategoryBackfillBatch)
+		if err != nil {
+			fmt.Printf("Warning: failed to find orders without item categories: %v\n", err)
+			return
+		}
+		if len(orders) == 0 || seen[orders[0].ID] {
+			return
+		}
+		for _, order := range orders {
+			seen[order.ID] = true
+		}
+
+		var variantIDs []string
+		for _, order := range orders {
+			for _, item := range order.Items {
+				if item.CategoryIDs == nil && !slices.Contains(variantIDs, item.VariantID) {
+					variantIDs = append(variantIDs, item.VariantID)
+				}
+			}
+		}
+		variants, err := s.productClient.GetVariantsByIdsIncludingDisabled(variantIDs)
+		if err != nil {
+			fmt.Printf("Warning: failed to get variants for item categories: %v\n", err)
+			return
+		}
+		categoriesByVariant := make(map[string][]string, len(variants))
+		for _, v := range variants {
+			categoriesByVariant[v.Variant.ID] = v.CategoryIds
+		}
+
+		for _, order := range orders {
+			categories := make(map[string][]string)
+			for _, item := range order.Items {
+				if item.CategoryIDs == nil {
+					categories[item.VariantID] = append([]string{}, categoriesByVariant[item.VariantID]...)
+				}
+			}
+			if err := s.repo.SetItemCategories(order.ID, categories); err != nil {
+				fmt.Printf("Warning: failed to backfill item categories of order %s: %v\n", order.ID, err)
+				return
+			}
+		}
+	}
+}
+
+// autoChangeStatus applies a status change as the system and tells the buyer
+// (best-effort). Orders the buyer or seller changed meanwhile are skipped.
+func (s *orderService) autoChangeStatus(order *model.Order, status, reason, title, message string) {
+	if err := s.changeStatus(context.Background(), order, status, model.ActorSystem, "", reason); err != nil {
+		if err == errOrderChanged {
+			return
+		}
+		fmt.Printf("Warning: failed to move order %s to %s: %v\n", order.ID, status, err)
+		return
+	}
+
+	err := s.notificationClient.CreateNotification(client.CreateNotificationRequest{
+		UserID:  order.User.ID,
+		Type:    "order",
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"orderId": order.ID,
+			"status":  status,
+		},
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to send notification to buyer: %v\n", err)
+	}
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"order-service/client"
 	"order-service/client/payment"
+	walletclient "order-service/client/payment/wallet"
 	"order-service/config"
 	"order-service/dto"
 	appError "order-service/error"
@@ -12,25 +14,34 @@ import (
 	"order-service/repository"
 	"os"
 	"slices"
+	"strconv"
 	"time"
 
-	"github.com/stripe/stripe-go/v84"
-	"github.com/stripe/stripe-go/v84/checkout/session"
+	"github.com/google/uuid"
 )
 
+// errOrderChanged is returned when an order changed status while it was being updated
+var errOrderChanged = appError.NewAppError(409, "The order was updated in the meantime, please reload it")
+
 type OrderService interface {
 	Checkout(userID string, request dto.CheckoutRequest) (*dto.CheckoutResponse, error)
 	UpdateOrderPaymentStatus(ctx context.Context, orderID string, status string) error
 	CreateCheckoutSession(ctx context.Context, orderID string) (*dto.CreatePaymentResponse, error)
-	HandleCheckoutSessionCompleted(ctx context.Context, orderID, sessionID, paymentIntentID string) error
-	HandlePaymentFailed(ctx context.Context, paymentIntentID string) error
+	HandlePaymentSucceeded(ctx context.Context, event *payment.PaymentEvent) error
+	HandlePaymentFailed(ctx context.Context, event *payment.PaymentEvent) error
 	GetUserOrders(ctx context.Context, userID string, request dto.GetOrdersRequest) (*dto.GetOrdersResponse, error)
 	GetSellerOrders(ctx context.Context, sellerID string, request dto.GetOrdersBySellerRequest) (*dto.GetOrdersResponse, error)
 	UpdateOrderStatus(ctx context.Context, userID string, orderID string, request dto.UpdateOrderStatusRequest) error
 	VerifyVariantPurchase(userID, productID, variantID string) (bool, error)
 	GetSellerStatistics(ctx context.Context, sellerID string, request dto.GetSellerStatisticsRequest) (*dto.GetSellerStatisticsResponse, error)
 	InstantCheckout(userID string, request dto.InstantCheckoutRequest) (*dto.CheckoutResponse, error)
-	ApplyVoucher(voucherId string, totalAmount float64, sellerId string, variants []dto.ProductVariantDto, userId string) (float64, *model.OrderVoucher, error)
+	ApplyVoucher(voucherId string, totalAmount model.Money, sellerId string, variants []dto.ProductVariantDto, userId string) (model.Money, *model.OrderVoucher, error)
+	RequestReturn(ctx context.Context, userID string, orderID string, request dto.ReturnRequestRequest) error
+	CancelOrderItems(ctx context.Context, userID string, orderID string, request dto.CancelOrderItemsRequest) (*dto.OrderDto, error)
+	CreateShipment(ctx context.Context, sellerID string, orderID string, request dto.CreateShipmentRequest) (*dto.OrderDto, error)
+	UpdateShipmentStatus(ctx context.Context, sellerID string, orderID string, shipmentID string, request dto.UpdateShipmentStatusRequest) (*dto.OrderDto, error)
+	// StartScheduler cancels and completes overdue orders in the background
+	StartScheduler(leases repository.JobLeaseRepository)
 }
 
 type orderService struct {
@@ -38,10 +49,20 @@ type orderService struct {
 	cartRepo           repository.CartRepository
 	productClient      *client.ProductServiceClient
 	userClient         *client.UserServiceClient
-	paymentClient      payment.PaymentClient
+	payments           *payment.Registry
 	GHNClient          *client.GHNClient
 	notificationClient *client.NotificationServiceClient
+	loyaltyService     LoyaltyService
+	walletClient       *walletclient.WalletClient
+	taxService         TaxService
+	payoutService      PayoutService
+	trackingService    TrackingService
+	codRiskService     CodRiskService
+	purchaseLimits     PurchaseLimitService
 	clientURL          string
+	paymentWindow      time.Duration // TO_PAY orders are cancelled after this
+	confirmWindow      time.Duration // TO_CONFIRM orders are cancelled after this
+	completeAfter      time.Duration // delivered SHIPPING orders are completed this long after delivery
 }
 
 func NewOrderService(
@@ -49,23 +70,116 @@ func NewOrderService(
 	cartRepo repository.CartRepository,
 	productClient *client.ProductServiceClient,
 	userClient *client.UserServiceClient,
-	paymentClient payment.PaymentClient,
+	payments *payment.Registry,
 	GHNClient *client.GHNClient,
 	notificationClient *client.NotificationServiceClient,
+	loyaltyService LoyaltyService,
+	walletClient *walletclient.WalletClient,
+	taxService TaxService,
+	payoutService PayoutService,
+	trackingService TrackingService,
+	codRiskService CodRiskService,
+	purchaseLimits PurchaseLimitService,
 ) OrderService {
+	paymentMinutes, err := strconv.Atoi(os.Getenv("ORDER_PAYMENT_WINDOW_MINUTES"))
+	if err != nil || paymentMinutes < 1 {
+		paymentMinutes = 60
+	}
+	confirmDays, err := strconv.Atoi(os.Getenv("ORDER_CONFIRM_DAYS"))
+	if err != nil || confirmDays < 1 {
+		confirmDays = 3
+	}
+	completeDays, err := strconv.Atoi(os.Getenv("ORDER_AUTO_COMPLETE_DAYS"))
+	if err != nil || completeDays < 1 {
+		completeDays = 7
+	}
+
 	return &orderService{
 		repo:               orderRepo,
 		cartRepo:           cartRepo,
 		productClient:      productClient,
 		userClient:         userClient,
-		paymentClient:      paymentClient,
+		payments:           payments,
 		GHNClient:          GHNClient,
 		notificationClient: notificationClient,
+		loyaltyService:     loyaltyService,
+		walletClient:       walletClient,
+		taxService:         taxService,
+		payoutService:      payoutService,
+		trackingService:    trackingService,
+		codRiskService:     codRiskService,
+		purchaseLimits:     purchaseLimits,
 		clientURL:          os.Getenv("CLIENT_URL"),
+		paymentWindow:      time.Duration(paymentMinutes) * time.Minute,
+		confirmWindow:      time.Duration(confirmDays) * 24 * time.Hour,
+		completeAfter:      time.Duration(completeDays) * 24 * time.Hour,
 	}
 }
 
+// Checkout places an order for the cart items. Pre-ordered items ship later, so
+// when they come with items in stock they are placed as an order of their own;
+// the voucher, loyalty points and wallet part stay with the items in stock.
 func (s *orderService) Checkout(userID string, request dto.CheckoutRequest) (*dto.CheckoutResponse, error) {
+	var cartItems []*model.CartItem
+	var variantIDs []string
+	for _, itemID := range request.CartItemIDs {
+		item, err := s.cartRepo.FindCartItemByID(itemID)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil || item.UserID != userID {
+			// Rejected by the checkout itself
+			return s.checkout(userID, request)
+		}
+		cartItems = append(cartItems, item)
+		if !item.IsBundle() {
+			variantIDs = append(variantIDs, item.Variant.ID)
+		}
+	}
+
+	variants, err := s.variantsByID(variantIDs)
+	if err != nil {
+		return nil, err
+	}
+	inStock, preOrder := request, request
+	inStock.CartItemIDs, preOrder.CartItemIDs = nil, nil
+	for _, item := range cartItems {
+		if variant, ok := variants[item.Variant.ID]; ok && !item.IsBundle() && variant.IsPreOrder(item.Quantity) {
+			preOrder.CartItemIDs = append(preOrder.CartItemIDs, item.ID)
+		} else {
+			inStock.CartItemIDs = append(inStock.CartItemIDs, item.ID)
+		}
+	}
+	if len(inStock.CartItemIDs) == 0 || len(preOrder.CartItemIDs) == 0 {
+		return s.checkout(userID, request)
+	}
+
+	response, err := s.checkout(userID, inStock)
+	if err != nil {
+		return nil, err
+	}
+	// The order of the items in stock stands whatever happens to the pre-order.
+	// Its items left the cart, so a failed pre-order can simply be checked out again.
+	preOrder.VoucherID, preOrder.RedeemPoints, preOrder.WalletAmount = "", 0, 0
+	response.PreOrder, err = s.checkout(userID, preOrder)
+	if err != nil {
+		response.PreOrderError = checkoutErrorMessage(userID, err)
+	}
+	return response, nil
+}
+
+// checkoutErrorMessage tells the buyer why the pre-order half of a split
+// checkout failed, after the order of the items in stock was placed
+func checkoutErrorMessage(userID string, err error) string {
+	var appErr *appError.AppError
+	if errors.As(err, &appErr) && appErr.Code < 500 {
+		return appErr.Message
+	}
+	fmt.Printf("Warning: failed to place the pre-order of user %s: %v\n", userID, err)
+	return "The pre-ordered items could not be ordered, please try again"
+}
+
+func (s *orderService) checkout(userID string, request dto.CheckoutRequest) (*dto.CheckoutResponse, error) {
 	//fetch seller
 	sellerFetch, err := s.userClient.GetUserByID(userID)
 	if err != nil {
@@ -113,11 +227,13 @@ func (s *orderService) Checkout(userID string, request dto.CheckoutRequest) (*dt
 
 	// 4. Fetch Variant details and reverse stock
 	var orderItems []model.OrderItem
-	var totalAmount float64
+	var totalAmount model.Money
 	var variantIDs []string
 
 	for _, item := range cartItems {
-		variantIDs = append(variantIDs, item.Variant.ID)
+		if !item.IsBundle() {
+			variantIDs = append(variantIDs, item.Variant.ID)
+		}
 	}
 
 	variants, err := s.productClient.GetVariantsByIds(variantIDs)
@@ -130,30 +246,59 @@ func (s *orderService) Checkout(userID string, request dto.CheckoutRequest) (*dt
 		variantMap[v.Variant.ID] = dto.ProductVariantDto{
 			ProductName:       v.ProductName,
 			SellerID:          v.SellerID,
+			CategoryIds:       v.CategoryIds,
 			SellerCategoryIds: v.SellerCategoryIds,
 			Variant: dto.VariantDto{
-				ID:      v.Variant.ID,
-				SKU:     v.Variant.SKU,
-				Options: v.Variant.Options,
-				Price:   v.Variant.Price,
-				Stock:   v.Variant.Stock,
-				Image:   v.Variant.Image,
+				ID:       v.Variant.ID,
+				SKU:      v.Variant.SKU,
+				Options:  v.Variant.Options,
+				Price:    v.Variant.Price,
+				Currency: v.Variant.Currency,
+				Stock:    v.Variant.Stock,
+				Image:    v.Variant.Image,
+				PreOrder: v.Variant.PreOrder,
 			},
 		}
 	}
 
+	var reserveItems []client.ReserveStockItem
 	for _, item := range cartItems {
+		// Bundles are expanded into one order item per component, with the
+		// bundle discount split across them
+		if item.IsBundle() {
+			bundleItems, bundleReserveItems, bundleVariants, err := s.buildBundleOrderItems(item, sellerID)
+			if err != nil {
+				return nil, err
+			}
+			for i, bundleItem := range bundleItems {
+				if err := addLineTotal(&totalAmount, int64(bundleItem.LineTotal()), bundleVariants[i].Variant.Currency); err != nil {
+					return nil, err
+				}
+			}
+			orderItems = append(orderItems, bundleItems...)
+			reserveItems = append(reserveItems, bundleReserveItems...)
+			variants = append(variants, bundleVariants...)
+			continue
+		}
+
 		variantInfo, exists := variantMap[item.Variant.ID]
 		if !exists {
 			return nil, appError.NewAppError(404, fmt.Sprintf("variant %s not found", item.Variant.ID))
 		}
 
-		if variantInfo.Variant.Stock < item.Quantity {
+		if variantInfo.Variant.MaxOrderable() < item.Quantity {
 			return nil, appError.NewAppError(409, fmt.Sprintf("not enough stock for product %s", variantInfo.ProductName))
 		}
+		var preOrderShipDate *time.Time
+		if variantInfo.Variant.IsPreOrder(item.Quantity) {
+			shipDate := variantInfo.Variant.PreOrder.ShipDate
+			preOrderShipDate = &shipDate
+		}
 
-		itemTotal := float64(variantInfo.Variant.Price * item.Quantity)
-		totalAmount += itemTotal
+		itemTotal := int64(variantInfo.Variant.Price * item.Quantity)
+		if err := addLineTotal(&totalAmount, itemTotal, variantInfo.Variant.Currency); err != nil {
+			return nil, err
+		}
 
 		// Convert options map to string
 		variantName := ""
@@ -165,26 +310,41 @@ func (s *orderService) Checkout(userID string, request dto.CheckoutRequest) (*dt
 		}
 
 		orderItems = append(orderItems, model.OrderItem{
-			ProductID:   item.Product.ID,
-			ProductName: variantInfo.ProductName,
-			VariantID:   item.Variant.ID,
-			VariantName: variantName,
-			SKU:         variantInfo.Variant.SKU,
-			Price:       variantInfo.Variant.Price,
-			Quantity:    item.Quantity,
-			Image:       variantInfo.Variant.Image,
+			ProductID:        item.Product.ID,
+			ProductName:      variantInfo.ProductName,
+			VariantID:        item.Variant.ID,
+			VariantName:      variantName,
+			SKU:              variantInfo.Variant.SKU,
+			Price:            variantInfo.Variant.Price,
+			Quantity:         item.Quantity,
+			Image:            variantInfo.Variant.Image,
+			CategoryIDs:      variantInfo.CategoryIds,
+			PreOrderShipDate: preOrderShipDate,
 		})
-	}
 
-	// Reserve stock for all items
-	var reserveItems []client.ReserveStockItem
-	for _, item := range cartItems {
 		reserveItems = append(reserveItems, client.ReserveStockItem{
 			VariantID: item.Variant.ID,
 			Quantity:  item.Quantity,
+			PreOrder:  preOrderShipDate != nil,
 		})
 	}
 
+	// Check purchase limits; the user's other checkouts wait until this order is saved
+	unlock, err := s.purchaseLimits.Lock(userID, variants)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := s.purchaseLimits.CheckOrder(userID, orderItems, variants); err != nil {
+		return nil, err
+	}
+
+	// Pre-ordered items ship later, so they are kept in orders of their own
+	expectedShipDate, err := latestPreOrderShipDate(orderItems)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a temporary order ID for stock reservation
 	// We'll use this to track the reservation before the actual order is created
 	tempOrderID := fmt.Sprintf("temp_%s_%d", userID, time.Now().UnixNano())
@@ -227,7 +387,9 @@ func (s *orderService) Checkout(userID string, request dto.CheckoutRequest) (*dt
 		},
 		Items:             orderItems,
 		Voucher:           saveOrderVoucher,
-		Total:             totalAmount,
+		Currency:          totalAmount.Currency,
+		Total:             totalAmount.Amount,
+		ExpectedShipDate:  expectedShipDate,
 		DeliveryServiceID: request.DeliveryServiceID,
 		ShippingAddress: model.OrderAddress{
 			FullName:    request.ShippingAddress.FullName,
@@ -244,26 +406,61 @@ func (s *orderService) Checkout(userID string, request dto.CheckoutRequest) (*dt
 		},
 	}
 
+	// Compute VAT per item; tax-exclusive sellers have it added to the total
+	if err := s.taxService.ApplyTax(order, variants); err != nil {
+		// Rollback: release reserved stock
+		_ = s.productClient.ReleaseStock(tempOrderID)
+		return nil, err
+	}
+
+	// Redeem loyalty points as a discount line
+	if request.RedeemPoints > 0 {
+		if err := s.redeemLoyaltyPoints(userID, order, request.RedeemPoints); err != nil {
+			// Rollback: release reserved stock
+			_ = s.productClient.ReleaseStock(tempOrderID)
+			return nil, err
+		}
+	}
+
 	//handle delivery fee
 	// calculate delivery fee
 	calculateFeeRequest, err := s.GHNClient.CreateCalculateFeeRequest(*order, *sellerFetch)
 	deliveryFeeResponse, err := s.GHNClient.CalculateFee(*calculateFeeRequest)
 	if err != nil {
-		// Rollback: release reserved stock
+		// Rollback: release reserved stock and redeemed points
 		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
 		return nil, fmt.Errorf("failed to get delivery fee: %w", err)
 	}
 	order.DeliveryFee = deliveryFeeResponse.Total
 
+	// Score COD orders, refusing COD to the riskiest ones
+	if err := s.assessCodRisk(order, user); err != nil {
+		// Rollback: release reserved stock and redeemed points
+		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
+		return nil, err
+	}
+
+	// Pay all or part of the order from the wallet
+	if err := s.payFromWallet(order, request.PaymentMethod, request.WalletAmount); err != nil {
+		// Rollback: release reserved stock and redeemed points
+		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
+		return nil, err
+	}
+
 	if err := s.repo.CreateOrder(order); err != nil {
-		// Rollback: release reserved stock
+		// Rollback: release reserved stock, redeemed points and wallet payment
 		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
+		s.cancelWalletPayment(order)
 		return nil, err
 	}
 
 	// Publish purchase interaction events for each item (best-effort, non-blocking)
-	// COD orders: publish here; online-paid orders: publish in HandleCheckoutSessionCompleted
-	if request.PaymentMethod == "COD" {
+	// COD and wallet-paid orders: publish here; gateway-paid orders: publish in HandlePaymentSucceeded
+	if request.PaymentMethod == "COD" || order.PaymentStatus == "PAID" {
 		for _, item := range orderItems {
 			pid := item.ProductID
 			go config.PublishUserInteraction(userID, pid, "purchase", 10)
@@ -288,7 +485,8 @@ func (s *orderService) Checkout(userID string, request dto.CheckoutRequest) (*dt
 	if request.PaymentMethod == "COD" {
 		orderData := map[string]interface{}{
 			"orderId":      order.ID,
-			"total":        order.Total + float64(order.DeliveryFee),
+			"total":        order.GrandTotal().Amount,
+			"currency":     order.Currency,
 			"itemCount":    len(order.Items),
 			"customerName": user.Name,
 		}
@@ -307,10 +505,17 @@ func (s *orderService) Checkout(userID string, request dto.CheckoutRequest) (*dt
 		}
 	}
 
+	// Orders fully paid from the wallet skip the payment step
+	if order.PaymentStatus == "PAID" {
+		s.notifySellerPaidOrder(order)
+	}
+
 	return &dto.CheckoutResponse{
 		OrderID:     order.ID,
-		TotalAmount: order.Total + float64(order.DeliveryFee),
+		TotalAmount: order.GrandTotal().Amount,
+		Currency:    order.Currency,
 		Status:      order.Status,
+		PaymentUrl:  s.paymentURLFor(order),
 	}, nil
 }
 
@@ -343,78 +548,93 @@ func (s *orderService) CreateCheckoutSession(ctx context.Context, orderID string
 	if order == nil {
 		return nil, appError.NewAppError(404, "order not found")
 	}
-
-	var lineItems []*stripe.CheckoutSessionLineItemParams
-
-	// Add order total (excluding delivery fee) as a line item
-	if order.Total > 0 {
-		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
-			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-				Currency: stripe.String("vnd"),
-				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-					Name: stripe.String("Order Total"),
-				},
-				UnitAmount: stripe.Int64(int64(order.Total)),
-			},
-			Quantity: stripe.Int64(1),
-		})
-	}
-
-	// Add delivery fee as a separate line item
-	if order.DeliveryFee > 0 {
-		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
-			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-				Currency: stripe.String("vnd"),
-				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-					Name: stripe.String("Delivery Fee"),
-				},
-				UnitAmount: stripe.Int64(int64(order.DeliveryFee)),
-			},
-			Quantity: stripe.Int64(1),
-		})
+	if order.PaymentStatus == "PAID" {
+		return nil, appError.NewAppError(409, "order is already paid")
 	}
 
-	params := &stripe.CheckoutSessionParams{
-		PaymentMethodTypes: stripe.StringSlice([]string{
-			"card",
-		}),
-		LineItems:  lineItems,
-		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
-		SuccessURL: stripe.String(s.clientURL + "/checkout/success"),
-		CancelURL:  stripe.String(s.clientURL + "/checkout/failure"),
-		Metadata: map[string]string{
-			"order_id": order.ID,
-		},
+	// Pick the gateway from the order's payment method
+	gateway, err := s.payments.Get(order.PaymentMethod)
+	if err != nil {
+		return nil, err
 	}
 
-	sess, err := session.New(params)
+	paymentURL, err := gateway.CreatePayment(order, s.clientURL+"/checkout/success", s.clientURL+"/checkout/failure")
 	if err != nil {
-		return nil, appError.NewAppError(500, "failed to create checkout session")
+		var appErr *appError.AppError
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, appError.NewAppErrorWithErr(500, "failed to create payment", err)
 	}
 
 	return &dto.CreatePaymentResponse{
-		PaymentUrl: sess.URL,
+		PaymentUrl: paymentURL,
 	}, nil
 }
 
-func (s *orderService) HandleCheckoutSessionCompleted(ctx context.Context, orderID, sessionID, paymentIntentID string) error {
-	order, err := s.repo.FindOrderByID(orderID)
+// HandlePaymentSucceeded marks an order paid after a verified gateway callback.
+// Callbacks are retried by gateways, so an already paid order is left as is.
+func (s *orderService) HandlePaymentSucceeded(ctx context.Context, event *payment.PaymentEvent) error {
+	order, err := s.repo.FindOrderByID(event.OrderID)
 	if err != nil {
 		return err
 	}
 	if order == nil {
-		return fmt.Errorf("order not found: %s", orderID)
+		return appError.NewAppError(404, "order not found: "+event.OrderID)
+	}
+	if order.PaymentStatus == "PAID" {
+		return nil
+	}
+	// A payment link created before items were cancelled still charges the old
+	// amount; the difference is refunded to the wallet below
+	paid := order.AmountDue()
+	if !event.Amount.IsZero() {
+		overpaid := len(order.Cancellations) > 0 && event.Amount.Currency == paid.Currency && event.Amount.Amount > paid.Amount
+		if event.Amount != paid && !overpaid {
+			return appError.NewAppError(400, "paid amount does not match the order")
+		}
+		paid = event.Amount
+	}
+
+	order.PaymentID = event.PaymentID
+	if order.Status == "CANCELLED" {
+		// Paid after the order was cancelled, e.g. past the payment window:
+		// the money goes back to the buyer's wallet
+		if err := s.walletClient.RecordCardCapture(order, int(paid.Amount)); err != nil {
+			return err
+		}
+		// Refunded apart from the refund made on cancellation, which may have covered a wallet part
+		if _, err := s.walletClient.RefundExcess(ctx, order.ID, 0, order.ID+":late-payment"); err != nil {
+			return err
+		}
+		order.PaymentStatus = "REFUNDED"
+		_, err := s.repo.TransitionOrder(order, "CANCELLED")
+		return err
 	}
 
 	// Update payment status to PAID
 	order.PaymentStatus = "PAID"
 	// Update order status to TO_CONFIRM
-	order.Status = "TO_CONFIRM"
+	from := order.Status
+	order.SetStatus("TO_CONFIRM", model.ActorSystem, "", "payment received")
 
-	err = s.repo.UpdateOrder(order)
+	moved, err := s.repo.TransitionOrder(order, from)
 	if err != nil {
 		return err
 	}
+	if !moved {
+		// Cancelled while the payment came in: handled as a late payment
+		return s.HandlePaymentSucceeded(ctx, event)
+	}
+
+	// Record the gateway part in the wallet ledger so refunds can go to the wallet (best-effort)
+	if err := s.walletClient.RecordCardCapture(order, int(paid.Amount)); err != nil {
+		fmt.Printf("Warning: failed to record card payment for order %s: %v\n", order.ID, err)
+	} else if paid.Amount > order.AmountDue().Amount {
+		if _, err := s.walletClient.RefundExcess(ctx, order.ID, int(order.GrandTotal().Amount), order.ID+":overpaid"); err != nil {
+			fmt.Printf("Warning: failed to refund overpayment for order %s: %v\n", order.ID, err)
+		}
+	}
 
 	// Publish purchase interaction events for online-paid orders (best-effort, non-blocking)
 	for _, item := range order.Items {
@@ -422,16 +642,22 @@ func (s *orderService) HandleCheckoutSessionCompleted(ctx context.Context, order
 		go config.PublishUserInteraction(order.User.ID, pid, "purchase", 10)
 	}
 
-	// Send notification to seller about the paid order
+	s.notifySellerPaidOrder(order)
+	return nil
+}
+
+// notifySellerPaidOrder tells the seller about a new paid order (best-effort)
+func (s *orderService) notifySellerPaidOrder(order *model.Order) {
 	orderData := map[string]interface{}{
 		"orderId":       order.ID,
-		"total":         order.Total + float64(order.DeliveryFee),
+		"total":         order.GrandTotal().Amount,
+		"currency":      order.Currency,
 		"itemCount":     len(order.Items),
 		"customerName":  order.User.Name,
 		"paymentMethod": order.PaymentMethod,
 	}
 
-	err = s.notificationClient.CreateNotification(client.CreateNotificationRequest{
+	err := s.notificationClient.CreateNotification(client.CreateNotificationRequest{
 		UserID:  order.Seller.ID,
 		Type:    "order",
 		Title:   "New Paid Order",
@@ -443,17 +669,27 @@ func (s *orderService) HandleCheckoutSessionCompleted(ctx context.Context, order
 		// Log error but don't fail the order update
 		fmt.Printf("Warning: failed to send notification to seller: %v\n", err)
 	}
-
-	return nil
 }
 
-func (s *orderService) HandlePaymentFailed(ctx context.Context, paymentIntentID string) error {
-	// Find order by payment intent ID would require storing it
-	// For now, we'll need to add a field to store payment intent ID
-	// This is a simplified version that just logs the failure
-	// In production, you'd want to find the order by payment intent ID
-	// and update its status accordingly
-	return nil
+// HandlePaymentFailed records a failed attempt. The order stays TO_PAY so the
+// buyer can retry the payment or cancel the order.
+func (s *orderService) HandlePaymentFailed(ctx context.Context, event *payment.PaymentEvent) error {
+	if event.OrderID == "" {
+		return nil
+	}
+
+	order, err := s.repo.FindOrderByID(event.OrderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return appError.NewAppError(404, "order not found: "+event.OrderID)
+	}
+
+	// A late failure for an earlier attempt must not undo a successful one, so
+	// only an order still awaiting payment is marked
+	_, err = s.repo.MarkPaymentFailed(order.ID)
+	return err
 }
 
 func (s *orderService) GetUserOrders(ctx context.Context, userID string, request dto.GetOrdersRequest) (*dto.GetOrdersResponse, error) {
@@ -471,7 +707,17 @@ func (s *orderService) GetUserOrders(ctx context.Context, userID string, request
 	}
 
 	// Query repository
-	orders, totalCount, err := s.repo.FindOrdersByUser(userID, request.Status, page, limit, request.SortBy, request.SortOrder)
+	orders, totalCount, err := s.repo.FindOrdersByUser(
+		userID,
+		request.Status,
+		request.Search,
+		request.StartDate,
+		request.EndDate,
+		page,
+		limit,
+		request.SortBy,
+		request.SortOrder,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -572,10 +818,11 @@ func (s *orderService) GetSellerOrders(ctx context.Context, sellerID string, req
 		return nil, err
 	}
 
-	// Map to DTOs
+	// Map to DTOs, with the COD risk buyers don't see
 	orderDtos := make([]dto.OrderDto, len(orders))
 	for i, order := range orders {
 		orderDtos[i] = convertOrderToDto(order)
+		orderDtos[i].CodRisk = order.CodRisk
 	}
 
 	// Calculate total pages
@@ -631,20 +878,23 @@ func (s *orderService) UpdateOrderStatus(ctx context.Context, userID string, ord
 			if oldOrder.Seller.ID != userID {
 				return appError.NewAppError(403, "Only the seller can confirm an order")
 			}
-			ghnRequest, err := s.GHNClient.CreateRequest(*oldOrder, *seller)
-			if err != nil {
-				return err
-			}
-			deliveryCode, err := s.GHNClient.CreateOrder(ghnRequest)
-			if err != nil {
+			// The whole order goes in one parcel; CreateShipment splits it
+			if err := s.createShipment(oldOrder, oldOrder.UnshippedItems(), *seller); err != nil {
 				return err
 			}
-			oldOrder.DeliveryCode = deliveryCode
 		}
 	case "TO_PICKUP":
 		if request.Status != "SHIPPING" && request.Status != "CANCELLED" && request.Status != "COMPLETED" {
 			return appError.NewAppError(400, "Invalid status")
 		}
+		// The order status follows its parcels, so they are marked shipped too
+		if request.Status == "SHIPPING" {
+			for i := range oldOrder.Shipments {
+				if oldOrder.Shipments[i].Status == model.ShipmentToPickup {
+					oldOrder.Shipments[i].SetStatus(model.ShipmentShipping, time.Now())
+				}
+			}
+		}
 	case "SHIPPING":
 		if request.Status != "COMPLETED" && request.Status != "RETURNED" {
 			return appError.NewAppError(400, "Invalid status")
@@ -653,16 +903,479 @@ func (s *orderService) UpdateOrderStatus(ctx context.Context, userID string, ord
 		return appError.NewAppError(409, "Current status of this order can't be updated: "+oldOrder.Status)
 	}
 
+	actor := model.ActorBuyer
+	if oldOrder.Seller.ID == userID {
+		actor = model.ActorSeller
+	}
+	return s.changeStatus(ctx, oldOrder, request.Status, actor, userID, "")
+}
+
+// changeStatus moves an order to a status already checked to be valid, with
+// everything that comes with it: refunds, stock, loyalty points and the seller
+// ledger. The status is written first, on condition that the order is still in
+// the status it was read in, so a concurrent change is never overwritten and
+// its side effects run only once.
+func (s *orderService) changeStatus(ctx context.Context, order *model.Order, status, actor, actorID, reason string) error {
+	previous := *order
+	order.SetStatus(status, actor, actorID, reason)
+	moved, err := s.repo.TransitionOrder(order, previous.Status)
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return errOrderChanged
+	}
+
+	// Refund what was paid into the buyer's wallet
+	if status == "CANCELLED" || status == "RETURNED" {
+		if previous.PaymentStatus == "PAID" || previous.WalletAmount > 0 {
+			if err := s.walletClient.RefundPayment(ctx, order.ID); err != nil {
+				// Put the order back so that the change can be tried again
+				*order = previous
+				if _, revertErr := s.repo.TransitionOrder(order, status); revertErr != nil {
+					fmt.Printf("Warning: failed to restore order %s after a failed refund: %v\n", order.ID, revertErr)
+				}
+				return err
+			}
+			order.PaymentStatus = "REFUNDED"
+		}
+	}
+
 	// Release reserved stock when an order is cancelled
-	if request.Status == "CANCELLED" {
-		if err := s.productClient.ReleaseStock(oldOrder.ID); err != nil {
-			fmt.Printf("Warning: failed to release stock for cancelled order %s: %v\n", oldOrder.ID, err)
+	if status == "CANCELLED" {
+		if err := s.productClient.ReleaseStock(order.ID); err != nil {
+			fmt.Printf("Warning: failed to release stock for cancelled order %s: %v\n", order.ID, err)
 		}
+		s.cancelLoyaltyRedemption(order)
+		s.cancelShipments(order)
 	}
 
-	//update in database
-	oldOrder.Status = request.Status
-	return s.repo.UpdateOrder(oldOrder)
+	if order.PaymentStatus != previous.PaymentStatus || status == "CANCELLED" {
+		if _, err := s.repo.TransitionOrder(order, status); err != nil {
+			return err
+		}
+	}
+
+	// Loyalty points: earn on completion, take back on return
+	switch status {
+	case "COMPLETED":
+		if err := s.loyaltyService.EarnForOrder(order); err != nil {
+			fmt.Printf("Warning: failed to award loyalty points for order %s: %v\n", order.ID, err)
+		}
+	case "RETURNED":
+		if err := s.loyaltyService.ReverseForOrder(order); err != nil {
+			fmt.Printf("Warning: failed to reverse loyalty points for order %s: %v\n", order.ID, err)
+		}
+	}
+
+	// Seller ledger; can be rebuilt later with a payout recompute
+	switch status {
+	case "COMPLETED", "CANCELLED", "RETURNED":
+		if err := s.payoutService.RecordOrder(order); err != nil {
+			fmt.Printf("Warning: failed to record seller payout for order %s: %v\n", order.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// RequestReturn records the buyer's wish to return an order that is being
+// delivered. The seller settles it by marking the order RETURNED or COMPLETED;
+// until then the order is not completed automatically.
+func (s *orderService) RequestReturn(ctx context.Context, userID string, orderID string, request dto.ReturnRequestRequest) error {
+	order, err := s.repo.FindOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return appError.NewAppError(404, "Order not found")
+	}
+	if order.User.ID != userID {
+		return appError.NewAppError(403, "Only the buyer can request a return")
+	}
+	if order.Status != "SHIPPING" {
+		return appError.NewAppError(400, "Returns can only be requested for orders being delivered")
+	}
+	if order.ReturnRequest != nil {
+		return appError.NewAppError(409, "A return has already been requested for this order")
+	}
+
+	now := time.Now()
+	order.ReturnRequest = &model.ReturnRequest{
+		Reason:      request.Reason,
+		RequestedAt: now,
+	}
+	order.UpdatedAt = now
+	updated, err := s.repo.SetReturnRequest(order.ID, order.ReturnRequest)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errOrderChanged
+	}
+
+	err = s.notificationClient.CreateNotification(client.CreateNotificationRequest{
+		UserID:  order.Seller.ID,
+		Type:    "order",
+		Title:   "Return Requested",
+		Message: fmt.Sprintf("%s asked to return order %s", order.User.Name, order.ID),
+		Data: map[string]interface{}{
+			"orderId": order.ID,
+			"reason":  request.Reason,
+		},
+	})
+	if err != nil {
+		// Log error but don't fail the request
+		fmt.Printf("Warning: failed to send notification to seller: %v\n", err)
+	}
+
+	return nil
+}
+
+// CancelOrderItems takes items or quantities off an order before the seller
+// confirms it. The voucher, VAT and delivery fee are worked out again for what is
+// left, only the cancelled items' stock is released, and whatever the order now
+// holds above its new total goes back to the buyer's wallet. Cancelling every
+// item cancels the order.
+func (s *orderService) CancelOrderItems(ctx context.Context, userID string, orderID string, request dto.CancelOrderItemsRequest) (*dto.OrderDto, error) {
+	order, err := s.repo.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, appError.NewAppError(404, "Order not found")
+	}
+	if order.Seller.ID != userID && order.User.ID != userID {
+		return nil, appError.NewAppError(403, "You are not authorized to update this order")
+	}
+
+	switch order.Status {
+	case "TO_PAY":
+		if order.User.ID != userID {
+			return nil, appError.NewAppError(403, "Only the buyer can cancel items of an unpaid order")
+		}
+	case "TO_CONFIRM":
+	default:
+		return nil, appError.NewAppError(409, "Items can only be cancelled before the seller confirms the order")
+	}
+
+	actor := model.ActorBuyer
+	if order.Seller.ID == userID {
+		actor = model.ActorSeller
+	}
+
+	// The discount given at checkout, derived from the lines before they change
+	oldDiscount := order.VoucherDiscount()
+	previous := *order
+
+	items := slices.Clone(order.Items)
+	var releaseItems []client.ReserveStockItem
+	var cancelledItems []model.CancelledItem
+	for _, requested := range request.Items {
+		// Bundle discounts are split across the components, so a bundle is cancelled whole
+		if requested.BundleID != "" {
+			if requested.Quantity != 0 {
+				return nil, appError.NewAppError(400, "bundles can only be cancelled whole")
+			}
+			found := false
+			items = slices.DeleteFunc(items, func(item model.OrderItem) bool {
+				if item.BundleID != requested.BundleID {
+					return false
+				}
+				found = true
+				releaseItems = append(releaseItems, client.ReserveStockItem{VariantID: item.VariantID, Quantity: item.Quantity})
+				cancelledItems = append(cancelledItems, model.CancelledItem{VariantID: item.VariantID, BundleID: item.BundleID, Quantity: item.Quantity})
+				return true
+			})
+			if !found {
+				return nil, appError.NewAppError(404, fmt.Sprintf("bundle %s is not in this order", requested.BundleID))
+			}
+			continue
+		}
+
+		index := slices.IndexFunc(items, func(item model.OrderItem) bool {
+			return item.VariantID == requested.VariantID && item.BundleID == ""
+		})
+		if index < 0 {
+			return nil, appError.NewAppError(404, fmt.Sprintf("variant %s is not in this order", requested.VariantID))
+		}
+		quantity := requested.Quantity
+		if quantity == 0 {
+			quantity = items[index].Quantity
+		}
+		if quantity > items[index].Quantity {
+			return nil, appError.NewAppError(400, fmt.Sprintf("only %d of variant %s are left in this order", items[index].Quantity, requested.VariantID))
+		}
+
+		items[index].Quantity -= quantity
+		if items[index].Quantity == 0 {
+			items = slices.Delete(items, index, index+1)
+		}
+		releaseItems = append(releaseItems, client.ReserveStockItem{VariantID: requested.VariantID, Quantity: quantity})
+		cancelledItems = append(cancelledItems, model.CancelledItem{VariantID: requested.VariantID, Quantity: quantity})
+	}
+
+	if len(items) == 0 {
+		if err := s.changeStatus(ctx, order, "CANCELLED", actor, userID, request.Reason); err != nil {
+			return nil, err
+		}
+		orderDto := convertOrderToDto(order)
+		return &orderDto, nil
+	}
+
+	cancellation := model.ItemCancellation{
+		Items:   cancelledItems,
+		Actor:   actor,
+		ActorID: userID,
+		Reason:  request.Reason,
+		At:      time.Now(),
+	}
+	order.Items = items
+
+	// Voucher: the discount is worked out again on the items left, and the
+	// voucher is dropped once they no longer reach its minimum order value
+	itemsTotal := model.NewMoney(order.ItemsTotal(), order.Currency)
+	var discount int64
+	if order.Voucher != nil {
+		switch {
+		case order.Voucher.ID == "":
+			// Terms not kept on older orders: keep the checkout discount
+			discount = oldDiscount
+		case itemsTotal.Amount < int64(order.Voucher.MinOrderValue):
+			cancellation.RemovedVoucher = order.Voucher.Code
+			order.Voucher = nil
+		default:
+			discount = voucherDiscount(order.Voucher, itemsTotal).Amount
+		}
+	}
+	if discount > itemsTotal.Amount {
+		discount = itemsTotal.Amount
+	}
+	order.Total = itemsTotal.Amount - discount
+
+	// VAT with the rates kept at checkout, then the redeemed points. Points
+	// worth more than what is left stay spent on the order.
+	s.taxService.RecomputeTax(order)
+	if int64(order.PointsDiscount) > order.Total {
+		order.PointsDiscount = int(order.Total)
+	}
+	order.Total -= int64(order.PointsDiscount)
+
+	// Re-quote the delivery fee before anything is refunded or released
+	seller, err := s.userClient.GetUserByID(order.Seller.ID)
+	if err != nil {
+		return nil, err
+	}
+	calculateFeeRequest, err := s.GHNClient.CreateCalculateFeeRequest(*order, *seller)
+	if err != nil {
+		return nil, err
+	}
+	deliveryFeeResponse, err := s.GHNClient.CalculateFee(*calculateFeeRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery fee: %w", err)
+	}
+	order.DeliveryFee = deliveryFeeResponse.Total
+
+	// The order holds more than its total now: the difference for paid orders,
+	// or a wallet part that covers more than an unpaid order costs
+	grandTotal := int(order.GrandTotal().Amount)
+	refundDue := order.PaymentStatus == "PAID" || order.WalletAmount > grandTotal
+	if order.WalletAmount > grandTotal {
+		order.WalletAmount = grandTotal
+	}
+	paidNow := order.PaymentStatus != "PAID" && order.WalletAmount > 0 && order.WalletAmount == grandTotal
+	if paidNow {
+		order.PaymentStatus = "PAID"
+		order.SetStatus("TO_CONFIRM", model.ActorSystem, "", "paid from the wallet")
+	}
+
+	order.Cancellations = append(order.Cancellations, cancellation)
+	order.UpdatedAt = cancellation.At
+	saved, err := s.repo.ReplaceOrderIf(order, previous.Status, previous.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, errOrderChanged
+	}
+
+	// Refunded only once the cancellation is saved. The reference is the
+	// cancellation's position, so the same cancellation is never refunded twice.
+	if refundDue {
+		index := len(order.Cancellations) - 1
+		reference := fmt.Sprintf("%s:cancel:%d", order.ID, index+1)
+		refund, err := s.walletClient.RefundExcess(ctx, order.ID, grandTotal, reference)
+		if err != nil {
+			// Put the items back, unless the order moved on already
+			cancelled := *order
+			*order = previous
+			order.UpdatedAt = time.Now()
+			if _, revertErr := s.repo.ReplaceOrderIf(order, cancelled.Status, cancelled.UpdatedAt); revertErr != nil {
+				fmt.Printf("Warning: failed to restore items of order %s after a failed refund: %v\n", order.ID, revertErr)
+			}
+			return nil, err
+		}
+		order.Cancellations[index].Refund = refund
+		if err := s.repo.SetCancellationRefund(order.ID, index, refund); err != nil {
+			fmt.Printf("Warning: failed to record refund of order %s: %v\n", order.ID, err)
+		}
+	}
+
+	if err := s.productClient.ReleaseStockItems(order.ID, releaseItems); err != nil {
+		fmt.Printf("Warning: failed to release stock for cancelled items of order %s: %v\n", order.ID, err)
+	}
+
+	// Tell the other party (best-effort)
+	notifyUserID := order.Seller.ID
+	notifyMessage := fmt.Sprintf("%s cancelled some items of order %s", order.User.Name, order.ID)
+	if actor == model.ActorSeller {
+		notifyUserID = order.User.ID
+		notifyMessage = fmt.Sprintf("%s cancelled some items of your order %s", order.Seller.Name, order.ID)
+	}
+	err = s.notificationClient.CreateNotification(client.CreateNotificationRequest{
+		UserID:  notifyUserID,
+		Type:    "order",
+		Title:   "Order Items Cancelled",
+		Message: notifyMessage,
+		Data: map[string]interface{}{
+			"orderId":  order.ID,
+			"total":    order.GrandTotal().Amount,
+			"currency": order.Currency,
+			"refund":   cancellation.Refund,
+		},
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to send notification for order %s: %v\n", order.ID, err)
+	}
+
+	// An unpaid order now covered by its wallet part goes to the seller like any paid order
+	if paidNow {
+		for _, item := range order.Items {
+			pid := item.ProductID
+			go config.PublishUserInteraction(order.User.ID, pid, "purchase", 10)
+		}
+		s.notifySellerPaidOrder(order)
+	}
+
+	orderDto := convertOrderToDto(order)
+	return &orderDto, nil
+}
+
+// redeemLoyaltyPoints spends the buyer's points on the order and lowers its total.
+// The order ID is assigned up front so the ledger entry can reference it.
+func (s *orderService) redeemLoyaltyPoints(userID string, order *model.Order, points int) error {
+	if order.ID == "" {
+		order.ID = uuid.New().String()
+	}
+
+	discount, err := s.loyaltyService.Redeem(userID, order.ID, points, order.Total)
+	if err != nil {
+		return err
+	}
+	if discount <= 0 {
+		return nil
+	}
+
+	order.RedeemedPoints = points
+	order.PointsDiscount = discount
+	order.Total -= int64(discount)
+	return nil
+}
+
+// paymentURLFor returns the gateway redirect for a new order that still has something to pay.
+// It is best-effort: the buyer can always request a new one through CreateCheckoutSession.
+func (s *orderService) paymentURLFor(order *model.Order) string {
+	if order.PaymentStatus == "PAID" || order.AmountDue().Amount <= 0 || !s.payments.Has(order.PaymentMethod) {
+		return ""
+	}
+
+	gateway, err := s.payments.Get(order.PaymentMethod)
+	if err != nil {
+		return ""
+	}
+	paymentURL, err := gateway.CreatePayment(order, s.clientURL+"/checkout/success", s.clientURL+"/checkout/failure")
+	if err != nil {
+		fmt.Printf("Warning: failed to create payment for order %s: %v\n", order.ID, err)
+		return ""
+	}
+	return paymentURL
+}
+
+// assessCodRisk scores a COD order at checkout. When the risk can't be
+// assessed the order goes through without a score.
+func (s *orderService) assessCodRisk(order *model.Order, buyer *dto.UserResponse) error {
+	if order.PaymentMethod != "COD" {
+		return nil
+	}
+
+	risk, err := s.codRiskService.Assess(order, buyer)
+	if err != nil {
+		fmt.Printf("Warning: failed to assess COD risk for user %s: %v\n", buyer.ID, err)
+		return nil
+	}
+	if s.codRiskService.Blocks(risk) {
+		return appError.NewAppError(403, "Cash on delivery is not available for this order, please choose another payment method")
+	}
+	order.CodRisk = risk
+	return nil
+}
+
+// payFromWallet charges the wallet part of an order before it is created.
+// WALLET orders are paid in full; gateway orders may pay part of the total from the wallet.
+func (s *orderService) payFromWallet(order *model.Order, paymentMethod string, walletAmount int) error {
+	amountDue := int(order.GrandTotal().Amount)
+	switch paymentMethod {
+	case "WALLET":
+		walletAmount = amountDue
+	case "COD":
+		if walletAmount > 0 {
+			return appError.NewAppError(400, "wallet payment can't be combined with COD")
+		}
+	}
+	if walletAmount <= 0 {
+		return nil
+	}
+	if order.Currency != model.WalletCurrency {
+		return appError.NewAppError(400, "the wallet can only pay "+model.WalletCurrency+" orders")
+	}
+	if walletAmount > amountDue {
+		return appError.NewAppError(400, "wallet amount exceeds the order total")
+	}
+
+	if order.ID == "" {
+		order.ID = uuid.New().String()
+	}
+	order.WalletAmount = walletAmount
+	if _, err := s.walletClient.CreatePayment(order, "", ""); err != nil {
+		order.WalletAmount = 0
+		return err
+	}
+
+	if walletAmount == amountDue {
+		order.PaymentStatus = "PAID"
+		order.Status = "TO_CONFIRM"
+	}
+	return nil
+}
+
+// cancelWalletPayment gives back the wallet part of an order that failed to be created (best-effort)
+func (s *orderService) cancelWalletPayment(order *model.Order) {
+	if order.WalletAmount <= 0 {
+		return
+	}
+	if err := s.walletClient.CancelPayment(context.Background(), order.ID); err != nil {
+		fmt.Printf("Warning: failed to reverse wallet payment for order %s: %v\n", order.ID, err)
+	}
+}
+
+// cancelLoyaltyRedemption gives back points redeemed on an order (best-effort)
+func (s *orderService) cancelLoyaltyRedemption(order *model.Order) {
+	if order.RedeemedPoints <= 0 {
+		return
+	}
+	if err := s.loyaltyService.CancelRedemption(order.User.ID, order.ID); err != nil {
+		fmt.Printf("Warning: failed to refund loyalty points for order %s: %v\n", order.ID, err)
+	}
 }
 
 // Helper function to convert Order model to OrderDto
@@ -671,14 +1384,21 @@ func convertOrderToDto(order *model.Order) dto.OrderDto {
 	itemDtos := make([]dto.OrderItemDto, len(order.Items))
 	for i, item := range order.Items {
 		itemDtos[i] = dto.OrderItemDto{
-			ProductID:   item.ProductID,
-			VariantID:   item.VariantID,
-			ProductName: item.ProductName,
-			VariantName: item.VariantName,
-			SKU:         item.SKU,
-			Price:       item.Price,
-			Image:       item.Image,
-			Quantity:    item.Quantity,
+			ProductID:        item.ProductID,
+			VariantID:        item.VariantID,
+			ProductName:      item.ProductName,
+			VariantName:      item.VariantName,
+			SKU:              item.SKU,
+			Price:            item.Price,
+			Image:            item.Image,
+			Quantity:         item.Quantity,
+			BundleID:         item.BundleID,
+			BundleName:       item.BundleName,
+			Discount:         item.Discount,
+			TaxRate:          item.TaxRate,
+			TaxableAmount:    item.TaxableAmount,
+			TaxAmount:        item.TaxAmount,
+			PreOrderShipDate: item.PreOrderShipDate,
 		}
 	}
 
@@ -709,12 +1429,18 @@ func convertOrderToDto(order *model.Order) dto.OrderDto {
 			Name:     order.Seller.Name,
 			Email:    order.Seller.Email,
 		},
-		Items:     itemDtos,
-		CreatedAt: order.CreatedAt,
-		UpdatedAt: order.UpdatedAt,
-		Voucher:   voucherDto,
-		Total:     order.Total,
-		Phone:     order.Phone,
+		Items:          itemDtos,
+		CreatedAt:      order.CreatedAt,
+		UpdatedAt:      order.UpdatedAt,
+		Voucher:        voucherDto,
+		Currency:       order.Currency,
+		Total:          order.Total,
+		TaxTotal:       order.TaxTotal,
+		TaxInclusive:   order.TaxInclusive,
+		RedeemedPoints: order.RedeemedPoints,
+		PointsDiscount: order.PointsDiscount,
+		WalletAmount:   order.WalletAmount,
+		Phone:          order.Phone,
 		ShippingAddress: dto.OrderAddressDto{
 			FullName:    order.ShippingAddress.FullName,
 			Phone:       order.ShippingAddress.Phone,
@@ -726,8 +1452,15 @@ func convertOrderToDto(order *model.Order) dto.OrderDto {
 			Latitude:    order.ShippingAddress.Latitude,
 			Longitude:   order.ShippingAddress.Longitude,
 		},
-		DeliveryCode: order.DeliveryCode,
-		ItemCount:    len(order.Items),
+		DeliveryCode:     order.DeliveryCode,
+		DeliveryFee:      order.DeliveryFee,
+		Shipments:        order.Shipments,
+		DeliveredAt:      order.DeliveredAt,
+		ReturnRequest:    order.ReturnRequest,
+		StatusHistory:    order.StatusHistory,
+		Cancellations:    order.Cancellations,
+		ExpectedShipDate: order.ExpectedShipDate,
+		ItemCount:        len(order.Items),
 	}
 }
 
@@ -778,7 +1511,44 @@ func (s *orderService) GetSellerStatistics(ctx context.Context, sellerID string,
 	return response, nil
 }
 
+// InstantCheckout places an order for the given items, splitting off the
+// pre-ordered ones like Checkout does
 func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckoutRequest) (*dto.CheckoutResponse, error) {
+	var variantIDs []string
+	for _, item := range request.Items {
+		variantIDs = append(variantIDs, item.VariantID)
+	}
+	variants, err := s.variantsByID(variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	inStock, preOrder := request, request
+	inStock.Items, preOrder.Items = nil, nil
+	for _, item := range request.Items {
+		if variant, ok := variants[item.VariantID]; ok && variant.IsPreOrder(item.Quantity) {
+			preOrder.Items = append(preOrder.Items, item)
+		} else {
+			inStock.Items = append(inStock.Items, item)
+		}
+	}
+	if len(inStock.Items) == 0 || len(preOrder.Items) == 0 {
+		return s.instantCheckout(userID, request)
+	}
+
+	response, err := s.instantCheckout(userID, inStock)
+	if err != nil {
+		return nil, err
+	}
+	preOrder.VoucherID, preOrder.RedeemPoints, preOrder.WalletAmount = "", 0, 0
+	response.PreOrder, err = s.instantCheckout(userID, preOrder)
+	if err != nil {
+		response.PreOrderError = checkoutErrorMessage(userID, err)
+	}
+	return response, nil
+}
+
+func (s *orderService) instantCheckout(userID string, request dto.InstantCheckoutRequest) (*dto.CheckoutResponse, error) {
 	// Validate all items from same seller
 	if len(request.Items) == 0 {
 		return nil, appError.NewAppError(400, "no items provided")
@@ -810,7 +1580,7 @@ func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckou
 
 	// Fetch Variant details and validate stock
 	var orderItems []model.OrderItem
-	var totalAmount float64
+	var totalAmount model.Money
 	var variantIDs []string
 
 	for _, item := range request.Items {
@@ -827,14 +1597,17 @@ func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckou
 		variantMap[v.Variant.ID] = dto.ProductVariantDto{
 			ProductName:       v.ProductName,
 			SellerID:          v.SellerID,
+			CategoryIds:       v.CategoryIds,
 			SellerCategoryIds: v.SellerCategoryIds,
 			Variant: dto.VariantDto{
-				ID:      v.Variant.ID,
-				SKU:     v.Variant.SKU,
-				Options: v.Variant.Options,
-				Price:   v.Variant.Price,
-				Stock:   v.Variant.Stock,
-				Image:   v.Variant.Image,
+				ID:       v.Variant.ID,
+				SKU:      v.Variant.SKU,
+				Options:  v.Variant.Options,
+				Price:    v.Variant.Price,
+				Currency: v.Variant.Currency,
+				Stock:    v.Variant.Stock,
+				Image:    v.Variant.Image,
+				PreOrder: v.Variant.PreOrder,
 			},
 		}
 	}
@@ -845,12 +1618,19 @@ func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckou
 			return nil, appError.NewAppError(404, fmt.Sprintf("variant %s not found", item.VariantID))
 		}
 
-		if variantInfo.Variant.Stock < item.Quantity {
+		if variantInfo.Variant.MaxOrderable() < item.Quantity {
 			return nil, appError.NewAppError(409, fmt.Sprintf("not enough stock for product %s", variantInfo.ProductName))
 		}
+		var preOrderShipDate *time.Time
+		if variantInfo.Variant.IsPreOrder(item.Quantity) {
+			shipDate := variantInfo.Variant.PreOrder.ShipDate
+			preOrderShipDate = &shipDate
+		}
 
-		itemTotal := float64(variantInfo.Variant.Price * item.Quantity)
-		totalAmount += itemTotal
+		itemTotal := int64(variantInfo.Variant.Price * item.Quantity)
+		if err := addLineTotal(&totalAmount, itemTotal, variantInfo.Variant.Currency); err != nil {
+			return nil, err
+		}
 
 		// Convert options map to string
 		variantName := ""
@@ -862,23 +1642,42 @@ func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckou
 		}
 
 		orderItems = append(orderItems, model.OrderItem{
-			ProductID:   item.ProductID,
-			ProductName: variantInfo.ProductName,
-			VariantID:   item.VariantID,
-			VariantName: variantName,
-			SKU:         variantInfo.Variant.SKU,
-			Price:       variantInfo.Variant.Price,
-			Quantity:    item.Quantity,
-			Image:       variantInfo.Variant.Image,
+			ProductID:        item.ProductID,
+			ProductName:      variantInfo.ProductName,
+			VariantID:        item.VariantID,
+			VariantName:      variantName,
+			SKU:              variantInfo.Variant.SKU,
+			Price:            variantInfo.Variant.Price,
+			Quantity:         item.Quantity,
+			Image:            variantInfo.Variant.Image,
+			CategoryIDs:      variantInfo.CategoryIds,
+			PreOrderShipDate: preOrderShipDate,
 		})
 	}
 
+	// Check purchase limits; the user's other checkouts wait until this order is saved
+	unlock, err := s.purchaseLimits.Lock(userID, variants)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := s.purchaseLimits.CheckOrder(userID, orderItems, variants); err != nil {
+		return nil, err
+	}
+
+	// Pre-ordered items ship later, so they are kept in orders of their own
+	expectedShipDate, err := latestPreOrderShipDate(orderItems)
+	if err != nil {
+		return nil, err
+	}
+
 	// Reserve stock for all items
 	var reserveItems []client.ReserveStockItem
-	for _, item := range request.Items {
+	for _, item := range orderItems {
 		reserveItems = append(reserveItems, client.ReserveStockItem{
 			VariantID: item.VariantID,
 			Quantity:  item.Quantity,
+			PreOrder:  item.PreOrderShipDate != nil,
 		})
 	}
 
@@ -923,7 +1722,9 @@ func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckou
 		},
 		Items:             orderItems,
 		Voucher:           saveOrderVoucher,
-		Total:             totalAmount,
+		Currency:          totalAmount.Currency,
+		Total:             totalAmount.Amount,
+		ExpectedShipDate:  expectedShipDate,
 		DeliveryServiceID: request.DeliveryServiceID,
 		ShippingAddress: model.OrderAddress{
 			FullName:    request.ShippingAddress.FullName,
@@ -940,30 +1741,66 @@ func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckou
 		},
 	}
 
+	// Compute VAT per item; tax-exclusive sellers have it added to the total
+	if err := s.taxService.ApplyTax(order, variants); err != nil {
+		// Rollback: release reserved stock
+		_ = s.productClient.ReleaseStock(tempOrderID)
+		return nil, err
+	}
+
+	// Redeem loyalty points as a discount line
+	if request.RedeemPoints > 0 {
+		if err := s.redeemLoyaltyPoints(userID, order, request.RedeemPoints); err != nil {
+			// Rollback: release reserved stock
+			_ = s.productClient.ReleaseStock(tempOrderID)
+			return nil, err
+		}
+	}
+
 	// Calculate delivery fee
 	calculateFeeRequest, err := s.GHNClient.CreateCalculateFeeRequest(*order, *sellerFetch)
 	if err != nil {
-		// Rollback: release reserved stock
+		// Rollback: release reserved stock and redeemed points
 		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
 		return nil, fmt.Errorf("failed to create calculate fee request: %w", err)
 	}
 	deliveryFeeResponse, err := s.GHNClient.CalculateFee(*calculateFeeRequest)
 	if err != nil {
-		// Rollback: release reserved stock
+		// Rollback: release reserved stock and redeemed points
 		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
 		return nil, fmt.Errorf("failed to get delivery fee: %w", err)
 	}
 	order.DeliveryFee = deliveryFeeResponse.Total
 
+	// Score COD orders, refusing COD to the riskiest ones
+	if err := s.assessCodRisk(order, user); err != nil {
+		// Rollback: release reserved stock and redeemed points
+		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
+		return nil, err
+	}
+
+	// Pay all or part of the order from the wallet
+	if err := s.payFromWallet(order, request.PaymentMethod, request.WalletAmount); err != nil {
+		// Rollback: release reserved stock and redeemed points
+		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
+		return nil, err
+	}
+
 	if err := s.repo.CreateOrder(order); err != nil {
-		// Rollback: release reserved stock
+		// Rollback: release reserved stock, redeemed points and wallet payment
 		_ = s.productClient.ReleaseStock(tempOrderID)
+		s.cancelLoyaltyRedemption(order)
+		s.cancelWalletPayment(order)
 		return nil, err
 	}
 
 	// Publish purchase interaction events for each item (best-effort, non-blocking)
-	// COD orders: publish here; online-paid orders: publish in HandleCheckoutSessionCompleted
-	if request.PaymentMethod == "COD" {
+	// COD and wallet-paid orders: publish here; gateway-paid orders: publish in HandlePaymentSucceeded
+	if request.PaymentMethod == "COD" || order.PaymentStatus == "PAID" {
 		for _, item := range orderItems {
 			pid := item.ProductID
 			go config.PublishUserInteraction(userID, pid, "purchase", 10)
@@ -981,7 +1818,8 @@ func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckou
 	if request.PaymentMethod == "COD" {
 		orderData := map[string]interface{}{
 			"orderId":      order.ID,
-			"total":        order.Total + float64(order.DeliveryFee),
+			"total":        order.GrandTotal().Amount,
+			"currency":     order.Currency,
 			"itemCount":    len(order.Items),
 			"customerName": user.Name,
 		}
@@ -1000,48 +1838,63 @@ func (s *orderService) InstantCheckout(userID string, request dto.InstantCheckou
 		}
 	}
 
-	return &dto.CheckoutResponse{
+	// Orders fully paid from the wallet skip the payment step
+	if order.PaymentStatus == "PAID" {
+		s.notifySellerPaidOrder(order)
+	}
+
+	response := &dto.CheckoutResponse{
 		OrderID:     order.ID,
-		TotalAmount: order.Total + float64(order.DeliveryFee),
+		TotalAmount: order.GrandTotal().Amount,
+		Currency:    order.Currency,
 		Status:      order.Status,
-	}, nil
+	}
+	if !request.OffSession {
+		response.PaymentUrl = s.paymentURLFor(order)
+	}
+	return response, nil
 }
 
-func (s *orderService) ApplyVoucher(voucherId string, totalAmount float64, sellerId string, variants []dto.ProductVariantDto, userId string) (float64, *model.OrderVoucher, error) {
+func (s *orderService) ApplyVoucher(voucherId string, totalAmount model.Money, sellerId string, variants []dto.ProductVariantDto, userId string) (model.Money, *model.OrderVoucher, error) {
 
 	voucher, err := s.productClient.GetVoucherByID(voucherId)
 	if err != nil {
-		return 0, nil, err
+		return model.Money{}, nil, err
 	}
 
 	// Voucher should belong to seller
 	if voucher.SellerID != sellerId {
-		return 0, nil, appError.NewAppError(400, "voucher does not belong to seller")
+		return model.Money{}, nil, appError.NewAppError(400, "voucher does not belong to seller")
 	}
 
 	// Validate status
 	if voucher.Status != "ACTIVE" {
-		return 0, nil, appError.NewAppError(400, "voucher is not active")
+		return model.Money{}, nil, appError.NewAppError(400, "voucher is not active")
+	}
+
+	// Voucher amounts are in the voucher's currency
+	if model.NormalizeCurrency(voucher.Currency) != totalAmount.Currency {
+		return model.Money{}, nil, appError.NewAppError(400, "voucher currency does not match the order currency")
 	}
 
 	// Validate min order value
-	if totalAmount < float64(voucher.MinOrderValue) {
-		return 0, nil, appError.NewAppError(400, "order value does not meet voucher minimum requirement")
+	if totalAmount.Amount < int64(voucher.MinOrderValue) {
+		return model.Money{}, nil, appError.NewAppError(400, "order value does not meet voucher minimum requirement")
 	}
 
 	//validate time
 	if time.Now().Before(voucher.StartTime) || time.Now().After(voucher.EndTime) {
-		return 0, nil, appError.NewAppError(400, "voucher time has ended or not started")
+		return model.Money{}, nil, appError.NewAppError(400, "voucher time has ended or not started")
 	}
 	//validate scope
 	if voucher.ApplyScope == "CATEGORY" {
 		if voucher.ApplySellerCategoryIds == nil {
-			return 0, nil, appError.NewAppError(400, "voucher apply scope is category but no category id")
+			return model.Money{}, nil, appError.NewAppError(400, "voucher apply scope is category but no category id")
 		}
 
 		for _, variant := range variants {
 			if !HasAny(voucher.ApplySellerCategoryIds, variant.SellerCategoryIds) {
-				return 0, nil, appError.NewAppError(400, "voucher apply scope is category but no category id")
+				return model.Money{}, nil, appError.NewAppError(400, "voucher apply scope is category but no category id")
 			}
 		}
 	}
@@ -1049,36 +1902,154 @@ func (s *orderService) ApplyVoucher(voucherId string, totalAmount float64, selle
 	//call use voucher api
 	_, err = s.productClient.UseVoucher(userId, voucherId)
 	if err != nil {
-		return 0, nil, appError.NewAppError(400, "fail to use voucher")
+		return model.Money{}, nil, appError.NewAppError(400, "fail to use voucher")
+	}
+
+	orderVoucher := &model.OrderVoucher{
+		ID:               voucherId,
+		Code:             voucher.Code,
+		DiscountType:     voucher.DiscountType,
+		DiscountValue:    voucher.DiscountValue,
+		MinOrderValue:    voucher.MinOrderValue,
+		MaxDiscountValue: voucher.MaxDiscountValue,
+		// Vouchers without a seller are issued by the platform
+		PlatformFunded: voucher.SellerID == "",
+	}
+
+	totalAmount, err = totalAmount.Sub(voucherDiscount(orderVoucher, totalAmount))
+	if err != nil {
+		return model.Money{}, nil, fmt.Errorf("failed to apply voucher: %w", err)
+	}
+	if totalAmount.IsNegative() {
+		totalAmount.Amount = 0
 	}
 
-	// Calculate discount
-	var discount float64
+	return totalAmount, orderVoucher, nil
+}
+
+// voucherDiscount calculates the discount of a voucher on an items total,
+// rounded down to a whole minor unit
+func voucherDiscount(voucher *model.OrderVoucher, totalAmount model.Money) model.Money {
+	maxDiscount := model.NewMoney(int64(voucher.MaxDiscountValue), totalAmount.Currency)
+	discount := model.NewMoney(0, totalAmount.Currency)
 	if voucher.DiscountType == "PERCENTAGE" {
-		discount = totalAmount * float64(voucher.DiscountValue) / 100
-		if discount > float64(voucher.MaxDiscountValue) {
-			discount = float64(voucher.MaxDiscountValue)
+		discount = totalAmount.Percent(float64(voucher.DiscountValue))
+		if discount.Amount > maxDiscount.Amount {
+			discount = maxDiscount
 		}
 	} else if voucher.DiscountType == "FIXED" {
-		discount = float64(voucher.DiscountValue)
+		discount = model.NewMoney(int64(voucher.DiscountValue), totalAmount.Currency)
 	}
 
-	if voucher.MaxDiscountValue > 0 && discount > float64(voucher.MaxDiscountValue) {
-		discount = float64(voucher.MaxDiscountValue)
+	if maxDiscount.Amount > 0 && discount.Amount > maxDiscount.Amount {
+		discount = maxDiscount
 	}
+	return discount
+}
 
-	totalAmount -= discount
-	if totalAmount < 0 {
-		totalAmount = 0
+// buildBundleOrderItems expands a bundle cart line into one order item per component
+// using live bundle data. The bundle discount is split across the components in
+// proportion to their value so refunds and ratings keep working per item.
+func (s *orderService) buildBundleOrderItems(item *model.CartItem, sellerID string) ([]model.OrderItem, []client.ReserveStockItem, []dto.ProductVariantDto, error) {
+	bundle, err := s.productClient.GetBundleByID(item.Bundle.ID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get bundle details: %w", err)
+	}
+	if bundle == nil || !bundle.IsActive {
+		return nil, nil, nil, appError.NewAppError(404, fmt.Sprintf("bundle %s not found", item.Bundle.ID))
+	}
+	if bundle.SellerID != sellerID {
+		return nil, nil, nil, appError.NewAppError(400, "all items must be from the same seller")
+	}
+	if bundle.Stock < item.Quantity {
+		return nil, nil, nil, appError.NewAppError(409, fmt.Sprintf("not enough stock for bundle %s", bundle.Name))
 	}
 
-	orderVoucher := &model.OrderVoucher{
-		Code:          voucher.Code,
-		DiscountType:  voucher.DiscountType,
-		DiscountValue: voucher.DiscountValue,
+	originalTotal := bundle.OriginalPrice * item.Quantity
+	discountTotal := bundle.Discount * item.Quantity
+
+	orderItems := make([]model.OrderItem, 0, len(bundle.Components))
+	reserveItems := make([]client.ReserveStockItem, 0, len(bundle.Components))
+	variants := make([]dto.ProductVariantDto, 0, len(bundle.Components))
+	allocated := 0
+
+	for i, component := range bundle.Components {
+		if !component.Available {
+			return nil, nil, nil, appError.NewAppError(409, fmt.Sprintf("bundle %s is no longer available", bundle.Name))
+		}
+
+		quantity := component.Quantity * item.Quantity
+		lineValue := component.Variant.Price * quantity
+
+		// Last component takes the rounding remainder so the split adds up exactly
+		lineDiscount := discountTotal - allocated
+		if i < len(bundle.Components)-1 && originalTotal > 0 {
+			lineDiscount = discountTotal * lineValue / originalTotal
+		}
+		allocated += lineDiscount
+
+		orderItems = append(orderItems, model.OrderItem{
+			ProductID:   component.ProductID,
+			ProductName: component.ProductName,
+			VariantID:   component.Variant.ID,
+			VariantName: formatVariantName(component.Variant.Options),
+			SKU:         component.Variant.SKU,
+			Price:       component.Variant.Price,
+			Quantity:    quantity,
+			Image:       component.Variant.Image,
+			CategoryIDs: component.CategoryIds,
+			BundleID:    bundle.ID,
+			BundleName:  bundle.Name,
+			Discount:    lineDiscount,
+		})
+
+		reserveItems = append(reserveItems, client.ReserveStockItem{
+			VariantID: component.Variant.ID,
+			Quantity:  quantity,
+		})
+
+		variants = append(variants, dto.ProductVariantDto{
+			ProductID:         component.ProductID,
+			ProductName:       component.ProductName,
+			CategoryIds:       component.CategoryIds,
+			SellerID:          bundle.SellerID,
+			SellerCategoryIds: component.SellerCategoryIds,
+			Variant:           component.Variant,
+			PurchaseLimit:     component.PurchaseLimit,
+		})
 	}
 
-	return totalAmount, orderVoucher, nil
+	return orderItems, reserveItems, variants, nil
+}
+
+// addLineTotal adds a line to the running order total. An order is paid in a
+// single currency, so a line priced in another currency is rejected.
+func addLineTotal(total *model.Money, amount int64, currency string) error {
+	line := model.NewMoney(amount, currency)
+	if total.Currency == "" {
+		total.Currency = line.Currency
+	}
+	if total.Currency != line.Currency {
+		return appError.NewAppError(400, "all items of an order must be priced in the same currency")
+	}
+	sum, err := total.Add(line)
+	if err != nil {
+		return err
+	}
+	*total = sum
+	return nil
+}
+
+// formatVariantName converts variant options to a display string, e.g. "Size: M, Color: Red"
+func formatVariantName(options map[string]string) string {
+	variantName := ""
+	for k, v := range options {
+		variantName += fmt.Sprintf("%s: %s, ", k, v)
+	}
+	if len(variantName) > 2 {
+		variantName = variantName[:len(variantName)-2]
+	}
+	return variantName
 }
 
 func HasAny(a, b []string) bool {
@@ -1089,3 +2060,40 @@ func HasAny(a, b []string) bool {
 	}
 	return false
 }
+
+// variantsByID fetches the live variants, keyed by ID
+func (s *orderService) variantsByID(variantIDs []string) (map[string]dto.VariantDto, error) {
+	if len(variantIDs) == 0 {
+		return nil, nil
+	}
+	variants, err := s.productClient.GetVariantsByIds(variantIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant details: %w", err)
+	}
+	byID := make(map[string]dto.VariantDto, len(variants))
+	for _, v := range variants {
+		byID[v.Variant.ID] = v.Variant
+	}
+	return byID, nil
+}
+
+// latestPreOrderShipDate returns when a pre-order can ship, the latest ship
+// date of its items, or nil when nothing was pre-ordered. Checkout splits
+// pre-ordered items off, so a mix only happens when stock changed meanwhile.
+func latestPreOrderShipDate(items []model.OrderItem) (*time.Time, error) {
+	var latest *time.Time
+	preOrdered := 0
+	for _, item := range items {
+		if item.PreOrderShipDate == nil {
+			continue
+		}
+		preOrdered++
+		if latest == nil || item.PreOrderShipDate.After(*latest) {
+			latest = item.PreOrderShipDate
+		}
+	}
+	if preOrdered > 0 && preOrdered < len(items) {
+		return nil, appError.NewAppError(409, "pre-order items must be checked out separately from items in stock")
+	}
+	return latest, nil
+}
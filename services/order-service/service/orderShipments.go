@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"time"
+)
+
+// CreateShipment ships some of an order's items, or all that is left, in a new
+// parcel with its own carrier order. The first parcel confirms the order.
+func (s *orderService) CreateShipment(ctx context.Context, sellerID string, orderID string, request dto.CreateShipmentRequest) (*dto.OrderDto, error) {
+	order, err := s.repo.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, appError.NewAppError(404, "Order not found")
+	}
+	if order.Seller.ID != sellerID {
+		return nil, appError.NewAppError(403, "Only the seller can ship an order")
+	}
+	if order.Status != "TO_CONFIRM" && order.Status != "TO_PICKUP" && order.Status != "SHIPPING" {
+		return nil, appError.NewAppError(409, "Current status of this order can't be shipped: "+order.Status)
+	}
+
+	unshipped := order.UnshippedItems()
+	if len(unshipped) == 0 {
+		return nil, appError.NewAppError(409, "Every item of this order is already in a parcel")
+	}
+
+	items := unshipped
+	if len(request.Items) > 0 {
+		left := make(map[[2]string]int, len(unshipped))
+		for _, item := range unshipped {
+			left[[2]string{item.VariantID, item.BundleID}] += item.Quantity
+		}
+
+		items = nil
+		for _, requested := range request.Items {
+			key := [2]string{requested.VariantID, requested.BundleID}
+			if requested.Quantity > left[key] {
+				return nil, appError.NewAppError(400, fmt.Sprintf("only %d of variant %s are left to ship", left[key], requested.VariantID))
+			}
+			left[key] -= requested.Quantity
+			items = append(items, model.ShipmentItem{
+				VariantID: requested.VariantID,
+				BundleID:  requested.BundleID,
+				Quantity:  requested.Quantity,
+			})
+		}
+	}
+
+	seller, err := s.userClient.GetUserByID(order.Seller.ID)
+	if err != nil {
+		return nil, err
+	}
+	previous := *order
+	if err := s.createShipment(order, items, *seller); err != nil {
+		return nil, err
+	}
+
+	if order.Status == "TO_CONFIRM" {
+		order.SetStatus("TO_PICKUP", model.ActorSeller, sellerID, "")
+	}
+	s.applyShipmentChanges(order, model.ActorSeller, sellerID)
+
+	// The order may have been cancelled or shipped concurrently; the carrier
+	// order of a parcel that can't be saved is cancelled again
+	saved, err := s.repo.ReplaceOrderIf(order, previous.Status, previous.UpdatedAt)
+	if err == nil && !saved {
+		err = errOrderChanged
+	}
+	if err != nil {
+		parcel := order.Shipments[len(order.Shipments)-1]
+		if cancelErr := s.GHNClient.CancelOrder(parcel.TrackingCode); cancelErr != nil {
+			fmt.Printf("Warning: failed to cancel carrier order %s of order %s: %v\n", parcel.TrackingCode, order.ID, cancelErr)
+		}
+		return nil, err
+	}
+
+	orderDto := convertOrderToDto(order)
+	return &orderDto, nil
+}
+
+// UpdateShipmentStatus lets the seller record what happened to a parcel when
+// the carrier does not report it, or cancel a parcel that was not picked up.
+// The items of a cancelled parcel can be shipped again.
+func (s *orderService) UpdateShipmentStatus(ctx context.Context, sellerID string, orderID string, shipmentID string, request dto.UpdateShipmentStatusRequest) (*dto.OrderDto, error) {
+	order, err := s.repo.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, appError.NewAppError(404, "Order not found")
+	}
+	if order.Seller.ID != sellerID {
+		return nil, appError.NewAppError(403, "Only the seller can update a shipment")
+	}
+	if order.Status != "TO_PICKUP" && order.Status != "SHIPPING" {
+		return nil, appError.NewAppError(409, "Current status of this order can't be updated: "+order.Status)
+	}
+
+	shipment := order.FindShipment(shipmentID)
+	if shipment == nil {
+		return nil, appError.NewAppError(404, "Shipment not found")
+	}
+
+	switch request.Status {
+	case model.ShipmentShipping:
+		if shipment.Status != model.ShipmentToPickup {
+			return nil, appError.NewAppError(400, "Invalid status")
+		}
+	case model.ShipmentDelivered:
+		if shipment.Status != model.ShipmentToPickup && shipment.Status != model.ShipmentShipping {
+			return nil, appError.NewAppError(400, "Invalid status")
+		}
+	case model.ShipmentCancelled:
+		if shipment.Status != model.ShipmentToPickup {
+			return nil, appError.NewAppError(400, "Only parcels that were not picked up can be cancelled")
+		}
+		if shipment.Carrier == model.CarrierGHN && shipment.TrackingCode != "" {
+			if err := s.GHNClient.CancelOrder(shipment.TrackingCode); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	previous := *order
+	shipment.SetStatus(request.Status, time.Now())
+	s.applyShipmentChanges(order, model.ActorSeller, sellerID)
+	saved, err := s.repo.ReplaceOrderIf(order, previous.Status, previous.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, errOrderChanged
+	}
+
+	orderDto := convertOrderToDto(order)
+	return &orderDto, nil
+}
+
+// createShipment creates the carrier order for a parcel and adds it to the
+// order. COD parcels collect their share of the amount due; the last one
+// collects what is left so the parcels add up to the order.
+func (s *orderService) createShipment(order *model.Order, items []model.ShipmentItem, seller dto.UserResponse) error {
+	parcel := *order
+	parcel.Items = nil
+	var value int64
+	for _, item := range items {
+		for _, line := range order.Items {
+			if line.VariantID == item.VariantID && line.BundleID == item.BundleID {
+				value += int64(line.LineTotal()) * int64(item.Quantity) / int64(line.Quantity)
+				line.Quantity = item.Quantity
+				parcel.Items = append(parcel.Items, line)
+				break
+			}
+		}
+	}
+
+	var codAmount int
+	if order.PaymentMethod == "COD" {
+		grandTotal := order.GrandTotal().Amount
+		var collected, left int64
+		for _, shipment := range order.Shipments {
+			if shipment.Status != model.ShipmentCancelled {
+				collected += int64(shipment.CodAmount)
+			}
+		}
+		for _, item := range order.UnshippedItems() {
+			left += int64(item.Quantity)
+		}
+		for _, item := range items {
+			left -= int64(item.Quantity)
+		}
+
+		if itemsTotal := order.ItemsTotal(); left > 0 && itemsTotal > 0 {
+			codAmount = int(grandTotal * value / itemsTotal)
+		} else {
+			codAmount = int(grandTotal - collected)
+		}
+	}
+
+	ghnRequest, err := s.GHNClient.CreateRequest(parcel, seller)
+	if err != nil {
+		return err
+	}
+	ghnRequest.CodAmount = codAmount
+	deliveryCode, err := s.GHNClient.CreateOrder(ghnRequest)
+	if err != nil {
+		return err
+	}
+
+	shipment := model.Shipment{
+		Carrier:      model.CarrierGHN,
+		TrackingCode: deliveryCode,
+		Items:        items,
+		CodAmount:    codAmount,
+	}
+	shipment.BeforeCreate()
+	order.Shipments = append(order.Shipments, shipment)
+	return nil
+}
+
+// cancelShipments cancels the parcels that were not picked up when the order
+// is cancelled (best-effort)
+func (s *orderService) cancelShipments(order *model.Order) {
+	for i := range order.Shipments {
+		shipment := &order.Shipments[i]
+		if shipment.Status != model.ShipmentToPickup {
+			continue
+		}
+		if shipment.Carrier == model.CarrierGHN && shipment.TrackingCode != "" {
+			if err := s.GHNClient.CancelOrder(shipment.TrackingCode); err != nil {
+				fmt.Printf("Warning: failed to cancel shipment %s of order %s: %v\n", shipment.TrackingCode, order.ID, err)
+			}
+		}
+		shipment.SetStatus(model.ShipmentCancelled, time.Now())
+	}
+}
+
+// refreshShipments updates the parcels on their way from the carrier and
+// reports whether any of them changed
+func (s *orderService) refreshShipments(order *model.Order) bool {
+	changed := false
+	for i := range order.Shipments {
+		shipment := &order.Shipments[i]
+		if shipment.Status != model.ShipmentToPickup && shipment.Status != model.ShipmentShipping {
+			continue
+		}
+
+		tracking, err := s.trackingService.TrackShipment(order.ID, shipment)
+		if err != nil {
+			fmt.Printf("Warning: failed to get delivery status of order %s: %v\n", order.ID, err)
+			continue
+		}
+		if tracking == nil || tracking.Stale || tracking.Status == shipment.Status {
+			continue
+		}
+
+		at := tracking.FetchedAt
+		if len(tracking.Events) > 0 {
+			at = tracking.Events[len(tracking.Events)-1].At
+		}
+		shipment.SetStatus(tracking.Status, at)
+		changed = true
+	}
+	return changed
+}
+
+// applyShipmentChanges derives the order status and delivery time from its parcels
+func (s *orderService) applyShipmentChanges(order *model.Order, actor, actorID string) {
+	if len(order.Shipments) == 0 {
+		return
+	}
+	if order.Status == "TO_PICKUP" && order.ShippingStatus() == "SHIPPING" {
+		order.SetStatus("SHIPPING", actor, actorID, "parcel shipped")
+	}
+	if order.Status == "SHIPPING" {
+		order.DeliveredAt = order.ShipmentsDeliveredAt()
+	}
+	order.UpdatedAt = time.Now()
+}
@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"os"
+	"strconv"
+	"time"
+)
+
+type PayoutService interface {
+	// RecordOrder brings the seller ledger of an order in line with the order.
+	// It is safe to call any number of times.
+	RecordOrder(order *model.Order) error
+	RecomputeOrders(ctx context.Context, request dto.RecomputePayoutsRequest) (*dto.RecomputePayoutsResponse, error)
+	GetCommissionRules() ([]*model.CommissionRule, error)
+	UpdateCommissionRule(request dto.UpdateCommissionRuleRequest) (*model.CommissionRule, error)
+	DeleteCommissionRule(ruleID string) error
+	GetBalance(sellerID string) (*dto.PayoutBalanceResponse, error)
+	GetStatements(sellerID string, request dto.GetStatementsRequest) (*dto.GetStatementsResponse, error)
+	// GetStatement returns a statement and its entries. A non-empty seller ID
+	// restricts it to that seller's statements.
+	GetStatement(sellerID, statementID string) (*dto.StatementDetailResponse, error)
+	GenerateStatements(request dto.GenerateStatementsRequest) (*dto.GenerateStatementsResponse, error)
+	MarkStatementPaid(statementID string, request dto.MarkStatementPaidRequest) (*model.SettlementStatement, error)
+	// StartStatementJob settles the previous week every Monday in the background
+	StartStatementJob(leases repository.JobLeaseRepository)
+}
+
+type payoutService struct {
+	repo        repository.PayoutRepository
+	defaultRate float64 // percent, used when no default rule is stored
+	location    *time.Location
+}
+
+func NewPayoutService(repo repository.PayoutRepository) PayoutService {
+	defaultRate, err := strconv.ParseFloat(os.Getenv("PAYOUT_DEFAULT_COMMISSION_RATE"), 64)
+	if err != nil || defaultRate < 0 || defaultRate > 100 {
+		defaultRate = 5
+	}
+	timezone := os.Getenv("PAYOUT_TIMEZONE")
+	if timezone == "" {
+		timezone = "Asia/Ho_Chi_Minh"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		fmt.Printf("Warning: unknown PAYOUT_TIMEZONE %q, using UTC\n", timezone)
+		location = time.UTC
+	}
+
+	return &payoutService{
+		repo:        repo,
+		defaultRate: defaultRate,
+		location:    location,
+	}
+}
+
+func (s *payoutService) RecordOrder(order *model.Order) error {
+	rules, err := s.ruleMap()
+	if err != nil {
+		return fmt.Errorf("failed to get commission rules: %w", err)
+	}
+	return s.recordOrder(order, rules)
+}
+
+// recordOrder replaces the order's unsettled entries with what the order is owed
+// now. Amounts already in a statement are not touched; the difference is
+// recorded as adjustments, or as a refund once the order is no longer completed.
+func (s *payoutService) recordOrder(order *model.Order, rules map[string]float64) error {
+	existing, err := s.repo.FindEntriesByOrder(order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+
+	settled := make(map[string]int64)
+	settledIDs := make(map[string]bool)
+	var settledTotal int64
+	for _, entry := range existing {
+		if entry.StatementID == "" {
+			continue
+		}
+		settled[entry.Type] += entry.Amount
+		settledIDs[entry.ID] = true
+		settledTotal += entry.Amount
+	}
+	if err := s.repo.DeleteUnsettledEntries(order.ID); err != nil {
+		return fmt.Errorf("failed to clear ledger entries: %w", err)
+	}
+
+	// A completed order is owed its amounts; any other order gives back what
+	// was settled, as a single refund
+	owed := make(map[string]int64)
+	if order.Status == "COMPLETED" {
+		owed = s.orderAmounts(order, rules)
+	} else {
+		for entryType, amount := range settled {
+			owed[entryType] = amount
+		}
+		owed[model.PayoutRefund] = settled[model.PayoutRefund] - settledTotal
+	}
+
+	occurredAt := order.UpdatedAt
+	if order.Status == "COMPLETED" && order.CompletedAt != nil {
+		occurredAt = *order.CompletedAt
+	}
+
+	var entries []*model.SellerLedgerEntry
+	for _, entryType := range model.PayoutEntryTypes {
+		amount := owed[entryType] - settled[entryType]
+		if amount == 0 {
+			continue
+		}
+		entry := &model.SellerLedgerEntry{
+			ID:         model.PayoutEntryID(order.ID, entryType),
+			SellerID:   order.Seller.ID,
+			OrderID:    order.ID,
+			Type:       entryType,
+			Currency:   model.NormalizeCurrency(order.Currency),
+			Amount:     amount,
+			OccurredAt: occurredAt,
+		}
+		if settledIDs[entry.ID] {
+			// Settled amounts are corrected in a later statement
+			entry.ID = ""
+			entry.Adjustment = true
+			entry.OccurredAt = time.Now()
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := s.repo.InsertEntries(entries); err != nil {
+		return fmt.Errorf("failed to record ledger entries: %w", err)
+	}
+	return nil
+}
+
+// orderAmounts splits what a completed order is worth to its seller into the
+// ledger entry types
+func (s *payoutService) orderAmounts(order *model.Order, rules map[string]float64) map[string]int64 {
+	subsidy := int64(order.PointsDiscount)
+	var sellerVoucher int64
+	if order.Voucher != nil {
+		if order.Voucher.PlatformFunded {
+			subsidy += order.VoucherDiscount()
+		} else {
+			sellerVoucher = order.VoucherDiscount()
+		}
+	}
+
+	return map[string]int64{
+		model.PayoutSale:            order.Total + int64(order.DeliveryFee),
+		model.PayoutPlatformSubsidy: subsidy,
+		model.PayoutCommission:      -s.commission(order, rules, sellerVoucher),
+		model.PayoutShipping:        -int64(order.DeliveryFee),
+	}
+}
+
+// commission returns the platform commission on the order items. The base of a
+// line is what the seller sells it for: the line after its share of a seller
+// voucher, VAT included. Platform subsidies don't lower the base.
+func (s *payoutService) commission(order *model.Order, rules map[string]float64, sellerVoucher int64) int64 {
+	itemsTotal := order.ItemsTotal()
+
+	var commission, allocated int64
+	for i := range order.Items {
+		item := &order.Items[i]
+		line := int64(item.LineTotal())
+
+		// Last line takes the rounding remainder so the split adds up exactly
+		lineDiscount := sellerVoucher - allocated
+		if i < len(order.Items)-1 && itemsTotal > 0 {
+			lineDiscount = sellerVoucher * line / itemsTotal
+		}
+		allocated += lineDiscount
+
+		base := line - lineDiscount
+		if !order.TaxInclusive {
+			base += int64(item.TaxAmount)
+		}
+		bps := model.TaxBasisPoints(s.rateFor(rules, order.Seller.ID, item.CategoryIDs))
+		commission += (base*bps + 5000) / 10000
+	}
+	return commission
+}
+
+func (s *payoutService) ruleMap() (map[string]float64, error) {
+	rules, err := s.repo.FindCommissionRules()
+	if err != nil {
+		return nil, err
+	}
+	ruleMap := make(map[string]float64, len(rules))
+	for _, rule := range rules {
+		ruleMap[rule.ID] = rule.Rate
+	}
+	return ruleMap, nil
+}
+
+// rateFor returns the seller's rate, then the rate of the first of the item's
+// categories that has one, then the stored default rate, then the configured default
+func (s *payoutService) rateFor(rules map[string]float64, sellerID string, categoryIDs []string) float64 {
+	if rate, ok := rules[model.SellerCommissionRuleID(sellerID)]; ok {
+		return rate
+	}
+	for _, categoryID := range categoryIDs {
+		if rate, ok := rules[model.CategoryCommissionRuleID(categoryID)]; ok {
+			return rate
+		}
+	}
+	if rate, ok := rules[model.DefaultCommissionRuleID]; ok {
+		return rate
+	}
+	return s.defaultRate
+}
+
+// RecomputeOrders rebuilds the ledger of the orders created in the range from
+// the orders themselves, with the current commission rules
+func (s *payoutService) RecomputeOrders(ctx context.Context, request dto.RecomputePayoutsRequest) (*dto.RecomputePayoutsResponse, error) {
+	if !request.From.Before(request.To) {
+		return nil, appError.NewAppError(400, "invalid time range: 'from' must be before 'to'")
+	}
+
+	rules, err := s.ruleMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commission rules: %w", err)
+	}
+
+	count := 0
+	err = s.repo.EachOrderToSettle(ctx, request.SellerID, request.From, request.To, func(order *model.Order) error {
+		if err := s.recordOrder(order, rules); err != nil {
+			return fmt.Errorf("order %s: %w", order.ID, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute payouts: %w", err)
+	}
+
+	return &dto.RecomputePayoutsResponse{OrderCount: count}, nil
+}
+
+// GetCommissionRules returns the configured rules and the default rate
+func (s *payoutService) GetCommissionRules() ([]*model.CommissionRule, error) {
+	rules, err := s.repo.FindCommissionRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commission rules: %w", err)
+	}
+
+	for _, rule := range rules {
+		if rule.ID == model.DefaultCommissionRuleID {
+			return rules, nil
+		}
+	}
+	return append([]*model.CommissionRule{{ID: model.DefaultCommissionRuleID, Rate: s.defaultRate}}, rules...), nil
+}
+
+func (s *payoutService) UpdateCommissionRule(request dto.UpdateCommissionRuleRequest) (*model.CommissionRule, error) {
+	rule := &model.CommissionRule{
+		SellerID:   request.SellerID,
+		CategoryID: request.CategoryID,
+		Rate:       request.Rate,
+	}
+	switch {
+	case request.SellerID != "" && request.CategoryID != "":
+		return nil, appError.NewAppError(400, "a commission rule is for a seller or a category, not both")
+	case request.SellerID != "":
+		rule.ID = model.SellerCommissionRuleID(request.SellerID)
+	case request.CategoryID != "":
+		rule.ID = model.CategoryCommissionRuleID(request.CategoryID)
+	default:
+		rule.ID = model.DefaultCommissionRuleID
+	}
+
+	if err := s.repo.UpsertCommissionRule(rule); err != nil {
+		return nil, fmt.Errorf("failed to update commission rule: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteCommissionRule removes a rule so its seller or category falls back to the next rule
+func (s *payoutService) DeleteCommissionRule(ruleID string) error {
+	if err := s.repo.DeleteCommissionRule(ruleID); err != nil {
+		return fmt.Errorf("failed to delete commission rule: %w", err)
+	}
+	return nil
+}
+
+func (s *payoutService) GetBalance(sellerID string) (*dto.PayoutBalanceResponse, error) {
+	balances, err := s.repo.FindBalances(sellerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payout balance: %w", err)
+	}
+
+	response := &dto.PayoutBalanceResponse{
+		SellerID: sellerID,
+		Balances: make([]dto.PayoutBalanceDto, 0, len(balances)),
+	}
+	for _, balance := range balances {
+		response.Balances = append(response.Balances, dto.PayoutBalanceDto{
+			Currency:  balance.Currency,
+			Unsettled: balance.Unsettled,
+			Payable:   balance.Payable,
+			Paid:      balance.Paid,
+		})
+	}
+	return response, nil
+}
+
+func (s *payoutService) GetStatements(sellerID string, request dto.GetStatementsRequest) (*dto.GetStatementsResponse, error) {
+	page := request.Page
+	if page < 1 {
+		page = 1
+	}
+
+	limit := request.Limit
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100 // max limit
+	}
+
+	statements, totalCount, err := s.repo.FindStatements(sellerID, request.Status, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statements: %w", err)
+	}
+	if statements == nil {
+		statements = []*model.SettlementStatement{}
+	}
+
+	totalPages := int(totalCount) / limit
+	if int(totalCount)%limit > 0 {
+		totalPages++
+	}
+
+	return &dto.GetStatementsResponse{
+		Statements: statements,
+		TotalCount: totalCount,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *payoutService) GetStatement(sellerID, statementID string) (*dto.StatementDetailResponse, error) {
+	statement, err := s.repo.FindStatementByID(statementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement: %w", err)
+	}
+	if statement == nil || (sellerID != "" && statement.SellerID != sellerID) {
+		return nil, appError.NewAppError(404, "Statement not found")
+	}
+
+	entries, err := s.repo.FindEntriesByStatement(statement.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+	if entries == nil {
+		entries = []*model.SellerLedgerEntry{}
+	}
+
+	return &dto.StatementDetailResponse{Statement: statement, Entries: entries}, nil
+}
+
+func (s *payoutService) GenerateStatements(request dto.GenerateStatementsRequest) (*dto.GenerateStatementsResponse, error) {
+	periodStart := s.weekStart(time.Now()).AddDate(0, 0, -7)
+	if request.Week != "" {
+		day, err := time.ParseInLocation("2006-01-02", request.Week, s.location)
+		if err != nil {
+			return nil, appError.NewAppError(400, "invalid week: expected YYYY-MM-DD")
+		}
+		periodStart = s.weekStart(day)
+	}
+	periodEnd := periodStart.AddDate(0, 0, 7)
+	if periodEnd.After(time.Now()) {
+		return nil, appError.NewAppError(400, "the week is not over yet")
+	}
+
+	count, err := s.generateStatements(periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.GenerateStatementsResponse{
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Statements:  count,
+	}, nil
+}
+
+// generateStatements settles every seller's unsettled entries that occurred
+// before the end of the week. Running it again for the same week adds the
+// entries recorded since to the statements that are not paid yet.
+func (s *payoutService) generateStatements(periodStart, periodEnd time.Time) (int, error) {
+	accounts, err := s.repo.FindUnsettledAccounts(periodEnd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find unsettled ledger entries: %w", err)
+	}
+
+	count := 0
+	for _, account := range accounts {
+		statementID := model.SettlementStatementID(account.SellerID, account.Currency, periodStart)
+		if err := s.repo.CreateStatementIfMissing(&model.SettlementStatement{
+			ID:          statementID,
+			SellerID:    account.SellerID,
+			Currency:    account.Currency,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+			Status:      model.StatementPending,
+		}); err != nil {
+			return count, fmt.Errorf("failed to create statement %s: %w", statementID, err)
+		}
+
+		statement, err := s.repo.SettleStatement(statementID, account, periodEnd)
+		if err != nil {
+			return count, fmt.Errorf("failed to settle statement %s: %w", statementID, err)
+		}
+		if statement == nil {
+			// Late entries of a paid week wait for the next statement
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *payoutService) MarkStatementPaid(statementID string, request dto.MarkStatementPaidRequest) (*model.SettlementStatement, error) {
+	statement, err := s.repo.FindStatementByID(statementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement: %w", err)
+	}
+	if statement == nil {
+		return nil, appError.NewAppError(404, "Statement not found")
+	}
+
+	paid, err := s.repo.MarkStatementPaid(statementID, request.PaymentReference, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update statement: %w", err)
+	}
+	if paid == nil {
+		return nil, appError.NewAppError(409, "Statement is already paid")
+	}
+	return paid, nil
+}
+
+func (s *payoutService) StartStatementJob(leases repository.JobLeaseRepository) {
+	go func() {
+		for {
+			// Early on Monday, once last week is over
+			next := s.weekStart(time.Now()).AddDate(0, 0, 7).Add(30 * time.Minute)
+			time.Sleep(time.Until(next))
+
+			runJob(leases, "settlement-statements", 24*time.Hour, func() {
+				periodEnd := s.weekStart(time.Now())
+				periodStart := periodEnd.AddDate(0, 0, -7)
+				count, err := s.generateStatements(periodStart, periodEnd)
+				if err != nil {
+					fmt.Printf("Warning: failed to generate settlement statements: %v\n", err)
+					return
+				}
+				fmt.Printf("Generated %d settlement statements for the week of %s\n", count, periodStart.Format("2006-01-02"))
+			})
+		}
+	}()
+}
+
+// weekStart returns the Monday midnight that starts the week of t
+func (s *payoutService) weekStart(t time.Time) time.Time {
+	t = t.In(s.location)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
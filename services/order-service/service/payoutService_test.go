@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+
+	"order-service/model"
+)
+
+func TestCommission(t *testing.T) {
+	tests := []struct {
+		name          string
+		inclusive     bool
+		items         []model.OrderItem
+		rules         map[string]float64
+		sellerVoucher int64
+		want          int64
+	}{
+		{
+			name:      "seller rate rounded half up",
+			inclusive: true,
+			items: []model.OrderItem{
+				{Price: 10005, Quantity: 1},
+				{Price: 2500, Quantity: 2},
+			},
+			rules: map[string]float64{model.SellerCommissionRuleID("seller-1"): 10},
+			want:  1001 + 500,
+		},
+		{
+			name: "exclusive VAT is part of the base, voucher split by line",
+			items: []model.OrderItem{
+				{Price: 10000, Quantity: 1, TaxAmount: 1000},
+				{Price: 5000, Quantity: 1, TaxAmount: 500, CategoryIDs: []string{"books"}},
+			},
+			rules:         map[string]float64{model.CategoryCommissionRuleID("books"): 2.5},
+			sellerVoucher: 1000,
+			// 10000 - 666 + 1000 at the default 5%, 5000 - 334 + 500 at 2.5%
+			want: 517 + 129,
+		},
+		{
+			name:      "last line takes the voucher remainder",
+			inclusive: true,
+			items: []model.OrderItem{
+				{Price: 1000, Quantity: 1},
+				{Price: 1000, Quantity: 1},
+				{Price: 1000, Quantity: 1},
+			},
+			rules:         map[string]float64{model.DefaultCommissionRuleID: 50},
+			sellerVoucher: 100,
+			// Bases of 967, 967 and 966
+			want: 484 + 484 + 483,
+		},
+		{
+			name:      "voucher covers the whole order",
+			inclusive: true,
+			items: []model.OrderItem{
+				{Price: 3000, Quantity: 1},
+				{Price: 2000, Quantity: 1},
+			},
+			sellerVoucher: 5000,
+			want:          0,
+		},
+		{
+			name:      "free items",
+			inclusive: true,
+			items: []model.OrderItem{
+				{Price: 0, Quantity: 1},
+				{Price: 0, Quantity: 3},
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &model.Order{
+				Seller:       model.User{ID: "seller-1"},
+				Items:        tt.items,
+				TaxInclusive: tt.inclusive,
+			}
+			service := &payoutService{defaultRate: 5}
+
+			if got := service.commission(order, tt.rules, tt.sellerVoucher); got != tt.want {
+				t.Errorf("commission = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
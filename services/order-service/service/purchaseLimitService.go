@@ -0,0 +1,199 @@
+package service
+
+import (
+	"fmt"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"time"
+)
+
+// checkoutLeaseTTL bounds how long a checkout can keep the user's other
+// checkouts out if it never releases its lease
+const checkoutLeaseTTL = 30 * time.Second
+
+type PurchaseLimitService interface {
+	// CheckCart checks that a variant's cart quantity, with what the user bought
+	// before, stays within the variant's and product's limits. cartItems are
+	// the user's other cart lines.
+	CheckCart(userID string, variant dto.ProductVariantDto, quantity int, cartItems []model.CartItem) error
+	// MaxCartQuantity returns the most units of a variant the cart can hold
+	// within the same limits, or -1 when neither the variant nor its product
+	// has a limit
+	MaxCartQuantity(userID string, variant dto.ProductVariantDto, cartItems []model.CartItem) (int, error)
+	// CheckOrder checks that an order's items, with what the user bought
+	// before, stay within the limits of their variants and products
+	CheckOrder(userID string, items []model.OrderItem, variants []dto.ProductVariantDto) error
+	// Lock keeps the user's other checkouts out until unlock is called, so two
+	// checkouts can't both pass the same limit. It is a no-op when none of the
+	// variants has a limit.
+	Lock(userID string, variants []dto.ProductVariantDto) (unlock func(), err error)
+}
+
+type purchaseLimitService struct {
+	repo repository.PurchaseLimitRepository
+}
+
+func NewPurchaseLimitService(repo repository.PurchaseLimitRepository) PurchaseLimitService {
+	return &purchaseLimitService{repo: repo}
+}
+
+// limitedQuantity is what an order or cart would add up to for one limit
+type limitedQuantity struct {
+	field    string
+	id       string
+	name     string
+	limit    *dto.PurchaseLimitDto
+	quantity int
+}
+
+func (s *purchaseLimitService) CheckCart(userID string, variant dto.ProductVariantDto, quantity int, cartItems []model.CartItem) error {
+	return s.check(userID, cartQuantities(variant, quantity, cartItems))
+}
+
+func (s *purchaseLimitService) MaxCartQuantity(userID string, variant dto.ProductVariantDto, cartItems []model.CartItem) (int, error) {
+	maxQuantity := -1
+	for _, q := range cartQuantities(variant, 0, cartItems) {
+		bought, err := s.purchased(userID, q)
+		if err != nil {
+			return 0, err
+		}
+		left := max(q.limit.MaxQuantity-bought-q.quantity, 0)
+		if maxQuantity < 0 || left < maxQuantity {
+			maxQuantity = left
+		}
+	}
+	return maxQuantity, nil
+}
+
+// cartQuantities returns what the cart would add up to for the limits of a
+// variant holding the given quantity
+func cartQuantities(variant dto.ProductVariantDto, quantity int, cartItems []model.CartItem) []*limitedQuantity {
+	var quantities []*limitedQuantity
+	if variant.Variant.PurchaseLimit != nil {
+		quantities = append(quantities, &limitedQuantity{
+			field:    repository.PurchasedByVariant,
+			id:       variant.Variant.ID,
+			name:     variant.ProductName,
+			limit:    variant.Variant.PurchaseLimit,
+			quantity: quantity,
+		})
+	}
+	if variant.PurchaseLimit != nil {
+		total := quantity
+		for _, item := range cartItems {
+			if !item.IsBundle() && item.Product.ID == variant.ProductID && item.Variant.ID != variant.Variant.ID {
+				total += item.Quantity
+			}
+		}
+		quantities = append(quantities, &limitedQuantity{
+			field:    repository.PurchasedByProduct,
+			id:       variant.ProductID,
+			name:     variant.ProductName,
+			limit:    variant.PurchaseLimit,
+			quantity: total,
+		})
+	}
+	return quantities
+}
+
+func (s *purchaseLimitService) CheckOrder(userID string, items []model.OrderItem, variants []dto.ProductVariantDto) error {
+	variantLimits := make(map[string]*dto.PurchaseLimitDto)
+	productLimits := make(map[string]*dto.PurchaseLimitDto)
+	for _, variant := range variants {
+		if variant.Variant.PurchaseLimit != nil {
+			variantLimits[variant.Variant.ID] = variant.Variant.PurchaseLimit
+		}
+		if variant.PurchaseLimit != nil && variant.ProductID != "" {
+			productLimits[variant.ProductID] = variant.PurchaseLimit
+		}
+	}
+
+	var quantities []*limitedQuantity
+	byKey := make(map[string]*limitedQuantity)
+	add := func(field, id, name string, limit *dto.PurchaseLimitDto, quantity int) {
+		key := field + ":" + id
+		if q, ok := byKey[key]; ok {
+			q.quantity += quantity
+			return
+		}
+		q := &limitedQuantity{field: field, id: id, name: name, limit: limit, quantity: quantity}
+		byKey[key] = q
+		quantities = append(quantities, q)
+	}
+	for _, item := range items {
+		if limit, ok := variantLimits[item.VariantID]; ok {
+			add(repository.PurchasedByVariant, item.VariantID, item.ProductName, limit, item.Quantity)
+		}
+		if limit, ok := productLimits[item.ProductID]; ok {
+			add(repository.PurchasedByProduct, item.ProductID, item.ProductName, limit, item.Quantity)
+		}
+	}
+	return s.check(userID, quantities)
+}
+
+func (s *purchaseLimitService) check(userID string, quantities []*limitedQuantity) error {
+	for _, q := range quantities {
+		if q.quantity > q.limit.MaxQuantity {
+			return appError.NewAppError(409, fmt.Sprintf("You can buy at most %d of %s", q.limit.MaxQuantity, q.name))
+		}
+		bought, err := s.purchased(userID, q)
+		if err != nil {
+			return err
+		}
+		if bought+q.quantity > q.limit.MaxQuantity {
+			period := ""
+			if q.limit.WindowDays > 0 {
+				period = fmt.Sprintf(" every %d days", q.limit.WindowDays)
+			}
+			return appError.NewAppError(409, fmt.Sprintf("You can buy at most %d of %s%s and already ordered %d", q.limit.MaxQuantity, q.name, period, bought))
+		}
+	}
+	return nil
+}
+
+// purchased returns how much of a limit the user used up with past orders
+func (s *purchaseLimitService) purchased(userID string, q *limitedQuantity) (int, error) {
+	// Guests haven't bought anything yet
+	if model.IsGuestCartOwner(userID) {
+		return 0, nil
+	}
+
+	var since time.Time
+	if q.limit.WindowDays > 0 {
+		since = time.Now().AddDate(0, 0, -q.limit.WindowDays)
+	}
+	purchased, err := s.repo.SumPurchased(userID, q.field, []string{q.id}, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check purchase limit: %w", err)
+	}
+	return purchased[q.id], nil
+}
+
+func (s *purchaseLimitService) Lock(userID string, variants []dto.ProductVariantDto) (func(), error) {
+	limited := false
+	for _, variant := range variants {
+		if variant.Variant.PurchaseLimit != nil || variant.PurchaseLimit != nil {
+			limited = true
+			break
+		}
+	}
+	if !limited {
+		return func() {}, nil
+	}
+
+	token, err := s.repo.AcquireCheckoutLease(userID, checkoutLeaseTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start checkout: %w", err)
+	}
+	if token == "" {
+		return nil, appError.NewAppError(409, "Another checkout is in progress, please try again in a moment")
+	}
+
+	return func() {
+		if err := s.repo.ReleaseCheckoutLease(userID, token); err != nil {
+			fmt.Printf("Warning: failed to release checkout lease of user %s: %v\n", userID, err)
+		}
+	}, nil
+}
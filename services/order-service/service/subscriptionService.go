@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"order-service/client"
+	stripeclient "order-service/client/payment/stripe"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"slices"
+	"strings"
+	"time"
+)
+
+type SubscriptionService interface {
+	// SetupPayment starts saving a card for STRIPE subscriptions
+	SetupPayment(userID string) (*dto.SubscriptionPaymentSetupResponse, error)
+	CreateSubscription(userID string, request dto.CreateSubscriptionRequest) (*model.Subscription, error)
+	GetSubscriptions(userID string) ([]*model.Subscription, error)
+	GetSubscription(userID, subscriptionID string) (*model.Subscription, error)
+	// SkipNext moves the next renewal on by one interval
+	SkipNext(userID, subscriptionID string) (*model.Subscription, error)
+	Pause(userID, subscriptionID string) (*model.Subscription, error)
+	Resume(userID, subscriptionID string) (*model.Subscription, error)
+	Cancel(userID, subscriptionID string) (*model.Subscription, error)
+	// StartRenewalJob places the orders of due subscriptions every hour in the background
+	StartRenewalJob(leases repository.JobLeaseRepository)
+}
+
+type subscriptionService struct {
+	repo               repository.SubscriptionRepository
+	orderRepo          repository.OrderRepository
+	orderService       OrderService
+	productClient      *client.ProductServiceClient
+	userClient         *client.UserServiceClient
+	stripeClient       *stripeclient.StripeClient
+	notificationClient *client.NotificationServiceClient
+}
+
+func NewSubscriptionService(
+	repo repository.SubscriptionRepository,
+	orderRepo repository.OrderRepository,
+	orderService OrderService,
+	productClient *client.ProductServiceClient,
+	userClient *client.UserServiceClient,
+	stripeClient *stripeclient.StripeClient,
+	notificationClient *client.NotificationServiceClient,
+) SubscriptionService {
+	return &subscriptionService{
+		repo:               repo,
+		orderRepo:          orderRepo,
+		orderService:       orderService,
+		productClient:      productClient,
+		userClient:         userClient,
+		stripeClient:       stripeClient,
+		notificationClient: notificationClient,
+	}
+}
+
+func (s *subscriptionService) SetupPayment(userID string) (*dto.SubscriptionPaymentSetupResponse, error) {
+	customer, err := s.repo.FindPaymentCustomer(userID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		user, err := s.userClient.GetUserByID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user info: %w", err)
+		}
+		customerID, err := s.stripeClient.CreateCustomer(userID, user.Email, user.Name)
+		if err != nil {
+			return nil, appError.NewAppErrorWithErr(500, "failed to set up payment", err)
+		}
+		customer = &model.PaymentCustomer{UserID: userID, StripeCustomerID: customerID}
+		if err := s.repo.CreatePaymentCustomer(customer); err != nil {
+			return nil, err
+		}
+	}
+
+	clientSecret, err := s.stripeClient.CreateSetupIntent(customer.StripeCustomerID)
+	if err != nil {
+		return nil, appError.NewAppErrorWithErr(500, "failed to set up payment", err)
+	}
+	return &dto.SubscriptionPaymentSetupResponse{ClientSecret: clientSecret}, nil
+}
+
+func (s *subscriptionService) CreateSubscription(userID string, request dto.CreateSubscriptionRequest) (*model.Subscription, error) {
+	subscription := &model.Subscription{
+		UserID:            userID,
+		SellerID:          request.SellerID,
+		IntervalDays:      request.IntervalDays,
+		ShippingAddress:   toOrderAddress(request.ShippingAddress),
+		DeliveryServiceID: request.DeliveryServiceID,
+		PaymentMethod:     request.PaymentMethod,
+		Status:            model.SubscriptionActive,
+		NextOrderAt:       time.Now(),
+	}
+	if request.StartAt != nil && request.StartAt.After(subscription.NextOrderAt) {
+		subscription.NextOrderAt = *request.StartAt
+	}
+
+	// Every variant must exist and belong to the seller
+	var variantIDs []string
+	for _, item := range request.Items {
+		if slices.Contains(variantIDs, item.VariantID) {
+			return nil, appError.NewAppError(400, "each variant can only be listed once")
+		}
+		variantIDs = append(variantIDs, item.VariantID)
+	}
+	variants, err := s.productClient.GetVariantsByIds(variantIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant details: %w", err)
+	}
+	for _, item := range request.Items {
+		live := findVariant(variants, item.VariantID)
+		if live == nil || live.ProductID != item.ProductID {
+			return nil, appError.NewAppError(404, fmt.Sprintf("variant %s not found", item.VariantID))
+		}
+		if live.SellerID != request.SellerID {
+			return nil, appError.NewAppError(400, "all items must be from the same seller")
+		}
+		subscription.Items = append(subscription.Items, model.SubscriptionItem{
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			ProductName: live.ProductName,
+			Quantity:    item.Quantity,
+			Price:       live.Variant.Price,
+			Currency:    model.NormalizeCurrency(live.Variant.Currency),
+		})
+	}
+
+	// Renewals are charged to a card the buyer saved with their customer
+	if request.PaymentMethod == model.SubscriptionPayStripe {
+		if request.PaymentMethodID == "" {
+			return nil, appError.NewAppError(400, "payment_method_id is required for STRIPE subscriptions")
+		}
+		customer, err := s.repo.FindPaymentCustomer(userID)
+		if err != nil {
+			return nil, err
+		}
+		if customer == nil {
+			return nil, appError.NewAppError(400, "save a card before subscribing with STRIPE")
+		}
+		owner, err := s.stripeClient.PaymentMethodCustomer(request.PaymentMethodID)
+		if err != nil {
+			return nil, err
+		}
+		if owner != customer.StripeCustomerID {
+			return nil, appError.NewAppError(400, "payment method does not belong to you")
+		}
+		subscription.StripeCustomerID = customer.StripeCustomerID
+		subscription.StripePaymentMethodID = request.PaymentMethodID
+	}
+
+	if err := s.repo.CreateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *subscriptionService) GetSubscriptions(userID string) ([]*model.Subscription, error) {
+	subscriptions, err := s.repo.FindSubscriptionsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if subscriptions == nil {
+		subscriptions = []*model.Subscription{}
+	}
+	return subscriptions, nil
+}
+
+func (s *subscriptionService) GetSubscription(userID, subscriptionID string) (*model.Subscription, error) {
+	return s.findSubscription(userID, subscriptionID)
+}
+
+func (s *subscriptionService) SkipNext(userID, subscriptionID string) (*model.Subscription, error) {
+	subscription, err := s.findSubscription(userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status != model.SubscriptionActive {
+		return nil, appError.NewAppError(409, "only active subscriptions can skip a delivery")
+	}
+
+	subscription.NextOrderAt = subscription.NextOrderAt.Add(subscription.Interval())
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *subscriptionService) Pause(userID, subscriptionID string) (*model.Subscription, error) {
+	subscription, err := s.findSubscription(userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status != model.SubscriptionActive {
+		return nil, appError.NewAppError(409, "only active subscriptions can be paused")
+	}
+
+	subscription.Status = model.SubscriptionPaused
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Resume restarts a paused subscription. Renewals missed while it was paused
+// are not placed; the next one is the first still ahead.
+func (s *subscriptionService) Resume(userID, subscriptionID string) (*model.Subscription, error) {
+	subscription, err := s.findSubscription(userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status != model.SubscriptionPaused {
+		return nil, appError.NewAppError(409, "only paused subscriptions can be resumed")
+	}
+
+	subscription.Status = model.SubscriptionActive
+	subscription.AdvanceNextOrder(time.Now())
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *subscriptionService) Cancel(userID, subscriptionID string) (*model.Subscription, error) {
+	subscription, err := s.findSubscription(userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status == model.SubscriptionCancelled {
+		return nil, appError.NewAppError(409, "subscription is already cancelled")
+	}
+
+	subscription.Status = model.SubscriptionCancelled
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// findSubscription returns one of the user's subscriptions
+func (s *subscriptionService) findSubscription(userID, subscriptionID string) (*model.Subscription, error) {
+	subscription, err := s.repo.FindSubscriptionByID(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil || subscription.UserID != userID {
+		return nil, appError.NewAppError(404, "subscription not found")
+	}
+	return subscription, nil
+}
+
+func (s *subscriptionService) StartRenewalJob(leases repository.JobLeaseRepository) {
+	go func() {
+		for {
+			// At the start of every hour
+			next := time.Now().Truncate(time.Hour).Add(time.Hour)
+			time.Sleep(time.Until(next))
+
+			runJob(leases, "subscription-renewals", 30*time.Minute, s.renewDueSubscriptions)
+		}
+	}()
+}
+
+// renewDueSubscriptions places the orders of the subscriptions that are due
+func (s *subscriptionService) renewDueSubscriptions() {
+	now := time.Now()
+	subscriptions, err := s.repo.FindDueSubscriptions(now)
+	if err != nil {
+		fmt.Printf("Warning: failed to find due subscriptions: %v\n", err)
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if err := s.renew(subscription, now); err != nil {
+			fmt.Printf("Warning: failed to renew subscription %s: %v\n", subscription.ID, err)
+		}
+	}
+}
+
+// renew places one renewal order through the checkout. Items out of stock are
+// left out of it, and the buyer is told about them and about price changes.
+// A renewal that fails is not retried; the next one is an interval later.
+func (s *subscriptionService) renew(subscription *model.Subscription, now time.Time) error {
+	dueAt := subscription.NextOrderAt
+	subscription.AdvanceNextOrder(now)
+	claimed, err := s.repo.ClaimRenewal(subscription.ID, dueAt, subscription.NextOrderAt)
+	if err != nil || !claimed {
+		return err
+	}
+
+	var variantIDs []string
+	for _, item := range subscription.Items {
+		variantIDs = append(variantIDs, item.VariantID)
+	}
+	variants, err := s.productClient.GetVariantsByIds(variantIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get variant details: %w", err)
+	}
+
+	var checkoutItems []dto.InstantCheckoutItem
+	var outOfStock, repriced []string
+	for i := range subscription.Items {
+		item := &subscription.Items[i]
+		live := findVariant(variants, item.VariantID)
+		if live == nil || live.Variant.Stock < item.Quantity {
+			outOfStock = append(outOfStock, item.ProductName)
+			continue
+		}
+
+		currency := model.NormalizeCurrency(live.Variant.Currency)
+		if live.Variant.Price != item.Price || currency != item.Currency {
+			repriced = append(repriced, fmt.Sprintf("%s from %s to %s", item.ProductName,
+				model.NewMoney(int64(item.Price), item.Currency), model.NewMoney(int64(live.Variant.Price), currency)))
+			item.Price = live.Variant.Price
+			item.Currency = currency
+		}
+		checkoutItems = append(checkoutItems, dto.InstantCheckoutItem{
+			SellerID:  subscription.SellerID,
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	if len(repriced) > 0 {
+		s.notify(subscription, "Subscription price changed",
+			"The price of "+strings.Join(repriced, ", ")+" has changed", nil)
+	}
+	if len(outOfStock) > 0 {
+		s.notify(subscription, "Subscription item out of stock",
+			strings.Join(outOfStock, ", ")+" is out of stock and was left out of this delivery", nil)
+	}
+
+	orderID := ""
+	if len(checkoutItems) > 0 {
+		orderID = s.placeOrder(subscription, checkoutItems)
+	}
+	return s.repo.RecordRenewal(subscription.ID, subscription.Items, orderID)
+}
+
+// placeOrder checks out a renewal and charges STRIPE renewals to the saved
+// card. It returns the ID of the order, empty if none could be placed.
+func (s *subscriptionService) placeOrder(subscription *model.Subscription, items []dto.InstantCheckoutItem) string {
+	response, err := s.orderService.InstantCheckout(subscription.UserID, dto.InstantCheckoutRequest{
+		Items:             items,
+		ShippingAddress:   toShippingAddressDto(subscription.ShippingAddress),
+		PaymentMethod:     subscription.PaymentMethod,
+		DeliveryServiceID: subscription.DeliveryServiceID,
+		OffSession:        true,
+	})
+	if err != nil {
+		reason := "please check your subscription"
+		var appErr *appError.AppError
+		if errors.As(err, &appErr) {
+			reason = appErr.Message
+		}
+		fmt.Printf("Warning: failed to place order for subscription %s: %v\n", subscription.ID, err)
+		s.notify(subscription, "Subscription order failed",
+			"We could not place this delivery of your subscription: "+reason, nil)
+		return ""
+	}
+
+	if subscription.PaymentMethod == model.SubscriptionPayStripe {
+		if err := s.chargeSavedCard(subscription, response.OrderID); err != nil {
+			fmt.Printf("Warning: failed to charge subscription order %s: %v\n", response.OrderID, err)
+			s.notify(subscription, "Subscription payment failed",
+				fmt.Sprintf("Your saved card could not be charged for order %s. Please pay it from your orders before it is cancelled.", response.OrderID),
+				map[string]interface{}{"orderId": response.OrderID})
+			return response.OrderID
+		}
+	}
+
+	s.notify(subscription, "Subscription order placed",
+		fmt.Sprintf("Your subscription order %s has been placed", response.OrderID),
+		map[string]interface{}{"orderId": response.OrderID})
+	return response.OrderID
+}
+
+// chargeSavedCard pays what is left on a renewal order with the saved card
+func (s *subscriptionService) chargeSavedCard(subscription *model.Subscription, orderID string) error {
+	order, err := s.orderRepo.FindOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return fmt.Errorf("order %s not found", orderID)
+	}
+	if order.PaymentStatus == "PAID" {
+		return nil
+	}
+
+	event, err := s.stripeClient.ChargeOffSession(order, subscription.StripeCustomerID, subscription.StripePaymentMethodID)
+	if err != nil {
+		return err
+	}
+	return s.orderService.HandlePaymentSucceeded(context.Background(), event)
+}
+
+// notify tells the buyer about their subscription (best-effort)
+func (s *subscriptionService) notify(subscription *model.Subscription, title, message string, data map[string]interface{}) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["subscriptionId"] = subscription.ID
+
+	err := s.notificationClient.CreateNotification(client.CreateNotificationRequest{
+		UserID:  subscription.UserID,
+		Type:    "order",
+		Title:   title,
+		Message: message,
+		Data:    data,
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to send notification to buyer: %v\n", err)
+	}
+}
+
+// findVariant returns the variant with the given ID, nil if it is not listed
+func findVariant(variants []dto.ProductVariantDto, variantID string) *dto.ProductVariantDto {
+	for i := range variants {
+		if variants[i].Variant.ID == variantID {
+			return &variants[i]
+		}
+	}
+	return nil
+}
+
+func toOrderAddress(address dto.ShippingAddressDto) model.OrderAddress {
+	return model.OrderAddress{
+		FullName:    address.FullName,
+		Phone:       address.Phone,
+		AddressLine: address.AddressLine,
+		Ward:        address.Ward,
+		District:    address.District,
+		Province:    address.Province,
+		Country:     address.Country,
+		Latitude:    address.Latitude,
+		Longitude:   address.Longitude,
+		WardCode:    address.WardCode,
+		ProvinceID:  address.ProvinceID,
+		DistrictID:  address.DistrictID,
+	}
+}
+
+func toShippingAddressDto(address model.OrderAddress) dto.ShippingAddressDto {
+	return dto.ShippingAddressDto{
+		FullName:    address.FullName,
+		Phone:       address.Phone,
+		AddressLine: address.AddressLine,
+		Ward:        address.Ward,
+		District:    address.District,
+		Province:    address.Province,
+		Country:     address.Country,
+		Latitude:    address.Latitude,
+		Longitude:   address.Longitude,
+		WardCode:    address.WardCode,
+		ProvinceID:  address.ProvinceID,
+		DistrictID:  address.DistrictID,
+	}
+}
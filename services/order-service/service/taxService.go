@@ -0,0 +1,252 @@
+package service
+
+import (
+	"fmt"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// taxedStatuses are the order statuses counted in tax summaries: the sale went
+// through and was not cancelled or returned
+var taxedStatuses = []string{"TO_CONFIRM", "TO_PICKUP", "SHIPPING", "COMPLETED"}
+
+type TaxService interface {
+	ApplyTax(order *model.Order, variants []dto.ProductVariantDto) error
+	RecomputeTax(order *model.Order)
+	GetRates() ([]*model.TaxRate, error)
+	UpdateRate(request dto.UpdateTaxRateRequest) (*model.TaxRate, error)
+	DeleteRate(categoryID string) error
+	GetSellerSetting(sellerID string) (*model.SellerTaxSetting, error)
+	UpdateSellerSetting(sellerID string, request dto.UpdateSellerTaxSettingRequest) (*model.SellerTaxSetting, error)
+	GetMonthlySummary(sellerID string, request dto.GetTaxSummaryRequest) (*dto.TaxSummaryResponse, error)
+}
+
+type taxService struct {
+	repo                    repository.TaxRepository
+	defaultRate             float64 // percent, used when no default rate is stored
+	defaultPricesIncludeTax bool
+}
+
+func NewTaxService(repo repository.TaxRepository) TaxService {
+	defaultRate, err := strconv.ParseFloat(os.Getenv("TAX_DEFAULT_RATE"), 64)
+	if err != nil || defaultRate < 0 || defaultRate > 100 {
+		defaultRate = 10
+	}
+	defaultPricesIncludeTax := strings.ToLower(os.Getenv("TAX_PRICES_INCLUDE_TAX")) != "false"
+
+	return &taxService{
+		repo:                    repo,
+		defaultRate:             defaultRate,
+		defaultPricesIncludeTax: defaultPricesIncludeTax,
+	}
+}
+
+// ApplyTax computes the VAT of every order item and stores it on the item.
+// Order-level discounts (vouchers) lower the taxable amount and are split across
+// the lines in proportion to their value. For tax-exclusive sellers the VAT is
+// added to the order total; otherwise it is already part of the price.
+// Must run before loyalty points and wallet payments are applied.
+func (s *taxService) ApplyTax(order *model.Order, variants []dto.ProductVariantDto) error {
+	rates, err := s.rateMap()
+	if err != nil {
+		return fmt.Errorf("failed to get tax rates: %w", err)
+	}
+	setting, err := s.GetSellerSetting(order.Seller.ID)
+	if err != nil {
+		return err
+	}
+
+	categoriesByVariant := make(map[string][]string, len(variants))
+	for _, v := range variants {
+		categoriesByVariant[v.Variant.ID] = v.CategoryIds
+	}
+
+	for i := range order.Items {
+		order.Items[i].TaxRate = s.rateFor(rates, categoriesByVariant[order.Items[i].VariantID])
+	}
+	order.TaxInclusive = setting.PricesIncludeTax
+	s.RecomputeTax(order)
+	return nil
+}
+
+// RecomputeTax splits the VAT again after the items of an order changed, using
+// the rates and tax mode stored at checkout. Like ApplyTax, it expects Total to
+// be the items total after the voucher, before tax and loyalty points.
+func (s *taxService) RecomputeTax(order *model.Order) {
+	itemsTotal := order.ItemsTotal()
+	orderDiscount := itemsTotal - order.Total
+	if orderDiscount < 0 {
+		orderDiscount = 0
+	}
+
+	var taxTotal, allocated int64
+	for i := range order.Items {
+		item := &order.Items[i]
+		line := int64(item.LineTotal())
+
+		// Last line takes the rounding remainder so the split adds up exactly
+		lineDiscount := orderDiscount - allocated
+		if i < len(order.Items)-1 && itemsTotal > 0 {
+			lineDiscount = orderDiscount * line / itemsTotal
+		}
+		allocated += lineDiscount
+		amount := line - lineDiscount
+
+		bps := model.TaxBasisPoints(item.TaxRate)
+
+		var tax int64
+		if order.TaxInclusive {
+			// amount = net + net*rate, rounded half up
+			tax = (amount*bps + (10000+bps)/2) / (10000 + bps)
+			item.TaxableAmount = int(amount - tax)
+		} else {
+			tax = (amount*bps + 5000) / 10000
+			item.TaxableAmount = int(amount)
+		}
+		item.TaxAmount = int(tax)
+		taxTotal += tax
+	}
+
+	order.TaxTotal = taxTotal
+	if !order.TaxInclusive {
+		order.Total += taxTotal
+	}
+}
+
+// GetRates returns the configured category rates and the default rate
+func (s *taxService) GetRates() ([]*model.TaxRate, error) {
+	rates, err := s.repo.FindAllRates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax rates: %w", err)
+	}
+
+	for _, rate := range rates {
+		if rate.ID == model.DefaultTaxRateID {
+			return rates, nil
+		}
+	}
+	return append([]*model.TaxRate{{ID: model.DefaultTaxRateID, Rate: s.defaultRate}}, rates...), nil
+}
+
+func (s *taxService) UpdateRate(request dto.UpdateTaxRateRequest) (*model.TaxRate, error) {
+	id := request.CategoryID
+	if id == "" {
+		id = model.DefaultTaxRateID
+	}
+
+	rate := &model.TaxRate{
+		ID:   id,
+		Rate: request.Rate,
+	}
+	if err := s.repo.UpsertRate(rate); err != nil {
+		return nil, fmt.Errorf("failed to update tax rate: %w", err)
+	}
+	return rate, nil
+}
+
+// DeleteRate removes a category rate so the category falls back to the default rate
+func (s *taxService) DeleteRate(categoryID string) error {
+	if err := s.repo.DeleteRate(categoryID); err != nil {
+		return fmt.Errorf("failed to delete tax rate: %w", err)
+	}
+	return nil
+}
+
+func (s *taxService) GetSellerSetting(sellerID string) (*model.SellerTaxSetting, error) {
+	setting, err := s.repo.FindSellerSetting(sellerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller tax setting: %w", err)
+	}
+	if setting == nil {
+		setting = &model.SellerTaxSetting{
+			SellerID:         sellerID,
+			PricesIncludeTax: s.defaultPricesIncludeTax,
+		}
+	}
+	return setting, nil
+}
+
+func (s *taxService) UpdateSellerSetting(sellerID string, request dto.UpdateSellerTaxSettingRequest) (*model.SellerTaxSetting, error) {
+	setting := &model.SellerTaxSetting{
+		SellerID:         sellerID,
+		PricesIncludeTax: *request.PricesIncludeTax,
+	}
+	if err := s.repo.UpsertSellerSetting(setting); err != nil {
+		return nil, fmt.Errorf("failed to update seller tax setting: %w", err)
+	}
+	return setting, nil
+}
+
+func (s *taxService) GetMonthlySummary(sellerID string, request dto.GetTaxSummaryRequest) (*dto.TaxSummaryResponse, error) {
+	now := time.Now()
+	year, month := request.Year, request.Month
+	if year == 0 {
+		year = now.Year()
+	}
+	if month == 0 {
+		month = int(now.Month())
+	}
+	if year < 2000 || year > now.Year()+1 {
+		return nil, appError.NewAppError(400, "invalid year")
+	}
+
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+
+	lines, orderCount, err := s.repo.SumTaxBySeller(sellerID, from, to, taxedStatuses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax summary: %w", err)
+	}
+
+	lineDtos := make([]dto.TaxSummaryLineDto, len(lines))
+	for i, line := range lines {
+		lineDtos[i] = dto.TaxSummaryLineDto{
+			Currency:      line.Currency,
+			Rate:          line.Rate,
+			ItemCount:     line.ItemCount,
+			TaxableAmount: line.TaxableAmount,
+			TaxAmount:     line.TaxAmount,
+			GrossAmount:   line.TaxableAmount + line.TaxAmount,
+		}
+	}
+
+	return &dto.TaxSummaryResponse{
+		SellerID:   sellerID,
+		Year:       year,
+		Month:      month,
+		OrderCount: orderCount,
+		Lines:      lineDtos,
+	}, nil
+}
+
+func (s *taxService) rateMap() (map[string]float64, error) {
+	rates, err := s.repo.FindAllRates()
+	if err != nil {
+		return nil, err
+	}
+	rateMap := make(map[string]float64, len(rates))
+	for _, rate := range rates {
+		rateMap[rate.ID] = rate.Rate
+	}
+	return rateMap, nil
+}
+
+// rateFor returns the rate of the first of the product's categories that has one,
+// then the stored default rate, then the configured default
+func (s *taxService) rateFor(rates map[string]float64, categoryIDs []string) float64 {
+	for _, categoryID := range categoryIDs {
+		if rate, ok := rates[categoryID]; ok {
+			return rate
+		}
+	}
+	if rate, ok := rates[model.DefaultTaxRateID]; ok {
+		return rate
+	}
+	return s.defaultRate
+}
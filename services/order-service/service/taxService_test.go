@@ -0,0 +1,108 @@
+package service
+
+import (
+	"testing"
+
+	"order-service/model"
+)
+
+func TestRecomputeTax(t *testing.T) {
+	tests := []struct {
+		name         string
+		inclusive    bool
+		items        []model.OrderItem
+		total        int64
+		wantTaxable  []int
+		wantTax      []int
+		wantTaxTotal int64
+		wantTotal    int64
+	}{
+		{
+			name: "exclusive VAT rounded half up per line",
+			items: []model.OrderItem{
+				{Price: 19999, Quantity: 1, TaxRate: 10},
+				{Price: 1005, Quantity: 3, TaxRate: 8},
+				{Price: 15, Quantity: 1, TaxRate: 10},
+			},
+			total:        23029,
+			wantTaxable:  []int{19999, 3015, 15},
+			wantTax:      []int{2000, 241, 2},
+			wantTaxTotal: 2243,
+			wantTotal:    25272,
+		},
+		{
+			name:      "inclusive VAT after the voucher, last line takes the remainder",
+			inclusive: true,
+			items: []model.OrderItem{
+				{Price: 10000, Quantity: 1, TaxRate: 10},
+				{Price: 10000, Quantity: 1, TaxRate: 0},
+				{Price: 10000, Quantity: 1, TaxRate: 10},
+			},
+			total:        29000,
+			wantTaxable:  []int{8788, 9667, 8787},
+			wantTax:      []int{879, 0, 879},
+			wantTaxTotal: 1758,
+			wantTotal:    29000,
+		},
+		{
+			name: "exclusive VAT after a bundle and voucher discount",
+			items: []model.OrderItem{
+				{Price: 3000, Quantity: 2, Discount: 500, TaxRate: 10},
+				{Price: 4500, Quantity: 1, TaxRate: 10},
+			},
+			total:        8999,
+			wantTaxable:  []int{4950, 4049},
+			wantTax:      []int{495, 405},
+			wantTaxTotal: 900,
+			wantTotal:    9899,
+		},
+		{
+			name: "voucher covers the whole order",
+			items: []model.OrderItem{
+				{Price: 2000, Quantity: 1, TaxRate: 10},
+				{Price: 3000, Quantity: 1, TaxRate: 10},
+			},
+			total:        0,
+			wantTaxable:  []int{0, 0},
+			wantTax:      []int{0, 0},
+			wantTaxTotal: 0,
+			wantTotal:    0,
+		},
+		{
+			name:      "free items",
+			inclusive: true,
+			items: []model.OrderItem{
+				{Price: 0, Quantity: 1, TaxRate: 10},
+				{Price: 0, Quantity: 2, TaxRate: 10},
+			},
+			total:        0,
+			wantTaxable:  []int{0, 0},
+			wantTax:      []int{0, 0},
+			wantTaxTotal: 0,
+			wantTotal:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &model.Order{
+				Items:        tt.items,
+				Total:        tt.total,
+				TaxInclusive: tt.inclusive,
+			}
+			(&taxService{}).RecomputeTax(order)
+
+			for i, item := range order.Items {
+				if item.TaxableAmount != tt.wantTaxable[i] || item.TaxAmount != tt.wantTax[i] {
+					t.Errorf("item %d: taxable %d, tax %d, want %d and %d", i, item.TaxableAmount, item.TaxAmount, tt.wantTaxable[i], tt.wantTax[i])
+				}
+			}
+			if order.TaxTotal != tt.wantTaxTotal {
+				t.Errorf("tax total = %d, want %d", order.TaxTotal, tt.wantTaxTotal)
+			}
+			if order.Total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", order.Total, tt.wantTotal)
+			}
+		})
+	}
+}
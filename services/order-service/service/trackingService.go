@@ -0,0 +1,183 @@
+package service
+
+import (
+	"fmt"
+	"order-service/client"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ghnStatusDescriptions describes the GHN statuses for buyers
+var ghnStatusDescriptions = map[string]string{
+	"ready_to_pick":            "Waiting for the carrier to pick up the parcel",
+	"picking":                  "The carrier is picking up the parcel",
+	"money_collect_picking":    "The carrier is picking up the parcel",
+	"picked":                   "Picked up by the carrier",
+	"storing":                  "At the carrier's warehouse",
+	"transporting":             "In transit",
+	"sorting":                  "Being sorted",
+	"delivering":               "Out for delivery",
+	"money_collect_delivering": "Out for delivery",
+	"delivered":                "Delivered",
+	"delivery_fail":            "Delivery attempt failed",
+	"waiting_to_return":        "Waiting to be returned to the seller",
+	"return":                   "Being returned to the seller",
+	"return_transporting":      "Being returned to the seller",
+	"return_sorting":           "Being returned to the seller",
+	"returning":                "Being returned to the seller",
+	"return_fail":              "Return to the seller failed",
+	"returned":                 "Returned to the seller",
+	"exception":                "Delayed by an exception at the carrier",
+	"damage":                   "Damaged in transit",
+	"lost":                     "Lost in transit",
+	"cancel":                   "Cancelled",
+}
+
+type TrackingService interface {
+	GetOrderTracking(userID string, orderID string, isAdmin bool) (*dto.OrderTrackingResponse, error)
+	// TrackShipment returns what the carrier reports for a parcel, from the
+	// last fetch while it is fresh or when the carrier can't be reached
+	TrackShipment(orderID string, shipment *model.Shipment) (*model.ShipmentTracking, error)
+}
+
+type trackingService struct {
+	repo      repository.TrackingRepository
+	orderRepo repository.OrderRepository
+	GHNClient *client.GHNClient
+	cacheTTL  time.Duration
+}
+
+func NewTrackingService(repo repository.TrackingRepository, orderRepo repository.OrderRepository, GHNClient *client.GHNClient) TrackingService {
+	cacheMinutes, err := strconv.Atoi(os.Getenv("TRACKING_CACHE_MINUTES"))
+	if err != nil || cacheMinutes < 0 {
+		cacheMinutes = 5
+	}
+
+	return &trackingService{
+		repo:      repo,
+		orderRepo: orderRepo,
+		GHNClient: GHNClient,
+		cacheTTL:  time.Duration(cacheMinutes) * time.Minute,
+	}
+}
+
+// GetOrderTracking returns the parcels of an order with their carrier events,
+// to its buyer, its seller or an admin
+func (s *trackingService) GetOrderTracking(userID string, orderID string, isAdmin bool) (*dto.OrderTrackingResponse, error) {
+	order, err := s.orderRepo.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, appError.NewAppError(404, "Order not found")
+	}
+	if !isAdmin && order.User.ID != userID && order.Seller.ID != userID {
+		return nil, appError.NewAppError(403, "You don't have permission to view this order")
+	}
+
+	parcels := make([]dto.ParcelTrackingDto, 0, len(order.Shipments))
+	for i := range order.Shipments {
+		shipment := &order.Shipments[i]
+		parcel := dto.ParcelTrackingDto{
+			ShipmentID:   shipment.ID,
+			Carrier:      shipment.Carrier,
+			TrackingCode: shipment.TrackingCode,
+			Status:       shipment.Status,
+			Items:        shipment.Items,
+		}
+
+		tracking, err := s.TrackShipment(order.ID, shipment)
+		if err != nil {
+			// Nothing known yet; the parcel is listed without events
+			fmt.Printf("Warning: failed to track shipment %s of order %s: %v\n", shipment.TrackingCode, order.ID, err)
+		}
+		parcel.Tracking = tracking
+		parcels = append(parcels, parcel)
+	}
+
+	return &dto.OrderTrackingResponse{
+		OrderID: order.ID,
+		Status:  order.Status,
+		Parcels: parcels,
+	}, nil
+}
+
+func (s *trackingService) TrackShipment(orderID string, shipment *model.Shipment) (*model.ShipmentTracking, error) {
+	if shipment.Carrier != model.CarrierGHN || shipment.TrackingCode == "" {
+		return nil, nil
+	}
+
+	cached, err := s.repo.FindByTrackingCode(shipment.TrackingCode)
+	if err != nil {
+		fmt.Printf("Warning: failed to get cached tracking of shipment %s: %v\n", shipment.TrackingCode, err)
+		cached = nil
+	}
+	if cached != nil && (cached.IsFinal() || time.Since(cached.FetchedAt) < s.cacheTTL) {
+		return cached, nil
+	}
+
+	detail, err := s.GHNClient.GetOrderDetail(shipment.TrackingCode)
+	if err != nil {
+		if cached != nil {
+			fmt.Printf("Warning: failed to reach GHN for shipment %s, using the last known state: %v\n", shipment.TrackingCode, err)
+			cached.Stale = true
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	tracking := normalizeGHNTracking(orderID, shipment, detail)
+	if err := s.repo.Upsert(tracking); err != nil {
+		fmt.Printf("Warning: failed to cache tracking of shipment %s: %v\n", shipment.TrackingCode, err)
+	}
+	return tracking, nil
+}
+
+// normalizeGHNTracking turns a GHN order detail into our tracking structure
+func normalizeGHNTracking(orderID string, shipment *model.Shipment, detail *client.OrderDetailData) *model.ShipmentTracking {
+	events := make([]model.TrackingEvent, 0, len(detail.Log))
+	for _, entry := range detail.Log {
+		events = append(events, model.TrackingEvent{
+			Status:        client.GHNShipmentStatus(entry.Status),
+			CarrierStatus: entry.Status,
+			Description:   describeGHNStatus(entry.Status),
+			At:            entry.UpdatedDate,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+
+	// Statuses such as a failed delivery attempt leave the parcel where it was
+	status := detail.ShipmentStatus()
+	if status == "" {
+		status = shipment.Status
+	}
+
+	var expectedDelivery *time.Time
+	if !detail.Leadtime.IsZero() {
+		expectedDelivery = &detail.Leadtime
+	}
+
+	return &model.ShipmentTracking{
+		TrackingCode:     shipment.TrackingCode,
+		OrderID:          orderID,
+		Carrier:          model.CarrierGHN,
+		Status:           status,
+		CarrierStatus:    detail.Status,
+		ExpectedDelivery: expectedDelivery,
+		Events:           events,
+		FetchedAt:        time.Now(),
+	}
+}
+
+func describeGHNStatus(status string) string {
+	if description, ok := ghnStatusDescriptions[status]; ok {
+		return description
+	}
+	return status
+}
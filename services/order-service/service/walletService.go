@@ -0,0 +1,212 @@
+package service
+
+import (
+	"crypto/rand"
+	"math/big"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	stripeclient "order-service/client/payment/stripe"
+	walletclient "order-service/client/payment/wallet"
+
+	"github.com/stripe/stripe-go/v84"
+	"github.com/stripe/stripe-go/v84/checkout/session"
+)
+
+// WalletTopUpMetadataKey marks a Stripe checkout session as a wallet top-up
+const WalletTopUpMetadataKey = "wallet_user_id"
+
+type WalletService interface {
+	GetWallet(userID string, request dto.GetWalletRequest) (*dto.GetWalletResponse, error)
+	CreateTopUp(userID string, request dto.WalletTopUpRequest) (*dto.CreatePaymentResponse, error)
+	HandleTopUpCompleted(sessionID, userID string, amount model.Money) error
+	RedeemGiftCard(userID string, request dto.RedeemGiftCardRequest) (*dto.WalletBalanceResponse, error)
+	CreateGiftCards(adminID string, request dto.CreateGiftCardsRequest) (*dto.CreateGiftCardsResponse, error)
+}
+
+type walletService struct {
+	repo         repository.WalletRepository
+	walletClient *walletclient.WalletClient
+	clientURL    string
+}
+
+func NewWalletService(repo repository.WalletRepository, walletClient *walletclient.WalletClient) WalletService {
+	return &walletService{
+		repo:         repo,
+		walletClient: walletClient,
+		clientURL:    os.Getenv("CLIENT_URL"),
+	}
+}
+
+func (s *walletService) GetWallet(userID string, request dto.GetWalletRequest) (*dto.GetWalletResponse, error) {
+	page := request.Page
+	if page < 1 {
+		page = 1
+	}
+
+	limit := request.Limit
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100 // max limit
+	}
+
+	balance, err := s.walletClient.Balance(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := model.UserWalletAccount(userID)
+	transactions, totalCount, err := s.repo.FindTransactionsByAccount(account, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionDtos := make([]dto.WalletTransactionDto, 0, len(transactions))
+	for _, transaction := range transactions {
+		amount := 0
+		for _, entry := range transaction.Entries {
+			if entry.Account == account {
+				amount += entry.Amount
+			}
+		}
+		transactionDtos = append(transactionDtos, dto.WalletTransactionDto{
+			ID:          transaction.ID,
+			Type:        transaction.Type,
+			Reference:   transaction.Reference,
+			Amount:      amount,
+			Description: transaction.Description,
+			CreatedAt:   transaction.CreatedAt,
+		})
+	}
+
+	totalPages := int(totalCount) / limit
+	if int(totalCount)%limit > 0 {
+		totalPages++
+	}
+
+	return &dto.GetWalletResponse{
+		Balance:      balance,
+		Transactions: transactionDtos,
+		TotalCount:   totalCount,
+		Page:         page,
+		Limit:        limit,
+		TotalPages:   totalPages,
+	}, nil
+}
+
+// CreateTopUp opens a Stripe checkout session; the wallet is credited by the webhook
+func (s *walletService) CreateTopUp(userID string, request dto.WalletTopUpRequest) (*dto.CreatePaymentResponse, error) {
+	params := &stripe.CheckoutSessionParams{
+		PaymentMethodTypes: stripe.StringSlice([]string{
+			"card",
+		}),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			stripeclient.LineItem("Wallet Top-up", model.NewMoney(int64(request.Amount), model.WalletCurrency)),
+		},
+		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL: stripe.String(s.clientURL + "/wallet?topup=success"),
+		CancelURL:  stripe.String(s.clientURL + "/wallet?topup=failure"),
+		Metadata: map[string]string{
+			WalletTopUpMetadataKey: userID,
+			"amount":               strconv.Itoa(request.Amount),
+		},
+	}
+
+	sess, err := session.New(params)
+	if err != nil {
+		return nil, appError.NewAppError(500, "failed to create checkout session")
+	}
+
+	return &dto.CreatePaymentResponse{
+		PaymentUrl: sess.URL,
+	}, nil
+}
+
+func (s *walletService) HandleTopUpCompleted(sessionID, userID string, amount model.Money) error {
+	if amount.Amount <= 0 || amount.Currency != model.WalletCurrency {
+		return appError.NewAppError(400, "invalid top-up amount")
+	}
+	return s.walletClient.TopUp(userID, int(amount.Amount), sessionID)
+}
+
+func (s *walletService) RedeemGiftCard(userID string, request dto.RedeemGiftCardRequest) (*dto.WalletBalanceResponse, error) {
+	code := strings.ToUpper(strings.TrimSpace(request.Code))
+
+	giftCard, err := s.repo.ClaimGiftCard(code, userID)
+	if err != nil {
+		return nil, err
+	}
+	if giftCard == nil {
+		return nil, appError.NewAppError(404, "gift card not found, already redeemed or expired")
+	}
+
+	if err := s.walletClient.CreditGiftCard(userID, giftCard); err != nil {
+		// Rollback: make the code usable again
+		_ = s.repo.ReleaseGiftCard(code)
+		return nil, err
+	}
+
+	balance, err := s.walletClient.Balance(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.WalletBalanceResponse{Balance: balance}, nil
+}
+
+func (s *walletService) CreateGiftCards(adminID string, request dto.CreateGiftCardsRequest) (*dto.CreateGiftCardsResponse, error) {
+	if request.ExpiresAt != nil && request.ExpiresAt.Before(time.Now()) {
+		return nil, appError.NewAppError(400, "expires_at must be in the future")
+	}
+
+	quantity := request.Quantity
+	if quantity < 1 {
+		quantity = 1
+	}
+
+	giftCards := make([]*model.GiftCard, 0, quantity)
+	for i := 0; i < quantity; i++ {
+		code, err := generateGiftCardCode()
+		if err != nil {
+			return nil, appError.NewAppErrorWithErr(500, "failed to generate gift card code", err)
+		}
+
+		giftCard := &model.GiftCard{
+			Code:      code,
+			Amount:    request.Amount,
+			CreatedBy: adminID,
+			ExpiresAt: request.ExpiresAt,
+		}
+		if err := s.repo.CreateGiftCard(giftCard); err != nil {
+			return nil, err
+		}
+		giftCards = append(giftCards, giftCard)
+	}
+
+	return &dto.CreateGiftCardsResponse{GiftCards: giftCards}, nil
+}
+
+// generateGiftCardCode returns a random code like ABCD-EFGH-JKLM-NPQR.
+// Ambiguous characters (0, O, 1, I) are left out.
+func generateGiftCardCode() (string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	var builder strings.Builder
+	for i := 0; i < 16; i++ {
+		if i > 0 && i%4 == 0 {
+			builder.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		builder.WriteByte(alphabet[n.Int64()])
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"fmt"
+	"order-service/client"
+	"order-service/config"
+	"order-service/dto"
+	appError "order-service/error"
+	"order-service/model"
+	"order-service/repository"
+	"strings"
+)
+
+// DefaultWishlistAlias can be used in place of the ID of the user's default list
+const DefaultWishlistAlias = "default"
+
+type WishlistService interface {
+	GetWishlists(userID string) ([]dto.WishlistDto, error)
+	CreateWishlist(userID string, request dto.WishlistRequest) (*dto.WishlistDto, error)
+	RenameWishlist(userID, wishlistID string, request dto.WishlistRequest) (*dto.WishlistDto, error)
+	DeleteWishlist(userID, wishlistID string) error
+	GetWishlist(userID, wishlistID string) (*dto.WishlistDetailResponse, error)
+	AddItem(userID, wishlistID string, request dto.AddWishlistItemRequest) (*model.WishlistItem, error)
+	DeleteItem(userID, itemID string) error
+	// MoveItemToCart adds a wishlist item to the cart and removes it from the list
+	MoveItemToCart(userID, itemID string, request dto.MoveWishlistItemToCartRequest) (*dto.CartItemResponse, error)
+	// MoveCartItemToWishlist saves a cart line for later and removes it from the cart
+	MoveCartItemToWishlist(userID, cartItemID string, request dto.MoveCartItemToWishlistRequest) (*model.WishlistItem, error)
+}
+
+type wishlistService struct {
+	repo          repository.WishlistRepository
+	cartRepo      repository.CartRepository
+	cartService   CartService
+	productClient *client.ProductServiceClient
+}
+
+func NewWishlistService(
+	repo repository.WishlistRepository,
+	cartRepo repository.CartRepository,
+	cartService CartService,
+	productClient *client.ProductServiceClient,
+) WishlistService {
+	return &wishlistService{
+		repo:          repo,
+		cartRepo:      cartRepo,
+		cartService:   cartService,
+		productClient: productClient,
+	}
+}
+
+func (s *wishlistService) GetWishlists(userID string) ([]dto.WishlistDto, error) {
+	// Make sure the default list exists so it is always listed
+	if _, err := s.repo.EnsureDefaultWishlist(userID); err != nil {
+		return nil, err
+	}
+
+	wishlists, err := s.repo.FindWishlistsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repo.CountItemsByWishlist(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.WishlistDto, 0, len(wishlists))
+	for _, wishlist := range wishlists {
+		result = append(result, dto.ToWishlistDto(wishlist, counts[wishlist.ID]))
+	}
+	return result, nil
+}
+
+func (s *wishlistService) CreateWishlist(userID string, request dto.WishlistRequest) (*dto.WishlistDto, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, appError.NewAppError(400, "wishlist name is required")
+	}
+
+	count, err := s.repo.CountWishlistsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= model.MaxWishlistsPerUser {
+		return nil, appError.NewAppError(409, fmt.Sprintf("you can have at most %d wishlists", model.MaxWishlistsPerUser))
+	}
+
+	wishlist := &model.Wishlist{UserID: userID, Name: name}
+	if err := s.repo.CreateWishlist(wishlist); err != nil {
+		return nil, err
+	}
+
+	result := dto.ToWishlistDto(wishlist, 0)
+	return &result, nil
+}
+
+func (s *wishlistService) RenameWishlist(userID, wishlistID string, request dto.WishlistRequest) (*dto.WishlistDto, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, appError.NewAppError(400, "wishlist name is required")
+	}
+
+	wishlist, err := s.findWishlist(userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateWishlistName(wishlist.ID, name); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.FindWishlistByID(wishlist.ID)
+	if err != nil || updated == nil {
+		return nil, appError.NewAppError(500, "failed to get wishlist")
+	}
+	counts, err := s.repo.CountItemsByWishlist(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.ToWishlistDto(updated, counts[updated.ID])
+	return &result, nil
+}
+
+func (s *wishlistService) DeleteWishlist(userID, wishlistID string) error {
+	wishlist, err := s.findWishlist(userID, wishlistID)
+	if err != nil {
+		return err
+	}
+	if wishlist.IsDefault {
+		return appError.NewAppError(400, "the default wishlist cannot be deleted")
+	}
+	return s.repo.DeleteWishlist(wishlist.ID)
+}
+
+func (s *wishlistService) GetWishlist(userID, wishlistID string) (*dto.WishlistDetailResponse, error) {
+	wishlist, err := s.findWishlist(userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.FindItemsByWishlist(wishlist.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the items into saved variants and saved products
+	var variantIDs, productIDs []string
+	for _, item := range items {
+		if item.VariantID != "" {
+			variantIDs = append(variantIDs, item.VariantID)
+		} else {
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+
+	// Get live data from product-service, disabled variants included so they
+	// can be told apart from deleted ones
+	productVariants, err := s.productClient.GetVariantsByIdsIncludingDisabled(variantIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant details: %w", err)
+	}
+	variantMap := make(map[string]dto.ProductVariantDto, len(productVariants))
+	for _, pv := range productVariants {
+		variantMap[pv.Variant.ID] = pv
+	}
+
+	products, err := s.productClient.GetProductsByIds(productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product details: %w", err)
+	}
+	productMap := make(map[string]dto.ProductSummaryDto, len(products))
+	for _, product := range products {
+		productMap[product.ProductID] = product
+	}
+
+	result := make([]dto.WishlistItemDto, 0, len(items))
+	for _, item := range items {
+		detail := dto.WishlistItemDto{WishlistItem: *item}
+		if item.VariantID != "" {
+			if live, ok := variantMap[item.VariantID]; ok {
+				detail.CurrentPrice = live.Variant.Price
+				detail.CurrentMaxPrice = live.Variant.Price
+				detail.Currency = model.NormalizeCurrency(live.Variant.Currency)
+				detail.Stock = live.Variant.Stock
+				detail.ProductDisabled = live.IsDisabled
+			} else {
+				detail.Deleted = true
+			}
+		} else {
+			// Disabled products are not returned, so they show as deleted
+			if live, ok := productMap[item.ProductID]; ok {
+				detail.CurrentPrice = live.MinPrice
+				detail.CurrentMaxPrice = live.MaxPrice
+				detail.Currency = model.NormalizeCurrency(live.Currency)
+				detail.Stock = live.Stock
+			} else {
+				detail.Deleted = true
+			}
+		}
+
+		if !detail.Deleted {
+			detail.PriceDropped = detail.CurrentPrice < item.Price
+			detail.OutOfStock = detail.Stock <= 0
+			detail.Available = !detail.OutOfStock && !detail.ProductDisabled
+		}
+		result = append(result, detail)
+	}
+
+	return &dto.WishlistDetailResponse{
+		Wishlist: dto.ToWishlistDto(wishlist, len(items)),
+		Items:    result,
+	}, nil
+}
+
+func (s *wishlistService) AddItem(userID, wishlistID string, request dto.AddWishlistItemRequest) (*model.WishlistItem, error) {
+	wishlist, err := s.findWishlist(userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	return s.addItem(userID, wishlist, request.ProductID, request.VariantID)
+}
+
+// addItem saves a product or variant to a list, checking it against
+// product-service. Saving an item that is already in the list returns it.
+func (s *wishlistService) addItem(userID string, wishlist *model.Wishlist, productID, variantID string) (*model.WishlistItem, error) {
+	existing, err := s.repo.FindItem(wishlist.ID, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	item := &model.WishlistItem{
+		UserID:     userID,
+		WishlistID: wishlist.ID,
+		ProductID:  productID,
+		VariantID:  variantID,
+	}
+
+	if variantID != "" {
+		productVariants, err := s.productClient.GetVariantsByIds([]string{variantID})
+		if err != nil {
+			return nil, appError.NewAppError(500, "failed to get variant details")
+		}
+		if len(productVariants) == 0 {
+			return nil, appError.NewAppError(404, "variant not found")
+		}
+		live := productVariants[0]
+		if live.ProductID != productID {
+			return nil, appError.NewAppError(400, "variant does not belong to the product")
+		}
+		item.SellerID = live.SellerID
+		item.ProductName = live.ProductName
+		item.Options = live.Variant.Options
+		item.Image = live.Variant.Image
+		item.Price = live.Variant.Price
+		item.Currency = model.NormalizeCurrency(live.Variant.Currency)
+	} else {
+		products, err := s.productClient.GetProductsByIds([]string{productID})
+		if err != nil {
+			return nil, appError.NewAppError(500, "failed to get product details")
+		}
+		if len(products) == 0 {
+			return nil, appError.NewAppError(404, "product not found")
+		}
+		live := products[0]
+		item.SellerID = live.SellerID
+		item.ProductName = live.Name
+		item.Image = live.Image
+		item.Price = live.MinPrice
+		item.Currency = model.NormalizeCurrency(live.Currency)
+	}
+
+	created, err := s.repo.CreateItem(item)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Added concurrently
+		return s.repo.FindItem(wishlist.ID, productID, variantID)
+	}
+
+	// Publish add_to_wishlist interaction to ai-service via RabbitMQ (best-effort)
+	go config.PublishUserInteraction(userID, productID, "add_to_wishlist", 5)
+
+	return item, nil
+}
+
+func (s *wishlistService) DeleteItem(userID, itemID string) error {
+	item, err := s.findItem(userID, itemID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteItem(item.ID)
+}
+
+func (s *wishlistService) MoveItemToCart(userID, itemID string, request dto.MoveWishlistItemToCartRequest) (*dto.CartItemResponse, error) {
+	item, err := s.findItem(userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A saved product needs a variant picked before it can go in the cart
+	variantID := item.VariantID
+	if variantID == "" {
+		variantID = request.VariantID
+	}
+	if variantID == "" {
+		return nil, appError.NewAppError(400, "variant_id is required to move a product to the cart")
+	}
+
+	quantity := request.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+
+	cartItem, err := s.cartService.AddCartItem(userID, dto.AddCartItemRequest{
+		SellerID:  item.SellerID,
+		ProductID: item.ProductID,
+		VariantID: variantID,
+		Quantity:  quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The item is in the cart now; a leftover wishlist entry is harmless
+	if err := s.repo.DeleteItem(item.ID); err != nil {
+		fmt.Printf("Warning: failed to remove wishlist item %s after moving it to the cart: %v\n", item.ID, err)
+	}
+
+	return cartItem, nil
+}
+
+func (s *wishlistService) MoveCartItemToWishlist(userID, cartItemID string, request dto.MoveCartItemToWishlistRequest) (*model.WishlistItem, error) {
+	cartItem, err := s.cartRepo.FindCartItemByID(cartItemID)
+	if err != nil {
+		return nil, appError.NewAppError(500, "failed to get cart item")
+	}
+	if cartItem == nil {
+		return nil, appError.NewAppError(404, "cart item not found")
+	}
+	if cartItem.UserID != userID {
+		return nil, appError.NewAppError(401, "unauthorized: you can only move your own cart items")
+	}
+	if cartItem.IsBundle() {
+		return nil, appError.NewAppError(400, "bundles cannot be saved to a wishlist")
+	}
+
+	wishlist, err := s.findWishlist(userID, request.WishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.addItem(userID, wishlist, cartItem.Product.ID, cartItem.Variant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.DeleteCartItem(cartItem.ID); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// findWishlist returns one of the user's lists; an empty ID or
+// DefaultWishlistAlias selects the default list
+func (s *wishlistService) findWishlist(userID, wishlistID string) (*model.Wishlist, error) {
+	if wishlistID == "" || wishlistID == DefaultWishlistAlias || wishlistID == model.DefaultWishlistID(userID) {
+		return s.repo.EnsureDefaultWishlist(userID)
+	}
+
+	wishlist, err := s.repo.FindWishlistByID(wishlistID)
+	if err != nil {
+		return nil, appError.NewAppError(500, "failed to get wishlist")
+	}
+	if wishlist == nil || wishlist.UserID != userID {
+		return nil, appError.NewAppError(404, "wishlist not found")
+	}
+	return wishlist, nil
+}
+
+func (s *wishlistService) findItem(userID, itemID string) (*model.WishlistItem, error) {
+	item, err := s.repo.FindItemByID(itemID)
+	if err != nil {
+		return nil, appError.NewAppError(500, "failed to get wishlist item")
+	}
+	if item == nil || item.UserID != userID {
+		return nil, appError.NewAppError(404, "wishlist item not found")
+	}
+	return item, nil
+}
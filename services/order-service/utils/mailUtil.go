@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/smtp"
 	"os"
+	"strings"
 )
 
 // SendEmail gửi email, cấu hình lấy từ biến môi trường:
@@ -81,3 +82,41 @@ Your App Team
 
 	return content
 }
+
+// BuildCartReminderEmailContent tạo nội dung email nhắc giỏ hàng bị bỏ quên.
+// priceDrops và lowStock là các dòng mô tả sản phẩm giảm giá / sắp hết hàng.
+func BuildCartReminderEmailContent(name string, itemCount int, priceDrops, lowStock []string) string {
+	clientUrl := os.Getenv("CLIENT_URL")
+	cartLink := clientUrl + "/cart"
+
+	var details strings.Builder
+	if len(priceDrops) > 0 {
+		details.WriteString("\nĐã giảm giá:\n")
+		for _, line := range priceDrops {
+			details.WriteString("- " + line + "\n")
+		}
+	}
+	if len(lowStock) > 0 {
+		details.WriteString("\nSắp hết hàng:\n")
+		for _, line := range lowStock {
+			details.WriteString("- " + line + "\n")
+		}
+	}
+
+	content := fmt.Sprintf(`
+Hi %s,
+
+Giỏ hàng của bạn vẫn còn %d sản phẩm đang chờ thanh toán.
+%s
+Xem giỏ hàng tại đây:
+
+%s
+
+Bạn có thể tắt email nhắc giỏ hàng trong phần cài đặt tài khoản.
+
+Trân trọng,
+Your App Team
+`, name, itemCount, details.String(), cartLink)
+
+	return content
+}
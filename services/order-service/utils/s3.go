@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"order-service/config"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// UploadFileToS3 uploads generated content under key and returns its URL
+func UploadFileToS3(data []byte, key, contentType string) (string, error) {
+	return UploadReaderToS3(bytes.NewReader(data), key, contentType)
+}
+
+// UploadReaderToS3 uploads content read from body, e.g. a temporary file, so
+// large files are not loaded into memory
+func UploadReaderToS3(body io.ReadSeeker, key, contentType string) (string, error) {
+	if config.S3Client == nil {
+		return "", fmt.Errorf("S3 client not initialized")
+	}
+
+	_, err := config.S3Client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(config.S3BucketName),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to S3: %w", err)
+	}
+
+	// Construct the URL
+	url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s",
+		config.S3BucketName,
+		getRegionFromClient(),
+		key,
+	)
+
+	return url, nil
+}
+
+// DownloadFileFromS3 reads the object stored under key
+func DownloadFileFromS3(key string) ([]byte, error) {
+	if config.S3Client == nil {
+		return nil, fmt.Errorf("S3 client not initialized")
+	}
+
+	output, err := config.S3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(config.S3BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from S3: %w", err)
+	}
+	defer output.Body.Close()
+
+	return io.ReadAll(output.Body)
+}
+
+// PresignS3URL returns a temporary download link for a private object
+func PresignS3URL(key string, expiry time.Duration) (string, error) {
+	if config.S3Client == nil {
+		return "", fmt.Errorf("S3 client not initialized")
+	}
+
+	request, _ := config.S3Client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(config.S3BucketName),
+		Key:    aws.String(key),
+	})
+	url, err := request.Presign(expiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 URL: %w", err)
+	}
+	return url, nil
+}
+
+// Helper function to get region from S3 client
+func getRegionFromClient() string {
+	if config.S3Client != nil && config.S3Client.Config.Region != nil {
+		return *config.S3Client.Config.Region
+	}
+	return "us-east-1" // default region
+}
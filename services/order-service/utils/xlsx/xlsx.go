@@ -0,0 +1,101 @@
+// Package xlsx streams a single-sheet XLSX workbook row by row, so exports of
+// any size can be written without holding the rows in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	workbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	sheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd = `</sheetData></worksheet>`
+)
+
+// Writer writes rows to the single sheet of a workbook
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+// NewWriter starts a workbook with one sheet of the given name
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	z := zip.NewWriter(w)
+
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheetName))
+
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, name.String())},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+	} {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// The sheet is the last part, so it can stay open while rows are written
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(sheetStart); err != nil {
+		return nil, err
+	}
+
+	return &Writer{zip: z, sheet: sheet}, nil
+}
+
+// WriteRow appends a row. Integers and floats become numeric cells, anything
+// else is written as text.
+func (w *Writer) WriteRow(values []any) error {
+	w.sheet.WriteString("<row>")
+	for _, value := range values {
+		switch v := value.(type) {
+		case int:
+			fmt.Fprintf(w.sheet, "<c><v>%d</v></c>", v)
+		case int64:
+			fmt.Fprintf(w.sheet, "<c><v>%d</v></c>", v)
+		case float64:
+			fmt.Fprintf(w.sheet, "<c><v>%s</v></c>", strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			xml.EscapeText(w.sheet, []byte(fmt.Sprint(v)))
+			w.sheet.WriteString("</t></is></c>")
+		}
+	}
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+// Close finishes the sheet and the zip archive. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetEnd); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"net/http"
+	"product-service/dto"
+	"product-service/error"
+	"product-service/model"
+	"product-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BundleController struct {
+	service service.BundleService
+}
+
+func NewBundleController(service service.BundleService) *BundleController {
+	return &BundleController{service: service}
+}
+
+func (c *BundleController) CreateBundle(ctx *gin.Context) {
+	var bundle model.Bundle
+	if err := ctx.ShouldBindJSON(&bundle); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+	bundle.ID = ""
+	bundle.SellerID = ctx.GetHeader("X-User-Id")
+
+	// Validate bundle data
+	if err := bundle.Validate(); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Validation failed", err))
+		return
+	}
+
+	if err := c.service.CreateBundle(&bundle); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, bundle)
+}
+
+func (c *BundleController) UpdateBundle(ctx *gin.Context) {
+	var bundle model.Bundle
+	if err := ctx.ShouldBindJSON(&bundle); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+	bundle.ID = ctx.Param("id")
+	bundle.SellerID = ctx.GetHeader("X-User-Id")
+
+	// Validate bundle data
+	if err := bundle.Validate(); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Validation failed", err))
+		return
+	}
+
+	if err := c.service.UpdateBundle(&bundle); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, bundle)
+}
+
+func (c *BundleController) DeleteBundle(ctx *gin.Context) {
+	if err := c.service.DeleteBundle(ctx.GetHeader("X-User-Id"), ctx.Param("id")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Bundle deleted successfully"})
+}
+
+func (c *BundleController) GetBundleByID(ctx *gin.Context) {
+	response, err := c.service.GetBundleByID(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *BundleController) GetBundlesByIds(ctx *gin.Context) {
+	var request dto.GetBundlesByIdsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	bundles, err := c.service.GetBundlesByIds(request.BundleIDs)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.GetBundlesByIdsResponse{Bundles: bundles})
+}
+
+func (c *BundleController) GetActiveBundles(ctx *gin.Context) {
+	var params dto.BundleQueryParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+	params.SetDefaults()
+
+	response, err := c.service.GetActiveBundles(params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *BundleController) GetBundlesBySellerPublic(ctx *gin.Context) {
+	var params dto.BundleQueryParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+	params.SetDefaults()
+
+	response, err := c.service.GetBundlesBySeller(ctx.Param("sellerId"), true, params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *BundleController) GetMyBundles(ctx *gin.Context) {
+	var params dto.BundleQueryParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+	params.SetDefaults()
+
+	response, err := c.service.GetBundlesBySeller(ctx.GetHeader("X-User-Id"), false, params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -212,7 +212,7 @@ func (c *ProductController) GetVariantsByIds(ctx *gin.Context) {
 	}
 
 	// Get variants from service
-	variants, err := c.service.GetVariantsByIds(request.VariantIDs)
+	variants, err := c.service.GetVariantsByIds(request.VariantIDs, request.IncludeDisabled)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -226,6 +226,24 @@ func (c *ProductController) GetVariantsByIds(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+func (c *ProductController) GetProductsByIds(ctx *gin.Context) {
+	// Parse request body
+	var request dto.GetProductsByIdsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Invalid request body", err))
+		return
+	}
+
+	// Get product summaries from service
+	products, err := c.service.GetProductsByIds(request.ProductIDs)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.GetProductsByIdsResponse{Products: products})
+}
+
 func (c *ProductController) SearchProducts(ctx *gin.Context) {
 	// Parse query parameters
 	var params dto.SearchProductsQueryParams
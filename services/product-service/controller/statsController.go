@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"product-service/dto"
+	"product-service/error"
+	"product-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsController struct {
+	service service.StatsService
+}
+
+func NewStatsController(service service.StatsService) *StatsController {
+	return &StatsController{service: service}
+}
+
+// GetPlatformStats is called by order-service for the admin analytics dashboard
+func (c *StatsController) GetPlatformStats(ctx *gin.Context) {
+	var request dto.PlatformStatsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.Error(error.NewAppErrorWithErr(http.StatusBadRequest, "Invalid query parameters", err))
+		return
+	}
+
+	response, err := c.service.GetPlatformStats(request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
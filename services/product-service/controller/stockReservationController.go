@@ -65,3 +65,20 @@ func (ctrl *StockReservationController) ReleaseStock(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Stock released successfully"})
 }
+
+// ReleaseStockItems handles POST /api/v1/stock/release-items
+func (ctrl *StockReservationController) ReleaseStockItems(c *gin.Context) {
+	var req dto.ReleaseStockItemsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.stockReservationService.ReleaseStockItems(req.OrderID, req.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stock released successfully"})
+}
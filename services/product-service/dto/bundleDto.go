@@ -0,0 +1,65 @@
+package dto
+
+import "product-service/model"
+
+// BundleComponentDto is a bundle item enriched with live product and variant data
+type BundleComponentDto struct {
+	ProductID         string               `json:"product_id"`
+	ProductName       string               `json:"product_name"`
+	CategoryIds       []string             `json:"category_ids"`
+	SellerCategoryIds []string             `json:"seller_category_ids"`
+	Variant           model.Variant        `json:"variant"`
+	Quantity          int                  `json:"quantity"`
+	Available         bool                 `json:"available"`
+	PurchaseLimit     *model.PurchaseLimit `json:"purchase_limit,omitempty"` // the product's
+}
+
+// BundleDetailResponse is the listing/detail payload for a bundle.
+// Stock is the number of complete bundles that can be built from component stock.
+type BundleDetailResponse struct {
+	model.Bundle
+	Components    []BundleComponentDto `json:"components"`
+	Currency      string               `json:"currency"`       // ISO 4217, the components' currency
+	OriginalPrice int                  `json:"original_price"` // minor units of Currency, like Price and Discount
+	Price         int                  `json:"price"`
+	Discount      int                  `json:"discount"`
+	Stock         int                  `json:"stock"`
+}
+
+// GetBundlesByIdsRequest represents the request body for getting bundles by IDs
+type GetBundlesByIdsRequest struct {
+	BundleIDs []string `json:"bundle_ids" binding:"required,min=1"`
+}
+
+// GetBundlesByIdsResponse represents the response for getting bundles by IDs.
+// Deleted bundles are left out.
+type GetBundlesByIdsResponse struct {
+	Bundles []BundleDetailResponse `json:"bundles"`
+}
+
+// BundleQueryParams contains pagination parameters for bundle listings
+type BundleQueryParams struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+// SetDefaults sets default values for query parameters
+func (p *BundleQueryParams) SetDefaults() {
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.Limit <= 0 {
+		p.Limit = 10
+	}
+}
+
+// GetSkip calculates the number of documents to skip for pagination
+func (p *BundleQueryParams) GetSkip() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// PaginatedBundlesResponse represents paginated bundles response
+type PaginatedBundlesResponse struct {
+	Bundles    []BundleDetailResponse `json:"bundles"`
+	Pagination PaginationMetadata     `json:"pagination"`
+}
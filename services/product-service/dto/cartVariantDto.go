@@ -3,16 +3,21 @@ package dto
 import "product-service/model"
 
 type CartVariantDto struct {
+	ProductID         string        `json:"product_id"`
 	ProductName       string        `json:"product_name"`
+	IsDisabled        bool          `json:"is_disabled,omitempty"` // only with include_disabled
 	CategoryIds       []string      `json:"category_ids"`
 	SellerID          string        `json:"seller_id"`
 	SellerCategoryIds []string      `json:"seller_category_ids"`
 	Variant           model.Variant `json:"variant"`
+	// PurchaseLimit is the product's, shared by its variants
+	PurchaseLimit *model.PurchaseLimit `json:"purchase_limit,omitempty"`
 }
 
 // GetVariantsByIdsRequest represents the request body for getting variants by IDs
 type GetVariantsByIdsRequest struct {
-	VariantIDs []string `json:"variant_ids" binding:"required,min=1"`
+	VariantIDs      []string `json:"variant_ids" binding:"required,min=1"`
+	IncludeDisabled bool     `json:"include_disabled"` // also return variants of disabled products
 }
 
 // GetVariantsByIdsResponse represents the response for getting variants by IDs
@@ -87,6 +87,7 @@ func NewPaginatedProductsResponse(products []model.Product, total int64, page, l
 type ReserveStockItem struct {
 	VariantID string `json:"variant_id" binding:"required"`
 	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+	PreOrder  bool   `json:"pre_order"` // taken from the variant's pre-order quota instead of its stock
 }
 
 // ReserveStockRequest represents the request to reserve stock
@@ -100,6 +101,12 @@ type ReleaseStockRequest struct {
 	OrderID string `json:"order_id" binding:"required"`
 }
 
+// ReleaseStockItemsRequest represents the request to release part of an order's stock
+type ReleaseStockItemsRequest struct {
+	OrderID string             `json:"order_id" binding:"required"`
+	Items   []ReserveStockItem `json:"items" binding:"required,min=1,dive"`
+}
+
 // SearchProductsQueryParams represents query parameters for searching products
 type SearchProductsQueryParams struct {
 	Page          int      `form:"page"`
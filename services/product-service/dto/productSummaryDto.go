@@ -0,0 +1,25 @@
+package dto
+
+// ProductSummaryDto is the live state of a product for callers that only hold
+// its ID, such as wishlists
+type ProductSummaryDto struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	Image     string `json:"image"`
+	SellerID  string `json:"seller_id"`
+	MinPrice  int    `json:"min_price"`
+	MaxPrice  int    `json:"max_price"`
+	Currency  string `json:"currency"`
+	Stock     int    `json:"stock"` // across all variants
+}
+
+// GetProductsByIdsRequest represents the request body for getting product summaries by IDs
+type GetProductsByIdsRequest struct {
+	ProductIDs []string `json:"product_ids" binding:"required,min=1"`
+}
+
+// GetProductsByIdsResponse represents the response for getting product summaries by IDs.
+// Disabled and deleted products are left out.
+type GetProductsByIdsResponse struct {
+	Products []ProductSummaryDto `json:"products"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// PlatformStatsRequest selects the days [from, to) of a breakdown. Days are
+// counted in Timezone, an IANA name such as "Asia/Ho_Chi_Minh" (default UTC).
+type PlatformStatsRequest struct {
+	From     time.Time `form:"from" binding:"required"`
+	To       time.Time `form:"to" binding:"required"`
+	Timezone string    `form:"tz"`
+}
+
+type StatsDayResponse struct {
+	Date        string `json:"date"` // YYYY-MM-DD
+	NewProducts int    `json:"new_products"`
+	NewReports  int    `json:"new_reports"`
+}
+
+// PlatformStatsResponse holds the catalog and report totals, and the new products
+// and reports of each day with any
+type PlatformStatsResponse struct {
+	TotalProducts    int64              `json:"total_products"`
+	ActiveProducts   int64              `json:"active_products"`
+	DisabledProducts int64              `json:"disabled_products"`
+	OpenReports      int64              `json:"open_reports"` // pending or reviewed
+	NewProducts      int                `json:"new_products"`
+	NewReports       int                `json:"new_reports"`
+	Daily            []StatsDayResponse `json:"daily"`
+}
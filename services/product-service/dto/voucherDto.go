@@ -10,6 +10,7 @@ type VoucherRequest struct {
 	DiscountValue          int       `json:"discount_value" validate:"required,min=0"`
 	MaxDiscountValue       *int      `json:"max_discount_value"`
 	MinOrderValue          int       `json:"min_order_value" validate:"min=0"`
+	Currency               string    `json:"currency"` // defaults to VND
 	ApplyScope             string    `json:"apply_scope" validate:"required,oneof=ALL CATEGORY"`
 	ApplySellerCategoryIds []string  `json:"apply_seller_category_ids"`
 	TotalQuantity          int       `json:"total_quantity" validate:"required,min=1"`
@@ -29,6 +30,7 @@ type VoucherResponse struct {
 	DiscountValue          int                  `json:"discount_value"`
 	MaxDiscountValue       *int                 `json:"max_discount_value"`
 	MinOrderValue          int                  `json:"min_order_value"`
+	Currency               string               `json:"currency"`
 	ApplyScope             string               `json:"apply_scope"`
 	ApplySellerCategoryIds []string             `json:"apply_seller_category_ids"`
 	TotalQuantity          int                  `json:"total_quantity"`
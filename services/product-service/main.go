@@ -5,6 +5,7 @@ import (
 	"product-service/client"
 	"product-service/config"
 	"product-service/controller"
+	"product-service/migration"
 	"product-service/model"
 	"product-service/repository"
 	"product-service/router"
@@ -18,6 +19,7 @@ func main() {
 	fmt.Println("Hello Product Service")
 	// Kết nối DB
 	config.ConnectDatabase()
+	migration.Run(config.DB)
 
 	// Initialize S3
 	config.InitS3()
@@ -92,6 +94,16 @@ func main() {
 	chatBotService := service.NewChatBotService(chatBotClient, productRepo, categoryRepo, sellerCategoryRepo, voucherRepo, userClient)
 	chatBotController := controller.NewChatBotController(chatBotService)
 
+	// Wiring dependencies - Bundle
+	bundleRepo := repository.NewBundleRepository(config.DB)
+	bundleService := service.NewBundleService(bundleRepo, productRepo)
+	bundleController := controller.NewBundleController(bundleService)
+
+	// Wiring dependencies - Stats
+	statsRepo := repository.NewStatsRepository(config.DB)
+	statsService := service.NewStatsService(statsRepo)
+	statsController := controller.NewStatsController(statsService)
+
 	r := gin.Default()
 	r.Use(cors.Default())
 
@@ -107,6 +119,8 @@ func main() {
 		SearchHistoryController:    searchHistoryController,
 		ReportController:           reportController,
 		ChatBotController:          chatBotController,
+		BundleController:           bundleController,
+		StatsController:            statsController,
 	})
 
 	r.Run(":8085")
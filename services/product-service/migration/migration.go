@@ -0,0 +1,80 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// step is a one-off data migration. Applied steps are recorded in the
+// migrations collection so each one runs once per database.
+type step struct {
+	ID  string
+	Run func(ctx context.Context, db *mongo.Database) error
+}
+
+var steps = []step{
+	{ID: "2026-10-variant-voucher-currency", Run: backfillCurrency},
+}
+
+// Run applies the steps that have not been applied yet. A failed step is
+// logged and retried on the next start; later steps still run.
+func Run(db *mongo.Database) {
+	if db == nil {
+		return
+	}
+	applied := db.Collection("migrations")
+
+	for _, s := range steps {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+
+		count, err := applied.CountDocuments(ctx, bson.M{"_id": s.ID})
+		if err != nil {
+			fmt.Printf("Warning: failed to check migration %s: %v\n", s.ID, err)
+			cancel()
+			continue
+		}
+		if count > 0 {
+			cancel()
+			continue
+		}
+
+		if err := s.Run(ctx, db); err != nil {
+			fmt.Printf("Warning: migration %s failed: %v\n", s.ID, err)
+			cancel()
+			continue
+		}
+		if _, err := applied.InsertOne(ctx, bson.M{"_id": s.ID, "applied_at": time.Now()}); err != nil {
+			fmt.Printf("Warning: failed to record migration %s: %v\n", s.ID, err)
+		}
+		fmt.Printf("Applied migration %s\n", s.ID)
+		cancel()
+	}
+}
+
+// backfillCurrency records VND on variants and vouchers created before
+// currencies were stored
+func backfillCurrency(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection("products").UpdateMany(ctx,
+		bson.M{"variants": bson.M{"$elemMatch": bson.M{"currency": bson.M{"$exists": false}}}},
+		bson.M{"$set": bson.M{"variants.$[v].currency": "VND"}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"v.currency": bson.M{"$exists": false}}},
+		}),
+	); err != nil {
+		return fmt.Errorf("failed to set variant currency: %w", err)
+	}
+
+	if _, err := db.Collection("vouchers").UpdateMany(ctx,
+		bson.M{"currency": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"currency": "VND"}},
+	); err != nil {
+		return fmt.Errorf("failed to set voucher currency: %w", err)
+	}
+
+	return nil
+}
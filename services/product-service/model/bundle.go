@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bundle groups several variants of the same seller that are sold together
+// at a fixed bundle price or a percentage off the sum of the component prices.
+type Bundle struct {
+	ID            string       `bson:"_id" json:"id"`
+	SellerID      string       `bson:"seller_id" json:"seller_id"`
+	Name          string       `bson:"name" json:"name"`
+	Description   string       `bson:"description" json:"description"`
+	Image         string       `bson:"image" json:"image"`
+	Items         []BundleItem `bson:"items" json:"items"`
+	DiscountType  string       `bson:"discount_type" json:"discount_type"`   // FIXED_PRICE, PERCENTAGE
+	BundlePrice   int          `bson:"bundle_price" json:"bundle_price"`     // used when discount_type is FIXED_PRICE, minor units of the components' currency
+	DiscountValue int          `bson:"discount_value" json:"discount_value"` // percent off, used when discount_type is PERCENTAGE
+	IsActive      bool         `bson:"is_active" json:"is_active"`
+	CreatedAt     time.Time    `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time    `bson:"updated_at" json:"updated_at"`
+}
+
+type BundleItem struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	VariantID string `bson:"variant_id" json:"variant_id"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+}
+
+// Validate checks if the bundle has all required fields and valid data
+func (b *Bundle) Validate() error {
+	if b.Name == "" {
+		return fmt.Errorf("bundle name is required")
+	}
+	if len(b.Items) < 2 {
+		return fmt.Errorf("a bundle must contain at least two items")
+	}
+
+	seen := make(map[string]bool)
+	for i, item := range b.Items {
+		if item.ProductID == "" || item.VariantID == "" {
+			return fmt.Errorf("item %d: product_id and variant_id are required", i)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("item %d: quantity must be greater than 0", i)
+		}
+		if seen[item.VariantID] {
+			return fmt.Errorf("item %d: variant %s is listed more than once", i, item.VariantID)
+		}
+		seen[item.VariantID] = true
+	}
+
+	switch b.DiscountType {
+	case "FIXED_PRICE":
+		if b.BundlePrice <= 0 {
+			return fmt.Errorf("bundle_price must be greater than 0")
+		}
+	case "PERCENTAGE":
+		if b.DiscountValue <= 0 || b.DiscountValue >= 100 {
+			return fmt.Errorf("discount_value must be between 1 and 99")
+		}
+	default:
+		return fmt.Errorf("discount_type must be FIXED_PRICE or PERCENTAGE")
+	}
+
+	return nil
+}
+
+// BeforeCreate generates a new UUID for the ID field if not set and initializes timestamps
+func (b *Bundle) BeforeCreate() {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = time.Now()
+	}
+	if b.UpdatedAt.IsZero() {
+		b.UpdatedAt = time.Now()
+	}
+}
+
+// BeforeUpdate updates the UpdatedAt timestamp
+func (b *Bundle) BeforeUpdate() {
+	b.UpdatedAt = time.Now()
+}
+
+// PriceFor returns the bundle price given the sum of the current component prices.
+// The result never exceeds the original price.
+func (b *Bundle) PriceFor(originalPrice int) int {
+	price := originalPrice
+	switch b.DiscountType {
+	case "FIXED_PRICE":
+		price = b.BundlePrice
+	case "PERCENTAGE":
+		price = originalPrice - originalPrice*b.DiscountValue/100
+	}
+	if price > originalPrice {
+		price = originalPrice
+	}
+	if price < 0 {
+		price = 0
+	}
+	return price
+}
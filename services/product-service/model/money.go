@@ -0,0 +1,32 @@
+package model
+
+import "strings"
+
+// DefaultCurrency is used for documents created before currencies were recorded
+const DefaultCurrency = "VND"
+
+// supportedCurrencies are the ISO 4217 codes prices can be set in.
+// Prices are stored in the currency's minor units (VND has none).
+var supportedCurrencies = map[string]bool{
+	"VND": true,
+	"JPY": true,
+	"KRW": true,
+	"USD": true,
+	"EUR": true,
+	"SGD": true,
+	"THB": true,
+}
+
+// NormalizeCurrency upper-cases a currency code and falls back to DefaultCurrency when empty
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+// IsSupportedCurrency reports whether prices can be set in the currency
+func IsSupportedCurrency(currency string) bool {
+	return supportedCurrencies[NormalizeCurrency(currency)]
+}
@@ -27,6 +27,7 @@ type Product struct {
 
 	OptionGroups []OptionGroup      `bson:"option_groups" json:"option_groups"` 
 	Variants     []Variant          `bson:"variants" json:"variants"`
+	PurchaseLimit *PurchaseLimit    `bson:"purchase_limit" json:"purchase_limit,omitempty"` // across all variants
 
 	// Many-to-many relationships stored as arrays of IDs
 	CategoryIDs       []string `bson:"category_ids" json:"category_ids,omitempty"`
@@ -44,13 +45,56 @@ type OptionGroup struct {
 }
 
 type Variant struct {
-	ID      string            `bson:"_id" json:"id"`
-	SKU     string            `bson:"sku" json:"sku"`
-	Options map[string]string `bson:"options" json:"options"`
-	Price   int               `bson:"price" json:"price"`
-	Stock   int               `bson:"stock" json:"stock"`
-	Image   string            `bson:"image" json:"image"`
-	SoldCount int               `bson:"sold_count" json:"sold_count"`
+	ID            string            `bson:"_id" json:"id"`
+	SKU           string            `bson:"sku" json:"sku"`
+	Options       map[string]string `bson:"options" json:"options"`
+	Price         int               `bson:"price" json:"price"`       // minor units of Currency
+	Currency      string            `bson:"currency" json:"currency"` // ISO 4217
+	Stock         int               `bson:"stock" json:"stock"`
+	Image         string            `bson:"image" json:"image"`
+	SoldCount     int               `bson:"sold_count" json:"sold_count"`
+	PurchaseLimit *PurchaseLimit    `bson:"purchase_limit" json:"purchase_limit,omitempty"`
+	PreOrder      *PreOrder         `bson:"pre_order" json:"pre_order,omitempty"`
+}
+
+// PreOrder lets a variant be ordered beyond its stock, to ship around ShipDate.
+// Pre-ordered units come out of Quota instead of the stock.
+type PreOrder struct {
+	Quota    int       `bson:"quota" json:"quota"`       // units that can still be pre-ordered
+	Reserved int       `bson:"reserved" json:"reserved"` // units pre-ordered and not released
+	ShipDate time.Time `bson:"ship_date" json:"ship_date"` // expected
+}
+
+func (p *PreOrder) Validate() error {
+	if p.Quota < 0 {
+		return fmt.Errorf("pre-order quota cannot be negative")
+	}
+	if p.ShipDate.IsZero() {
+		return fmt.Errorf("pre-order ship date is required")
+	}
+	return nil
+}
+
+// IsOpen reports whether quantity more units can be pre-ordered
+func (p *PreOrder) IsOpen(quantity int) bool {
+	return p != nil && p.Quota >= quantity && p.ShipDate.After(time.Now())
+}
+
+// PurchaseLimit caps how many units one customer can buy, over the last
+// WindowDays days or, when it is 0, ever
+type PurchaseLimit struct {
+	MaxQuantity int `bson:"max_quantity" json:"max_quantity"`
+	WindowDays  int `bson:"window_days,omitempty" json:"window_days,omitempty"`
+}
+
+func (l *PurchaseLimit) Validate() error {
+	if l.MaxQuantity <= 0 {
+		return fmt.Errorf("purchase limit must be greater than 0")
+	}
+	if l.WindowDays < 0 {
+		return fmt.Errorf("purchase limit window cannot be negative")
+	}
+	return nil
 }
 
 // Validate checks if the product has all required fields and valid data
@@ -61,9 +105,22 @@ func (p *Product) Validate() error {
 	if len(p.Variants) == 0 {
 		return fmt.Errorf("at least one variant is required")
 	}
+	if p.PurchaseLimit != nil {
+		if err := p.PurchaseLimit.Validate(); err != nil {
+			return err
+		}
+	}
 
 	// Validate each variant
 	for i, variant := range p.Variants {
+		// A product is sold in a single currency
+		p.Variants[i].Currency = NormalizeCurrency(variant.Currency)
+		if !IsSupportedCurrency(p.Variants[i].Currency) {
+			return fmt.Errorf("variant %d: unsupported currency '%s'", i, variant.Currency)
+		}
+		if p.Variants[i].Currency != p.Variants[0].Currency {
+			return fmt.Errorf("variant %d: all variants must use the same currency", i)
+		}
 		if variant.SKU == "" {
 			return fmt.Errorf("variant %d: SKU is required", i)
 		}
@@ -73,6 +130,16 @@ func (p *Product) Validate() error {
 		if variant.Stock < 0 {
 			return fmt.Errorf("variant %d: stock cannot be negative", i)
 		}
+		if variant.PurchaseLimit != nil {
+			if err := variant.PurchaseLimit.Validate(); err != nil {
+				return fmt.Errorf("variant %d: %w", i, err)
+			}
+		}
+		if variant.PreOrder != nil {
+			if err := variant.PreOrder.Validate(); err != nil {
+				return fmt.Errorf("variant %d: %w", i, err)
+			}
+		}
 
 		// Validate that variant options match option groups
 		if len(p.OptionGroups) > 0 {
@@ -140,6 +207,9 @@ func (p *Product) BeforeCreate() {
 		}
 		// Initialize variant sold count to 0
 		p.Variants[i].SoldCount = 0
+		if p.Variants[i].PreOrder != nil {
+			p.Variants[i].PreOrder.Reserved = 0
+		}
 	}
 
 	// Initialize product sold count to 0
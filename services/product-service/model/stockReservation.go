@@ -11,7 +11,8 @@ type StockReservation struct {
 	OrderID   string    `bson:"order_id" json:"order_id"`
 	VariantID string    `bson:"variant_id" json:"variant_id"`
 	Quantity  int       `bson:"quantity" json:"quantity"`
-	Status    string    `bson:"status" json:"status"` // "RESERVED" or "RELEASED"
+	PreOrder  bool      `bson:"pre_order,omitempty" json:"pre_order,omitempty"` // held on the pre-order quota, not the stock
+	Status    string    `bson:"status" json:"status"`                           // "RESERVED" or "RELEASED"
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
@@ -12,11 +12,12 @@ type Voucher struct {
 	Code                   string    `bson:"code" json:"code"`
 	Name                   string    `bson:"name" json:"name"`
 	Description            string    `bson:"description" json:"description"`
-	DiscountType           string    `bson:"discount_type" json:"discount_type"` // FIXED, PERCENTAGE
-	DiscountValue          int       `bson:"discount_value" json:"discount_value"`
-	MaxDiscountValue       *int      `bson:"max_discount_value" json:"max_discount_value"`
-	MinOrderValue          int       `bson:"min_order_value" json:"min_order_value"`
-	ApplyScope             string    `bson:"apply_scope" json:"apply_scope"` // ALL, CATEGORY
+	DiscountType           string    `bson:"discount_type" json:"discount_type"`           // FIXED, PERCENTAGE
+	DiscountValue          int       `bson:"discount_value" json:"discount_value"`         // percent, or minor units of Currency for FIXED
+	MaxDiscountValue       *int      `bson:"max_discount_value" json:"max_discount_value"` // minor units of Currency
+	MinOrderValue          int       `bson:"min_order_value" json:"min_order_value"`       // minor units of Currency
+	Currency               string    `bson:"currency" json:"currency"`                     // ISO 4217 currency of the amounts above
+	ApplyScope             string    `bson:"apply_scope" json:"apply_scope"`               // ALL, CATEGORY
 	ApplySellerCategoryIds []string  `bson:"apply_seller_category_ids" json:"apply_seller_category_ids"`
 	TotalQuantity          int       `bson:"total_quantity" json:"total_quantity"`
 	UsedQuantity           int       `bson:"used_quantity" json:"used_quantity"`
@@ -44,6 +45,7 @@ func (v *Voucher) BeforeCreate() {
 	if v.Status == "" {
 		v.Status = "ACTIVE"
 	}
+	v.Currency = NormalizeCurrency(v.Currency)
 }
 
 func (v *Voucher) BeforeUpdate() {
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"product-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BundleRepository interface {
+	Create(bundle *model.Bundle) error
+	FindByID(id string) (*model.Bundle, error)
+	FindByIDs(ids []string) ([]model.Bundle, error)
+	FindBySeller(sellerID string, activeOnly bool, skip, limit int) ([]model.Bundle, int64, error)
+	FindActive(skip, limit int) ([]model.Bundle, int64, error)
+	Update(bundle *model.Bundle) error
+	Delete(id string) error
+}
+
+type bundleRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewBundleRepository(db *mongo.Database) BundleRepository {
+	return &bundleRepository{
+		db:         db,
+		collection: db.Collection("bundles"),
+	}
+}
+
+func (r *bundleRepository) Create(bundle *model.Bundle) error {
+	bundle.BeforeCreate()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.InsertOne(ctx, bundle)
+	return err
+}
+
+func (r *bundleRepository) FindByID(id string) (*model.Bundle, error) {
+	var bundle model.Bundle
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&bundle)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// FindByIDs returns the bundles that exist among the given IDs
+func (r *bundleRepository) FindByIDs(ids []string) ([]model.Bundle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bundles []model.Bundle
+	if err = cursor.All(ctx, &bundles); err != nil {
+		return nil, err
+	}
+	return bundles, nil
+}
+
+func (r *bundleRepository) FindBySeller(sellerID string, activeOnly bool, skip, limit int) ([]model.Bundle, int64, error) {
+	filter := bson.M{"seller_id": sellerID}
+	if activeOnly {
+		filter["is_active"] = true
+	}
+	return r.find(filter, skip, limit)
+}
+
+func (r *bundleRepository) FindActive(skip, limit int) ([]model.Bundle, int64, error) {
+	return r.find(bson.M{"is_active": true}, skip, limit)
+}
+
+func (r *bundleRepository) find(filter bson.M, skip, limit int) ([]model.Bundle, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var bundles []model.Bundle
+	if err = cursor.All(ctx, &bundles); err != nil {
+		return nil, 0, err
+	}
+	return bundles, total, nil
+}
+
+func (r *bundleRepository) Update(bundle *model.Bundle) error {
+	bundle.BeforeUpdate()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": bundle.ID}, bundle)
+	return err
+}
+
+func (r *bundleRepository) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
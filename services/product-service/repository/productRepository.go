@@ -25,6 +25,7 @@ type ProductRepository interface {
 	UpdateVariantImages(productID string, variantUpdates map[string]string) error
 	FindBySeller(sellerID string, filter bson.M, skip, limit int, sortField string, sortDirection int) ([]model.Product, int64, error)
 	FindVariantsByIds(variantIDs []string) (map[string]*model.Product, error)
+	FindVariantsByIdsIncludingDisabled(variantIDs []string) (map[string]*model.Product, error)
 	// UpdateVariantStock unconditionally applies stockDelta to a variant's stock.
 	// Use for releases (positive delta) only; for reservations use DecrementVariantStockAtomic.
 	UpdateVariantStock(productID string, variantID string, stockDelta int) error
@@ -32,6 +33,13 @@ type ProductRepository interface {
 	// single atomic operation that only succeeds when stock >= quantity at the
 	// moment of the write. Returns ErrInsufficientStock when the guard fails.
 	DecrementVariantStockAtomic(productID string, variantID string, quantity int) error
+	// ReservePreOrderAtomic takes quantity off a variant's pre-order quota in a
+	// single atomic operation that only succeeds while the pre-order is open
+	// and the quota covers it. Returns ErrInsufficientStock when the guard fails.
+	ReservePreOrderAtomic(productID string, variantID string, quantity int) error
+	// ReleasePreOrder gives quantity back to a variant's pre-order quota. It
+	// does nothing when the variant is no longer on pre-order.
+	ReleasePreOrder(productID string, variantID string, quantity int) error
 	SearchProducts(filter bson.M, skip, limit int, sortByTextScore bool, sortField string, sortDirection int) ([]model.Product, int64, error)
 	FindRandom(excludeIDs []string, limit int) ([]model.Product, error)
 }
@@ -219,14 +227,24 @@ func (r *productRepository) FindBySeller(sellerID string, filter bson.M, skip, l
 }
 
 func (r *productRepository) FindVariantsByIds(variantIDs []string) (map[string]*model.Product, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	// Query products that contain any of the variant IDs and are not disabled
-	filter := bson.M{
+	return r.findVariantsByIds(variantIDs, bson.M{
 		"variants._id": bson.M{"$in": variantIDs},
 		"is_disabled":  bson.M{"$ne": true},
-	}
+	})
+}
+
+// FindVariantsByIdsIncludingDisabled also returns disabled products, so callers
+// can tell a disabled product from a deleted variant
+func (r *productRepository) FindVariantsByIdsIncludingDisabled(variantIDs []string) (map[string]*model.Product, error) {
+	return r.findVariantsByIds(variantIDs, bson.M{
+		"variants._id": bson.M{"$in": variantIDs},
+	})
+}
+
+func (r *productRepository) findVariantsByIds(variantIDs []string, filter bson.M) (map[string]*model.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
 	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
@@ -318,6 +336,68 @@ func (r *productRepository) DecrementVariantStockAtomic(productID string, varian
 	return nil
 }
 
+// ReservePreOrderAtomic works like DecrementVariantStockAtomic on the variant's
+// pre-order quota, and also refuses pre-orders once the ship date has passed
+func (r *productRepository) ReservePreOrderAtomic(productID string, variantID string, quantity int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id": productID,
+			"variants": bson.M{
+				"$elemMatch": bson.M{
+					"_id":                 variantID,
+					"pre_order.quota":     bson.M{"$gte": quantity},
+					"pre_order.ship_date": bson.M{"$gt": now},
+				},
+			},
+		},
+		bson.M{
+			"$inc": bson.M{
+				"variants.$.pre_order.quota":    -quantity,
+				"variants.$.pre_order.reserved": quantity,
+			},
+			"$set": bson.M{"updated_at": now},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+func (r *productRepository) ReleasePreOrder(productID string, variantID string, quantity int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id": productID,
+			"variants": bson.M{
+				"$elemMatch": bson.M{
+					"_id":       variantID,
+					"pre_order": bson.M{"$type": "object"},
+				},
+			},
+		},
+		bson.M{
+			"$inc": bson.M{
+				"variants.$.pre_order.quota":    quantity,
+				"variants.$.pre_order.reserved": -quantity,
+			},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
 func (r *productRepository) FindRandom(excludeIDs []string, limit int) ([]model.Product, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
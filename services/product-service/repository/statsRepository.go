@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"product-service/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DayCount holds the number of documents created on one day
+type DayCount struct {
+	Date  string `bson:"_id"` // YYYY-MM-DD
+	Count int    `bson:"count"`
+}
+
+// ProductCounts holds the catalog totals
+type ProductCounts struct {
+	Total    int64
+	Active   int64
+	Disabled int64
+}
+
+type StatsRepository interface {
+	CountProducts() (*ProductCounts, error)
+	CountOpenReports() (int64, error)
+	CountProductsByDay(from, to time.Time, timezone string) ([]DayCount, error)
+	CountReportsByDay(from, to time.Time, timezone string) ([]DayCount, error)
+}
+
+type statsRepository struct {
+	productsCollection *mongo.Collection
+	reportsCollection  *mongo.Collection
+}
+
+func NewStatsRepository(db *mongo.Database) StatsRepository {
+	return &statsRepository{
+		productsCollection: db.Collection("products"),
+		reportsCollection:  db.Collection("reports"),
+	}
+}
+
+func (r *statsRepository) CountProducts() (*ProductCounts, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var counts ProductCounts
+	var err error
+	if counts.Total, err = r.productsCollection.CountDocuments(ctx, bson.M{}); err != nil {
+		return nil, err
+	}
+	if counts.Active, err = r.productsCollection.CountDocuments(ctx, bson.M{"is_active": true, "is_disabled": bson.M{"$ne": true}}); err != nil {
+		return nil, err
+	}
+	if counts.Disabled, err = r.productsCollection.CountDocuments(ctx, bson.M{"is_disabled": true}); err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+func (r *statsRepository) CountOpenReports() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.reportsCollection.CountDocuments(ctx, bson.M{
+		"status": bson.M{"$in": bson.A{model.ReportStatusPending, model.ReportStatusReviewed}},
+	})
+}
+
+func (r *statsRepository) CountProductsByDay(from, to time.Time, timezone string) ([]DayCount, error) {
+	return countByDay(r.productsCollection, from, to, timezone)
+}
+
+func (r *statsRepository) CountReportsByDay(from, to time.Time, timezone string) ([]DayCount, error) {
+	return countByDay(r.reportsCollection, from, to, timezone)
+}
+
+// countByDay counts the documents created in [from, to) per day in the given time zone
+func countByDay(collection *mongo.Collection, from, to time.Time, timezone string) ([]DayCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%d",
+				"date":     "$created_at",
+				"timezone": timezone,
+			}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var days []DayCount
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
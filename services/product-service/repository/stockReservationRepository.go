@@ -14,6 +14,7 @@ type StockReservationRepository interface {
 	CreateMany(reservations []model.StockReservation) error
 	FindByOrderID(orderID string) ([]model.StockReservation, error)
 	UpdateStatusByOrderID(orderID string, status string) error
+	Update(reservation *model.StockReservation) error
 }
 
 type stockReservationRepository struct {
@@ -89,3 +90,23 @@ func (r *stockReservationRepository) UpdateStatusByOrderID(orderID string, statu
 	)
 	return err
 }
+
+// Update saves the quantity and status of a single reservation
+func (r *stockReservationRepository) Update(reservation *model.StockReservation) error {
+	reservation.BeforeUpdate()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": reservation.ID},
+		bson.M{
+			"$set": bson.M{
+				"quantity":   reservation.Quantity,
+				"status":     reservation.Status,
+				"updated_at": reservation.UpdatedAt,
+			},
+		},
+	)
+	return err
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"product-service/controller"
+	"product-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterBundleRoutes(rg *gin.RouterGroup, c *controller.BundleController) {
+	bundle := rg.Group("")
+	{
+		// Public routes
+		bundle.GET("/public/bundles", c.GetActiveBundles)
+		bundle.GET("/public/bundles/:id", c.GetBundleByID)
+		bundle.POST("/public/bundles/batch", c.GetBundlesByIds)
+		bundle.GET("/public/bundles/seller/:sellerId", c.GetBundlesBySellerPublic)
+
+		// Seller routes - manage their own bundles
+		bundle.GET("/bundles", middleware.RequireSeller(), c.GetMyBundles)
+		bundle.POST("/bundles", middleware.RequireSeller(), c.CreateBundle)
+		bundle.PUT("/bundles/:id", middleware.RequireSeller(), c.UpdateBundle)
+		bundle.DELETE("/bundles/:id", middleware.RequireSeller(), c.DeleteBundle)
+	}
+}
@@ -17,6 +17,7 @@ func RegisterProductRoutes(rg *gin.RouterGroup, c controller.ProductController)
 		product.GET("/public", c.GetAllProducts)
 		product.GET("/public/products/seller/:sellerId", c.GetProductsBySeller)
 		product.POST("/public/variants/batch", c.GetVariantsByIds)
+		product.POST("/public/products/batch", c.GetProductsByIds)
 		product.GET("/public/search", c.SearchProducts)
 		product.POST("/public/check-status", c.CheckProductsStatus)
 
@@ -19,6 +19,8 @@ type AppRouter struct {
 	SearchHistoryController     *controller.SearchHistoryController
 	ReportController            *controller.ReportController
 	ChatBotController           *controller.ChatBotController
+	BundleController            *controller.BundleController
+	StatsController             *controller.StatsController
 }
 
 // SetupRouter builds the main Gin router and registers all module routes
@@ -61,6 +63,12 @@ func SetupRouter(engine *gin.Engine, appRouter *AppRouter) *gin.Engine {
 
 		// ChatBot routes
 		RegisterChatBotRoutes(productGroup, appRouter.ChatBotController)
+
+		// Bundle routes
+		RegisterBundleRoutes(productGroup, appRouter.BundleController)
+
+		// Stats routes
+		RegisterStatsRoutes(productGroup, appRouter.StatsController)
 	}
 
 	return engine
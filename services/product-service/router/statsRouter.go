@@ -0,0 +1,14 @@
+package router
+
+import (
+	"product-service/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterStatsRoutes(rg *gin.RouterGroup, c *controller.StatsController) {
+	stats := rg.Group("/stats")
+	{
+		stats.GET("", c.GetPlatformStats)
+	}
+}
@@ -11,5 +11,6 @@ func SetupStockReservationRoutes(r *gin.RouterGroup, ctrl *controller.StockReser
 	{
 		stock.POST("/reserve", ctrl.ReserveStock)
 		stock.POST("/release", ctrl.ReleaseStock)
+		stock.POST("/release-items", ctrl.ReleaseStockItems)
 	}
 }
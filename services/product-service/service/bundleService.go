@@ -0,0 +1,256 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"product-service/dto"
+	appError "product-service/error"
+	"product-service/model"
+	"product-service/repository"
+)
+
+type BundleService interface {
+	CreateBundle(bundle *model.Bundle) error
+	UpdateBundle(bundle *model.Bundle) error
+	DeleteBundle(sellerID, id string) error
+	GetBundleByID(id string) (*dto.BundleDetailResponse, error)
+	GetBundlesByIds(ids []string) ([]dto.BundleDetailResponse, error)
+	GetBundlesBySeller(sellerID string, activeOnly bool, params dto.BundleQueryParams) (*dto.PaginatedBundlesResponse, error)
+	GetActiveBundles(params dto.BundleQueryParams) (*dto.PaginatedBundlesResponse, error)
+}
+
+type bundleService struct {
+	repo        repository.BundleRepository
+	productRepo repository.ProductRepository
+}
+
+func NewBundleService(repo repository.BundleRepository, productRepo repository.ProductRepository) BundleService {
+	return &bundleService{
+		repo:        repo,
+		productRepo: productRepo,
+	}
+}
+
+func (s *bundleService) CreateBundle(bundle *model.Bundle) error {
+	if err := s.validateComponents(bundle); err != nil {
+		return err
+	}
+	bundle.IsActive = true
+	return s.repo.Create(bundle)
+}
+
+func (s *bundleService) UpdateBundle(bundle *model.Bundle) error {
+	oldBundle, err := s.repo.FindByID(bundle.ID)
+	if err != nil {
+		return err
+	}
+	if oldBundle == nil {
+		return appError.NewAppError(http.StatusNotFound, "Bundle not found")
+	}
+	if oldBundle.SellerID != bundle.SellerID {
+		return appError.NewAppError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if err := s.validateComponents(bundle); err != nil {
+		return err
+	}
+
+	bundle.CreatedAt = oldBundle.CreatedAt
+	return s.repo.Update(bundle)
+}
+
+func (s *bundleService) DeleteBundle(sellerID, id string) error {
+	oldBundle, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if oldBundle == nil {
+		return appError.NewAppError(http.StatusNotFound, "Bundle not found")
+	}
+	if oldBundle.SellerID != sellerID {
+		return appError.NewAppError(http.StatusUnauthorized, "Unauthorized")
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *bundleService) GetBundleByID(id string) (*dto.BundleDetailResponse, error) {
+	bundle, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, appError.NewAppError(http.StatusNotFound, "Bundle not found")
+	}
+
+	details, err := s.buildDetails([]model.Bundle{*bundle})
+	if err != nil {
+		return nil, err
+	}
+	return &details[0], nil
+}
+
+// GetBundlesByIds returns the bundles that exist among the given IDs, inactive
+// ones included, with their live component data
+func (s *bundleService) GetBundlesByIds(ids []string) ([]dto.BundleDetailResponse, error) {
+	bundles, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildDetails(bundles)
+}
+
+func (s *bundleService) GetBundlesBySeller(sellerID string, activeOnly bool, params dto.BundleQueryParams) (*dto.PaginatedBundlesResponse, error) {
+	bundles, total, err := s.repo.FindBySeller(sellerID, activeOnly, params.GetSkip(), params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.paginate(bundles, total, params)
+}
+
+func (s *bundleService) GetActiveBundles(params dto.BundleQueryParams) (*dto.PaginatedBundlesResponse, error) {
+	bundles, total, err := s.repo.FindActive(params.GetSkip(), params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.paginate(bundles, total, params)
+}
+
+func (s *bundleService) paginate(bundles []model.Bundle, total int64, params dto.BundleQueryParams) (*dto.PaginatedBundlesResponse, error) {
+	details, err := s.buildDetails(bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / params.Limit
+	if int(total)%params.Limit > 0 {
+		totalPages++
+	}
+
+	return &dto.PaginatedBundlesResponse{
+		Bundles: details,
+		Pagination: dto.PaginationMetadata{
+			CurrentPage:  params.Page,
+			TotalPages:   totalPages,
+			TotalItems:   total,
+			ItemsPerPage: params.Limit,
+		},
+	}, nil
+}
+
+// validateComponents ensures every bundle item references an existing variant
+// of a product owned by the bundle's seller.
+func (s *bundleService) validateComponents(bundle *model.Bundle) error {
+	variantIDs := make([]string, len(bundle.Items))
+	for i, item := range bundle.Items {
+		variantIDs[i] = item.VariantID
+	}
+
+	variantToProduct, err := s.productRepo.FindVariantsByIds(variantIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch variants: %w", err)
+	}
+
+	currency := ""
+	for _, item := range bundle.Items {
+		product, exists := variantToProduct[item.VariantID]
+		if !exists || product.ID != item.ProductID {
+			return appError.NewAppError(http.StatusBadRequest, fmt.Sprintf("variant %s not found in product %s", item.VariantID, item.ProductID))
+		}
+		if product.SellerID != bundle.SellerID {
+			return appError.NewAppError(http.StatusBadRequest, "all bundle items must belong to the seller")
+		}
+
+		// The bundle price is derived from the components, so they must share a currency
+		for _, variant := range product.Variants {
+			if variant.ID != item.VariantID {
+				continue
+			}
+			variantCurrency := model.NormalizeCurrency(variant.Currency)
+			if currency != "" && variantCurrency != currency {
+				return appError.NewAppError(http.StatusBadRequest, "all bundle items must be priced in the same currency")
+			}
+			currency = variantCurrency
+		}
+	}
+	return nil
+}
+
+// buildDetails enriches bundles with live component data in a single variant lookup.
+// Price and stock are derived from the components, so they always reflect the
+// current variant price and stock.
+func (s *bundleService) buildDetails(bundles []model.Bundle) ([]dto.BundleDetailResponse, error) {
+	var variantIDs []string
+	for _, bundle := range bundles {
+		for _, item := range bundle.Items {
+			variantIDs = append(variantIDs, item.VariantID)
+		}
+	}
+
+	variantToProduct := map[string]*model.Product{}
+	if len(variantIDs) > 0 {
+		var err error
+		variantToProduct, err = s.productRepo.FindVariantsByIds(variantIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch variants: %w", err)
+		}
+	}
+
+	result := make([]dto.BundleDetailResponse, 0, len(bundles))
+	for _, bundle := range bundles {
+		detail := dto.BundleDetailResponse{
+			Bundle:     bundle,
+			Components: make([]dto.BundleComponentDto, 0, len(bundle.Items)),
+		}
+
+		stock := -1
+		for _, item := range bundle.Items {
+			component := dto.BundleComponentDto{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+			}
+
+			product, exists := variantToProduct[item.VariantID]
+			if exists {
+				component.ProductName = product.Name
+				component.CategoryIds = product.CategoryIDs
+				component.SellerCategoryIds = product.SellerCategoryIDs
+				component.PurchaseLimit = product.PurchaseLimit
+				for _, variant := range product.Variants {
+					if variant.ID == item.VariantID {
+						component.Variant = variant
+						component.Variant.Currency = model.NormalizeCurrency(variant.Currency)
+						component.Available = true
+						break
+					}
+				}
+			}
+
+			// A missing or disabled component makes the whole bundle unavailable
+			componentStock := 0
+			if component.Available {
+				componentStock = component.Variant.Stock / item.Quantity
+				detail.OriginalPrice += component.Variant.Price * item.Quantity
+			}
+			if stock < 0 || componentStock < stock {
+				stock = componentStock
+			}
+
+			detail.Components = append(detail.Components, component)
+		}
+
+		if stock < 0 {
+			stock = 0
+		}
+		detail.Currency = model.DefaultCurrency
+		if len(detail.Components) > 0 && detail.Components[0].Available {
+			detail.Currency = detail.Components[0].Variant.Currency
+		}
+		detail.Stock = stock
+		detail.Price = bundle.PriceFor(detail.OriginalPrice)
+		detail.Discount = detail.OriginalPrice - detail.Price
+
+		result = append(result, detail)
+	}
+
+	return result, nil
+}
@@ -25,7 +25,8 @@ type ProductService interface {
 	ProcessProductImageUpload(productID string, files []*multipart.FileHeader) ([]model.ProductImages, error)
 	ProcessVariantImageUpload(productID string, fileMap map[string][]*multipart.FileHeader) (map[string]string, error)
 	GetProductsBySeller(sellerID string, params dto.GetProductsQueryParams) (*dto.PaginatedProductsResponse, error)
-	GetVariantsByIds(variantIDs []string) ([]dto.CartVariantDto, error)
+	GetVariantsByIds(variantIDs []string, includeDisabled bool) ([]dto.CartVariantDto, error)
+	GetProductsByIds(productIDs []string) ([]dto.ProductSummaryDto, error)
 	SearchProducts(params dto.SearchProductsQueryParams, userID string) (*dto.PaginatedProductsResponse, error)
 	GetRecentlyViewedProducts(userID string, limit int) ([]model.Product, error)
 	SetProductDisabled(productID string, isDisabled bool, reason string) error
@@ -182,6 +183,20 @@ func (s *productService) UpdateProduct(product *model.Product) error {
 	product.Rating = oldProduct.Rating
 	product.CalculatePrice()
 
+	// Pre-ordered units are counted by reservations, not by the seller
+	for i := range product.Variants {
+		if product.Variants[i].PreOrder == nil {
+			continue
+		}
+		product.Variants[i].PreOrder.Reserved = 0
+		for _, old := range oldProduct.Variants {
+			if old.ID == product.Variants[i].ID && old.PreOrder != nil {
+				product.Variants[i].PreOrder.Reserved = old.PreOrder.Reserved
+				break
+			}
+		}
+	}
+
 	// Update the product
 	err = s.repo.Update(product)
 	if err != nil {
@@ -447,9 +462,13 @@ func (s *productService) GetProductsBySeller(sellerID string, params dto.GetProd
 	return response, nil
 }
 
-func (s *productService) GetVariantsByIds(variantIDs []string) ([]dto.CartVariantDto, error) {
+func (s *productService) GetVariantsByIds(variantIDs []string, includeDisabled bool) ([]dto.CartVariantDto, error) {
 	// Get products containing the variants
-	variantToProduct, err := s.repo.FindVariantsByIds(variantIDs)
+	findVariants := s.repo.FindVariantsByIds
+	if includeDisabled {
+		findVariants = s.repo.FindVariantsByIdsIncludingDisabled
+	}
+	variantToProduct, err := findVariants(variantIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -467,11 +486,14 @@ func (s *productService) GetVariantsByIds(variantIDs []string) ([]dto.CartVarian
 		for _, variant := range product.Variants {
 			if variant.ID == variantID {
 				result = append(result, dto.CartVariantDto{
+					ProductID:         product.ID,
 					ProductName:       product.Name,
+					IsDisabled:        product.IsDisabled,
 					CategoryIds:       product.CategoryIDs,
 					SellerID:          product.SellerID,
 					SellerCategoryIds: product.SellerCategoryIDs,
 					Variant:           variant,
+					PurchaseLimit:     product.PurchaseLimit,
 				})
 				break
 			}
@@ -481,6 +503,38 @@ func (s *productService) GetVariantsByIds(variantIDs []string) ([]dto.CartVarian
 	return result, nil
 }
 
+func (s *productService) GetProductsByIds(productIDs []string) ([]dto.ProductSummaryDto, error) {
+	// Disabled products are skipped by the repository
+	products, err := s.repo.FindByIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.ProductSummaryDto, 0, len(products))
+	for _, product := range products {
+		summary := dto.ProductSummaryDto{
+			ProductID: product.ID,
+			Name:      product.Name,
+			SellerID:  product.SellerID,
+			MinPrice:  product.Price.Min,
+			MaxPrice:  product.Price.Max,
+			Currency:  model.DefaultCurrency,
+		}
+		if len(product.Images) > 0 {
+			summary.Image = product.Images[0].URL
+		}
+		for i, variant := range product.Variants {
+			if i == 0 {
+				summary.Currency = model.NormalizeCurrency(variant.Currency)
+			}
+			summary.Stock += variant.Stock
+		}
+		result = append(result, summary)
+	}
+
+	return result, nil
+}
+
 func (s *productService) SearchProducts(params dto.SearchProductsQueryParams, userID string) (*dto.PaginatedProductsResponse, error) {
 	// Save search history if user is logged in and has search query
 	if userID != "" && params.SearchQuery != "" {
@@ -85,6 +85,7 @@ func (s *savedVoucherService) GetSavedVouchers(userID string) ([]dto.SavedVouche
 				DiscountValue:          voucher.DiscountValue,
 				MaxDiscountValue:       voucher.MaxDiscountValue,
 				MinOrderValue:          voucher.MinOrderValue,
+				Currency:               model.NormalizeCurrency(voucher.Currency),
 				ApplyScope:             voucher.ApplyScope,
 				ApplySellerCategoryIds: voucher.ApplySellerCategoryIds,
 				TotalQuantity:          voucher.TotalQuantity,
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"product-service/dto"
+	appError "product-service/error"
+	"product-service/repository"
+	"sort"
+	"time"
+)
+
+type StatsService interface {
+	GetPlatformStats(request dto.PlatformStatsRequest) (*dto.PlatformStatsResponse, error)
+}
+
+type statsService struct {
+	repo repository.StatsRepository
+}
+
+func NewStatsService(repo repository.StatsRepository) StatsService {
+	return &statsService{repo: repo}
+}
+
+// GetPlatformStats returns the catalog and report totals, and the products and
+// reports created on each day of [from, to)
+func (s *statsService) GetPlatformStats(request dto.PlatformStatsRequest) (*dto.PlatformStatsResponse, error) {
+	if !request.From.Before(request.To) {
+		return nil, appError.NewAppError(400, "invalid time range: 'from' must be before 'to'")
+	}
+	timezone := request.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, appError.NewAppError(400, "invalid time zone: "+timezone)
+	}
+
+	products, err := s.repo.CountProducts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
+	openReports, err := s.repo.CountOpenReports()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reports: %w", err)
+	}
+	productDays, err := s.repo.CountProductsByDay(request.From, request.To, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count new products: %w", err)
+	}
+	reportDays, err := s.repo.CountReportsByDay(request.From, request.To, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count new reports: %w", err)
+	}
+
+	response := &dto.PlatformStatsResponse{
+		TotalProducts:    products.Total,
+		ActiveProducts:   products.Active,
+		DisabledProducts: products.Disabled,
+		OpenReports:      openReports,
+	}
+
+	daily := make(map[string]*dto.StatsDayResponse)
+	day := func(date string) *dto.StatsDayResponse {
+		if daily[date] == nil {
+			daily[date] = &dto.StatsDayResponse{Date: date}
+		}
+		return daily[date]
+	}
+	for _, d := range productDays {
+		day(d.Date).NewProducts = d.Count
+		response.NewProducts += d.Count
+	}
+	for _, d := range reportDays {
+		day(d.Date).NewReports = d.Count
+		response.NewReports += d.Count
+	}
+
+	response.Daily = make([]dto.StatsDayResponse, 0, len(daily))
+	for _, d := range daily {
+		response.Daily = append(response.Daily, *d)
+	}
+	sort.Slice(response.Daily, func(i, j int) bool {
+		return response.Daily[i].Date < response.Daily[j].Date
+	})
+
+	return response, nil
+}
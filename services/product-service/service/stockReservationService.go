@@ -11,6 +11,7 @@ import (
 type StockReservationService interface {
 	ReserveStock(orderID string, items []dto.ReserveStockItem) error
 	ReleaseStock(orderID string) error
+	ReleaseStockItems(orderID string, items []dto.ReserveStockItem) error
 }
 
 type stockReservationService struct {
@@ -61,13 +62,19 @@ func (s *stockReservationService) ReserveStock(orderID string, items []dto.Reser
 		if variant == nil {
 			return fmt.Errorf("variant %s not found in product", item.VariantID)
 		}
+		if item.PreOrder {
+			if !variant.PreOrder.IsOpen(item.Quantity) {
+				return fmt.Errorf("variant %s can't be pre-ordered: the pre-order is closed or its quota is too low", item.VariantID)
+			}
+			continue
+		}
 		if variant.Stock < item.Quantity {
 			return fmt.Errorf("insufficient stock for variant %s: requested %d, available %d",
 				item.VariantID, item.Quantity, variant.Stock)
 		}
 	}
 
-	// 3. Atomically decrement each variant's stock one by one.
+	// 3. Atomically decrement each variant's stock (or pre-order quota) one by one.
 	//    DecrementVariantStockAtomic uses a single UpdateOne with a $gte guard,
 	//    so two concurrent requests competing for the same stock cannot both
 	//    succeed — the second one will get ErrInsufficientStock.
@@ -76,11 +83,11 @@ func (s *stockReservationService) ReserveStock(orderID string, items []dto.Reser
 	//    back exactly those items if a later decrement fails.
 	for i, item := range items {
 		product := variantToProduct[item.VariantID]
-		if err := s.productRepo.DecrementVariantStockAtomic(product.ID, item.VariantID, item.Quantity); err != nil {
+		if err := s.take(product.ID, item); err != nil {
 			// Roll back all decrements that already succeeded (indices 0..i-1).
 			for _, done := range items[:i] {
 				doneProduct := variantToProduct[done.VariantID]
-				_ = s.productRepo.UpdateVariantStock(doneProduct.ID, done.VariantID, done.Quantity)
+				_ = s.giveBack(doneProduct.ID, done.VariantID, done.Quantity, done.PreOrder)
 			}
 			if errors.Is(err, repository.ErrInsufficientStock) {
 				return fmt.Errorf("insufficient stock for variant %s", item.VariantID)
@@ -97,6 +104,7 @@ func (s *stockReservationService) ReserveStock(orderID string, items []dto.Reser
 			OrderID:   orderID,
 			VariantID: item.VariantID,
 			Quantity:  item.Quantity,
+			PreOrder:  item.PreOrder,
 			Status:    "RESERVED",
 		}
 	}
@@ -104,7 +112,7 @@ func (s *stockReservationService) ReserveStock(orderID string, items []dto.Reser
 	if err := s.stockReservationRepo.CreateMany(reservations); err != nil {
 		for _, item := range items {
 			product := variantToProduct[item.VariantID]
-			_ = s.productRepo.UpdateVariantStock(product.ID, item.VariantID, item.Quantity)
+			_ = s.giveBack(product.ID, item.VariantID, item.Quantity, item.PreOrder)
 		}
 		return fmt.Errorf("failed to create stock reservations: %w", err)
 	}
@@ -112,6 +120,22 @@ func (s *stockReservationService) ReserveStock(orderID string, items []dto.Reser
 	return nil
 }
 
+// take reserves an item from the variant's stock, or from its pre-order quota
+func (s *stockReservationService) take(productID string, item dto.ReserveStockItem) error {
+	if item.PreOrder {
+		return s.productRepo.ReservePreOrderAtomic(productID, item.VariantID, item.Quantity)
+	}
+	return s.productRepo.DecrementVariantStockAtomic(productID, item.VariantID, item.Quantity)
+}
+
+// giveBack returns reserved units to where they were taken from
+func (s *stockReservationService) giveBack(productID string, variantID string, quantity int, preOrder bool) error {
+	if preOrder {
+		return s.productRepo.ReleasePreOrder(productID, variantID, quantity)
+	}
+	return s.productRepo.UpdateVariantStock(productID, variantID, quantity)
+}
+
 func (s *stockReservationService) ReleaseStock(orderID string) error {
 	// 1. Find all reservations for the order with status "RESERVED"
 	reservations, err := s.stockReservationRepo.FindByOrderID(orderID)
@@ -153,9 +177,7 @@ func (s *stockReservationService) ReleaseStock(orderID string) error {
 			return fmt.Errorf("variant %s not found", reservation.VariantID)
 		}
 
-		stockDelta := reservation.Quantity // Positive to increase
-
-		if err := s.productRepo.UpdateVariantStock(product.ID, reservation.VariantID, stockDelta); err != nil {
+		if err := s.giveBack(product.ID, reservation.VariantID, reservation.Quantity, reservation.PreOrder); err != nil {
 			return fmt.Errorf("failed to release stock for variant %s: %w", reservation.VariantID, err)
 		}
 	}
@@ -167,3 +189,80 @@ func (s *stockReservationService) ReleaseStock(orderID string) error {
 
 	return nil
 }
+
+// ReleaseStockItems gives back part of an order's reserved stock, e.g. when some
+// items are cancelled. Reservations are reduced by the released quantity and
+// marked "RELEASED" once nothing is left on them.
+func (s *stockReservationService) ReleaseStockItems(orderID string, items []dto.ReserveStockItem) error {
+	// 1. Find the order's reservations that are still held, per variant
+	reservations, err := s.stockReservationRepo.FindByOrderID(orderID)
+	if err != nil {
+		return fmt.Errorf("failed to find reservations for order %s: %w", orderID, err)
+	}
+
+	reservedByVariant := make(map[string][]*model.StockReservation)
+	for i := range reservations {
+		if reservations[i].Status == "RESERVED" {
+			reservedByVariant[reservations[i].VariantID] = append(reservedByVariant[reservations[i].VariantID], &reservations[i])
+		}
+	}
+
+	// 2. Make sure every item is covered before changing anything
+	requested := make(map[string]int)
+	variantIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if _, seen := requested[item.VariantID]; !seen {
+			variantIDs = append(variantIDs, item.VariantID)
+		}
+		requested[item.VariantID] += item.Quantity
+	}
+	for variantID, quantity := range requested {
+		reserved := 0
+		for _, reservation := range reservedByVariant[variantID] {
+			reserved += reservation.Quantity
+		}
+		if reserved < quantity {
+			return fmt.Errorf("not enough reserved stock for variant %s: requested %d, reserved %d",
+				variantID, quantity, reserved)
+		}
+	}
+
+	variantToProduct, err := s.productRepo.FindVariantsByIds(variantIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch variants: %w", err)
+	}
+
+	// 3. Increase stock and take the quantity off the reservations
+	for _, variantID := range variantIDs {
+		product, exists := variantToProduct[variantID]
+		if !exists {
+			return fmt.Errorf("variant %s not found", variantID)
+		}
+
+		// An order's reservations of a variant are all pre-orders or none are
+		preOrder := reservedByVariant[variantID][0].PreOrder
+		if err := s.giveBack(product.ID, variantID, requested[variantID], preOrder); err != nil {
+			return fmt.Errorf("failed to release stock for variant %s: %w", variantID, err)
+		}
+
+		remaining := requested[variantID]
+		for _, reservation := range reservedByVariant[variantID] {
+			if remaining == 0 {
+				break
+			}
+			if reservation.Quantity <= remaining {
+				// Released in full: keep the quantity for the record, like ReleaseStock does
+				remaining -= reservation.Quantity
+				reservation.Status = "RELEASED"
+			} else {
+				reservation.Quantity -= remaining
+				remaining = 0
+			}
+			if err := s.stockReservationRepo.Update(reservation); err != nil {
+				return fmt.Errorf("failed to update reservation %s: %w", reservation.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
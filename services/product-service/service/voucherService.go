@@ -32,6 +32,10 @@ func NewVoucherService(repo repository.VoucherRepository, savedVoucherRepo repos
 }
 
 func (s *voucherService) CreateVoucher(sellerID string, request dto.VoucherRequest) (dto.VoucherResponse, error) {
+	if !model.IsSupportedCurrency(request.Currency) {
+		return dto.VoucherResponse{}, appError.NewAppError(400, "unsupported currency")
+	}
+
 	voucher := &model.Voucher{
 		SellerID:               sellerID,
 		Code:                   request.Code,
@@ -41,6 +45,7 @@ func (s *voucherService) CreateVoucher(sellerID string, request dto.VoucherReque
 		DiscountValue:          request.DiscountValue,
 		MaxDiscountValue:       request.MaxDiscountValue,
 		MinOrderValue:          request.MinOrderValue,
+		Currency:               model.NormalizeCurrency(request.Currency),
 		ApplyScope:             request.ApplyScope,
 		ApplySellerCategoryIds: request.ApplySellerCategoryIds,
 		TotalQuantity:          request.TotalQuantity,
@@ -102,6 +107,12 @@ func (s *voucherService) UpdateVoucher(id string, sellerID string, request dto.V
 	voucher.DiscountValue = request.DiscountValue
 	voucher.MaxDiscountValue = request.MaxDiscountValue
 	voucher.MinOrderValue = request.MinOrderValue
+	if request.Currency != "" {
+		if !model.IsSupportedCurrency(request.Currency) {
+			return dto.VoucherResponse{}, appError.NewAppError(400, "unsupported currency")
+		}
+		voucher.Currency = model.NormalizeCurrency(request.Currency)
+	}
 	voucher.ApplyScope = request.ApplyScope
 	voucher.ApplySellerCategoryIds = request.ApplySellerCategoryIds
 	voucher.TotalQuantity = request.TotalQuantity
@@ -145,6 +156,7 @@ func (s *voucherService) mapToResponse(voucher *model.Voucher, userID string) dt
 		DiscountValue:          voucher.DiscountValue,
 		MaxDiscountValue:       voucher.MaxDiscountValue,
 		MinOrderValue:          voucher.MinOrderValue,
+		Currency:               model.NormalizeCurrency(voucher.Currency),
 		ApplyScope:             voucher.ApplyScope,
 		ApplySellerCategoryIds: voucher.ApplySellerCategoryIds,
 		TotalQuantity:          voucher.TotalQuantity,
@@ -458,3 +458,22 @@ func (c *UserController) UpdateUserInfo(ctx *gin.Context) {
 
 	utils.SuccessResponse(ctx, 200, "User info updated successfully", nil)
 }
+
+// GetUserStats is called by order-service for the admin analytics dashboard
+func (c *UserController) GetUserStats(ctx *gin.Context) {
+	var request dto.UserStatsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(appError.NewAppErrorWithErr(400, "Invalid query parameters", err))
+		ctx.Abort()
+		return
+	}
+
+	response, err := c.service.GetUserStats(request)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.Abort()
+		return
+	}
+
+	utils.SuccessResponse(ctx, 200, "Get user stats successfully", response)
+}
@@ -1,13 +1,16 @@
 package dto
 
+import "time"
+
 type UserResponse struct {
-	ID       string           `json:"id"`
-	Username string           `json:"username"`
-	Name     string           `json:"name"`
-	Phone    string           `json:"phone"`
-	Email    string           `json:"email"`
-	Image    string           `json:"image"`
-	Address  *AddressResponse `json:"address,omitempty"` // Default address only
+	ID        string           `json:"id"`
+	Username  string           `json:"username"`
+	Name      string           `json:"name"`
+	Phone     string           `json:"phone"`
+	Email     string           `json:"email"`
+	Image     string           `json:"image"`
+	Address   *AddressResponse `json:"address,omitempty"`    // Default address only
+	CreatedAt *time.Time       `json:"created_at,omitempty"` // unknown for accounts created before it was recorded
 }
 
 type SaleInfoResponse struct {
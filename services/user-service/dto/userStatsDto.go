@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+// UserStatsRequest selects the days [from, to) of a signup breakdown. Days are
+// counted in Timezone, an IANA name such as "Asia/Ho_Chi_Minh" (default UTC).
+type UserStatsRequest struct {
+	From     time.Time `form:"from" binding:"required"`
+	To       time.Time `form:"to" binding:"required"`
+	Timezone string    `form:"tz"`
+}
+
+type SignupDayResponse struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	NewUsers   int64  `json:"new_users"`
+	NewSellers int64  `json:"new_sellers"`
+}
+
+// UserStatsResponse holds the account totals and the signups of each day with any
+type UserStatsResponse struct {
+	TotalUsers   int64               `json:"total_users"`
+	TotalSellers int64               `json:"total_sellers"`
+	NewUsers     int64               `json:"new_users"`
+	NewSellers   int64               `json:"new_sellers"`
+	Daily        []SignupDayResponse `json:"daily"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"time"
 	"user-service/dto"
 
 	"github.com/google/uuid"
@@ -19,6 +20,7 @@ type User struct {
 	IsActive bool      `json:"is_active" gorm:"column:is_active;default:false"`
 	IsVerify bool      `json:"is_verify" gorm:"column:is_verify;default:false"`
 	IsBanned bool      `json:"is_banned" gorm:"column:is_banned;default:false"`
+	CreatedAt *time.Time `json:"created_at,omitempty" gorm:"column:created_at;autoCreateTime"` // unknown for accounts created before it was recorded
 	Addresses []Address `json:"addresses" gorm:"foreignKey:UserID"`
 	SaleInfo  *SaleInfo `json:"seller_info" gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`}
 
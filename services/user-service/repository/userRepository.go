@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"time"
 	"user-service/model"
 
 	"github.com/google/uuid"
@@ -22,6 +23,15 @@ type UserRepository interface {
 	UpdateFollowCount(userId string, increment bool) (*model.SaleInfo, error)
 	GetAllUsers(skip, limit int, role *string, isBanned *bool) ([]model.User, int64, error)
 	UpdateUserInfo(userId string, name string, phone string, email string) error
+	CountUsers() (int64, int64, error)
+	CountSignupsByDay(from, to time.Time, timezone string) ([]SignupDay, error)
+}
+
+// SignupDay holds the accounts created on one day
+type SignupDay struct {
+	Date    string `gorm:"column:date"` // YYYY-MM-DD
+	Users   int64  `gorm:"column:users"`
+	Sellers int64  `gorm:"column:sellers"`
 }
 
 type userRepository struct {
@@ -237,3 +247,27 @@ func (r *userRepository) UpdateUserInfo(userId string, name string, phone string
 	}
 	return r.db.Save(&user).Error
 }
+
+// CountUsers returns the number of accounts and of seller accounts
+func (r *userRepository) CountUsers() (int64, int64, error) {
+	var total, sellers int64
+	if err := r.db.Model(&model.User{}).Count(&total).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := r.db.Model(&model.User{}).Where("role = ?", "seller").Count(&sellers).Error; err != nil {
+		return 0, 0, err
+	}
+	return total, sellers, nil
+}
+
+// CountSignupsByDay counts the accounts created in [from, to) per day in the given time zone
+func (r *userRepository) CountSignupsByDay(from, to time.Time, timezone string) ([]SignupDay, error) {
+	var days []SignupDay
+	err := r.db.Model(&model.User{}).
+		Select("to_char(created_at AT TIME ZONE ?, 'YYYY-MM-DD') AS date, COUNT(*) AS users, COUNT(*) FILTER (WHERE role = ?) AS sellers", timezone, "seller").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("date").
+		Order("date").
+		Scan(&days).Error
+	return days, err
+}
@@ -25,6 +25,7 @@ func RegisterUserRoutes(rg *gin.RouterGroup, c controller.UserController) {
 		user.POST("/upload-image", c.UploadUserImage)
 		user.POST("/seller/rating", c.UpdateSellerRating)
 		user.POST("/seller/product-count", c.UpdateProductCount)
+		user.GET("/stats", c.GetUserStats)
 
 		// Admin routes
 		user.GET("/admin/users", middleware.RequireAdmin(), c.GetAllUsers)
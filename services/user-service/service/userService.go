@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"mime/multipart"
+	"time"
 	"user-service/dto"
 	customError "user-service/error"
 	"user-service/model"
@@ -28,6 +29,7 @@ type UserService interface {
 	GetAllUsers(page, limit int, role *string, isBanned *bool) (dto.GetAllUsersResponse, error)
 	SetUserBanned(userId string, isBanned bool) error
 	UpdateUserInfo(userId string, request dto.UpdateUserInfoRequest) error
+	GetUserStats(request dto.UserStatsRequest) (dto.UserStatsResponse, error)
 }
 
 type userService struct {
@@ -207,13 +209,14 @@ func (s *userService) GetUserByID(userId string) (dto.UserResponse, error) {
 	}
 
 	return dto.UserResponse{
-		ID:       user.ID.String(),
-		Username: user.Username,
-		Name:     user.Name,
-		Phone:    user.Phone,
-		Email:    user.Email,
-		Image:    user.Image,
-		Address:  defaultAddress,
+		ID:        user.ID.String(),
+		Username:  user.Username,
+		Name:      user.Name,
+		Phone:     user.Phone,
+		Email:     user.Email,
+		Image:     user.Image,
+		Address:   defaultAddress,
+		CreatedAt: user.CreatedAt,
 	}, nil
 }
 
@@ -453,3 +456,42 @@ func (s *userService) UpdateUserInfo(userId string, request dto.UpdateUserInfoRe
 
 	return nil
 }
+
+// GetUserStats returns the account totals and the signups of each day in [from, to)
+func (s *userService) GetUserStats(request dto.UserStatsRequest) (dto.UserStatsResponse, error) {
+	if !request.From.Before(request.To) {
+		return dto.UserStatsResponse{}, customError.NewAppError(400, "invalid time range: 'from' must be before 'to'")
+	}
+	timezone := request.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return dto.UserStatsResponse{}, customError.NewAppError(400, "invalid time zone: "+timezone)
+	}
+
+	totalUsers, totalSellers, err := s.repo.CountUsers()
+	if err != nil {
+		return dto.UserStatsResponse{}, err
+	}
+	days, err := s.repo.CountSignupsByDay(request.From, request.To, timezone)
+	if err != nil {
+		return dto.UserStatsResponse{}, err
+	}
+
+	response := dto.UserStatsResponse{
+		TotalUsers:   totalUsers,
+		TotalSellers: totalSellers,
+		Daily:        make([]dto.SignupDayResponse, 0, len(days)),
+	}
+	for _, day := range days {
+		response.NewUsers += day.Users
+		response.NewSellers += day.Sellers
+		response.Daily = append(response.Daily, dto.SignupDayResponse{
+			Date:       day.Date,
+			NewUsers:   day.Users,
+			NewSellers: day.Sellers,
+		})
+	}
+	return response, nil
+}